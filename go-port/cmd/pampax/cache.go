@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	"github.com/alessandrojcm/pampax-go/internal/config"
+	"github.com/alessandrojcm/pampax-go/internal/embedcache"
+)
+
+func newCacheCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect and prune the embedding cache in .pampa/",
+	}
+
+	cmd.AddCommand(newCacheStatsCommand(), newCachePruneCommand())
+	return cmd
+}
+
+func newCacheStatsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stats [path]",
+		Short: "Show embedding cache size and entry count",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cache, cfg, err := openEmbedCache(projectPathArg(args))
+			if err != nil {
+				return err
+			}
+
+			stats := cache.Stats()
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Entries: %d\n", stats.Entries)
+			fmt.Fprintf(out, "Size: %s\n", humanize.IBytes(uint64(stats.Bytes)))
+			fmt.Fprintf(out, "Limit: %s\n", describeCacheLimits(cfg.EmbedCache))
+			if stats.Entries > 0 {
+				fmt.Fprintf(out, "Oldest use: %s\n", stats.Oldest.Format(time.RFC3339))
+				fmt.Fprintf(out, "Newest use: %s\n", stats.Newest.Format(time.RFC3339))
+			}
+
+			return nil
+		},
+	}
+}
+
+func newCachePruneCommand() *cobra.Command {
+	var (
+		maxSizeMB  int64
+		maxEntries int
+		all        bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "prune [path]",
+		Short: "Evict least-recently-used embeddings down to the configured limits",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectRoot := projectPathArg(args)
+			// Pruning rewrites the cache an index run may be writing to.
+			unlock, err := lockIndex(cmd, projectRoot)
+			if err != nil {
+				return err
+			}
+			defer unlock()
+			cache, _, err := openEmbedCache(projectRoot)
+			if err != nil {
+				return err
+			}
+
+			limits := cache.Limits()
+			if cmd.Flags().Changed("max-size-mb") {
+				limits.MaxBytes = maxSizeMB << 20
+			}
+			if cmd.Flags().Changed("max-entries") {
+				limits.MaxEntries = maxEntries
+			}
+			if all {
+				limits = embedcache.Limits{}
+			} else if limits.MaxBytes <= 0 && limits.MaxEntries <= 0 {
+				return fmt.Errorf("no cache limits configured; pass --max-size-mb, --max-entries or --all")
+			}
+
+			before := cache.Stats()
+			removed, err := cache.Prune(limits)
+			if err != nil {
+				return err
+			}
+
+			after := cache.Stats()
+			fmt.Fprintf(cmd.OutOrStdout(), "Removed %d entries (%s freed), %d remaining\n",
+				removed, humanize.IBytes(uint64(before.Bytes-after.Bytes)), after.Entries)
+			return nil
+		},
+	}
+
+	cmd.Flags().Int64Var(&maxSizeMB, "max-size-mb", 0, "prune until the cache is at most this many MiB")
+	cmd.Flags().IntVar(&maxEntries, "max-entries", 0, "prune until at most this many entries remain")
+	cmd.Flags().BoolVar(&all, "all", false, "remove every cached embedding")
+
+	return cmd
+}
+
+func openEmbedCache(projectRoot string) (*embedcache.Cache, config.Config, error) {
+	cfg, err := config.Load(projectRoot)
+	if err != nil {
+		return nil, config.Config{}, err
+	}
+
+	cache, err := embedcache.Open(config.ResolvePaths(projectRoot).EmbedCache, embedCacheLimits(cfg.EmbedCache))
+	if err != nil {
+		return nil, config.Config{}, err
+	}
+
+	return cache, cfg, nil
+}
+
+func embedCacheLimits(cfg config.EmbedCacheConfig) embedcache.Limits {
+	return embedcache.Limits{
+		MaxBytes:   cfg.MaxSizeMB << 20,
+		MaxEntries: cfg.MaxEntries,
+	}
+}
+
+func describeCacheLimits(cfg config.EmbedCacheConfig) string {
+	if cfg.MaxSizeMB <= 0 && cfg.MaxEntries <= 0 {
+		return "unbounded"
+	}
+
+	parts := ""
+	if cfg.MaxSizeMB > 0 {
+		parts = humanize.IBytes(uint64(cfg.MaxSizeMB << 20))
+	}
+	if cfg.MaxEntries > 0 {
+		if parts != "" {
+			parts += ", "
+		}
+		parts += fmt.Sprintf("%d entries", cfg.MaxEntries)
+	}
+
+	return parts
+}
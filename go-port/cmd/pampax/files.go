@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alessandrojcm/pampax-go/internal/config"
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+)
+
+func walkOptions(cfg config.Config) (indexer.WalkOptions, error) {
+	overrides := make([]indexer.LanguageOverride, 0, len(cfg.LanguageOverrides))
+	for _, override := range cfg.LanguageOverrides {
+		overrides = append(overrides, indexer.LanguageOverride{Pattern: override.Pattern, Lang: override.Lang})
+	}
+	detector, err := indexer.NewDetector(overrides)
+	if err != nil {
+		return indexer.WalkOptions{}, err
+	}
+
+	var excludes []string
+	if cfg.Index.DefaultExcludes {
+		excludes = append(excludes, indexer.DefaultExcludes...)
+	}
+	excludes = append(excludes, cfg.Index.Exclude...)
+
+	maxSize := cfg.Index.MaxFileSizeKB << 10
+	if maxSize <= 0 {
+		maxSize = -1
+	}
+
+	return indexer.WalkOptions{
+		Excludes:       excludes,
+		MaxFileSize:    maxSize,
+		FollowSymlinks: cfg.Index.FollowSymlinks,
+		Detector:       detector,
+		IncludeUnknown: cfg.Index.UnknownLanguages,
+	}, nil
+}
+
+// listProjectFiles enumerates the indexable files of projectRoot, from the
+// git index when configured and available, else by walking the tree.
+func listProjectFiles(ctx context.Context, projectRoot string, cfg config.Config) (indexer.WalkResult, error) {
+	opts, err := walkOptions(cfg)
+	if err != nil {
+		return indexer.WalkResult{}, err
+	}
+
+	if cfg.Index.Git {
+		result, err := indexer.GitFiles(ctx, projectRoot, indexer.GitOptions{
+			WalkOptions:      opts,
+			IncludeUntracked: cfg.Index.GitUntracked,
+		})
+		if !errors.Is(err, indexer.ErrNotGitRepository) {
+			return result, err
+		}
+	}
+
+	return indexer.Walk(ctx, projectRoot, opts)
+}
+
+func newFilesCommand() *cobra.Command {
+	var showSkipped bool
+
+	cmd := &cobra.Command{
+		Use:   "files [path]",
+		Short: "List the files that would be indexed",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectRoot := projectPathArg(args)
+			cfg, err := config.Load(projectRoot)
+			if err != nil {
+				return err
+			}
+
+			result, err := listProjectFiles(cmd.Context(), projectRoot, cfg)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			for _, file := range result.Files {
+				lang := file.Rule.Lang
+				if lang == "" {
+					lang = indexer.TextLang
+				}
+				fmt.Fprintf(out, "%s\t%s\n", lang, file.Path)
+			}
+			if showSkipped {
+				for _, skipped := range result.Skipped {
+					path := skipped.Path
+					if skipped.Dir {
+						path += "/"
+					}
+					if skipped.Detail != "" {
+						fmt.Fprintf(out, "skip\t%s\t%s (%s)\n", path, skipped.Reason, skipped.Detail)
+					} else {
+						fmt.Fprintf(out, "skip\t%s\t%s\n", path, skipped.Reason)
+					}
+				}
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "%d files, %d skipped\n", len(result.Files), len(result.Skipped))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&showSkipped, "skipped", false, "also list skipped paths with the reason")
+	return cmd
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alessandrojcm/pampax-go/internal/config"
+	"github.com/alessandrojcm/pampax-go/internal/graph"
+	"github.com/alessandrojcm/pampax-go/internal/mcp"
+)
+
+// graphFlags are the flags shared by the graph subcommands.
+type graphFlags struct {
+	path   string
+	depth  int
+	format string
+}
+
+func (f *graphFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.path, "path", "p", ".", "project root containing pampa.codemap.json")
+	cmd.Flags().IntVarP(&f.depth, "depth", "d", graph.DefaultDepth, "maximum number of calls to follow")
+	cmd.Flags().StringVarP(&f.format, "format", "f", string(graph.FormatText), "output format: text, json or dot")
+}
+
+func loadGraph(projectRoot string) (*graph.Graph, error) {
+	return graph.Load(config.ResolvePaths(projectRoot).Codemap)
+}
+
+func newGraphCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Query the call graph recorded in the codemap",
+	}
+
+	cmd.AddCommand(
+		newGraphTraversalCommand(graph.CallersOf, "Show what calls a symbol, transitively: the impact radius of changing it"),
+		newGraphTraversalCommand(graph.CalleesOf, "Show what a symbol calls, transitively"),
+		newGraphPathCommand(),
+	)
+	return cmd
+}
+
+func newGraphTraversalCommand(dir graph.Direction, short string) *cobra.Command {
+	var flags graphFlags
+
+	cmd := &cobra.Command{
+		Use:   string(dir) + " <symbol>",
+		Short: short,
+		Long: short + ".\n\nA symbol is a name, file:name, a codemap chunk ID or a chunk SHA prefix; " +
+			"every chunk it names is a root.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := graph.ParseFormat(flags.format)
+			if err != nil {
+				return err
+			}
+			g, err := loadGraph(flags.path)
+			if err != nil {
+				return err
+			}
+			result, err := g.Query(args[0], dir, flags.depth)
+			if err != nil {
+				return err
+			}
+			return graph.WriteTraversal(cmd.OutOrStdout(), result, format)
+		},
+	}
+
+	flags.register(cmd)
+	return cmd
+}
+
+func newGraphPathCommand() *cobra.Command {
+	var flags graphFlags
+
+	cmd := &cobra.Command{
+		Use:   "path <from> <to>",
+		Short: "Show the shortest chain of calls from one symbol to another",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := graph.ParseFormat(flags.format)
+			if err != nil {
+				return err
+			}
+			g, err := loadGraph(flags.path)
+			if err != nil {
+				return err
+			}
+			result, err := g.PathBetween(args[0], args[1], flags.depth)
+			if err != nil {
+				return err
+			}
+			return graph.WritePath(cmd.OutOrStdout(), result, format)
+		},
+	}
+
+	flags.register(cmd)
+	return cmd
+}
+
+// graphToolArgs are the arguments of the graph MCP tools.
+type graphToolArgs struct {
+	Symbol string `json:"symbol"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Path   string `json:"path"`
+	Depth  int    `json:"depth"`
+	Format string `json:"format"`
+}
+
+func parseGraphToolArgs(raw json.RawMessage) (graphToolArgs, graph.Format, error) {
+	args := graphToolArgs{Path: ".", Depth: graph.DefaultDepth, Format: string(graph.FormatJSON)}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return args, "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	format, err := graph.ParseFormat(args.Format)
+	return args, format, err
+}
+
+func graphToolSchema(required []string, properties map[string]any) map[string]any {
+	properties["path"] = map[string]any{"type": "string", "description": "Project root containing pampa.codemap.json", "default": "."}
+	properties["depth"] = map[string]any{"type": "integer", "description": "Maximum number of calls to follow", "default": graph.DefaultDepth, "minimum": 1}
+	properties["format"] = map[string]any{"type": "string", "enum": []string{"json", "text", "dot"}, "default": "json"}
+	return map[string]any{"type": "object", "properties": properties, "required": required}
+}
+
+// graphTools are the MCP tools of the graph commands.
+func graphTools() []mcp.Tool {
+	symbol := map[string]any{"type": "string", "description": "Symbol name, file:name, chunk ID or SHA prefix"}
+	traversal := func(dir graph.Direction, description string) mcp.Tool {
+		return mcp.Tool{
+			Name:        "graph_" + string(dir),
+			Description: description,
+			InputSchema: graphToolSchema([]string{"symbol"}, map[string]any{"symbol": symbol}),
+			Handler: func(_ context.Context, raw json.RawMessage) (string, error) {
+				args, format, err := parseGraphToolArgs(raw)
+				if err != nil {
+					return "", err
+				}
+				g, err := loadGraph(args.Path)
+				if err != nil {
+					return "", err
+				}
+				result, err := g.Query(args.Symbol, dir, args.Depth)
+				if err != nil {
+					return "", err
+				}
+				var out strings.Builder
+				err = graph.WriteTraversal(&out, result, format)
+				return out.String(), err
+			},
+		}
+	}
+
+	return []mcp.Tool{
+		traversal(graph.CallersOf, "List the chunks that call a symbol, transitively up to depth: what a change to it affects."),
+		traversal(graph.CalleesOf, "List the chunks a symbol calls, transitively up to depth."),
+		{
+			Name:        "graph_path",
+			Description: "Find the shortest chain of calls from one symbol to another.",
+			InputSchema: graphToolSchema([]string{"from", "to"}, map[string]any{"from": symbol, "to": symbol}),
+			Handler: func(_ context.Context, raw json.RawMessage) (string, error) {
+				args, format, err := parseGraphToolArgs(raw)
+				if err != nil {
+					return "", err
+				}
+				g, err := loadGraph(args.Path)
+				if err != nil {
+					return "", err
+				}
+				result, err := g.PathBetween(args.From, args.To, args.Depth)
+				if err != nil {
+					return "", err
+				}
+				var out strings.Builder
+				err = graph.WritePath(&out, result, format)
+				return out.String(), err
+			},
+		},
+	}
+}
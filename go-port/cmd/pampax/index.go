@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alessandrojcm/pampax-go/internal/app"
+	"github.com/alessandrojcm/pampax-go/internal/config"
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+)
+
+func newIndexCommand() *cobra.Command {
+	var workers, maxErrors int
+	var progressMode, logLevel string
+	var flags indexFlags
+
+	cmd := &cobra.Command{
+		Use:   "index [path]",
+		Short: "Index the project: chunk, embed and store every changed file",
+		Long: "Discover the project files, then parse, chunk, embed and store the ones whose content\n" +
+			"changed since the last run, and drop the files that are gone. Ctrl+C stops the run; files\n" +
+			"not recorded yet are indexed again by the next one.\n\n" +
+			"A file that cannot be read, parsed, embedded or stored is logged and skipped; the run\n" +
+			"goes on and writes .pampa/last-index-report.json. By default failed files do not fail\n" +
+			"the command; set --max-errors (index.max_errors) to fail it when more files than that\n" +
+			"failed.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectRoot := projectPathArg(args)
+			cfg, err := config.Load(projectRoot)
+			if err != nil {
+				return err
+			}
+			progress, err := newIndexProgress(progressMode, cmd.OutOrStdout(), cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			logger, err := newIndexLogger(logLevel, cmd.ErrOrStderr(), progress)
+			if err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("max-errors") {
+				maxErrors = cfg.Index.MaxErrors
+			}
+
+			unlock, err := lockIndex(cmd, projectRoot)
+			if err != nil {
+				return err
+			}
+			defer unlock()
+			opts, err := indexerOptions(projectRoot, cfg, flags, cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			opts.Logger = logger
+			ix := app.New(projectRoot, opts)
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			result, err := ix.Index(ctx, app.IndexRequest{
+				Discover: func(ctx context.Context) (indexer.WalkResult, error) {
+					return listProjectFiles(ctx, projectRoot, cfg)
+				},
+				Workers:  workers,
+				Progress: progress.report,
+			})
+			progress.finish()
+			if errors.Is(err, context.Canceled) {
+				return fmt.Errorf("indexing interrupted: %w", err)
+			}
+			if err != nil {
+				return err
+			}
+
+			if progressMode == "json" {
+				writeIndexSummary(cmd.OutOrStdout(), result)
+			} else {
+				reportIndex(cmd.ErrOrStderr(), result)
+			}
+			if result.TooManyFailures(maxErrors) {
+				return fmt.Errorf("%d files failed to index, more than the %d allowed (see %s)",
+					len(result.Failed), maxErrors, config.ResolvePaths(projectRoot).Report)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&workers, "workers", 0, "files parsed and chunked at once (default: number of CPUs)")
+	cmd.Flags().StringVar(&progressMode, "progress", "auto", "progress output: "+strings.Join(progressModes, ", ")+" (json writes JSON lines to stdout)")
+	cmd.Flags().IntVar(&maxErrors, "max-errors", -1, "files that may fail before the command exits non-zero; negative never fails (default: index.max_errors)")
+	cmd.Flags().StringVar(&logLevel, "log-level", "warn", "level of the logs written to stderr: debug, info, warn, error or disabled")
+	flags.register(cmd)
+
+	return cmd
+}
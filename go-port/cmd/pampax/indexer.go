@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alessandrojcm/pampax-go/internal/app"
+	"github.com/alessandrojcm/pampax-go/internal/chunks"
+	"github.com/alessandrojcm/pampax-go/internal/config"
+	"github.com/alessandrojcm/pampax-go/internal/embedcache"
+	"github.com/alessandrojcm/pampax-go/internal/embedtext"
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+	"github.com/alessandrojcm/pampax-go/internal/lock"
+	"github.com/alessandrojcm/pampax-go/internal/providers"
+	"github.com/alessandrojcm/pampax-go/internal/tags"
+	"github.com/alessandrojcm/pampax-go/internal/tokens"
+)
+
+// indexFlags are the flags of the commands that write the index.
+type indexFlags struct {
+	provider string
+	encrypt  string
+}
+
+func (f *indexFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.provider, "provider", "auto", "embedding provider: "+strings.Join(providers.Names, ", "))
+	cmd.Flags().StringVar(&f.encrypt, "encrypt", "", "encrypt chunk files with "+chunks.KeyEnvVar+" (on|off; default: when the key is set)")
+}
+
+// newProjectIndexer creates the app.Indexer of projectRoot.
+func newProjectIndexer(projectRoot string, cfg config.Config, flags indexFlags, stderr io.Writer) (*app.Indexer, error) {
+	opts, err := indexerOptions(projectRoot, cfg, flags, stderr)
+	if err != nil {
+		return nil, err
+	}
+	return app.New(projectRoot, opts), nil
+}
+
+// indexerOptions maps cfg and flags into the options of an app.Indexer.
+func indexerOptions(projectRoot string, cfg config.Config, flags indexFlags, stderr io.Writer) (app.Options, error) {
+	provider, err := providers.FromEnv(flags.provider, cfg.Dimensions, os.Getenv)
+	if err != nil {
+		return app.Options{}, err
+	}
+	profile := tokens.LookupProfile(provider.Name(), provider.Model(), tokens.Overrides{
+		MaxTokens:  cfg.MaxTokens,
+		Dimensions: cfg.Dimensions,
+	})
+
+	template, err := embeddingTemplate(cfg.EmbeddingText)
+	if err != nil {
+		return app.Options{}, err
+	}
+	budget := embedtext.ProfileBudget(profile)
+	switch limit := cfg.EmbeddingText.MaxTokens; {
+	case limit < 0:
+		budget = embedtext.Budget{}
+	case limit > 0:
+		budget.Limit = limit
+	}
+
+	masterKey, err := encryptionKey(flags.encrypt, stderr)
+	if err != nil {
+		return app.Options{}, err
+	}
+
+	opts := app.Options{
+		Chunker:   indexer.NewChunker(profile, chunkerOptions(cfg)),
+		Provider:  provider,
+		Template:  template,
+		Budget:    budget,
+		MasterKey: masterKey,
+	}
+	if cfg.EmbedCache.Enabled {
+		cache, err := embedcache.Open(config.ResolvePaths(projectRoot).EmbedCache, embedCacheLimits(cfg.EmbedCache))
+		if err != nil {
+			return app.Options{}, err
+		}
+		opts.Cache = cache
+	}
+	return opts, nil
+}
+
+// lockIndex takes the index lock of projectRoot for the command that writes
+// it; the returned function releases the lock.
+func lockIndex(cmd *cobra.Command, projectRoot string) (func(), error) {
+	held, err := lock.Acquire(config.ResolvePaths(projectRoot).Lock, cmd.CommandPath())
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		if err := held.Release(); err != nil {
+			fmt.Fprintln(cmd.ErrOrStderr(), err)
+		}
+	}, nil
+}
+
+func chunkerOptions(cfg config.Config) indexer.ChunkerOptions {
+	opts := indexer.ChunkerOptions{
+		Grouping: indexer.GroupingProfile{
+			Disabled:             !cfg.Chunking.Grouping.Enabled,
+			KeepSeparateMaxNodes: cfg.Chunking.Grouping.KeepSeparateMaxNodes,
+			FlushRatio:           cfg.Chunking.Grouping.FlushRatio,
+		},
+		WindowOverlap: cfg.Chunking.WindowOverlap,
+	}
+	if cfg.Tags.Enabled {
+		aliases := make([]tags.Alias, 0, len(cfg.Tags.Aliases))
+		for _, alias := range cfg.Tags.Aliases {
+			aliases = append(aliases, tags.Alias{Term: alias.Term, Tag: alias.Tag})
+		}
+		opts.Tagger = tags.NewExtractor(tags.Options{
+			Max:             cfg.Tags.Max,
+			ExtraDictionary: cfg.Tags.Dictionary,
+			ExtraAliases:    aliases,
+			ExtraStopwords:  cfg.Tags.Stopwords,
+		})
+	}
+	return opts
+}
+
+func embeddingTemplate(cfg config.EmbeddingTextConfig) (*embedtext.Template, error) {
+	sections := make([]embedtext.Section, 0, len(cfg.Sections))
+	for _, section := range cfg.Sections {
+		sections = append(sections, embedtext.Section{Name: section.Name, Template: section.Template, Priority: section.Priority})
+	}
+	return embedtext.Lookup(cfg.Template, sections)
+}
+
+// encryptionKey ports resolveEncryptionPreference: "off" disables
+// encryption, "on" requires a valid key, and otherwise a configured key
+// enables it while an invalid one only warns.
+func encryptionKey(mode string, stderr io.Writer) ([]byte, error) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case "off":
+		return nil, nil
+	case "", "on":
+	default:
+		fmt.Fprintf(stderr, "Unknown --encrypt mode %q. Expected \"on\" or \"off\". Falling back to environment configuration.\n", mode)
+	}
+
+	key, err := chunks.DecodeMasterKey(os.Getenv(chunks.KeyEnvVar))
+	switch {
+	case mode == "on" && err != nil:
+		return nil, err
+	case mode == "on" && key == nil:
+		return nil, fmt.Errorf("%s is not configured but encryption was requested (--encrypt on)", chunks.KeyEnvVar)
+	case err != nil:
+		fmt.Fprintf(stderr, "%v. Encryption disabled.\n", err)
+		return nil, nil
+	}
+	return key, nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// newIndexLogger logs at level and above to stderr, around the progress
+// bar: readable lines on a terminal, JSON lines otherwise.
+func newIndexLogger(level string, stderr io.Writer, progress *indexProgress) (*zerolog.Logger, error) {
+	parsed, err := zerolog.ParseLevel(level)
+	if err != nil || parsed == zerolog.NoLevel {
+		return nil, fmt.Errorf("unknown --log-level %q (expected debug, info, warn, error or disabled)", level)
+	}
+	var out io.Writer = stderr
+	if isTerminal(stderr) {
+		out = zerolog.ConsoleWriter{Out: stderr, TimeFormat: time.TimeOnly}
+	}
+	logger := zerolog.New(progress.logWriter(out)).Level(parsed).With().Timestamp().Logger()
+	return &logger, nil
+}
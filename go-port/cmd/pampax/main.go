@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// version is set at build time with -ldflags "-X main.version=...".
+var version = "dev"
+
+func main() {
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func newRootCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:          "pampax",
+		Short:        "PAMPAX - Pragmatic Agentic Memory via Portable Artifact eXchange",
+		SilenceUsage: true,
+	}
+
+	root.AddCommand(newCacheCommand(), newFilesCommand(), newGraphCommand(), newIndexCommand(), newMCPCommand(), newUpdateCommand(), newWatchCommand())
+
+	return root
+}
+
+// projectPathArg resolves the optional [path] argument shared by commands.
+func projectPathArg(args []string) string {
+	if len(args) > 0 && args[0] != "" {
+		return args[0]
+	}
+
+	return "."
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/alessandrojcm/pampax-go/internal/mcp"
+)
+
+func newMCPCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "mcp",
+		Short: "Serve the PAMPAX tools to an MCP client over stdio",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			server := newMCPServer()
+			return server.Serve(cmd.Context(), cmd.InOrStdin(), cmd.OutOrStdout())
+		},
+	}
+}
+
+func newMCPServer() *mcp.Server {
+	server := mcp.NewServer("pampax", version)
+	for _, tool := range graphTools() {
+		server.AddTool(tool)
+	}
+	return server
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alessandrojcm/pampax-go/internal/app"
+	"github.com/alessandrojcm/pampax-go/internal/codemap"
+)
+
+// progressModes are the values of --progress.
+var progressModes = []string{"auto", "bar", "json", "none"}
+
+// barInterval is the shortest time between two redraws of the bar.
+const barInterval = 100 * time.Millisecond
+
+const barWidth = 30
+
+// indexProgress renders the progress of an index run.
+type indexProgress struct {
+	mode   string
+	out    io.Writer
+	drawn  time.Time
+	active bool
+
+	mu sync.Mutex
+}
+
+// newIndexProgress renders a bar on stderr, or JSON lines on stdout; auto
+// draws the bar only when stderr is a terminal.
+func newIndexProgress(mode string, stdout, stderr io.Writer) (*indexProgress, error) {
+	switch mode {
+	case "auto":
+		mode = "none"
+		if isTerminal(stderr) {
+			mode = "bar"
+		}
+	case "bar", "none":
+	case "json":
+		return &indexProgress{mode: mode, out: stdout}, nil
+	default:
+		return nil, fmt.Errorf("unknown --progress %q (expected %s)", mode, strings.Join(progressModes, ", "))
+	}
+	return &indexProgress{mode: mode, out: stderr}, nil
+}
+
+// isTerminal reports whether out is a terminal.
+func isTerminal(out io.Writer) bool {
+	file, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (p *indexProgress) report(progress app.Progress) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch p.mode {
+	case "json":
+		writeJSONLine(p.out, struct {
+			Type string `json:"type"`
+			app.Progress
+		}{"progress", progress})
+	case "bar":
+		if time.Since(p.drawn) < barInterval && progress.Done < progress.Total {
+			return
+		}
+		p.drawn, p.active = time.Now(), true
+		filled := barWidth
+		if progress.Total > 0 {
+			filled = barWidth * progress.Done / progress.Total
+		}
+		fmt.Fprintf(p.out, "\r[%s%s] %d/%d files, %d chunks stored",
+			strings.Repeat("#", filled), strings.Repeat("-", barWidth-filled), progress.Done, progress.Total, progress.Chunks)
+	}
+}
+
+// finish ends the bar line so that what follows starts on its own.
+func (p *indexProgress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.endBar()
+}
+
+func (p *indexProgress) endBar() {
+	if p.active {
+		fmt.Fprintln(p.out)
+		p.active = false
+	}
+}
+
+// logWriter returns a writer to out that ends the bar before each log
+// line, so that the two do not run into each other.
+func (p *indexProgress) logWriter(out io.Writer) io.Writer {
+	return progressLogWriter{progress: p, out: out}
+}
+
+type progressLogWriter struct {
+	progress *indexProgress
+	out      io.Writer
+}
+
+func (w progressLogWriter) Write(line []byte) (int, error) {
+	w.progress.mu.Lock()
+	defer w.progress.mu.Unlock()
+	w.progress.endBar()
+	return w.out.Write(line)
+}
+
+func writeJSONLine(out io.Writer, value any) {
+	payload, _ := json.Marshal(value)
+	fmt.Fprintf(out, "%s\n", payload)
+}
+
+// indexSummary is the JSON form of an app.IndexResult.
+type indexSummary struct {
+	Type           string              `json:"type"`
+	Files          int                 `json:"files"`
+	Indexed        int                 `json:"indexed"`
+	Unchanged      int                 `json:"unchanged"`
+	Skipped        int                 `json:"skipped"`
+	Removed        int                 `json:"removed"`
+	ChunksStored   int                 `json:"chunksStored"`
+	ChunksResumed  int                 `json:"chunksResumed"`
+	ChunksKept     int                 `json:"chunksKept"`
+	ChunksDeleted  int                 `json:"chunksDeleted"`
+	TotalChunks    int                 `json:"totalChunks"`
+	Errors         []fileErrorJSON     `json:"errors"`
+	AmbiguousCalls []ambiguousCallJSON `json:"ambiguousCalls"`
+	TimingsMs      map[string]int64    `json:"timingsMs"`
+	ElapsedMs      int64               `json:"elapsedMs"`
+}
+
+type fileErrorJSON struct {
+	File     string `json:"file"`
+	Category string `json:"category"`
+	Error    string `json:"error"`
+}
+
+type ambiguousCallJSON struct {
+	File       string   `json:"file"`
+	Symbol     string   `json:"symbol"`
+	Call       string   `json:"call"`
+	Candidates []string `json:"candidates"`
+}
+
+func writeIndexSummary(out io.Writer, result app.IndexResult) {
+	summary := indexSummary{
+		Type:           "summary",
+		Files:          result.Files,
+		Indexed:        result.Indexed,
+		Unchanged:      result.Unchanged,
+		Skipped:        result.Skipped,
+		Removed:        result.Removed,
+		ChunksStored:   result.ChunksStored,
+		ChunksResumed:  result.ChunksResumed,
+		ChunksKept:     result.ChunksKept,
+		ChunksDeleted:  result.ChunksDeleted,
+		TotalChunks:    result.TotalChunks,
+		Errors:         []fileErrorJSON{},
+		AmbiguousCalls: []ambiguousCallJSON{},
+		TimingsMs:      map[string]int64{},
+		ElapsedMs:      result.Elapsed.Milliseconds(),
+	}
+	for _, failed := range result.Failed {
+		summary.Errors = append(summary.Errors, fileErrorJSON{File: failed.Path, Category: string(failed.Category), Error: failed.Err.Error()})
+	}
+	for _, call := range result.Ambiguous {
+		summary.AmbiguousCalls = append(summary.AmbiguousCalls, ambiguousCallJSON{File: call.File, Symbol: call.Symbol, Call: call.Call, Candidates: call.Candidates})
+	}
+	for stage, elapsed := range result.Timings {
+		summary.TimingsMs[string(stage)] = elapsed.Milliseconds()
+	}
+	writeJSONLine(out, summary)
+}
+
+func reportIndex(out io.Writer, result app.IndexResult) {
+	fmt.Fprintf(out, "%d files: %d indexed, %d unchanged, %d removed, %d skipped, %d failed\n",
+		result.Files, result.Indexed, result.Unchanged, result.Removed, result.Skipped, len(result.Failed))
+	fmt.Fprintf(out, "%d chunks stored", result.ChunksStored)
+	if result.ChunksResumed > 0 {
+		fmt.Fprintf(out, " (%d more resumed from an interrupted run)", result.ChunksResumed)
+	}
+	fmt.Fprintf(out, ", %d unchanged, %d removed; %d chunks in the index\n", result.ChunksKept, result.ChunksDeleted, result.TotalChunks)
+
+	timings := make([]string, 0, len(app.Stages))
+	for _, stage := range app.Stages {
+		timings = append(timings, fmt.Sprintf("%s %s", stage, result.Timings[stage].Round(time.Millisecond)))
+	}
+	fmt.Fprintf(out, "Time: %s (%s)\n", result.Elapsed.Round(time.Millisecond), strings.Join(timings, ", "))
+	for _, failed := range result.Failed {
+		fmt.Fprintf(out, "failed (%s): %v\n", failed.Category, failed)
+	}
+	reportAmbiguous(out, result.Ambiguous)
+}
+
+// maxAmbiguousShown caps the ambiguous calls listed on the terminal.
+const maxAmbiguousShown = 10
+
+// reportAmbiguous lists the calls the call graph left out.
+func reportAmbiguous(out io.Writer, calls []codemap.AmbiguousCall) {
+	if len(calls) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "%d ambiguous calls left out of the call graph:\n", len(calls))
+	for _, call := range calls[:min(len(calls), maxAmbiguousShown)] {
+		fmt.Fprintf(out, "  %s: %s calls %s (%d candidates)\n", call.File, call.Symbol, call.Call, len(call.Candidates))
+	}
+	if len(calls) > maxAmbiguousShown {
+		fmt.Fprintf(out, "  and %d more\n", len(calls)-maxAmbiguousShown)
+	}
+}
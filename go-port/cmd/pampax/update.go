@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alessandrojcm/pampax-go/internal/app"
+	"github.com/alessandrojcm/pampax-go/internal/config"
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+)
+
+func newUpdateCommand() *cobra.Command {
+	var since, until string
+	var dryRun bool
+	var flags indexFlags
+
+	cmd := &cobra.Command{
+		Use:   "update [path]",
+		Short: "Re-index the files that changed since the last index or update",
+		Long: "Re-index the files whose content changed since merkle.json was last saved and remove the\n" +
+			"chunks of deleted files. With --since, only the files git reports as changed between the\n" +
+			"two revisions are looked at.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectRoot := projectPathArg(args)
+			cfg, err := config.Load(projectRoot)
+			if err != nil {
+				return err
+			}
+
+			req := app.UpdateRequest{DryRun: dryRun}
+			if since != "" {
+				opts, err := walkOptions(cfg)
+				if err != nil {
+					return err
+				}
+				changes, err := indexer.GitChanges(cmd.Context(), projectRoot, since, until)
+				if err != nil {
+					return err
+				}
+				// Changed paths are tracked, so only excludes, .pampaxignore
+				// and the file checks decide whether they are re-chunked.
+				result, err := indexer.FilterPaths(cmd.Context(), projectRoot, changes.Changed, opts)
+				if err != nil {
+					return err
+				}
+				req.Files, req.Deleted, req.Partial = result.Files, changes.Deleted, true
+			} else {
+				result, err := listProjectFiles(cmd.Context(), projectRoot, cfg)
+				if err != nil {
+					return err
+				}
+				req.Files = result.Files
+			}
+
+			ix := app.New(projectRoot, app.Options{})
+			if !dryRun {
+				unlock, err := lockIndex(cmd, projectRoot)
+				if err != nil {
+					return err
+				}
+				defer unlock()
+				if ix, err = newProjectIndexer(projectRoot, cfg, flags, cmd.ErrOrStderr()); err != nil {
+					return err
+				}
+			}
+			result, err := ix.Update(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+
+			if dryRun {
+				writeChanges(cmd.OutOrStdout(), result)
+			}
+			reportUpdate(cmd.ErrOrStderr(), result)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "", "only look at files git reports as changed since this revision (branch, tag, HEAD~N or commit)")
+	cmd.Flags().StringVar(&until, "until", "HEAD", "git revision to compare --since to")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "list the changed files without indexing them")
+	flags.register(cmd)
+
+	return cmd
+}
+
+func writeChanges(out io.Writer, result app.UpdateResult) {
+	for _, path := range result.Changes.Added {
+		fmt.Fprintf(out, "A\t%s\n", path)
+	}
+	for _, path := range result.Changes.Modified {
+		fmt.Fprintf(out, "M\t%s\n", path)
+	}
+	for _, path := range result.Changes.Removed {
+		fmt.Fprintf(out, "D\t%s\n", path)
+	}
+}
+
+func reportUpdate(out io.Writer, result app.UpdateResult) {
+	changes := result.Changes
+	fmt.Fprintf(out, "%d added, %d modified, %d deleted", len(changes.Added), len(changes.Modified), len(changes.Removed))
+	if result.ChunksStored+result.ChunksKept+result.ChunksDeleted > 0 {
+		fmt.Fprintf(out, "; %d chunks stored, %d unchanged, %d removed", result.ChunksStored, result.ChunksKept, result.ChunksDeleted)
+	}
+	fmt.Fprintln(out)
+	for _, failed := range result.Failed {
+		fmt.Fprintf(out, "failed: %v\n", failed)
+	}
+	reportAmbiguous(out, result.Ambiguous)
+}
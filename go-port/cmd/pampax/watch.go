@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/alessandrojcm/pampax-go/internal/app"
+	"github.com/alessandrojcm/pampax-go/internal/config"
+)
+
+func newWatchCommand() *cobra.Command {
+	var debounceMs int
+	var flags indexFlags
+
+	cmd := &cobra.Command{
+		Use:   "watch [path]",
+		Short: "Keep the index up to date as files change",
+		Long: "Watch the project for changes and re-index the changed files once no change arrived for\n" +
+			"the debounce interval. Ctrl+C indexes the pending changes before exiting.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectRoot := projectPathArg(args)
+			cfg, err := config.Load(projectRoot)
+			if err != nil {
+				return err
+			}
+			opts, err := walkOptions(cfg)
+			if err != nil {
+				return err
+			}
+			unlock, err := lockIndex(cmd, projectRoot)
+			if err != nil {
+				return err
+			}
+			defer unlock()
+			ix, err := newProjectIndexer(projectRoot, cfg, flags, cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+
+			stderr := cmd.ErrOrStderr()
+			debounce := max(time.Duration(debounceMs)*time.Millisecond, app.MinDebounce)
+			watcher, err := ix.Watch(app.WatchOptions{
+				Walk:     opts,
+				Debounce: debounce,
+				OnBatch: func(batch app.WatchBatch) {
+					if batch.Err != nil {
+						fmt.Fprintf(stderr, "update failed: %v\n", batch.Err)
+						return
+					}
+					reportUpdate(stderr, batch.Result)
+				},
+				OnError: func(err error) {
+					fmt.Fprintf(stderr, "watch: %v\n", err)
+				},
+			})
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			// A second signal kills the process instead of waiting for the
+			// last update.
+			go func() {
+				<-ctx.Done()
+				stop()
+			}()
+
+			fmt.Fprintf(stderr, "Watching %s (debounce %s). Press Ctrl+C to stop.\n", projectRoot, debounce)
+			if err := watcher.Run(ctx); err != nil {
+				return err
+			}
+			fmt.Fprintln(stderr, "Stopped watching.")
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVarP(&debounceMs, "debounce", "d", int(app.DefaultDebounce/time.Millisecond), "debounce interval in milliseconds (minimum 50)")
+	flags.register(cmd)
+
+	return cmd
+}
@@ -0,0 +1,84 @@
+// Package app orchestrates the commands that write a project's index: the
+// chunk files, the SQLite database, the codemap and merkle.json under the
+// project root.
+package app
+
+import (
+	"github.com/rs/zerolog"
+
+	"github.com/alessandrojcm/pampax-go/internal/config"
+	"github.com/alessandrojcm/pampax-go/internal/embedcache"
+	"github.com/alessandrojcm/pampax-go/internal/embedtext"
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+	"github.com/alessandrojcm/pampax-go/internal/providers"
+)
+
+// Options configure an Indexer.
+type Options struct {
+	Chunker  *indexer.Chunker
+	Provider providers.EmbeddingProvider
+	// Cache, when set, serves the embeddings of chunks embedded before.
+	Cache *embedcache.Cache
+	// Template renders the text embedded for each chunk; nil uses
+	// embedtext.Default. Budget bounds it.
+	Template *embedtext.Template
+	Budget   embedtext.Budget
+	// MasterKey, when set, encrypts the chunk files.
+	MasterKey []byte
+	// Logger receives the files an index run could not process and its
+	// summary; nil discards them.
+	Logger *zerolog.Logger
+}
+
+// Indexer writes the index of one project.
+type Indexer struct {
+	paths   config.Paths
+	opts    Options
+	batcher *indexer.Batcher
+}
+
+// New creates the Indexer of the project at projectRoot.
+func New(projectRoot string, opts Options) *Indexer {
+	if opts.Template == nil {
+		opts.Template = embedtext.Default
+	}
+	if opts.Logger == nil {
+		nop := zerolog.Nop()
+		opts.Logger = &nop
+	}
+	ix := &Indexer{paths: config.ResolvePaths(projectRoot), opts: opts}
+	// Without a provider the Indexer can only report changes (dry runs).
+	if opts.Provider != nil {
+		ix.batcher = indexer.NewBatcher(opts.Provider, indexer.BatcherOptions{Cache: opts.Cache})
+	}
+	return ix
+}
+
+// ErrorCategory is the step at which a file failed.
+type ErrorCategory string
+
+const (
+	ErrorRead  ErrorCategory = "read"
+	ErrorParse ErrorCategory = "parse"
+	ErrorEmbed ErrorCategory = "embed"
+	ErrorStore ErrorCategory = "store"
+)
+
+// ErrorCategories lists the categories in pipeline order.
+var ErrorCategories = []ErrorCategory{ErrorRead, ErrorParse, ErrorEmbed, ErrorStore}
+
+// FileError is a file an index run could not process; its previous chunks
+// are left as they were.
+type FileError struct {
+	Path     string
+	Category ErrorCategory
+	Err      error
+}
+
+func (e FileError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e FileError) Unwrap() error {
+	return e.Err
+}
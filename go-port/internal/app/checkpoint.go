@@ -0,0 +1,200 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/alessandrojcm/pampax-go/internal/db"
+)
+
+// maxCheckpointLine bounds a checkpoint line, one file with all its chunks.
+const maxCheckpointLine = 64 << 20
+
+// checkpointHeader is the first line of a checkpoint. A checkpoint written
+// for another provider, model, dimension count, embedding text template or
+// encryption mode is discarded.
+type checkpointHeader struct {
+	Provider   string `json:"provider"`
+	Model      string `json:"model"`
+	Dimensions int    `json:"dimensions"`
+	// Template is the digest of the embedding text template.
+	Template  string `json:"template"`
+	Encrypted bool   `json:"encrypted"`
+}
+
+// checkpointEntry is a line of a checkpoint: a file whose chunks are in
+// code_chunks and the chunk directory, by ID with their SHAs.
+type checkpointEntry struct {
+	File   string            `json:"file"`
+	Chunks map[string]string `json:"chunks"`
+}
+
+// checkpoint is the JSON lines file where Index records the files it
+// stored. The codemap and merkle.json are only written at the end of a
+// run, so after an interruption the checkpoint is what tells the next run
+// which rows it can reuse instead of embedding their chunks again.
+type checkpoint struct {
+	path string
+	file *os.File
+	// resumed maps the chunk IDs recorded by earlier runs to their SHAs.
+	resumed map[string]string
+}
+
+// openCheckpoint loads the checkpoint at path if it matches header, or
+// starts a new one. Lines that cannot be parsed, such as one cut short by
+// a crash, are ignored.
+func openCheckpoint(path string, header checkpointHeader) (*checkpoint, error) {
+	cp := &checkpoint{path: path, resumed: map[string]string{}}
+	matched, err := cp.load(header)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create checkpoint directory: %w", err)
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if !matched {
+		flags |= os.O_TRUNC
+	}
+	cp.file, err = os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open checkpoint: %w", err)
+	}
+	if !matched {
+		if err := cp.append(header); err != nil {
+			cp.file.Close()
+			return nil, err
+		}
+	}
+	return cp, nil
+}
+
+func (cp *checkpoint) load(header checkpointHeader) (bool, error) {
+	file, err := os.Open(cp.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("open checkpoint: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, maxCheckpointLine)
+	var recorded checkpointHeader
+	if !scanner.Scan() || json.Unmarshal(scanner.Bytes(), &recorded) != nil || recorded != header {
+		return false, nil
+	}
+	for scanner.Scan() {
+		var entry checkpointEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+		for id, sha := range entry.Chunks {
+			cp.resumed[id] = sha
+		}
+	}
+	// A line over the limit ends the scan; the files after it are
+	// simply embedded again.
+	return true, nil
+}
+
+// record appends the stored chunks of files, once their rows committed.
+func (cp *checkpoint) record(files []fileChunks) error {
+	for _, file := range files {
+		if len(file.vectors) == 0 {
+			continue
+		}
+		entry := checkpointEntry{File: file.path, Chunks: map[string]string{}}
+		for _, chunk := range file.chunks {
+			id := chunk.ID(file.path)
+			if _, ok := file.vectors[id]; ok {
+				entry.Chunks[id] = chunk.SHA
+			}
+		}
+		if err := cp.append(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cp *checkpoint) append(line any) error {
+	payload, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+	if _, err := cp.file.Write(append(payload, '\n')); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	return nil
+}
+
+func (cp *checkpoint) close() error {
+	return cp.file.Close()
+}
+
+// remove deletes the checkpoint once the codemap and merkle.json cover
+// everything it recorded.
+func (cp *checkpoint) remove() error {
+	cp.close()
+	if err := os.Remove(cp.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove checkpoint: %w", err)
+	}
+	return nil
+}
+
+// resume fills the vectors of the chunks of files that an interrupted run
+// already stored, reading them back from code_chunks. Rows that are gone
+// or no longer match are left to be embedded.
+func (r *indexRun) resume(ctx context.Context, files []fileChunks) error {
+	if len(r.checkpoint.resumed) == 0 {
+		return nil
+	}
+	if r.reader == nil {
+		reader, err := db.OpenReadOnly(ctx, r.ix.paths.DBPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			clear(r.checkpoint.resumed)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		r.reader = reader
+	}
+	q := db.New(r.reader)
+
+	for _, file := range files {
+		for _, chunk := range file.chunks {
+			id := chunk.ID(file.path)
+			if vector, ok := file.vectors[id]; !ok || vector != nil || r.checkpoint.resumed[id] != chunk.SHA {
+				continue
+			}
+			row, err := q.GetChunk(ctx, id)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("read stored chunk %s: %w", id, err)
+			}
+			if row.Sha != chunk.SHA || row.EmbeddingProvider == nil || *row.EmbeddingProvider != r.ix.opts.Provider.Name() {
+				continue
+			}
+			vector, err := db.DecodeEmbedding(row.Embedding)
+			if err != nil || len(vector) != r.ix.opts.Provider.Dimensions() {
+				continue
+			}
+			file.vectors[id] = vector
+			file.resumed[id] = true
+		}
+	}
+	return nil
+}
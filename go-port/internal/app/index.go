@@ -0,0 +1,587 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alessandrojcm/pampax-go/internal/codemap"
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+	"github.com/alessandrojcm/pampax-go/internal/merkle"
+)
+
+// ErrNoProvider is returned by Index when the Indexer has no embedding
+// provider.
+var ErrNoProvider = errors.New("indexing needs an embedding provider")
+
+// Stage is a step of the index pipeline.
+type Stage string
+
+const (
+	StageDiscover Stage = "discover"
+	StageParse    Stage = "parse"
+	StageChunk    Stage = "chunk"
+	StageEmbed    Stage = "embed"
+	StageStore    Stage = "store"
+)
+
+// Stages lists the pipeline stages in order.
+var Stages = []Stage{StageDiscover, StageParse, StageChunk, StageEmbed, StageStore}
+
+// IndexRequest configures Index.
+type IndexRequest struct {
+	// Discover lists the files of the project, as indexer.Walk does.
+	Discover func(context.Context) (indexer.WalkResult, error)
+	// Workers is how many files are parsed and chunked at once; zero uses
+	// GOMAXPROCS.
+	Workers int
+	// Progress, when set, receives the progress of the run. It is never
+	// called concurrently.
+	Progress func(Progress)
+}
+
+// Progress is reported once discovery is done and whenever a file leaves
+// the pipeline.
+type Progress struct {
+	// Stage is the last stage the file went through: files whose content
+	// did not change, or that could not be read, leave after parse, and
+	// files that fail leave after the stage that failed.
+	Stage Stage  `json:"stage"`
+	File  string `json:"file,omitempty"`
+	// Done counts the files that left the pipeline out of Total.
+	Done  int `json:"done"`
+	Total int `json:"total"`
+	// Chunks counts the chunks stored so far.
+	Chunks int `json:"chunks"`
+}
+
+// IndexResult summarizes an index run, like indexProject's result.
+type IndexResult struct {
+	// Files were discovered; Skipped are the paths discovery left out.
+	Files   int
+	Skipped int
+	// Indexed files were chunked again because their content changed;
+	// Unchanged files were not. Removed files are no longer indexed.
+	Indexed   int
+	Unchanged int
+	Removed   int
+	// ChunksStored were embedded and written (processedChunks); ChunksResumed
+	// were stored by an interrupted run and reused from its checkpoint;
+	// ChunksKept were unchanged chunks of indexed files; ChunksDeleted were
+	// dropped. TotalChunks is the size of the codemap after the run.
+	ChunksStored  int
+	ChunksResumed int
+	ChunksKept    int
+	ChunksDeleted int
+	TotalChunks   int
+	Chunking      indexer.ChunkStats
+	// Failed lists the files that could not be read, parsed, embedded or
+	// stored; their previous chunks are kept and the next run tries them
+	// again.
+	Failed []FileError
+	// Ambiguous are the calls with several possible definitions, which get
+	// no edge in the call graph.
+	Ambiguous []codemap.AmbiguousCall
+	// Timings is the time spent in each stage, summed over its workers.
+	Timings map[Stage]time.Duration
+	Elapsed time.Duration
+}
+
+// TooManyFailures reports whether more files failed than maxErrors allows;
+// a negative maxErrors allows any number.
+func (r IndexResult) TooManyFailures(maxErrors int) bool {
+	return maxErrors >= 0 && len(r.Failed) > maxErrors
+}
+
+// maxFailedInARow is how many files may fail to embed or store one after
+// the other before Index gives up: past that the provider or the database
+// is down rather than a file at fault.
+const maxFailedInARow = 8
+
+// indexItem is a file going through the pipeline.
+type indexItem struct {
+	file    indexer.WalkedFile
+	stage   Stage
+	entry   merkle.Entry
+	source  []byte
+	tree    *indexer.Tree
+	changed bool
+	missing bool
+	err     *FileError
+	chunks  fileChunks
+	stats   indexer.ChunkStats
+}
+
+// indexRun is the state of one Index call.
+type indexRun struct {
+	ix         *Indexer
+	req        IndexRequest
+	known      map[string]string
+	prev       *merkle.Tree
+	next       *merkle.Tree
+	checkpoint *checkpoint
+	reader     *sql.DB
+	result     *IndexResult
+	done       int
+	// failedInARow counts, by stage, the files isolate failed since one
+	// went through.
+	failedInARow map[Stage]int
+
+	mu sync.Mutex
+}
+
+// Index ports indexProject: it runs every discovered file through the
+// parse → chunk → embed → store pipeline, skipping the files whose content
+// did not change since merkle.json was saved and the chunks already in the
+// codemap, and removes the files that are gone. The codemap and
+// merkle.json are only written once every file went through; until then
+// a checkpoint records the stored files, so a run that is interrupted is
+// resumed by the next one without embedding them again.
+//
+// A file that fails does not stop the run: it is listed in Failed and
+// logged. Whatever the outcome, the run is reported to
+// last-index-report.json.
+func (ix *Indexer) Index(ctx context.Context, req IndexRequest) (IndexResult, error) {
+	started := time.Now()
+	result, err := ix.index(ctx, req)
+	result.Elapsed = time.Since(started)
+	if reportErr := writeIndexReport(ix.paths.Report, newIndexReport(started, result, err)); err == nil {
+		err = reportErr
+	}
+	ix.logIndex(result, err)
+	return result, err
+}
+
+func (ix *Indexer) index(ctx context.Context, req IndexRequest) (IndexResult, error) {
+	result := IndexResult{Timings: map[Stage]time.Duration{}}
+	if ix.batcher == nil {
+		return result, ErrNoProvider
+	}
+	cm, err := ix.readCodemap()
+	if err != nil {
+		return result, err
+	}
+	prev, err := merkle.Load(ix.paths.Merkle)
+	if err != nil {
+		return result, err
+	}
+	cp, err := openCheckpoint(ix.paths.Checkpoint, checkpointHeader{
+		Provider:   ix.opts.Provider.Name(),
+		Model:      ix.opts.Provider.Model(),
+		Dimensions: ix.opts.Provider.Dimensions(),
+		Template:   ix.opts.Template.Digest(),
+		Encrypted:  ix.opts.MasterKey != nil,
+	})
+	if err != nil {
+		return result, err
+	}
+	defer cp.close()
+	run := &indexRun{ix: ix, req: req, known: knownChunks(cm), prev: prev, next: merkle.New(), checkpoint: cp, result: &result, failedInARow: map[Stage]int{}}
+	defer func() {
+		if run.reader != nil {
+			run.reader.Close()
+		}
+	}()
+
+	discoverStarted := time.Now()
+	walked, err := req.Discover(ctx)
+	if err != nil {
+		return result, err
+	}
+	run.clock(StageDiscover, discoverStarted)
+	result.Files, result.Skipped = len(walked.Files), len(walked.Skipped)
+	run.report(Progress{Stage: StageDiscover, Total: result.Files})
+
+	files, err := run.pipeline(ctx, walked.Files)
+	if err != nil {
+		return result, err
+	}
+
+	slices.SortFunc(result.Failed, func(a, b FileError) int { return strings.Compare(a.Path, b.Path) })
+
+	var removed []string
+	for _, path := range prev.Files() {
+		if _, ok := run.next.Get(path); !ok {
+			removed = append(removed, path)
+		}
+	}
+	removed = ix.orphanedFiles(cm, run.next, UpdateRequest{}, removed)
+	result.Removed = len(removed)
+
+	storeStarted := time.Now()
+	if len(removed) > 0 {
+		if err := ix.storeChunks(ctx, nil, removed); err != nil {
+			return result, err
+		}
+	}
+	stored, err := ix.commit(cm, files, removed)
+	if err != nil {
+		return result, err
+	}
+	if err := merkle.Save(ix.paths.Merkle, run.next); err != nil {
+		return result, err
+	}
+	if err := cp.remove(); err != nil {
+		return result, err
+	}
+	run.clock(StageStore, storeStarted)
+
+	result.ChunksDeleted = stored.deleted
+	result.Ambiguous = stored.ambiguous
+	result.TotalChunks = len(cm.Keys())
+	return result, nil
+}
+
+// pipeline runs files through the stages, connected by channels: parse
+// and chunk have Workers goroutines each, embed batches the chunks of
+// several files into one provider round and store writes them in one
+// transaction. It returns the indexed files, whose vectors were released.
+func (r *indexRun) pipeline(parent context.Context, files []indexer.WalkedFile) ([]fileChunks, error) {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	workers := r.req.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	queued := make(chan indexer.WalkedFile)
+	parsed := make(chan *indexItem, workers)
+	chunked := make(chan *indexItem, workers)
+	embedded := make(chan []*indexItem, 1)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(queued)
+		for _, file := range files {
+			if !send(ctx, queued, file) {
+				return
+			}
+		}
+	}()
+	fanOut(&wg, workers, parsed, func() {
+		for file := range queued {
+			if !send(ctx, parsed, r.parse(file)) {
+				return
+			}
+		}
+	})
+	fanOut(&wg, workers, chunked, func() {
+		for item := range parsed {
+			r.chunk(item)
+			if !send(ctx, chunked, item) {
+				return
+			}
+		}
+	})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(embedded)
+		if err := r.embed(ctx, chunked, embedded); err != nil {
+			fail(err)
+		}
+	}()
+
+	var indexed []fileChunks
+	for group := range embedded {
+		stored, err := r.store(ctx, group)
+		if err != nil {
+			fail(err)
+			break
+		}
+		indexed = append(indexed, stored...)
+	}
+	cancel()
+	wg.Wait()
+
+	if err := parent.Err(); err != nil {
+		return nil, err
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return indexed, nil
+}
+
+// parse reads and hashes a file and, when its content changed, parses it.
+// Sources without a parser, or that fail to parse, are cut into windows by
+// the chunk stage.
+func (r *indexRun) parse(file indexer.WalkedFile) *indexItem {
+	started := time.Now()
+	defer r.clock(StageParse, started)
+
+	item := &indexItem{file: file, stage: StageParse}
+	defer item.recover(ErrorParse)
+	entry, source, ok, err := merkle.ScanFile(r.ix.paths.Root, file.Path, r.prev)
+	switch {
+	case err != nil:
+		item.fail(ErrorRead, err)
+		return item
+	case !ok:
+		item.missing = true
+		return item
+	}
+	item.entry = entry
+	if old, known := r.prev.Get(file.Path); source == nil || known && old.ShaFile == entry.ShaFile {
+		return item
+	}
+
+	item.changed, item.source = true, source
+	if file.Rule.Lang != "" {
+		tree, err := indexer.ParseFile(file.Rule, source)
+		if err != nil && !errors.Is(err, indexer.ErrUnsupportedLanguage) {
+			r.ix.opts.Logger.Debug().Str("file", file.Path).Err(err).Msg("parse failed, chunking into line windows")
+		}
+		item.tree = tree
+	}
+	return item
+}
+
+func (r *indexRun) chunk(item *indexItem) {
+	if !item.indexed() {
+		return
+	}
+	started := time.Now()
+	defer r.clock(StageChunk, started)
+	item.stage = StageChunk
+	defer item.recover(ErrorParse)
+
+	chunked, stats := r.ix.opts.Chunker.ChunkParsed(item.file.Path, item.file.Rule, item.source, item.tree)
+	file, hashes := newFileChunks(r.known, item.file.Path, item.file.Rule, chunked)
+	item.chunks, item.stats, item.stage = file, stats, StageStore
+	item.entry.ChunkShas = hashes
+	item.source, item.tree = nil, nil
+}
+
+// embed groups the files of in until they hold enough chunks for one
+// round of provider calls, or no other file is waiting, and embeds them.
+func (r *indexRun) embed(ctx context.Context, in <-chan *indexItem, out chan<- []*indexItem) error {
+	limits := r.ix.opts.Provider.BatchLimits()
+	groupSize := max(limits.MaxBatchSize, 1) * max(limits.MaxConcurrency, 1)
+
+	var group []*indexItem
+	pending := 0
+	flush := func() error {
+		if len(group) == 0 {
+			return nil
+		}
+		started := time.Now()
+		if err := r.resume(ctx, indexedFiles(group)); err != nil {
+			return err
+		}
+		if err := r.isolate(ctx, group, StageEmbed, ErrorEmbed, r.ix.embed); err != nil {
+			return err
+		}
+		if pending > 0 {
+			r.clock(StageEmbed, started)
+		}
+		if !send(ctx, out, group) {
+			return ctx.Err()
+		}
+		group, pending = nil, 0
+		return nil
+	}
+
+	for item := range in {
+		group = append(group, item)
+		pending += len(item.chunks.vectors)
+		if pending < groupSize && len(in) > 0 {
+			continue
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+	return flush()
+}
+
+// store writes the chunks of group and accounts for its files.
+func (r *indexRun) store(ctx context.Context, group []*indexItem) ([]fileChunks, error) {
+	storeChunks := func(ctx context.Context, files []fileChunks) error {
+		return r.ix.storeChunks(ctx, files, nil)
+	}
+	started := time.Now()
+	if err := r.isolate(ctx, group, StageStore, ErrorStore, storeChunks); err != nil {
+		return nil, err
+	}
+	files := indexedFiles(group)
+	if len(files) > 0 {
+		if err := r.checkpoint.record(files); err != nil {
+			return nil, err
+		}
+		r.clock(StageStore, started)
+	}
+
+	result := r.result
+	for _, item := range group {
+		path := item.file.Path
+		switch {
+		case item.err != nil:
+			result.Failed = append(result.Failed, *item.err)
+			r.ix.opts.Logger.Warn().Str("file", path).Str("category", string(item.err.Category)).Err(item.err.Err).Msg("file not indexed")
+			if entry, ok := r.prev.Get(path); ok {
+				r.next.Set(path, entry)
+			}
+		case item.missing:
+		case !item.changed:
+			result.Unchanged++
+			r.next.Set(path, item.entry)
+		default:
+			stored, resumed := len(item.chunks.vectors), len(item.chunks.resumed)
+			result.Indexed++
+			result.ChunksStored += stored - resumed
+			result.ChunksResumed += resumed
+			result.ChunksKept += len(item.chunks.chunks) - stored
+			result.Chunking.Add(item.stats)
+			r.next.Set(path, item.entry)
+			// Only the IDs are needed from here on.
+			for id := range item.chunks.vectors {
+				item.chunks.vectors[id] = nil
+			}
+		}
+		r.done++
+		r.report(Progress{Stage: item.stage, File: path, Done: r.done, Total: result.Files, Chunks: result.ChunksStored})
+	}
+	return files, nil
+}
+
+// isolate runs step on the files of group that are still indexed. When
+// that fails, step runs again on each file alone and only the files it
+// fails for are marked failed in category; the run goes on without them
+// unless maxFailedInARow files failed in a row.
+func (r *indexRun) isolate(ctx context.Context, group []*indexItem, stage Stage, category ErrorCategory, step func(context.Context, []fileChunks) error) error {
+	files := indexedFiles(group)
+	if len(files) == 0 {
+		return nil
+	}
+	err := step(ctx, files)
+	if err == nil {
+		r.streak(stage, false)
+	}
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	alone := len(files) == 1
+	for _, item := range group {
+		if !item.indexed() {
+			continue
+		}
+		if !alone {
+			err = step(ctx, []fileChunks{item.chunks})
+		}
+		if err == nil {
+			r.streak(stage, false)
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		item.fail(category, err)
+		item.stage = stage
+		if failed := r.streak(stage, true); failed >= maxFailedInARow {
+			return fmt.Errorf("%d files in a row failed to %s, giving up: %w", failed, stage, err)
+		}
+	}
+	return nil
+}
+
+// streak counts a file that failed in stage, or resets the count when
+// one went through, and returns it.
+func (r *indexRun) streak(stage Stage, failed bool) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !failed {
+		r.failedInARow[stage] = 0
+		return 0
+	}
+	r.failedInARow[stage]++
+	return r.failedInARow[stage]
+}
+
+func indexedFiles(group []*indexItem) []fileChunks {
+	var files []fileChunks
+	for _, item := range group {
+		if item.indexed() {
+			files = append(files, item.chunks)
+		}
+	}
+	return files
+}
+
+// indexed reports whether the chunks of the file are to be stored.
+func (item *indexItem) indexed() bool {
+	return item.changed && item.err == nil
+}
+
+func (item *indexItem) fail(category ErrorCategory, err error) {
+	item.err = &FileError{Path: item.file.Path, Category: category, Err: err}
+}
+
+// recover turns a panic while processing the file, such as a parser
+// choking on its source, into a failure in category.
+func (item *indexItem) recover(category ErrorCategory) {
+	if p := recover(); p != nil {
+		item.fail(category, fmt.Errorf("panic: %v", p))
+		item.source, item.tree = nil, nil
+	}
+}
+
+func (r *indexRun) report(progress Progress) {
+	if r.req.Progress != nil {
+		r.req.Progress(progress)
+	}
+}
+
+func (r *indexRun) clock(stage Stage, started time.Time) {
+	elapsed := time.Since(started)
+	r.mu.Lock()
+	r.result.Timings[stage] += elapsed
+	r.mu.Unlock()
+}
+
+// send delivers v unless ctx is done first.
+func send[T any](ctx context.Context, ch chan<- T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// fanOut runs n copies of work and closes out once they all returned.
+func fanOut[T any](wg *sync.WaitGroup, n int, out chan T, work func()) {
+	var workers sync.WaitGroup
+	workers.Add(n)
+	for range n {
+		go func() {
+			defer workers.Done()
+			work()
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		workers.Wait()
+		close(out)
+	}()
+}
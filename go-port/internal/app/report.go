@@ -0,0 +1,147 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Report statuses.
+const (
+	ReportOK          = "ok"
+	ReportInterrupted = "interrupted"
+	ReportFailed      = "failed"
+)
+
+// IndexReport is the machine-readable account of an index run that Index
+// writes to last-index-report.json.
+type IndexReport struct {
+	// Status is ReportOK when the run went through, even if some files
+	// failed; otherwise Error says what stopped it.
+	Status        string                `json:"status"`
+	Error         string                `json:"error,omitempty"`
+	StartedAt     time.Time             `json:"startedAt"`
+	ElapsedMs     int64                 `json:"elapsedMs"`
+	Files         int                   `json:"files"`
+	Indexed       int                   `json:"indexed"`
+	Unchanged     int                   `json:"unchanged"`
+	Removed       int                   `json:"removed"`
+	Skipped       int                   `json:"skipped"`
+	ChunksStored  int                   `json:"chunksStored"`
+	ChunksResumed int                   `json:"chunksResumed"`
+	TotalChunks   int                   `json:"totalChunks"`
+	ErrorCount    int                   `json:"errorCount"`
+	ByCategory    map[ErrorCategory]int `json:"errorsByCategory"`
+	Errors        []ReportedError       `json:"errors"`
+	// AmbiguousCalls are the calls left out of the call graph because
+	// several definitions match them.
+	AmbiguousCalls []ReportedAmbiguousCall `json:"ambiguousCalls"`
+}
+
+// ReportedError is a FileError in an IndexReport.
+type ReportedError struct {
+	File     string        `json:"file"`
+	Category ErrorCategory `json:"category"`
+	Error    string        `json:"error"`
+}
+
+// ReportedAmbiguousCall is a codemap.AmbiguousCall in an IndexReport.
+type ReportedAmbiguousCall struct {
+	File   string `json:"file"`
+	Symbol string `json:"symbol"`
+	Call   string `json:"call"`
+	// Candidates are the SHAs of the chunks the call may reach.
+	Candidates []string `json:"candidates"`
+}
+
+func newIndexReport(started time.Time, result IndexResult, err error) IndexReport {
+	report := IndexReport{
+		Status:         ReportOK,
+		StartedAt:      started.UTC(),
+		ElapsedMs:      result.Elapsed.Milliseconds(),
+		Files:          result.Files,
+		Indexed:        result.Indexed,
+		Unchanged:      result.Unchanged,
+		Removed:        result.Removed,
+		Skipped:        result.Skipped,
+		ChunksStored:   result.ChunksStored,
+		ChunksResumed:  result.ChunksResumed,
+		TotalChunks:    result.TotalChunks,
+		ErrorCount:     len(result.Failed),
+		ByCategory:     map[ErrorCategory]int{},
+		Errors:         []ReportedError{},
+		AmbiguousCalls: []ReportedAmbiguousCall{},
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		report.Status, report.Error = ReportInterrupted, err.Error()
+	case err != nil:
+		report.Status, report.Error = ReportFailed, err.Error()
+	}
+	for _, category := range ErrorCategories {
+		report.ByCategory[category] = 0
+	}
+	for _, failed := range result.Failed {
+		report.ByCategory[failed.Category]++
+		report.Errors = append(report.Errors, ReportedError{File: failed.Path, Category: failed.Category, Error: failed.Err.Error()})
+	}
+	for _, call := range result.Ambiguous {
+		report.AmbiguousCalls = append(report.AmbiguousCalls, ReportedAmbiguousCall{File: call.File, Symbol: call.Symbol, Call: call.Call, Candidates: call.Candidates})
+	}
+	return report
+}
+
+// ReadIndexReport reads the report of the last index run.
+func ReadIndexReport(path string) (IndexReport, error) {
+	var report IndexReport
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return report, fmt.Errorf("read index report: %w", err)
+	}
+	if err := json.Unmarshal(payload, &report); err != nil {
+		return report, fmt.Errorf("parse index report: %w", err)
+	}
+	return report, nil
+}
+
+func writeIndexReport(path string, report IndexReport) error {
+	payload, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal index report: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create index report directory: %w", err)
+	}
+	if err := os.WriteFile(path, payload, 0o644); err != nil {
+		return fmt.Errorf("write index report: %w", err)
+	}
+	return nil
+}
+
+// logIndex logs the outcome of an index run; the files that failed were
+// logged as they left the pipeline.
+func (ix *Indexer) logIndex(result IndexResult, err error) {
+	log := ix.opts.Logger
+	if err != nil {
+		log.Error().Err(err).Int("indexed", result.Indexed).Int("failed", len(result.Failed)).Msg("index run stopped")
+		return
+	}
+	event := log.Info()
+	if len(result.Failed) > 0 {
+		event = log.Warn()
+	}
+	event.Int("files", result.Files).
+		Int("indexed", result.Indexed).
+		Int("unchanged", result.Unchanged).
+		Int("removed", result.Removed).
+		Int("failed", len(result.Failed)).
+		Int("ambiguousCalls", len(result.Ambiguous)).
+		Int("chunksStored", result.ChunksStored).
+		Int("totalChunks", result.TotalChunks).
+		Dur("elapsed", result.Elapsed).
+		Msg("index run finished")
+}
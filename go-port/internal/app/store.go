@@ -0,0 +1,279 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/alessandrojcm/pampax-go/internal/chunks"
+	"github.com/alessandrojcm/pampax-go/internal/codemap"
+	"github.com/alessandrojcm/pampax-go/internal/db"
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+)
+
+// fileChunks is the new state of an indexed file.
+type fileChunks struct {
+	path   string
+	lang   string
+	chunks []indexer.Chunk
+	// vectors holds the embeddings of the chunks to store, by chunk ID;
+	// the other chunks are already in the index.
+	vectors map[string][]float64
+	// resumed marks the vectors read back from the rows of an interrupted
+	// index run; those rows and chunk files are reused as they are.
+	resumed map[string]bool
+}
+
+// storeResult counts what store changed.
+type storeResult struct {
+	stored  int
+	deleted int
+	// ambiguous are the calls the call graph left out.
+	ambiguous []codemap.AmbiguousCall
+}
+
+// store writes files and drops every chunk of removed. The ordering keeps
+// the index consistent if the process dies at any point:
+//
+//  1. chunk files are written first; they are named by SHA, so extra ones
+//     are harmless;
+//  2. code_chunks rows change in one transaction;
+//  3. the codemap is replaced atomically, only the entries of the affected
+//     files changing;
+//  4. chunk files nothing references any more are removed last.
+//
+// A run interrupted before 3 is redone by the next update, because
+// merkle.json is saved after store returns.
+func (ix *Indexer) store(ctx context.Context, cm *codemap.OrderedMap, files []fileChunks, removed []string) (storeResult, error) {
+	if err := ix.storeChunks(ctx, files, removed); err != nil {
+		return storeResult{}, err
+	}
+	return ix.commit(cm, files, removed)
+}
+
+// storeChunks carries out steps 1 and 2 of store.
+func (ix *Indexer) storeChunks(ctx context.Context, files []fileChunks, removed []string) error {
+	for _, file := range files {
+		for _, chunk := range file.chunks {
+			id := chunk.ID(file.path)
+			if _, ok := file.vectors[id]; !ok {
+				continue
+			}
+			if file.resumed[id] && chunks.ChunkExists(ix.paths.ChunkDir, chunk.SHA, ix.opts.MasterKey != nil) {
+				continue
+			}
+			if err := chunks.WriteChunk(ix.paths.ChunkDir, chunk.SHA, chunk.Code, ix.opts.MasterKey != nil, ix.opts.MasterKey); err != nil {
+				return fmt.Errorf("%s: %w", file.path, err)
+			}
+		}
+	}
+	return ix.storeRows(ctx, files, removed)
+}
+
+// commit carries out steps 3 and 4 of store. Only the keys of the vectors
+// of files are looked at.
+func (ix *Indexer) commit(cm *codemap.OrderedMap, files []fileChunks, removed []string) (storeResult, error) {
+	var result storeResult
+	encrypted := ix.opts.MasterKey != nil
+	byFile := map[string][]string{}
+	for _, key := range cm.Keys() {
+		value, _ := cm.Get(key)
+		if entry, ok := value.(codemap.ChunkMetadata); ok {
+			byFile[entry.File] = append(byFile[entry.File], key)
+		}
+	}
+	var obsolete []string
+	drop := func(key string) {
+		value, _ := cm.Get(key)
+		obsolete = append(obsolete, value.(codemap.ChunkMetadata).SHA)
+		cm.Delete(key)
+		result.deleted++
+	}
+	for _, path := range removed {
+		for _, key := range byFile[path] {
+			drop(key)
+		}
+	}
+	for _, file := range files {
+		keep := map[string]bool{}
+		for _, chunk := range file.chunks {
+			keep[chunk.ID(file.path)] = true
+		}
+		for _, key := range byFile[file.path] {
+			if !keep[key] {
+				drop(key)
+			}
+		}
+		for _, chunk := range file.chunks {
+			id := chunk.ID(file.path)
+			if _, ok := file.vectors[id]; !ok {
+				continue
+			}
+			entry := chunk.Metadata(file.path, file.lang)
+			entry.Provider = ix.opts.Provider.Name()
+			entry.Dimensions = ix.opts.Provider.Dimensions()
+			entry.Encrypted = encrypted
+			cm.Set(id, codemap.NormalizeChunkMetadata(entry))
+			result.stored++
+		}
+	}
+	result.ambiguous = codemap.AttachSymbolGraph(cm, ix.callGraph(cm, files))
+	if err := codemap.WriteCodemap(ix.paths.Codemap, cm); err != nil {
+		return result, err
+	}
+
+	referenced := map[string]bool{}
+	for _, key := range cm.Keys() {
+		value, _ := cm.Get(key)
+		referenced[value.(codemap.ChunkMetadata).SHA] = true
+	}
+	for _, sha := range obsolete {
+		if referenced[sha] {
+			continue
+		}
+		referenced[sha] = true
+		if err := chunks.RemoveChunk(ix.paths.ChunkDir, sha); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// callGraph resolves the calls of the indexed files through their imports.
+// The codemap keeps neither imports nor calls, so every file of a language
+// the graph covers is read again, and those not in files chunked again. A
+// file that cannot be read or chunked keeps the symbol-name lookup.
+func (ix *Indexer) callGraph(cm *codemap.OrderedMap, files []fileChunks) *indexer.CallGraph {
+	graph := indexer.NewCallGraph(indexer.GoModulePath(ix.paths.Root))
+	chunked := map[string][]indexer.Chunk{}
+	for _, file := range files {
+		chunked[file.path] = file.chunks
+	}
+	langs := map[string]string{}
+	for _, key := range cm.Keys() {
+		value, _ := cm.Get(key)
+		if entry, ok := value.(codemap.ChunkMetadata); ok && indexer.ResolvesCalls(entry.Lang) {
+			langs[entry.File] = entry.Lang
+		}
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(langs)) {
+		lang := langs[path]
+		source, err := os.ReadFile(filepath.Join(ix.paths.Root, filepath.FromSlash(path)))
+		if err != nil {
+			continue
+		}
+		sourceChunks, ok := chunked[path]
+		if !ok {
+			rule, known := indexer.RuleForLang(lang)
+			if !known {
+				continue
+			}
+			var failed *FileError
+			if sourceChunks, failed = ix.chunkSource(path, rule, source); failed != nil {
+				continue
+			}
+		}
+		graph.AddFile(path, lang, source, sourceChunks)
+	}
+	return graph
+}
+
+// storeRows applies the code_chunks changes in one transaction.
+func (ix *Indexer) storeRows(ctx context.Context, files []fileChunks, removed []string) error {
+	conn, err := db.Open(ctx, ix.paths.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	q := db.New(tx)
+
+	for _, path := range removed {
+		if err := q.DeleteChunksByFile(ctx, path); err != nil {
+			return fmt.Errorf("delete chunks of %s: %w", path, err)
+		}
+	}
+	for _, file := range files {
+		keep := map[string]bool{}
+		for _, chunk := range file.chunks {
+			keep[chunk.ID(file.path)] = true
+		}
+		rows, err := q.ListChunksByFile(ctx, file.path)
+		if err != nil {
+			return fmt.Errorf("list chunks of %s: %w", file.path, err)
+		}
+		for _, row := range rows {
+			if keep[row.ID] {
+				continue
+			}
+			if err := q.DeleteChunk(ctx, row.ID); err != nil {
+				return fmt.Errorf("delete chunk %s: %w", row.ID, err)
+			}
+		}
+		for _, chunk := range file.chunks {
+			id := chunk.ID(file.path)
+			vector, ok := file.vectors[id]
+			if !ok || file.resumed[id] {
+				continue
+			}
+			if err := q.UpsertChunk(ctx, ix.chunkRow(id, file.path, file.lang, chunk, vector)); err != nil {
+				return fmt.Errorf("store chunk %s: %w", id, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// chunkRow is the code_chunks row of a chunk, as embedAndStore writes it.
+func (ix *Indexer) chunkRow(id, file, lang string, chunk indexer.Chunk, vector []float64) db.UpsertChunkParams {
+	dimensions := int64(ix.opts.Provider.Dimensions())
+	variables := chunk.Variables
+	if variables == nil {
+		variables = []indexer.Variable{}
+	}
+	variablesJSON, _ := json.Marshal(variables)
+	contextJSON, _ := json.Marshal(chunk.ContextInfo())
+
+	return db.UpsertChunkParams{
+		ID:                  id,
+		FilePath:            file,
+		Symbol:              chunk.Symbol,
+		Sha:                 chunk.SHA,
+		Lang:                lang,
+		ChunkType:           db.Text(chunk.ChunkType),
+		Embedding:           db.EncodeEmbedding(vector),
+		EmbeddingProvider:   db.Text(ix.opts.Provider.Name()),
+		EmbeddingDimensions: &dimensions,
+		PampaTags:           db.StringList(chunk.Tags),
+		PampaIntent:         db.Text(chunk.Pampa.Intent),
+		PampaDescription:    db.Text(chunk.Pampa.Description),
+		DocComments:         db.Text(chunk.DocComment),
+		VariablesUsed:       db.Text(string(variablesJSON)),
+		ContextInfo:         db.Text(string(contextJSON)),
+	}
+}
+
+// readCodemap loads the codemap, empty when the project has none yet.
+func (ix *Indexer) readCodemap() (*codemap.OrderedMap, error) {
+	cm, err := codemap.ReadCodemap(ix.paths.Codemap)
+	if errors.Is(err, fs.ErrNotExist) {
+		return codemap.NewOrderedMap(), nil
+	}
+	return cm, err
+}
@@ -0,0 +1,260 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/alessandrojcm/pampax-go/internal/codemap"
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+	"github.com/alessandrojcm/pampax-go/internal/merkle"
+)
+
+// UpdateRequest selects the files Update reconciles.
+type UpdateRequest struct {
+	// Files are the indexable files of the project. Unless Partial is
+	// set they are all of them, and indexed files not listed are removed.
+	Files []indexer.WalkedFile
+	// Deleted lists removed files; with Partial, only Files and Deleted
+	// are looked at.
+	Deleted []string
+	Partial bool
+	// DryRun reports the changes without writing anything.
+	DryRun bool
+}
+
+// UpdateResult reports what Update changed.
+type UpdateResult struct {
+	// Changes are the files whose content differs from merkle.json.
+	Changes merkle.Changes
+	// ChunksStored were embedded and written; ChunksKept were unchanged
+	// chunks of changed files; ChunksDeleted were dropped.
+	ChunksStored  int
+	ChunksKept    int
+	ChunksDeleted int
+	// Failed lists the changed files that could not be read or parsed;
+	// they are retried by the next update.
+	Failed []FileError
+	// Ambiguous are the calls with several possible definitions, which get
+	// no edge in the call graph.
+	Ambiguous []codemap.AmbiguousCall
+}
+
+// Update ports updateIndex: it re-indexes the files whose content changed
+// since merkle.json was saved and removes the chunks of deleted files.
+// Chunks whose ID and SHA are already in the codemap are not embedded
+// again.
+func (ix *Indexer) Update(ctx context.Context, req UpdateRequest) (UpdateResult, error) {
+	var result UpdateResult
+	if ix.batcher == nil && !req.DryRun {
+		return result, ErrNoProvider
+	}
+	cm, err := ix.readCodemap()
+	if err != nil {
+		return result, err
+	}
+	prev, err := merkle.Load(ix.paths.Merkle)
+	if err != nil {
+		return result, err
+	}
+
+	rules := map[string]indexer.LangRule{}
+	paths := make([]string, len(req.Files))
+	for i, file := range req.Files {
+		rules[file.Path] = file.Rule
+		paths[i] = file.Path
+	}
+	scanned, err := merkle.Scan(ix.paths.Root, paths, prev)
+	if err != nil {
+		return result, err
+	}
+	next := scanned
+	if req.Partial {
+		next = prev.Clone()
+		for _, path := range paths {
+			if entry, ok := scanned.Get(path); ok {
+				next.Set(path, entry)
+			} else {
+				next.Remove(path)
+			}
+		}
+		for _, path := range req.Deleted {
+			next.Remove(path)
+		}
+	}
+	result.Changes = merkle.Diff(prev, next)
+	result.Changes.Removed = ix.orphanedFiles(cm, next, req, result.Changes.Removed)
+
+	if req.DryRun {
+		return result, nil
+	}
+
+	known := knownChunks(cm)
+	var files []fileChunks
+	for _, path := range slices.Concat(result.Changes.Added, result.Changes.Modified) {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		chunked, failed := ix.readChunks(path, rules[path])
+		if failed != nil {
+			result.Failed = append(result.Failed, *failed)
+			if entry, ok := prev.Get(path); ok {
+				next.Set(path, entry)
+			} else {
+				next.Remove(path)
+			}
+			continue
+		}
+		file, hashes := newFileChunks(known, path, rules[path], chunked)
+		entry, _ := next.Get(path)
+		entry.ChunkShas = hashes
+		next.Set(path, entry)
+		result.ChunksKept += len(file.chunks) - len(file.vectors)
+		files = append(files, file)
+	}
+
+	if err := ix.embed(ctx, files); err != nil {
+		return result, err
+	}
+	stored, err := ix.store(ctx, cm, files, result.Changes.Removed)
+	if err != nil {
+		return result, err
+	}
+	result.ChunksStored, result.ChunksDeleted = stored.stored, stored.deleted
+	result.Ambiguous = stored.ambiguous
+
+	if err := merkle.Save(ix.paths.Merkle, next); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// readChunks reads and chunks the file at path.
+func (ix *Indexer) readChunks(path string, rule indexer.LangRule) ([]indexer.Chunk, *FileError) {
+	source, err := os.ReadFile(filepath.Join(ix.paths.Root, filepath.FromSlash(path)))
+	if err != nil {
+		return nil, &FileError{Path: path, Category: ErrorRead, Err: err}
+	}
+	return ix.chunkSource(path, rule, source)
+}
+
+// chunkSource chunks the source of the file at path. A parser that panics
+// on it fails the file rather than the run.
+func (ix *Indexer) chunkSource(path string, rule indexer.LangRule, source []byte) (chunked []indexer.Chunk, failed *FileError) {
+	defer func() {
+		if p := recover(); p != nil {
+			chunked, failed = nil, &FileError{Path: path, Category: ErrorParse, Err: fmt.Errorf("panic: %v", p)}
+		}
+	}()
+	chunked, _ = ix.opts.Chunker.ChunkFile(path, rule, source)
+	return chunked, nil
+}
+
+// orphanedFiles adds to removed the files the codemap indexes that are no
+// longer part of the project (for a partial update, the deleted ones) but
+// that merkle.json did not know, as after an index written without it.
+func (ix *Indexer) orphanedFiles(cm *codemap.OrderedMap, next *merkle.Tree, req UpdateRequest, removed []string) []string {
+	gone := map[string]bool{}
+	for _, path := range removed {
+		gone[path] = true
+	}
+	deleted := map[string]bool{}
+	for _, path := range req.Deleted {
+		deleted[path] = true
+	}
+	for _, key := range cm.Keys() {
+		value, _ := cm.Get(key)
+		entry, ok := value.(codemap.ChunkMetadata)
+		if !ok || gone[entry.File] {
+			continue
+		}
+		if _, tracked := next.Get(entry.File); tracked {
+			continue
+		}
+		if !req.Partial || deleted[entry.File] {
+			gone[entry.File] = true
+			removed = append(removed, entry.File)
+		}
+	}
+	slices.Sort(removed)
+	return removed
+}
+
+// knownChunks maps the chunk IDs of cm to their SHAs.
+func knownChunks(cm *codemap.OrderedMap) map[string]string {
+	known := map[string]string{}
+	for _, key := range cm.Keys() {
+		value, _ := cm.Get(key)
+		if entry, ok := value.(codemap.ChunkMetadata); ok {
+			known[key] = entry.SHA
+		}
+	}
+	return known
+}
+
+// newFileChunks returns the chunks of path with their merkle hashes.
+// Chunks already known with the same SHA get no vector entry, so they are
+// neither embedded nor stored again.
+func newFileChunks(known map[string]string, path string, rule indexer.LangRule, chunked []indexer.Chunk) (fileChunks, []string) {
+	lang := rule.Lang
+	if lang == "" {
+		lang = indexer.TextLang
+	}
+
+	file := fileChunks{path: path, lang: lang, vectors: map[string][]float64{}, resumed: map[string]bool{}}
+	seen := map[string]bool{}
+	var hashes []string
+	for _, chunk := range chunked {
+		id := chunk.ID(path)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		file.chunks = append(file.chunks, chunk)
+		hashes = append(hashes, merkle.FastHash([]byte(chunk.Code)))
+
+		if sha, ok := known[id]; ok && sha == chunk.SHA {
+			continue
+		}
+		file.vectors[id] = nil
+	}
+	return file, hashes
+}
+
+// embed fills the vectors of files that are still empty.
+func (ix *Indexer) embed(ctx context.Context, files []fileChunks) error {
+	type target struct {
+		file int
+		id   string
+	}
+	var targets []target
+	var texts []string
+	for i, file := range files {
+		for _, chunk := range file.chunks {
+			id := chunk.ID(file.path)
+			if vector, ok := file.vectors[id]; !ok || vector != nil {
+				continue
+			}
+			text, err := ix.opts.Template.Render(chunk.EmbeddingFields(file.path, file.lang), ix.opts.Budget)
+			if err != nil {
+				return fmt.Errorf("render embedding text of %s: %w", id, err)
+			}
+			targets = append(targets, target{file: i, id: id})
+			texts = append(texts, text)
+		}
+	}
+	if len(texts) == 0 {
+		return nil
+	}
+
+	vectors, err := ix.batcher.EmbedChunks(ctx, texts)
+	if err != nil {
+		return err
+	}
+	for i, t := range targets {
+		files[t.file].vectors[t.id] = vectors[i]
+	}
+	return nil
+}
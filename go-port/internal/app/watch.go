@@ -0,0 +1,338 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+	"github.com/alessandrojcm/pampax-go/internal/merkle"
+)
+
+const (
+	// DefaultDebounce is how long Watch waits after the last change before
+	// updating the index.
+	DefaultDebounce = 500 * time.Millisecond
+	// MinDebounce is the shortest debounce Watch accepts.
+	MinDebounce = 50 * time.Millisecond
+	// maxSettle bounds how long a shutdown waits for changes still in
+	// flight before the last update.
+	maxSettle = 200 * time.Millisecond
+	// maxRetryDelay bounds how long a failed batch waits to be retried.
+	maxRetryDelay = 30 * time.Second
+)
+
+// WatchOptions configure a Watcher.
+type WatchOptions struct {
+	// Walk holds the excludes and file checks shared with the indexer.
+	Walk indexer.WalkOptions
+	// Debounce is the quiet period batching changes into one update; zero
+	// means DefaultDebounce and shorter ones are raised to MinDebounce.
+	Debounce time.Duration
+	// OnBatch, when set, is called after each update.
+	OnBatch func(WatchBatch)
+	// OnError, when set, receives the errors that do not stop watching.
+	OnError func(error)
+}
+
+// WatchBatch is one update run by a Watcher.
+type WatchBatch struct {
+	// Changed and Deleted are the indexable files the batch looked at.
+	Changed []string
+	Deleted []string
+	Result  UpdateResult
+	// Err fails the whole batch; its paths are retried after a delay.
+	Err error
+}
+
+// Watcher ports startWatch: it keeps the index of a project up to date by
+// batching file system changes into partial updates.
+type Watcher struct {
+	ix      *Indexer
+	opts    WatchOptions
+	matcher *indexer.Matcher
+	fs      *fsnotify.Watcher
+	pending map[string]bool
+	// failures counts the batches that failed in a row.
+	failures int
+}
+
+// Watch starts watching every directory of the project that is not
+// ignored. Changes are only picked up once Run is called.
+func (ix *Indexer) Watch(opts WatchOptions) (*Watcher, error) {
+	switch {
+	case opts.Debounce == 0:
+		opts.Debounce = DefaultDebounce
+	case opts.Debounce < MinDebounce:
+		opts.Debounce = MinDebounce
+	}
+	matcher, err := indexer.NewMatcher(ix.paths.Root, opts.Walk)
+	if err != nil {
+		return nil, err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("start watcher: %w", err)
+	}
+
+	w := &Watcher{ix: ix, opts: opts, matcher: matcher, fs: watcher, pending: map[string]bool{}}
+	if err := watcher.Add(ix.paths.Root); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("watch %s: %w", ix.paths.Root, err)
+	}
+	w.addTree("", false)
+	return w, nil
+}
+
+// Run handles changes until ctx is done, then waits briefly for changes
+// still in flight, indexes the pending ones and stops watching. Updates
+// are never interrupted half-way.
+func (w *Watcher) Run(ctx context.Context) error {
+	defer w.fs.Close()
+	update := context.WithoutCancel(ctx)
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			w.settle()
+			w.flush(update)
+			return nil
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return nil
+			}
+			if w.record(event) {
+				debounce = time.After(w.opts.Debounce)
+			}
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return nil
+			}
+			w.report(err)
+		case <-debounce:
+			debounce = nil
+			if failed := w.flush(update); failed {
+				debounce = time.After(w.retryDelay())
+			}
+		}
+	}
+}
+
+// Close stops watching without indexing pending changes.
+func (w *Watcher) Close() error {
+	return w.fs.Close()
+}
+
+func (w *Watcher) settle() {
+	timeout := time.After(min(w.opts.Debounce, maxSettle))
+	for {
+		select {
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			w.record(event)
+		case <-timeout:
+			return
+		}
+	}
+}
+
+func (w *Watcher) report(err error) {
+	if w.opts.OnError != nil {
+		w.opts.OnError(err)
+	}
+}
+
+func (w *Watcher) ignored(rel string, isDir bool) bool {
+	ignored, err := w.matcher.Ignored(rel, isDir)
+	if err != nil {
+		w.report(err)
+	}
+	return ignored
+}
+
+// record queues the path of event, reporting whether it is of interest.
+// New directories are watched, and the files already in them queued, since
+// they may have been written before the watch was added.
+func (w *Watcher) record(event fsnotify.Event) bool {
+	rel, err := filepath.Rel(w.ix.paths.Root, event.Name)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+
+	switch {
+	case event.Has(fsnotify.Create):
+		if info, err := os.Lstat(event.Name); err == nil && info.IsDir() {
+			if w.ignored(rel, true) {
+				return false
+			}
+			w.addTree(rel, true)
+			return true
+		}
+	case event.Has(fsnotify.Write), event.Has(fsnotify.Remove), event.Has(fsnotify.Rename):
+	default:
+		return false
+	}
+	if w.ignored(rel, false) {
+		return false
+	}
+	w.pending[rel] = true
+	return true
+}
+
+// addTree watches the directories under rel that are not ignored, queueing
+// their files when queue is set.
+func (w *Watcher) addTree(rel string, queue bool) {
+	start := filepath.Join(w.ix.paths.Root, filepath.FromSlash(rel))
+	filepath.WalkDir(start, func(abs string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			w.report(err)
+			return nil
+		}
+		childRel, _ := filepath.Rel(w.ix.paths.Root, abs)
+		childRel = filepath.ToSlash(childRel)
+		if abs == w.ix.paths.Root {
+			return nil
+		}
+		if w.ignored(childRel, entry.IsDir()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.IsDir() {
+			if queue {
+				w.pending[childRel] = true
+			}
+			return nil
+		}
+		if err := w.fs.Add(abs); err != nil {
+			w.report(fmt.Errorf("watch %s: %w", childRel, err))
+		}
+		return nil
+	})
+}
+
+// flush runs one partial update over the pending paths. Paths that no
+// longer exist are deleted, along with the indexed files under them when
+// they were directories. When the batch fails its paths are queued again
+// and flush reports it.
+func (w *Watcher) flush(ctx context.Context) bool {
+	if len(w.pending) == 0 {
+		return false
+	}
+	paths := make([]string, 0, len(w.pending))
+	for rel := range w.pending {
+		paths = append(paths, rel)
+	}
+	slices.Sort(paths)
+	clear(w.pending)
+
+	var changed, gone []string
+	for _, rel := range paths {
+		if _, err := os.Lstat(filepath.Join(w.ix.paths.Root, filepath.FromSlash(rel))); errors.Is(err, fs.ErrNotExist) {
+			gone = append(gone, rel)
+		} else {
+			changed = append(changed, rel)
+		}
+	}
+
+	batch := WatchBatch{}
+	deleted, err := w.trackedUnder(gone)
+	if err != nil {
+		batch.Err = err
+		return w.failed(batch, paths)
+	}
+	walked, err := indexer.FilterPaths(ctx, w.ix.paths.Root, changed, w.opts.Walk)
+	if err != nil {
+		batch.Err = err
+		return w.failed(batch, paths)
+	}
+	if len(walked.Files) == 0 && len(deleted) == 0 {
+		return false
+	}
+	for _, file := range walked.Files {
+		batch.Changed = append(batch.Changed, file.Path)
+	}
+	batch.Deleted = deleted
+	batch.Result, batch.Err = w.update(ctx, walked.Files, deleted)
+	if batch.Err != nil {
+		return w.failed(batch, slices.Concat(batch.Changed, batch.Deleted))
+	}
+	w.failures = 0
+	w.done(batch)
+	return false
+}
+
+// failed reports a batch that failed and queues its paths again, so they
+// are retried rather than left out until they change again.
+func (w *Watcher) failed(batch WatchBatch, paths []string) bool {
+	for _, rel := range paths {
+		w.pending[rel] = true
+	}
+	w.failures++
+	w.done(batch)
+	return true
+}
+
+// retryDelay is how long Run waits before retrying a failed batch: the
+// debounce, doubled for each further failure in a row, up to
+// maxRetryDelay.
+func (w *Watcher) retryDelay() time.Duration {
+	return min(w.opts.Debounce<<min(w.failures-1, 10), maxRetryDelay)
+}
+
+// update runs the partial update of a batch. A panic fails the batch
+// rather than the watcher, which goes on with the next one.
+func (w *Watcher) update(ctx context.Context, files []indexer.WalkedFile, deleted []string) (result UpdateResult, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("update panicked: %v", p)
+		}
+	}()
+	return w.ix.Update(ctx, UpdateRequest{Files: files, Deleted: deleted, Partial: true})
+}
+
+func (w *Watcher) done(batch WatchBatch) {
+	if w.opts.OnBatch != nil {
+		w.opts.OnBatch(batch)
+	}
+}
+
+// trackedUnder returns the files of merkle.json that are one of paths or
+// inside one of them.
+func (w *Watcher) trackedUnder(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	tree, err := merkle.Load(w.ix.paths.Merkle)
+	if err != nil {
+		return nil, err
+	}
+	gone := map[string]bool{}
+	for _, rel := range paths {
+		gone[rel] = true
+	}
+	var files []string
+	for _, file := range tree.Files() {
+		for dir := file; dir != "."; dir = path.Dir(dir) {
+			if gone[dir] {
+				files = append(files, file)
+				break
+			}
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,30 @@
+package chunks
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// KeyEnvVar holds the master key for encrypted chunks.
+const KeyEnvVar = "PAMPAX_ENCRYPTION_KEY"
+
+// ErrInvalidMasterKey reports a key that is not 32 bytes of base64 or hex.
+var ErrInvalidMasterKey = errors.New(KeyEnvVar + " must be a 32-byte key encoded as base64 or hex")
+
+// DecodeMasterKey ports decodeKey: raw is tried as base64, then as hex. An
+// empty raw value returns a nil key and no error.
+func DecodeMasterKey(raw string) ([]byte, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(raw); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if key, err := hex.DecodeString(raw); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	return nil, ErrInvalidMasterKey
+}
@@ -107,6 +107,17 @@ func ReadChunk(chunkDir, sha string, encrypted bool, masterKey []byte) (string,
 	return string(decompressed), nil
 }
 
+// ChunkExists reports whether the chunk file of sha was written in the
+// given mode, plaintext or encrypted.
+func ChunkExists(chunkDir, sha string, encrypted bool) bool {
+	name := sha + ".gz"
+	if encrypted {
+		name += ".enc"
+	}
+	info, err := os.Stat(filepath.Join(chunkDir, name))
+	return err == nil && info.Mode().IsRegular()
+}
+
 // RemoveChunk deletes both plaintext and encrypted variants for a chunk SHA.
 func RemoveChunk(chunkDir, sha string) error {
 	if sha == "" {
@@ -0,0 +1,144 @@
+package codemap
+
+import (
+	"slices"
+	"strings"
+)
+
+// MaxNeighbors caps SymbolCallTargets and SymbolCallers; SymbolNeighbors
+// holds up to twice as many.
+const MaxNeighbors = 16
+
+// CallResolver resolves the calls of a codemap entry to the SHAs of the
+// chunks they reach. ok is false for entries it knows nothing about, which
+// keep the symbol-name lookup of the Node code.
+type CallResolver interface {
+	ResolveCalls(entry ChunkMetadata) (targets []string, ambiguous []AmbiguousCall, ok bool)
+}
+
+// AmbiguousCall is a call a CallResolver found several definitions for. It
+// gets no edge in the graph.
+type AmbiguousCall struct {
+	File   string
+	Symbol string
+	// Call is the call as written, qualifiers included.
+	Call string
+	// Candidates are the SHAs of the chunks the call may reach.
+	Candidates []string
+}
+
+type symbolCandidate struct {
+	sha  string
+	file string
+}
+
+// AttachSymbolGraph ports attachSymbolGraphToCodemap: it resolves the
+// SymbolCalls of every ChunkMetadata entry to the SHAs of the chunks
+// declaring those symbols, preferring a chunk of the same file, and fills
+// SymbolCallTargets, SymbolCallers and SymbolNeighbors. Entries are visited
+// in key order, so the edges are the same for the same codemap.
+//
+// When resolver is not nil, the entries it covers are resolved by it
+// instead, and the calls it found ambiguous are returned.
+func AttachSymbolGraph(codemap *OrderedMap, resolver CallResolver) []AmbiguousCall {
+	keys := codemap.Keys()
+	entries := make([]ChunkMetadata, 0, len(keys))
+	entryKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value, _ := codemap.Get(key)
+		if entry, ok := value.(ChunkMetadata); ok {
+			entries = append(entries, entry)
+			entryKeys = append(entryKeys, key)
+		}
+	}
+
+	index := map[string][]symbolCandidate{}
+	for _, entry := range entries {
+		if entry.Symbol == nil {
+			continue
+		}
+		if symbol := strings.ToLower(strings.TrimSpace(*entry.Symbol)); symbol != "" {
+			index[symbol] = append(index[symbol], symbolCandidate{sha: entry.SHA, file: entry.File})
+		}
+	}
+
+	// outgoing keeps the SHAs in the order of their first entry, as the
+	// adjacency Map of the Node code does.
+	var order []string
+	var ambiguous []AmbiguousCall
+	outgoing := map[string][]string{}
+	for _, entry := range entries {
+		if entry.SHA == "" {
+			continue
+		}
+		var targets []string
+		resolved := false
+		if resolver != nil {
+			var calls []AmbiguousCall
+			targets, calls, resolved = resolver.ResolveCalls(entry)
+			ambiguous = append(ambiguous, calls...)
+		}
+		if !resolved {
+			targets = nil
+			for _, call := range entry.SymbolCalls {
+				target, ok := selectCandidate(index[strings.ToLower(strings.TrimSpace(call))], entry.File)
+				if ok && target.sha != "" && target.sha != entry.SHA && !slices.Contains(targets, target.sha) {
+					targets = append(targets, target.sha)
+				}
+			}
+		}
+		if _, seen := outgoing[entry.SHA]; !seen {
+			order = append(order, entry.SHA)
+		}
+		outgoing[entry.SHA] = targets
+	}
+
+	incoming := map[string][]string{}
+	for _, from := range order {
+		for _, target := range outgoing[from] {
+			if !slices.Contains(incoming[target], from) {
+				incoming[target] = append(incoming[target], from)
+			}
+		}
+	}
+
+	for i, entry := range entries {
+		if entry.SHA == "" {
+			continue
+		}
+		targets, callers := outgoing[entry.SHA], incoming[entry.SHA]
+		neighbors := slices.Clone(targets)
+		for _, caller := range callers {
+			if !slices.Contains(neighbors, caller) {
+				neighbors = append(neighbors, caller)
+			}
+		}
+
+		entry.SymbolCallTargets = capped(targets, MaxNeighbors)
+		entry.SymbolCallers = capped(callers, MaxNeighbors)
+		entry.SymbolNeighbors = capped(neighbors, MaxNeighbors*2)
+		codemap.Set(entryKeys[i], entry)
+	}
+	return ambiguous
+}
+
+func selectCandidate(candidates []symbolCandidate, file string) (symbolCandidate, bool) {
+	if len(candidates) == 0 {
+		return symbolCandidate{}, false
+	}
+	if file != "" {
+		for _, candidate := range candidates {
+			if candidate.file == file {
+				return candidate, true
+			}
+		}
+	}
+	return candidates[0], true
+}
+
+func capped(values []string, limit int) []string {
+	if len(values) > limit {
+		return slices.Clone(values[:limit])
+	}
+	return values
+}
@@ -38,6 +38,25 @@ func (o *OrderedMap) Get(key string) (any, bool) {
 	return v, ok
 }
 
+// Delete removes key, keeping the order of the others.
+func (o *OrderedMap) Delete(key string) {
+	if o == nil {
+		return
+	}
+
+	if _, exists := o.values[key]; !exists {
+		return
+	}
+
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
 func (o *OrderedMap) Keys() []string {
 	if o == nil {
 		return []string{}
@@ -33,9 +33,68 @@ func WriteCodemap(path string, codemap *OrderedMap) error {
 		return fmt.Errorf("create codemap directory: %w", err)
 	}
 
-	if err := os.WriteFile(path, payload, 0o644); err != nil {
+	// Write a sibling file and rename it over the codemap, so a crash
+	// leaves either the old codemap or the new one.
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-")
+	if err != nil {
+		return fmt.Errorf("create codemap temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(payload); err != nil {
+		tmp.Close()
 		return fmt.Errorf("write codemap file: %w", err)
 	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("chmod codemap file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close codemap file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replace codemap file: %w", err)
+	}
 
 	return nil
 }
+
+// ReadCodemap loads a codemap written by WriteCodemap, keeping its key
+// order. Every value is a ChunkMetadata.
+func ReadCodemap(path string) (*OrderedMap, error) {
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read codemap file: %w", err)
+	}
+	codemap, err := UnmarshalCodemap(payload)
+	if err != nil {
+		return nil, fmt.Errorf("parse codemap %s: %w", path, err)
+	}
+	return codemap, nil
+}
+
+func UnmarshalCodemap(payload []byte) (*OrderedMap, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('{') {
+		return nil, fmt.Errorf("codemap is not a JSON object")
+	}
+
+	codemap := NewOrderedMap()
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var entry ChunkMetadata
+		if err := dec.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("entry %s: %w", tok, err)
+		}
+		codemap.Set(tok.(string), entry)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return codemap, nil
+}
@@ -30,6 +30,9 @@ type ChunkMetadata struct {
 	SymbolCallTargets []string
 	SymbolCallers     []string
 	SymbolNeighbors   []string
+	// GroupSymbols lists the symbols of a chunk that combines several
+	// functions of one file.
+	GroupSymbols []string
 }
 
 func NormalizeChunkMetadata(input ChunkMetadata) ChunkMetadata {
@@ -42,6 +45,12 @@ func NormalizeChunkMetadata(input ChunkMetadata) ChunkMetadata {
 	out.SymbolCallers = sanitizeStringArray(out.SymbolCallers)
 	out.SymbolNeighbors = sanitizeStringArray(out.SymbolNeighbors)
 
+	if groupSymbols := sanitizeStringArray(out.GroupSymbols); len(groupSymbols) > 0 {
+		out.GroupSymbols = groupSymbols
+	} else {
+		out.GroupSymbols = nil
+	}
+
 	params := sanitizeStringArray(out.SymbolParameters)
 	if len(params) > 0 {
 		out.SymbolParameters = params
@@ -125,9 +134,50 @@ func (m ChunkMetadata) MarshalJSON() ([]byte, error) {
 		payload["symbol_return"] = normalized.SymbolReturn
 	}
 
+	if len(normalized.GroupSymbols) > 0 {
+		payload["group_symbols"] = normalized.GroupSymbols
+	}
+
 	return json.Marshal(payload)
 }
 
+// chunkMetadataJSON mirrors the keys MarshalJSON writes.
+type chunkMetadataJSON struct {
+	File              string   `json:"file"`
+	Symbol            *string  `json:"symbol"`
+	SHA               string   `json:"sha"`
+	Lang              string   `json:"lang"`
+	ChunkType         string   `json:"chunkType"`
+	Provider          string   `json:"provider"`
+	Dimensions        int      `json:"dimensions"`
+	HasPampaTags      bool     `json:"hasPampaTags"`
+	HasIntent         bool     `json:"hasIntent"`
+	HasDocumentation  bool     `json:"hasDocumentation"`
+	VariableCount     int      `json:"variableCount"`
+	Synonyms          []string `json:"synonyms"`
+	PathWeight        float64  `json:"path_weight"`
+	LastUsedAt        string   `json:"last_used_at"`
+	SuccessRate       float64  `json:"success_rate"`
+	Encrypted         bool     `json:"encrypted"`
+	SymbolSignature   string   `json:"symbol_signature"`
+	SymbolParameters  []string `json:"symbol_parameters"`
+	SymbolReturn      string   `json:"symbol_return"`
+	SymbolCalls       []string `json:"symbol_calls"`
+	SymbolCallTargets []string `json:"symbol_call_targets"`
+	SymbolCallers     []string `json:"symbol_callers"`
+	SymbolNeighbors   []string `json:"symbol_neighbors"`
+	GroupSymbols      []string `json:"group_symbols"`
+}
+
+func (m *ChunkMetadata) UnmarshalJSON(data []byte) error {
+	var raw chunkMetadataJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*m = NormalizeChunkMetadata(ChunkMetadata(raw))
+	return nil
+}
+
 func normalizePathForStorage(path string) string {
 	normalized := strings.ReplaceAll(path, "\\", "/")
 	normalized = filepath.ToSlash(normalized)
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// FileName is the optional per-project config file, looked up in the project root.
+const FileName = ".pampax.yaml"
+
+// PampaDir is the artifact directory shared with the Node implementation.
+const PampaDir = ".pampa"
+
+type Config struct {
+	MaxTokens  int              `mapstructure:"max_tokens"`
+	Dimensions int              `mapstructure:"dimensions"`
+	EmbedCache EmbedCacheConfig `mapstructure:"embed_cache"`
+	// LanguageOverrides is a list rather than a map because viper splits
+	// map keys on "." and patterns such as "*.tpl" would not survive.
+	LanguageOverrides []LanguageOverride  `mapstructure:"language_overrides"`
+	Index             IndexConfig         `mapstructure:"index"`
+	Chunking          ChunkingConfig      `mapstructure:"chunking"`
+	Tags              TagsConfig          `mapstructure:"tags"`
+	EmbeddingText     EmbeddingTextConfig `mapstructure:"embedding_text"`
+}
+
+// EmbeddingTextConfig selects how chunks are turned into the text sent to
+// the embedding provider.
+type EmbeddingTextConfig struct {
+	// Template names a built-in template ("node", "header"); Sections,
+	// when set, replace it with a custom one.
+	Template string                 `mapstructure:"template"`
+	Sections []EmbeddingTextSection `mapstructure:"sections"`
+	// MaxTokens caps the text; 0 uses the model's input limit and a
+	// negative value disables truncation.
+	MaxTokens int `mapstructure:"max_tokens"`
+}
+
+// EmbeddingTextSection is one text/template section of a custom embedding
+// text template; sections with the lowest priority are dropped first when
+// the text is over budget.
+type EmbeddingTextSection struct {
+	Name     string `mapstructure:"name"`
+	Template string `mapstructure:"template"`
+	Priority int    `mapstructure:"priority"`
+}
+
+// TagsConfig controls the automatic tags derived from each chunk's path,
+// symbol and code. The lists extend the built-in dictionary, aliases and
+// stopwords.
+type TagsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	Max     int  `mapstructure:"max"`
+	// Dictionary lists domain words that become tags when code uses them.
+	Dictionary []string   `mapstructure:"dictionary"`
+	Aliases    []TagAlias `mapstructure:"aliases"`
+	Stopwords  []string   `mapstructure:"stopwords"`
+}
+
+// TagAlias adds Tag to chunks tagged Term (e.g. stripe -> payment).
+type TagAlias struct {
+	Term string `mapstructure:"term"`
+	Tag  string `mapstructure:"tag"`
+}
+
+// ChunkingConfig controls how parsed files are split into chunks.
+type ChunkingConfig struct {
+	Grouping GroupingConfig `mapstructure:"grouping"`
+	// WindowOverlap is the overlap between line windows of files chunked
+	// without syntax, in the model profile unit; 0 uses the profile overlap.
+	WindowOverlap int `mapstructure:"window_overlap"`
+}
+
+// GroupingConfig is the file-level grouping profile: files with more than
+// KeepSeparateMaxNodes functions have them combined into chunks of about
+// the model's optimal size, each closed at FlushRatio of it.
+type GroupingConfig struct {
+	Enabled              bool    `mapstructure:"enabled"`
+	KeepSeparateMaxNodes int     `mapstructure:"keep_separate_max_nodes"`
+	FlushRatio           float64 `mapstructure:"flush_ratio"`
+}
+
+// IndexConfig controls which files the indexer walks.
+type IndexConfig struct {
+	// Exclude adds .gitignore-syntax patterns to the built-in excludes.
+	Exclude []string `mapstructure:"exclude"`
+	// DefaultExcludes applies the Node indexer's ignore list (vendor/,
+	// node_modules/, lock files, hidden paths, ...).
+	DefaultExcludes bool  `mapstructure:"default_excludes"`
+	MaxFileSizeKB   int64 `mapstructure:"max_file_size_kb"`
+	FollowSymlinks  bool  `mapstructure:"follow_symlinks"`
+	// Git enumerates files from the git index instead of walking the tree
+	// when the project is a repository; GitUntracked adds untracked files
+	// that are not ignored.
+	Git          bool `mapstructure:"git"`
+	GitUntracked bool `mapstructure:"git_untracked"`
+	// UnknownLanguages indexes text files with no language rule (docs,
+	// configs, DSLs) as line windows instead of skipping them.
+	UnknownLanguages bool `mapstructure:"unknown_languages"`
+	// MaxErrors is how many files an index run may fail to process before
+	// the command exits non-zero; a negative value, the default, never
+	// fails it.
+	MaxErrors int `mapstructure:"max_errors"`
+}
+
+// LanguageOverride forces files matching Pattern (e.g. "*.tpl") to be
+// indexed as Lang (e.g. "php").
+type LanguageOverride struct {
+	Pattern string `mapstructure:"pattern"`
+	Lang    string `mapstructure:"lang"`
+}
+
+type EmbedCacheConfig struct {
+	Enabled    bool  `mapstructure:"enabled"`
+	MaxSizeMB  int64 `mapstructure:"max_size_mb"`
+	MaxEntries int   `mapstructure:"max_entries"`
+}
+
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("max_tokens", 0)
+	v.SetDefault("dimensions", 0)
+	v.SetDefault("embed_cache.enabled", true)
+	v.SetDefault("embed_cache.max_size_mb", 512)
+	v.SetDefault("embed_cache.max_entries", 0)
+	v.SetDefault("index.exclude", []string{})
+	v.SetDefault("index.default_excludes", true)
+	v.SetDefault("index.max_file_size_kb", 1024)
+	v.SetDefault("index.follow_symlinks", false)
+	v.SetDefault("index.git", false)
+	v.SetDefault("index.git_untracked", true)
+	v.SetDefault("index.unknown_languages", true)
+	v.SetDefault("index.max_errors", -1)
+	v.SetDefault("chunking.grouping.enabled", true)
+	v.SetDefault("chunking.grouping.keep_separate_max_nodes", 10)
+	v.SetDefault("chunking.grouping.flush_ratio", 0.9)
+	v.SetDefault("chunking.window_overlap", 0)
+	v.SetDefault("tags.enabled", true)
+	v.SetDefault("tags.max", 10)
+	v.SetDefault("tags.dictionary", []string{})
+	v.SetDefault("tags.stopwords", []string{})
+	v.SetDefault("embedding_text.template", "node")
+	v.SetDefault("embedding_text.max_tokens", 0)
+}
+
+// Load reads defaults, then .pampax.yaml in projectRoot, then PAMPAX_* env
+// variables (e.g. PAMPAX_EMBED_CACHE_MAX_SIZE_MB), later sources winning.
+func Load(projectRoot string) (Config, error) {
+	v := viper.New()
+	setDefaults(v)
+
+	v.SetConfigFile(filepath.Join(projectRoot, FileName))
+	if err := v.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) && !isMissingFile(err) {
+			return Config{}, fmt.Errorf("read %s: %w", FileName, err)
+		}
+	}
+
+	v.SetEnvPrefix("PAMPAX")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return Config{}, fmt.Errorf("decode config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// Default returns the configuration used when no file or env overrides exist.
+func Default() Config {
+	v := viper.New()
+	setDefaults(v)
+
+	var cfg Config
+	_ = v.Unmarshal(&cfg)
+	return cfg
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+)
+
+// Paths are the .pampa artifact locations for one project root.
+type Paths struct {
+	Root       string
+	PampaDir   string
+	ChunkDir   string
+	DBPath     string
+	Codemap    string
+	EmbedCache string
+	Merkle     string
+	Lock       string
+	Checkpoint string
+	Report     string
+}
+
+// ResolvePaths mirrors getPaths() from the Node service layer.
+func ResolvePaths(projectRoot string) Paths {
+	root := filepath.Clean(projectRoot)
+	pampaDir := filepath.Join(root, PampaDir)
+
+	return Paths{
+		Root:       root,
+		PampaDir:   pampaDir,
+		ChunkDir:   filepath.Join(pampaDir, "chunks"),
+		DBPath:     filepath.Join(pampaDir, "pampa.db"),
+		Codemap:    filepath.Join(root, "pampa.codemap.json"),
+		EmbedCache: filepath.Join(pampaDir, "embedding-cache"),
+		Merkle:     filepath.Join(pampaDir, "merkle.json"),
+		Lock:       filepath.Join(pampaDir, "index.lock"),
+		Checkpoint: filepath.Join(pampaDir, "index-checkpoint.jsonl"),
+		Report:     filepath.Join(pampaDir, "last-index-report.json"),
+	}
+}
+
+func isMissingFile(err error) bool {
+	return errors.Is(err, fs.ErrNotExist)
+}
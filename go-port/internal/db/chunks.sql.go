@@ -0,0 +1,150 @@
+// source: chunks.sql
+
+package db
+
+import (
+	"context"
+)
+
+const deleteChunk = `-- name: DeleteChunk :exec
+DELETE FROM code_chunks
+WHERE id = ?
+`
+
+func (q *Queries) DeleteChunk(ctx context.Context, id string) error {
+	_, err := q.exec(ctx, q.deleteChunkStmt, deleteChunk, id)
+	return err
+}
+
+const deleteChunksByFile = `-- name: DeleteChunksByFile :exec
+DELETE FROM code_chunks
+WHERE file_path = ?
+`
+
+func (q *Queries) DeleteChunksByFile(ctx context.Context, filePath string) error {
+	_, err := q.exec(ctx, q.deleteChunksByFileStmt, deleteChunksByFile, filePath)
+	return err
+}
+
+const getChunk = `-- name: GetChunk :one
+SELECT id, file_path, symbol, sha, lang, chunk_type, embedding, embedding_provider, embedding_dimensions, pampa_tags, pampa_intent, pampa_description, doc_comments, variables_used, context_info, created_at, updated_at FROM code_chunks
+WHERE id = ?
+`
+
+func (q *Queries) GetChunk(ctx context.Context, id string) (CodeChunk, error) {
+	row := q.queryRow(ctx, q.getChunkStmt, getChunk, id)
+	var i CodeChunk
+	err := row.Scan(
+		&i.ID,
+		&i.FilePath,
+		&i.Symbol,
+		&i.Sha,
+		&i.Lang,
+		&i.ChunkType,
+		&i.Embedding,
+		&i.EmbeddingProvider,
+		&i.EmbeddingDimensions,
+		&i.PampaTags,
+		&i.PampaIntent,
+		&i.PampaDescription,
+		&i.DocComments,
+		&i.VariablesUsed,
+		&i.ContextInfo,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listChunksByFile = `-- name: ListChunksByFile :many
+SELECT id, file_path, symbol, sha, lang, chunk_type, embedding, embedding_provider, embedding_dimensions, pampa_tags, pampa_intent, pampa_description, doc_comments, variables_used, context_info, created_at, updated_at FROM code_chunks
+WHERE file_path = ?
+ORDER BY id
+`
+
+func (q *Queries) ListChunksByFile(ctx context.Context, filePath string) ([]CodeChunk, error) {
+	rows, err := q.query(ctx, q.listChunksByFileStmt, listChunksByFile, filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CodeChunk{}
+	for rows.Next() {
+		var i CodeChunk
+		if err := rows.Scan(
+			&i.ID,
+			&i.FilePath,
+			&i.Symbol,
+			&i.Sha,
+			&i.Lang,
+			&i.ChunkType,
+			&i.Embedding,
+			&i.EmbeddingProvider,
+			&i.EmbeddingDimensions,
+			&i.PampaTags,
+			&i.PampaIntent,
+			&i.PampaDescription,
+			&i.DocComments,
+			&i.VariablesUsed,
+			&i.ContextInfo,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertChunk = `-- name: UpsertChunk :exec
+INSERT OR REPLACE INTO code_chunks
+    (id, file_path, symbol, sha, lang, chunk_type, embedding, embedding_provider, embedding_dimensions,
+     pampa_tags, pampa_intent, pampa_description, doc_comments, variables_used, context_info, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+`
+
+type UpsertChunkParams struct {
+	ID                  string  `json:"id"`
+	FilePath            string  `json:"file_path"`
+	Symbol              string  `json:"symbol"`
+	Sha                 string  `json:"sha"`
+	Lang                string  `json:"lang"`
+	ChunkType           *string `json:"chunk_type"`
+	Embedding           []byte  `json:"embedding"`
+	EmbeddingProvider   *string `json:"embedding_provider"`
+	EmbeddingDimensions *int64  `json:"embedding_dimensions"`
+	PampaTags           *string `json:"pampa_tags"`
+	PampaIntent         *string `json:"pampa_intent"`
+	PampaDescription    *string `json:"pampa_description"`
+	DocComments         *string `json:"doc_comments"`
+	VariablesUsed       *string `json:"variables_used"`
+	ContextInfo         *string `json:"context_info"`
+}
+
+func (q *Queries) UpsertChunk(ctx context.Context, arg UpsertChunkParams) error {
+	_, err := q.exec(ctx, q.upsertChunkStmt, upsertChunk,
+		arg.ID,
+		arg.FilePath,
+		arg.Symbol,
+		arg.Sha,
+		arg.Lang,
+		arg.ChunkType,
+		arg.Embedding,
+		arg.EmbeddingProvider,
+		arg.EmbeddingDimensions,
+		arg.PampaTags,
+		arg.PampaIntent,
+		arg.PampaDescription,
+		arg.DocComments,
+		arg.VariablesUsed,
+		arg.ContextInfo,
+	)
+	return err
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Text returns s as a nullable column value, nil when empty.
+func Text(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// StringList encodes values as the JSON array stored in list columns such
+// as pampa_tags; like the Node indexer, an empty list is stored as "[]".
+func StringList(values []string) *string {
+	if values == nil {
+		values = []string{}
+	}
+	data, _ := json.Marshal(values)
+	s := string(data)
+	return &s
+}
+
+// EncodeEmbedding stores a vector the way the Node indexer does, as the
+// JSON array text in the embedding BLOB.
+func EncodeEmbedding(vector []float64) []byte {
+	if vector == nil {
+		vector = []float64{}
+	}
+	data, _ := json.Marshal(vector)
+	return data
+}
+
+// ParseStringList decodes a JSON array column, ignoring malformed values.
+func ParseStringList(column *string) []string {
+	if column == nil {
+		return nil
+	}
+	var values []string
+	if err := json.Unmarshal([]byte(*column), &values); err != nil {
+		return nil
+	}
+	return values
+}
+
+// DecodeEmbedding parses a vector written by EncodeEmbedding.
+func DecodeEmbedding(data []byte) ([]float64, error) {
+	var vector []float64
+	if err := json.Unmarshal(data, &vector); err != nil {
+		return nil, fmt.Errorf("decode embedding: %w", err)
+	}
+	return vector, nil
+}
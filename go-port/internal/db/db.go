@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	PrepareContext(context.Context, string) (*sql.Stmt, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+func New(db DBTX) *Queries {
+	return &Queries{db: db}
+}
+
+func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
+	q := Queries{db: db}
+	var err error
+	if q.deleteChunkStmt, err = db.PrepareContext(ctx, deleteChunk); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteChunk: %w", err)
+	}
+	if q.deleteChunksByFileStmt, err = db.PrepareContext(ctx, deleteChunksByFile); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteChunksByFile: %w", err)
+	}
+	if q.getChunkStmt, err = db.PrepareContext(ctx, getChunk); err != nil {
+		return nil, fmt.Errorf("error preparing query GetChunk: %w", err)
+	}
+	if q.listChunksByFileStmt, err = db.PrepareContext(ctx, listChunksByFile); err != nil {
+		return nil, fmt.Errorf("error preparing query ListChunksByFile: %w", err)
+	}
+	if q.upsertChunkStmt, err = db.PrepareContext(ctx, upsertChunk); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertChunk: %w", err)
+	}
+	return &q, nil
+}
+
+func (q *Queries) Close() error {
+	var err error
+	if q.deleteChunkStmt != nil {
+		if cerr := q.deleteChunkStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteChunkStmt: %w", cerr)
+		}
+	}
+	if q.deleteChunksByFileStmt != nil {
+		if cerr := q.deleteChunksByFileStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteChunksByFileStmt: %w", cerr)
+		}
+	}
+	if q.getChunkStmt != nil {
+		if cerr := q.getChunkStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getChunkStmt: %w", cerr)
+		}
+	}
+	if q.listChunksByFileStmt != nil {
+		if cerr := q.listChunksByFileStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listChunksByFileStmt: %w", cerr)
+		}
+	}
+	if q.upsertChunkStmt != nil {
+		if cerr := q.upsertChunkStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertChunkStmt: %w", cerr)
+		}
+	}
+	return err
+}
+
+func (q *Queries) exec(ctx context.Context, stmt *sql.Stmt, query string, args ...interface{}) (sql.Result, error) {
+	switch {
+	case stmt != nil && q.tx != nil:
+		return q.tx.StmtContext(ctx, stmt).ExecContext(ctx, args...)
+	case stmt != nil:
+		return stmt.ExecContext(ctx, args...)
+	default:
+		return q.db.ExecContext(ctx, query, args...)
+	}
+}
+
+func (q *Queries) query(ctx context.Context, stmt *sql.Stmt, query string, args ...interface{}) (*sql.Rows, error) {
+	switch {
+	case stmt != nil && q.tx != nil:
+		return q.tx.StmtContext(ctx, stmt).QueryContext(ctx, args...)
+	case stmt != nil:
+		return stmt.QueryContext(ctx, args...)
+	default:
+		return q.db.QueryContext(ctx, query, args...)
+	}
+}
+
+func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, args ...interface{}) *sql.Row {
+	switch {
+	case stmt != nil && q.tx != nil:
+		return q.tx.StmtContext(ctx, stmt).QueryRowContext(ctx, args...)
+	case stmt != nil:
+		return stmt.QueryRowContext(ctx, args...)
+	default:
+		return q.db.QueryRowContext(ctx, query, args...)
+	}
+}
+
+type Queries struct {
+	db                     DBTX
+	tx                     *sql.Tx
+	deleteChunkStmt        *sql.Stmt
+	deleteChunksByFileStmt *sql.Stmt
+	getChunkStmt           *sql.Stmt
+	listChunksByFileStmt   *sql.Stmt
+	upsertChunkStmt        *sql.Stmt
+}
+
+func (q *Queries) WithTx(tx *sql.Tx) *Queries {
+	return &Queries{
+		db:                     tx,
+		tx:                     tx,
+		deleteChunkStmt:        q.deleteChunkStmt,
+		deleteChunksByFileStmt: q.deleteChunksByFileStmt,
+		getChunkStmt:           q.getChunkStmt,
+		listChunksByFileStmt:   q.listChunksByFileStmt,
+		upsertChunkStmt:        q.upsertChunkStmt,
+	}
+}
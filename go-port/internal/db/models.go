@@ -0,0 +1,25 @@
+package db
+
+import (
+	"time"
+)
+
+type CodeChunk struct {
+	ID                  string     `json:"id"`
+	FilePath            string     `json:"file_path"`
+	Symbol              string     `json:"symbol"`
+	Sha                 string     `json:"sha"`
+	Lang                string     `json:"lang"`
+	ChunkType           *string    `json:"chunk_type"`
+	Embedding           []byte     `json:"embedding"`
+	EmbeddingProvider   *string    `json:"embedding_provider"`
+	EmbeddingDimensions *int64     `json:"embedding_dimensions"`
+	PampaTags           *string    `json:"pampa_tags"`
+	PampaIntent         *string    `json:"pampa_intent"`
+	PampaDescription    *string    `json:"pampa_description"`
+	DocComments         *string    `json:"doc_comments"`
+	VariablesUsed       *string    `json:"variables_used"`
+	ContextInfo         *string    `json:"context_info"`
+	CreatedAt           *time.Time `json:"created_at"`
+	UpdatedAt           *time.Time `json:"updated_at"`
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/alessandrojcm/pampax-go/internal/migrations"
+)
+
+// busyTimeout is how long a connection waits for a lock held by another
+// one before failing with SQLITE_BUSY.
+const busyTimeout = "_pragma=busy_timeout(5000)"
+
+// Open opens the SQLite index at path, creating its directory and applying
+// pending migrations. The database is switched to WAL mode so readers are
+// not blocked while a writer is storing chunks.
+func Open(ctx context.Context, path string) (*sql.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create database directory: %w", err)
+	}
+	conn, err := sql.Open("sqlite", path+"?"+busyTimeout+"&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("open database %s: %w", path, err)
+	}
+	if err := migrations.Apply(ctx, conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("migrate database %s: %w", path, err)
+	}
+	return conn, nil
+}
+
+// OpenReadOnly opens an existing index for queries, without migrating it.
+// Writes through the connection fail.
+func OpenReadOnly(ctx context.Context, path string) (*sql.DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	conn, err := sql.Open("sqlite", path+"?"+busyTimeout+"&_pragma=query_only(1)")
+	if err != nil {
+		return nil, fmt.Errorf("open database %s: %w", path, err)
+	}
+	if err := conn.PingContext(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("open database %s: %w", path, err)
+	}
+	return conn, nil
+}
@@ -0,0 +1,15 @@
+package db
+
+import (
+	"context"
+)
+
+type Querier interface {
+	DeleteChunk(ctx context.Context, id string) error
+	DeleteChunksByFile(ctx context.Context, filePath string) error
+	GetChunk(ctx context.Context, id string) (CodeChunk, error)
+	ListChunksByFile(ctx context.Context, filePath string) ([]CodeChunk, error)
+	UpsertChunk(ctx context.Context, arg UpsertChunkParams) error
+}
+
+var _ Querier = (*Queries)(nil)
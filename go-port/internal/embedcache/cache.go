@@ -0,0 +1,364 @@
+package embedcache
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const entryExt = ".vec"
+
+var entryMagic = []byte("PAMPAEC1")
+
+// Key identifies one cached embedding.
+type Key struct {
+	// SHA is the sha1 of the embedded text.
+	SHA        string
+	Provider   string
+	Model      string
+	Dimensions int
+}
+
+// ID returns the content-addressed file name stem for the key.
+func (k Key) ID() string {
+	raw := strings.Join([]string{k.SHA, k.Provider, k.Model, strconv.Itoa(k.Dimensions)}, "\x00")
+	sum := sha1.Sum([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// Limits bounds the on-disk cache size. Zero values disable the bound.
+type Limits struct {
+	MaxBytes   int64
+	MaxEntries int
+}
+
+// Stats summarizes the cache contents.
+type Stats struct {
+	Entries int
+	Bytes   int64
+	Oldest  time.Time
+	Newest  time.Time
+}
+
+type entry struct {
+	size     int64
+	lastUsed time.Time
+}
+
+// Cache is a size-bounded, least-recently-used embedding store on disk.
+type Cache struct {
+	dir    string
+	limits Limits
+
+	mu      sync.Mutex
+	entries map[string]entry
+	bytes   int64
+}
+
+// Open loads the cache index from dir, creating the directory when missing.
+// A project's cache lives in config.Paths.EmbedCache.
+func Open(dir string, limits Limits) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create embedding cache directory: %w", err)
+	}
+
+	cache := &Cache{
+		dir:     dir,
+		limits:  limits,
+		entries: make(map[string]entry),
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || filepath.Ext(path) != entryExt {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		id := strings.TrimSuffix(d.Name(), entryExt)
+		cache.entries[id] = entry{size: info.Size(), lastUsed: info.ModTime()}
+		cache.bytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan embedding cache: %w", err)
+	}
+
+	return cache, nil
+}
+
+// Get returns the cached vector for key and marks it as recently used.
+func (c *Cache) Get(key Key) ([]float64, bool, error) {
+	id := key.ID()
+
+	c.mu.Lock()
+	_, ok := c.entries[id]
+	c.mu.Unlock()
+	if !ok {
+		return nil, false, nil
+	}
+
+	path := c.entryPath(id)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			c.forget(id)
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("read cached embedding: %w", err)
+	}
+
+	vector, err := decodeVector(raw)
+	if err != nil {
+		c.forget(id)
+		_ = os.Remove(path)
+		return nil, false, nil
+	}
+
+	if key.Dimensions > 0 && len(vector) != key.Dimensions {
+		return nil, false, nil
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	c.mu.Lock()
+	if current, exists := c.entries[id]; exists {
+		current.lastUsed = now
+		c.entries[id] = current
+	}
+	c.mu.Unlock()
+
+	return vector, true, nil
+}
+
+// Put stores vector under key and evicts least-recently-used entries when the
+// cache exceeds its limits.
+func (c *Cache) Put(key Key, vector []float64) error {
+	if key.SHA == "" {
+		return errors.New("sha is required")
+	}
+
+	if len(vector) == 0 {
+		return errors.New("vector is empty")
+	}
+
+	id := key.ID()
+	path := c.entryPath(id)
+	payload := encodeVector(vector)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create embedding cache shard: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), id+".tmp-")
+	if err != nil {
+		return fmt.Errorf("create temp cache entry: %w", err)
+	}
+
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(payload); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("write temp cache entry: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("close temp cache entry: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("rename cache entry: %w", err)
+	}
+
+	c.mu.Lock()
+	if previous, exists := c.entries[id]; exists {
+		c.bytes -= previous.size
+	}
+	c.entries[id] = entry{size: int64(len(payload)), lastUsed: time.Now()}
+	c.bytes += int64(len(payload))
+	c.mu.Unlock()
+
+	if c.overLimits(c.limits) {
+		if _, err := c.Prune(c.lowWater()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Stats reports the current number of entries, their size and age range.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := Stats{Entries: len(c.entries), Bytes: c.bytes}
+	for _, e := range c.entries {
+		if stats.Oldest.IsZero() || e.lastUsed.Before(stats.Oldest) {
+			stats.Oldest = e.lastUsed
+		}
+		if e.lastUsed.After(stats.Newest) {
+			stats.Newest = e.lastUsed
+		}
+	}
+
+	return stats
+}
+
+// Prune evicts least-recently-used entries until the cache fits limits and
+// returns the number of removed entries. Zero limits remove everything.
+func (c *Cache) Prune(limits Limits) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ids := make([]string, 0, len(c.entries))
+	for id := range c.entries {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := c.entries[ids[i]], c.entries[ids[j]]
+		if !a.lastUsed.Equal(b.lastUsed) {
+			return a.lastUsed.Before(b.lastUsed)
+		}
+		return ids[i] < ids[j]
+	})
+
+	removed := 0
+	for _, id := range ids {
+		if !c.exceeds(limits) {
+			break
+		}
+
+		if err := os.Remove(c.entryPath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, fmt.Errorf("remove cache entry: %w", err)
+		}
+
+		c.bytes -= c.entries[id].size
+		delete(c.entries, id)
+		removed++
+	}
+
+	return removed, nil
+}
+
+// Limits returns the limits the cache enforces on Put.
+func (c *Cache) Limits() Limits {
+	return c.limits
+}
+
+func (c *Cache) overLimits(limits Limits) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if limits.MaxBytes <= 0 && limits.MaxEntries <= 0 {
+		return false
+	}
+
+	return c.exceeds(limits)
+}
+
+// exceeds treats a zero limit as "keep nothing" and must be called with mu held.
+func (c *Cache) exceeds(limits Limits) bool {
+	if len(c.entries) == 0 {
+		return false
+	}
+
+	if limits.MaxBytes <= 0 && limits.MaxEntries <= 0 {
+		return true
+	}
+
+	if limits.MaxBytes > 0 && c.bytes > limits.MaxBytes {
+		return true
+	}
+
+	return limits.MaxEntries > 0 && len(c.entries) > limits.MaxEntries
+}
+
+// lowWater evicts down to 90% of the configured limits so that a full cache
+// does not rescan its index on every Put.
+func (c *Cache) lowWater() Limits {
+	low := Limits{
+		MaxBytes:   c.limits.MaxBytes * 9 / 10,
+		MaxEntries: c.limits.MaxEntries * 9 / 10,
+	}
+
+	if c.limits.MaxBytes > 0 && low.MaxBytes == 0 {
+		low.MaxBytes = c.limits.MaxBytes
+	}
+
+	if c.limits.MaxEntries > 0 && low.MaxEntries == 0 {
+		low.MaxEntries = c.limits.MaxEntries
+	}
+
+	return low
+}
+
+func (c *Cache) forget(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[id]; ok {
+		c.bytes -= e.size
+		delete(c.entries, id)
+	}
+}
+
+func (c *Cache) entryPath(id string) string {
+	return filepath.Join(c.dir, id[:2], id+entryExt)
+}
+
+func encodeVector(vector []float64) []byte {
+	payload := make([]byte, len(entryMagic)+4+8*len(vector))
+	copy(payload, entryMagic)
+	offset := len(entryMagic)
+	binary.LittleEndian.PutUint32(payload[offset:], uint32(len(vector)))
+	offset += 4
+
+	for _, value := range vector {
+		binary.LittleEndian.PutUint64(payload[offset:], math.Float64bits(value))
+		offset += 8
+	}
+
+	return payload
+}
+
+func decodeVector(payload []byte) ([]float64, error) {
+	header := len(entryMagic) + 4
+	if len(payload) < header || string(payload[:len(entryMagic)]) != string(entryMagic) {
+		return nil, errors.New("cache entry has an unknown header")
+	}
+
+	count := int(binary.LittleEndian.Uint32(payload[len(entryMagic):]))
+	if len(payload) != header+8*count {
+		return nil, errors.New("cache entry is truncated")
+	}
+
+	vector := make([]float64, count)
+	for i := range vector {
+		vector[i] = math.Float64frombits(binary.LittleEndian.Uint64(payload[header+8*i:]))
+	}
+
+	return vector, nil
+}
@@ -0,0 +1,187 @@
+package embedtext
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/alessandrojcm/pampax-go/internal/tokens"
+)
+
+// Fields are the values a template renders for one chunk.
+type Fields struct {
+	FilePath    string
+	Lang        string
+	Symbol      string
+	Signature   string
+	DocComment  string
+	Intent      string
+	Description string
+	Tags        []string
+	// Variables are the names of the important variables the chunk uses.
+	Variables []string
+	Code      string
+}
+
+// CodeSection is the section holding the chunk's code. Every template has
+// one; it is truncated rather than dropped when over budget.
+const CodeSection = "code"
+
+// Section is one part of the embedding text, rendered with text/template
+// over Fields. A section that renders blank is left out, so optional parts
+// are written as {{with .Intent}}...{{end}}.
+type Section struct {
+	Name     string
+	Template string
+	// Priority orders the sections for truncation: the lowest is dropped
+	// first. The code section is never dropped.
+	Priority int
+}
+
+// Template renders Fields into the text sent to the embedding provider.
+type Template struct {
+	sections  []section
+	separator string
+}
+
+type section struct {
+	Section
+	tmpl *template.Template
+}
+
+// Separator joins the rendered sections, as generateEnhancedEmbeddingText
+// joins its parts.
+const Separator = "\n\n"
+
+var funcs = template.FuncMap{"join": func(values []string, sep string) string { return strings.Join(values, sep) }}
+
+// New compiles sections, kept in the given order.
+func New(sections []Section) (*Template, error) {
+	t := &Template{separator: Separator}
+	hasCode := false
+	seen := map[string]bool{}
+	for _, s := range sections {
+		if s.Name == "" {
+			return nil, fmt.Errorf("embedding text section without a name")
+		}
+		if seen[s.Name] {
+			return nil, fmt.Errorf("embedding text section %q repeated", s.Name)
+		}
+		seen[s.Name] = true
+		hasCode = hasCode || s.Name == CodeSection
+
+		tmpl, err := template.New(s.Name).Funcs(funcs).Option("missingkey=error").Parse(s.Template)
+		if err != nil {
+			return nil, fmt.Errorf("embedding text section %q: %w", s.Name, err)
+		}
+		t.sections = append(t.sections, section{Section: s, tmpl: tmpl})
+	}
+	if !hasCode {
+		return nil, fmt.Errorf("embedding text template has no %q section", CodeSection)
+	}
+	return t, nil
+}
+
+// Digest identifies the template by its sections and separator: two
+// templates with the same digest render the same text.
+func (t *Template) Digest() string {
+	hash := sha1.New()
+	for _, s := range t.sections {
+		fmt.Fprintf(hash, "%q %q %d\n", s.Name, s.Template, s.Priority)
+	}
+	fmt.Fprintf(hash, "%q", t.separator)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Budget bounds the rendered text; a zero Limit or nil Measure means no
+// bound.
+type Budget struct {
+	Limit   int
+	Measure func(string) int
+}
+
+func (b Budget) fits(text string) bool {
+	return b.Limit <= 0 || b.Measure == nil || b.Measure(text) <= b.Limit
+}
+
+// Render renders f. Over budget, sections are dropped from the lowest
+// priority up until the text fits, and then the code is cut to whole lines
+// (or characters, for a single long line).
+func (t *Template) Render(f Fields, budget Budget) (string, error) {
+	var parts []renderedSection
+	for _, s := range t.sections {
+		var b strings.Builder
+		if err := s.tmpl.Execute(&b, f); err != nil {
+			return "", fmt.Errorf("embedding text section %q: %w", s.Name, err)
+		}
+		if strings.TrimSpace(b.String()) != "" {
+			parts = append(parts, renderedSection{Section: s.Section, text: b.String()})
+		}
+	}
+
+	if text := t.join(parts); budget.fits(text) {
+		return text, nil
+	}
+
+	droppable := make([]renderedSection, 0, len(parts))
+	for _, part := range parts {
+		if part.Name != CodeSection {
+			droppable = append(droppable, part)
+		}
+	}
+	sort.SliceStable(droppable, func(a, b int) bool { return droppable[a].Priority < droppable[b].Priority })
+	for _, drop := range droppable {
+		parts = slices.DeleteFunc(parts, func(part renderedSection) bool { return part.Name == drop.Name })
+		if text := t.join(parts); budget.fits(text) {
+			return text, nil
+		}
+	}
+
+	// Only the code is left, if it rendered at all.
+	if len(parts) == 0 {
+		return "", nil
+	}
+	return truncate(parts[0].text, budget), nil
+}
+
+type renderedSection struct {
+	Section
+	text string
+}
+
+func (t *Template) join(parts []renderedSection) string {
+	texts := make([]string, len(parts))
+	for i, part := range parts {
+		texts[i] = part.text
+	}
+	return strings.Join(texts, t.separator)
+}
+
+// truncate keeps the longest prefix of whole lines of text that fits, or
+// the longest character prefix of the first line.
+func truncate(text string, budget Budget) string {
+	lines := strings.SplitAfter(text, "\n")
+	n := sort.Search(len(lines), func(n int) bool {
+		return !budget.fits(strings.TrimRight(strings.Join(lines[:n+1], ""), "\n"))
+	})
+	if n > 0 {
+		return strings.TrimRight(strings.Join(lines[:n], ""), "\n")
+	}
+	runes := []rune(lines[0])
+	n = sort.Search(len(runes), func(n int) bool { return !budget.fits(string(runes[:n+1])) })
+	return string(runes[:n])
+}
+
+// ProfileBudget bounds the text by the model's input limit, counted with
+// the profile's tokenizer or, for character profiles, the estimator.
+func ProfileBudget(profile tokens.Profile) Budget {
+	var counter tokens.Counter = tokens.Estimator
+	if profile.UseTokens {
+		counter = profile.Counter()
+	}
+	return Budget{Limit: profile.MaxTokens, Measure: counter.Count}
+}
@@ -0,0 +1,81 @@
+package embedtext
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Built-in template names.
+const (
+	// NodeTemplate is generateEnhancedEmbeddingText: doc comment, code,
+	// then intent, description, tags and variables as comments. Embeddings
+	// match those of an index built by the Node implementation.
+	NodeTemplate = "node"
+	// HeaderTemplate puts the file path, symbol and signature before the
+	// rest, which helps smaller models place the code.
+	HeaderTemplate = "header"
+	// DefaultTemplate is used when no template is configured.
+	DefaultTemplate = NodeTemplate
+)
+
+var (
+	docSection         = Section{Name: "doc", Template: "{{.DocComment}}", Priority: 60}
+	codeSection        = Section{Name: CodeSection, Template: "{{.Code}}", Priority: 100}
+	intentSection      = Section{Name: "intent", Template: "{{with .Intent}}// Intent: {{.}}{{end}}", Priority: 50}
+	descriptionSection = Section{Name: "description", Template: "{{with .Description}}// Description: {{.}}{{end}}", Priority: 40}
+	tagsSection        = Section{Name: "tags", Template: `{{with .Tags}}// Tags: {{join . ", "}}{{end}}`, Priority: 30}
+	variablesSection   = Section{Name: "variables", Template: `{{with .Variables}}// Uses variables: {{join . ", "}}{{end}}`, Priority: 20}
+)
+
+var builtins = map[string][]Section{
+	NodeTemplate: {docSection, codeSection, intentSection, descriptionSection, tagsSection, variablesSection},
+	HeaderTemplate: {
+		{Name: "path", Template: "{{with .FilePath}}// File: {{.}}{{with $.Lang}} ({{.}}){{end}}{{end}}", Priority: 70},
+		{Name: "symbol", Template: "{{with .Symbol}}// Symbol: {{.}}{{end}}", Priority: 90},
+		{Name: "signature", Template: "{{with .Signature}}// Signature: {{.}}{{end}}", Priority: 80},
+		docSection, intentSection, descriptionSection, tagsSection, variablesSection, codeSection,
+	},
+}
+
+// Builtin returns the sections of a built-in template, for use as the base
+// of a custom one.
+func Builtin(name string) ([]Section, bool) {
+	sections, ok := builtins[name]
+	return append([]Section(nil), sections...), ok
+}
+
+// BuiltinNames lists the built-in templates.
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup compiles a built-in template, or sections when given; an empty
+// name means DefaultTemplate.
+func Lookup(name string, sections []Section) (*Template, error) {
+	if len(sections) > 0 {
+		return New(sections)
+	}
+	if name == "" {
+		name = DefaultTemplate
+	}
+	builtin, ok := Builtin(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown embedding text template %q (built-in: %v)", name, BuiltinNames())
+	}
+	return New(builtin)
+}
+
+// Default is the compiled DefaultTemplate.
+var Default = must(Lookup(DefaultTemplate, nil))
+
+func must(t *Template, err error) *Template {
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
@@ -0,0 +1,293 @@
+// Package graph answers questions over the call edges of a codemap: who
+// calls a symbol, what it calls, within a depth, and how one symbol
+// reaches another.
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alessandrojcm/pampax-go/internal/codemap"
+)
+
+// ErrNotFound reports a query that names no chunk.
+var ErrNotFound = errors.New("no chunk matches")
+
+// DefaultDepth is how far Callers and Callees follow edges by default.
+const DefaultDepth = 3
+
+// Node is a chunk of the codemap.
+type Node struct {
+	ID        string `json:"id"`
+	SHA       string `json:"sha"`
+	File      string `json:"file"`
+	Symbol    string `json:"symbol"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// Label names the node for text and DOT output.
+func (n Node) Label() string {
+	name := n.Symbol
+	if n.Signature != "" {
+		name = n.Signature
+	}
+	return n.File + ": " + name
+}
+
+// Graph indexes the call edges of a codemap by chunk SHA.
+type Graph struct {
+	nodes   []Node
+	bySHA   map[string]int
+	callees map[string][]string
+	callers map[string][]string
+}
+
+// New builds the graph of the ChunkMetadata entries of cm, whose
+// SymbolCallTargets and SymbolCallers are the edges.
+func New(cm *codemap.OrderedMap) *Graph {
+	g := &Graph{bySHA: map[string]int{}, callees: map[string][]string{}, callers: map[string][]string{}}
+	for _, key := range cm.Keys() {
+		value, _ := cm.Get(key)
+		entry, ok := value.(codemap.ChunkMetadata)
+		if !ok || entry.SHA == "" {
+			continue
+		}
+		if _, seen := g.bySHA[entry.SHA]; seen {
+			continue
+		}
+		node := Node{ID: key, SHA: entry.SHA, File: entry.File, Signature: entry.SymbolSignature}
+		if entry.Symbol != nil {
+			node.Symbol = *entry.Symbol
+		}
+		g.bySHA[entry.SHA] = len(g.nodes)
+		g.nodes = append(g.nodes, node)
+		g.callees[entry.SHA] = entry.SymbolCallTargets
+		g.callers[entry.SHA] = entry.SymbolCallers
+	}
+	return g
+}
+
+// Node returns the node of a chunk SHA.
+func (g *Graph) Node(sha string) (Node, bool) {
+	i, ok := g.bySHA[sha]
+	if !ok {
+		return Node{}, false
+	}
+	return g.nodes[i], true
+}
+
+// Find returns the nodes a query names: a codemap key, a SHA or SHA
+// prefix of at least 7 characters, "file:symbol", or a symbol, matched
+// case-insensitively against the chunk symbol and the name its signature
+// declares.
+func (g *Graph) Find(query string) []Node {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil
+	}
+	var exact, named []Node
+	for _, node := range g.nodes {
+		switch {
+		case node.ID == query, node.SHA == query, len(query) >= 7 && strings.HasPrefix(node.SHA, query):
+			exact = append(exact, node)
+		case matchesSymbol(node, query):
+			named = append(named, node)
+		}
+	}
+	if len(exact) > 0 {
+		return exact
+	}
+	return named
+}
+
+func matchesSymbol(node Node, query string) bool {
+	file, symbol, qualified := strings.Cut(query, ":")
+	if qualified {
+		if node.File != file {
+			return false
+		}
+		query = symbol
+	}
+	return strings.EqualFold(node.Symbol, query) || strings.EqualFold(declaredName(node.Signature), query)
+}
+
+// declaredName reads the name out of a signature such as
+// "(s *Store) Save(id int) : error" or "class Store".
+func declaredName(signature string) string {
+	if strings.HasPrefix(signature, "(") {
+		if _, rest, ok := strings.Cut(signature, ") "); ok {
+			signature = rest
+		}
+	}
+	if end := strings.IndexAny(signature, "(<["); end != -1 {
+		signature = signature[:end]
+	}
+	fields := strings.Fields(signature)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[len(fields)-1]
+}
+
+// Direction is the edge a traversal follows.
+type Direction string
+
+const (
+	CallersOf Direction = "callers"
+	CalleesOf Direction = "callees"
+)
+
+// Tree is a traversal from Node. A node reached twice appears once, at the
+// shallowest depth, so the tree is the impact radius of Node.
+type Tree struct {
+	Node     Node    `json:"node"`
+	Depth    int     `json:"depth"`
+	Children []*Tree `json:"children,omitempty"`
+}
+
+// Size returns the number of nodes in t, t included.
+func (t *Tree) Size() int {
+	size := 1
+	for _, child := range t.Children {
+		size += child.Size()
+	}
+	return size
+}
+
+// Traverse follows dir edges from root breadth-first, up to depth levels;
+// a depth under 1 uses DefaultDepth.
+func (g *Graph) Traverse(root Node, dir Direction, depth int) *Tree {
+	if depth < 1 {
+		depth = DefaultDepth
+	}
+	edges := g.callees
+	if dir == CallersOf {
+		edges = g.callers
+	}
+
+	tree := &Tree{Node: root}
+	seen := map[string]bool{root.SHA: true}
+	level := []*Tree{tree}
+	for d := 1; d <= depth && len(level) > 0; d++ {
+		var next []*Tree
+		for _, parent := range level {
+			for _, sha := range edges[parent.Node.SHA] {
+				node, ok := g.Node(sha)
+				if !ok || seen[sha] {
+					continue
+				}
+				seen[sha] = true
+				child := &Tree{Node: node, Depth: d}
+				parent.Children = append(parent.Children, child)
+				next = append(next, child)
+			}
+		}
+		level = next
+	}
+	return tree
+}
+
+// Path returns the shortest chain of calls from a node of from to a node
+// of to, both ends included, following at most maxDepth calls. It returns
+// nil when none reaches.
+func (g *Graph) Path(from, to []Node, maxDepth int) []Node {
+	if maxDepth < 1 {
+		maxDepth = DefaultDepth
+	}
+	targets := map[string]bool{}
+	for _, node := range to {
+		targets[node.SHA] = true
+	}
+
+	prev := map[string]string{}
+	var level []string
+	for _, node := range from {
+		if _, seen := prev[node.SHA]; !seen {
+			prev[node.SHA] = ""
+			level = append(level, node.SHA)
+		}
+	}
+	for d := 0; d <= maxDepth && len(level) > 0; d++ {
+		var next []string
+		for _, sha := range level {
+			if targets[sha] {
+				return g.chain(prev, sha)
+			}
+			if d == maxDepth {
+				continue
+			}
+			for _, callee := range g.callees[sha] {
+				if _, seen := prev[callee]; seen {
+					continue
+				}
+				if _, ok := g.bySHA[callee]; ok {
+					prev[callee] = sha
+					next = append(next, callee)
+				}
+			}
+		}
+		level = next
+	}
+	return nil
+}
+
+func (g *Graph) chain(prev map[string]string, sha string) []Node {
+	var path []Node
+	for ; sha != ""; sha = prev[sha] {
+		node, _ := g.Node(sha)
+		path = append([]Node{node}, path...)
+	}
+	return path
+}
+
+// Lookup is Find that fails with ErrNotFound when nothing matches.
+func (g *Graph) Lookup(query string) ([]Node, error) {
+	nodes := g.Find(query)
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, query)
+	}
+	return nodes, nil
+}
+
+// Load builds the graph of the codemap file at path.
+func Load(path string) (*Graph, error) {
+	cm, err := codemap.ReadCodemap(path)
+	if err != nil {
+		return nil, err
+	}
+	return New(cm), nil
+}
+
+// Query traverses dir edges from every node query names.
+func (g *Graph) Query(query string, dir Direction, depth int) (TraversalResult, error) {
+	if depth < 1 {
+		depth = DefaultDepth
+	}
+	roots, err := g.Lookup(query)
+	if err != nil {
+		return TraversalResult{}, err
+	}
+	result := TraversalResult{Query: query, Direction: dir, Depth: depth}
+	for _, root := range roots {
+		result.Trees = append(result.Trees, g.Traverse(root, dir, depth))
+	}
+	return result, nil
+}
+
+// PathBetween finds the call path from the nodes from names to those to
+// names.
+func (g *Graph) PathBetween(from, to string, depth int) (PathResult, error) {
+	if depth < 1 {
+		depth = DefaultDepth
+	}
+	sources, err := g.Lookup(from)
+	if err != nil {
+		return PathResult{}, err
+	}
+	targets, err := g.Lookup(to)
+	if err != nil {
+		return PathResult{}, err
+	}
+	return PathResult{From: from, To: to, Depth: depth, Path: g.Path(sources, targets, depth)}, nil
+}
@@ -0,0 +1,155 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Format is an output format of the graph commands.
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+	FormatDOT  Format = "dot"
+)
+
+// Formats lists the supported formats.
+var Formats = []Format{FormatText, FormatJSON, FormatDOT}
+
+// ParseFormat validates a format name.
+func ParseFormat(name string) (Format, error) {
+	format := Format(strings.ToLower(strings.TrimSpace(name)))
+	if !slices.Contains(Formats, format) {
+		return "", fmt.Errorf("unknown format %q (want text, json or dot)", name)
+	}
+	return format, nil
+}
+
+// TraversalResult is what Callers and Callees output: one tree per node
+// the query matched.
+type TraversalResult struct {
+	Query     string    `json:"query"`
+	Direction Direction `json:"direction"`
+	Depth     int       `json:"depth"`
+	Trees     []*Tree   `json:"trees"`
+}
+
+// PathResult is what Path outputs; Path is empty when no chain was found.
+type PathResult struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Depth int    `json:"depth"`
+	Path  []Node `json:"path"`
+}
+
+// WriteTraversal writes r to w in format.
+func WriteTraversal(w io.Writer, r TraversalResult, format Format) error {
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, r)
+	case FormatDOT:
+		var edges [][2]Node
+		var nodes []Node
+		var walk func(t *Tree)
+		walk = func(t *Tree) {
+			nodes = append(nodes, t.Node)
+			for _, child := range t.Children {
+				// Edges point from caller to callee either way.
+				if r.Direction == CallersOf {
+					edges = append(edges, [2]Node{child.Node, t.Node})
+				} else {
+					edges = append(edges, [2]Node{t.Node, child.Node})
+				}
+				walk(child)
+			}
+		}
+		for _, tree := range r.Trees {
+			walk(tree)
+		}
+		return writeDOT(w, string(r.Direction), nodes, edges)
+	default:
+		var b strings.Builder
+		var walk func(t *Tree, prefix string, last bool)
+		walk = func(t *Tree, prefix string, last bool) {
+			branch, indent := "├── ", "│   "
+			if last {
+				branch, indent = "└── ", "    "
+			}
+			fmt.Fprintf(&b, "%s%s%s\n", prefix, branch, t.Node.Label())
+			for i, child := range t.Children {
+				walk(child, prefix+indent, i == len(t.Children)-1)
+			}
+		}
+		for _, tree := range r.Trees {
+			fmt.Fprintf(&b, "%s (%s, %d within depth %d)\n", tree.Node.Label(), r.Direction, tree.Size()-1, r.Depth)
+			for i, child := range tree.Children {
+				walk(child, "", i == len(tree.Children)-1)
+			}
+		}
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+}
+
+// WritePath writes r to w in format.
+func WritePath(w io.Writer, r PathResult, format Format) error {
+	switch format {
+	case FormatJSON:
+		if r.Path == nil {
+			r.Path = []Node{}
+		}
+		return writeJSON(w, r)
+	case FormatDOT:
+		var edges [][2]Node
+		for i := 1; i < len(r.Path); i++ {
+			edges = append(edges, [2]Node{r.Path[i-1], r.Path[i]})
+		}
+		return writeDOT(w, "path", r.Path, edges)
+	default:
+		if len(r.Path) == 0 {
+			_, err := fmt.Fprintf(w, "no call path from %s to %s within depth %d\n", r.From, r.To, r.Depth)
+			return err
+		}
+		var b strings.Builder
+		for i, node := range r.Path {
+			if i > 0 {
+				b.WriteString(strings.Repeat("  ", i-1) + "└─> ")
+			}
+			b.WriteString(node.Label() + "\n")
+		}
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func writeDOT(w io.Writer, name string, nodes []Node, edges [][2]Node) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n  rankdir=LR;\n  node [shape=box];\n", dotQuote(name))
+	seen := map[string]bool{}
+	for _, node := range nodes {
+		if !seen[node.SHA] {
+			seen[node.SHA] = true
+			fmt.Fprintf(&b, "  %s [label=%s];\n", dotQuote(node.SHA), dotQuote(node.Label()))
+		}
+	}
+	for _, edge := range edges {
+		fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(edge[0].SHA), dotQuote(edge[1].SHA))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
@@ -0,0 +1,182 @@
+package indexer
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// PampaMetadata holds the @pampax-tags, @pampax-intent and
+// @pampax-description annotations of a doc comment. The @pampa- spelling
+// used by the Node indexer is accepted too.
+type PampaMetadata struct {
+	Tags        []string
+	Intent      string
+	Description string
+}
+
+var (
+	pampaTags        = regexp.MustCompile(`@pampax?-tags:[ \t]*([^\n]+)`)
+	pampaIntent      = regexp.MustCompile(`@pampax?-intent:[ \t]*([^\n]+)`)
+	pampaDescription = regexp.MustCompile(`@pampax?-description:[ \t]*([^\n]+)`)
+	// commentCloser is a block comment end left on an annotation line.
+	commentCloser = regexp.MustCompile(`\s*(\*/|-->|\*\)|-}|"""|''')\s*$`)
+)
+
+// ExtractPampaMetadata ports extractPampaMetadata: annotations run to the
+// end of their line and tags are comma separated.
+func ExtractPampaMetadata(comment string) PampaMetadata {
+	var metadata PampaMetadata
+	value := func(re *regexp.Regexp) string {
+		match := re.FindStringSubmatch(comment)
+		if match == nil {
+			return ""
+		}
+		return strings.TrimSpace(commentCloser.ReplaceAllString(match[1], ""))
+	}
+
+	for _, tag := range strings.Split(value(pampaTags), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			metadata.Tags = append(metadata.Tags, tag)
+		}
+	}
+	metadata.Intent = value(pampaIntent)
+	metadata.Description = value(pampaDescription)
+	return metadata
+}
+
+// lineCommentPrefixes lists the line comment markers of each language.
+var lineCommentPrefixes = map[string][]string{
+	"go":         {"//"},
+	"javascript": {"//"},
+	"typescript": {"//"},
+	"tsx":        {"//"},
+	"java":       {"//"},
+	"csharp":     {"//"},
+	"c":          {"//"},
+	"cpp":        {"//"},
+	"rust":       {"//"},
+	"kotlin":     {"//"},
+	"scala":      {"//"},
+	"swift":      {"//"},
+	"php":        {"//", "#"},
+	"python":     {"#"},
+	"ruby":       {"#"},
+	"bash":       {"#"},
+	"elixir":     {"#"},
+	"lua":        {"--"},
+	"haskell":    {"--"},
+}
+
+// wrapperTypes are parents that start before the declaration they wrap,
+// so a comment above them documents the declaration.
+var wrapperTypes = []string{"export_statement", "decorated_definition"}
+
+// DocComment returns the comment documenting node: for Python the
+// docstring of a definition, otherwise the run of line comments or the
+// block comment (per the rule's comment pattern) directly above it. Unlike
+// extractDocComments in Node, a comment separated from the node by code
+// or a blank line does not count.
+func DocComment(source []byte, node *Node, rule LangRule) string {
+	if rule.Lang == "python" {
+		if doc := docstring(source, node); doc != "" {
+			return doc
+		}
+	}
+
+	start := node.StartByte
+	for parent := node.Parent; parent != nil && parent.StartByte < start && containsString(wrapperTypes, parent.Type); parent = parent.Parent {
+		start = parent.StartByte
+	}
+	lineStart := bytes.LastIndexByte(source[:start], '\n') + 1
+	if len(bytes.TrimSpace(source[lineStart:start])) > 0 {
+		return ""
+	}
+
+	if doc := lineComments(source[:lineStart], lineCommentPrefixes[rule.Lang]); doc != "" {
+		return doc
+	}
+	if rule.CommentPattern == nil || rule.Lang == "python" {
+		return ""
+	}
+
+	// Block comments: the last match of the pattern, ending on the line
+	// above and starting on a line of its own.
+	before := source[max(0, lineStart-4096):lineStart]
+	trimmed := bytes.TrimRight(before, " \t\r\n")
+	if bytes.Count(before[len(trimmed):], []byte("\n")) > 1 {
+		return ""
+	}
+	matches := rule.CommentPattern.FindAllIndex(trimmed, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	match := matches[len(matches)-1]
+	if match[1] != len(trimmed) {
+		return ""
+	}
+	ownLine := bytes.LastIndexByte(trimmed[:match[0]], '\n') + 1
+	if len(bytes.TrimSpace(trimmed[ownLine:match[0]])) > 0 {
+		return ""
+	}
+	return string(trimmed[match[0]:match[1]])
+}
+
+// lineComments returns the consecutive comment lines at the end of before,
+// which ends at a line start.
+func lineComments(before []byte, prefixes []string) string {
+	if len(prefixes) == 0 {
+		return ""
+	}
+
+	var lines []string
+	for end := len(before); end > 0; {
+		start := bytes.LastIndexByte(before[:end-1], '\n') + 1
+		line := strings.TrimSpace(string(before[start:end]))
+		if !hasAnyPrefix(line, prefixes) || strings.HasPrefix(line, "#!") {
+			break
+		}
+		lines = append(lines, line)
+		end = start
+	}
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// docstring returns the string literal opening the body of a Python
+// function or class.
+func docstring(source []byte, node *Node) string {
+	if node.Type != "function_definition" && node.Type != "class_definition" {
+		return ""
+	}
+	body := node.ChildByField("body")
+	if body == nil || len(body.Children) == 0 || body.Children[0].Type != "expression_statement" {
+		return ""
+	}
+	text := body.Children[0].Text(source)
+	unprefixed := strings.TrimLeft(text, "rRuUbBfF")
+	if strings.HasPrefix(unprefixed, `"`) || strings.HasPrefix(unprefixed, "'") {
+		return text
+	}
+	return ""
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,273 @@
+package indexer
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alessandrojcm/pampax-go/internal/embedcache"
+	"github.com/alessandrojcm/pampax-go/internal/providers"
+	"github.com/alessandrojcm/pampax-go/internal/tokens"
+)
+
+// defaultRetryDelays mirrors the backoff schedule of the Node rate limiter.
+var defaultRetryDelays = []time.Duration{
+	1 * time.Second,
+	2 * time.Second,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// BatcherOptions tunes how a Batcher sizes and retries provider requests.
+type BatcherOptions struct {
+	// CountTokens sizes a text against the provider token budget. Defaults to
+	// the 4-characters-per-token estimate used by Node when no tokenizer exists.
+	CountTokens func(string) int
+	// RetryDelays is the wait before each retry of a failed batch. Nil uses the
+	// default schedule; an empty non-nil slice disables retries.
+	RetryDelays []time.Duration
+	// Cache, when set, is consulted by EmbedChunks before calling the provider.
+	Cache *embedcache.Cache
+}
+
+// Batch is a contiguous range of inputs sent to the provider in one request.
+type Batch struct {
+	Start  int
+	End    int
+	Tokens int
+}
+
+// Batcher packs chunk texts into provider-sized requests and runs a bounded
+// number of them concurrently.
+type Batcher struct {
+	provider    providers.EmbeddingProvider
+	limits      providers.BatchLimits
+	countTokens func(string) int
+	retryDelays []time.Duration
+	cache       *embedcache.Cache
+}
+
+// NewBatcher creates a Batcher driven by the provider's batch limits.
+func NewBatcher(provider providers.EmbeddingProvider, opts BatcherOptions) *Batcher {
+	limits := provider.BatchLimits()
+	if limits.MaxBatchSize <= 0 {
+		limits.MaxBatchSize = 1
+	}
+
+	if limits.MaxConcurrency <= 0 {
+		limits.MaxConcurrency = 1
+	}
+
+	if limits.MaxBatchTokens < 0 {
+		limits.MaxBatchTokens = 0
+	}
+
+	countTokens := opts.CountTokens
+	if countTokens == nil {
+		countTokens = tokens.Estimate
+	}
+
+	retryDelays := opts.RetryDelays
+	if retryDelays == nil {
+		retryDelays = defaultRetryDelays
+	}
+
+	return &Batcher{
+		provider:    provider,
+		limits:      limits,
+		countTokens: countTokens,
+		retryDelays: retryDelays,
+		cache:       opts.Cache,
+	}
+}
+
+// Plan splits texts into consecutive batches that respect the provider's count
+// and token limits. A single text larger than the token budget gets its own batch.
+func (b *Batcher) Plan(texts []string) []Batch {
+	batches := make([]Batch, 0)
+	current := Batch{}
+
+	for i, text := range texts {
+		tokens := b.countTokens(text)
+		size := i - current.Start
+
+		overCount := size >= b.limits.MaxBatchSize
+		overTokens := b.limits.MaxBatchTokens > 0 && size > 0 && current.Tokens+tokens > b.limits.MaxBatchTokens
+		if overCount || overTokens {
+			current.End = i
+			batches = append(batches, current)
+			current = Batch{Start: i}
+		}
+
+		current.Tokens += tokens
+	}
+
+	if len(texts) > current.Start {
+		current.End = len(texts)
+		batches = append(batches, current)
+	}
+
+	return batches
+}
+
+// Embed returns one vector per text in input order. Failed batches are retried
+// on their own; the first batch that exhausts its retries cancels the rest.
+func (b *Batcher) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	if len(texts) == 0 {
+		return vectors, nil
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+
+	sem := make(chan struct{}, b.limits.MaxConcurrency)
+
+dispatch:
+	for _, batch := range b.Plan(texts) {
+		select {
+		case sem <- struct{}{}:
+		case <-runCtx.Done():
+			break dispatch
+		}
+
+		wg.Add(1)
+		go func(batch Batch) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			out, err := b.embedWithRetry(runCtx, texts[batch.Start:batch.End])
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = fmt.Errorf("embed batch %d-%d: %w", batch.Start, batch.End-1, err)
+					cancel()
+				})
+				return
+			}
+
+			copy(vectors[batch.Start:batch.End], out)
+		}(batch)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return vectors, nil
+}
+
+// EmbedChunks embeds the rendered texts of chunks, serving cache hits from
+// the embedding cache and storing freshly computed vectors in it. Entries are
+// keyed by the text itself, so a template or context change is a miss.
+func (b *Batcher) EmbedChunks(ctx context.Context, texts []string) ([][]float64, error) {
+	if b.cache == nil {
+		return b.Embed(ctx, texts)
+	}
+
+	vectors := make([][]float64, len(texts))
+	missIndexes := make([]int, 0)
+	missTexts := make([]string, 0)
+
+	for i, text := range texts {
+		vector, ok, err := b.cache.Get(b.cacheKey(text))
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			vectors[i] = vector
+			continue
+		}
+
+		missIndexes = append(missIndexes, i)
+		missTexts = append(missTexts, texts[i])
+	}
+
+	if len(missTexts) == 0 {
+		return vectors, nil
+	}
+
+	fresh, err := b.Embed(ctx, missTexts)
+	if err != nil {
+		return nil, err
+	}
+
+	for j, i := range missIndexes {
+		vectors[i] = fresh[j]
+		if err := b.cache.Put(b.cacheKey(texts[i]), fresh[j]); err != nil {
+			return nil, fmt.Errorf("store cached embedding: %w", err)
+		}
+	}
+
+	return vectors, nil
+}
+
+func (b *Batcher) cacheKey(text string) embedcache.Key {
+	sum := sha1.Sum([]byte(text))
+	return embedcache.Key{
+		SHA:        hex.EncodeToString(sum[:]),
+		Provider:   b.provider.Name(),
+		Model:      b.provider.Model(),
+		Dimensions: b.provider.Dimensions(),
+	}
+}
+
+func (b *Batcher) embedWithRetry(ctx context.Context, texts []string) ([][]float64, error) {
+	var lastErr error
+
+	for attempt := 0; ; attempt++ {
+		out, err := b.provider.EmbedBatch(ctx, texts)
+		if err == nil {
+			err = validateBatchOutput(out, len(texts))
+		}
+
+		if err == nil {
+			return out, nil
+		}
+
+		lastErr = err
+		if ctx.Err() != nil || attempt >= len(b.retryDelays) {
+			break
+		}
+
+		timer := time.NewTimer(b.retryDelays[attempt])
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.Join(lastErr, ctx.Err())
+		}
+	}
+
+	return nil, lastErr
+}
+
+func validateBatchOutput(out [][]float64, want int) error {
+	if len(out) != want {
+		return fmt.Errorf("provider returned %d embeddings for %d inputs", len(out), want)
+	}
+
+	for i, vector := range out {
+		if len(vector) == 0 {
+			return fmt.Errorf("provider returned an empty embedding for input %d", i)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,501 @@
+package indexer
+
+func wordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}
+
+var (
+	cStyleLex = &lexSpec{
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        `"'`,
+	}
+	scriptLex = &lexSpec{
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        `"'`,
+		templateQuote: '`',
+		identExtra:    "$",
+		regexLiterals: true,
+	}
+	tripleQuoteLex = &lexSpec{
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        `"'`,
+		tripleQuotes:  true,
+	}
+	rustLex = &lexSpec{
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        `"'`,
+		lifetimes:     true,
+	}
+	phpLex = &lexSpec{
+		lineComments:   []string{"//", "#"},
+		blockComments:  [][2]string{{"/*", "*/"}},
+		quotes:         `"'`,
+		identExtra:     "$",
+		phpTags:        true,
+		hashAttributes: true,
+	}
+)
+
+func scriptDialect(lang string, typed bool) *braceDialect {
+	classNameType, fieldType, methodSignature := "identifier", "field_definition", ""
+	if typed {
+		classNameType, fieldType, methodSignature = "type_identifier", "public_field_definition", "method_signature"
+	}
+
+	decls := map[string]declSpec{
+		"function": {typ: "function_declaration", name: "identifier", rule: nameFirst, body: "statement_block"},
+		"class":    {typ: "class_declaration", name: classNameType, rule: nameFirst, body: "class_body", mode: bodyMembers},
+		"const":    {typ: "lexical_declaration", name: "identifier", rule: nameFirst, declarator: "variable_declarator"},
+		"let":      {typ: "lexical_declaration", name: "identifier", rule: nameFirst, declarator: "variable_declarator"},
+		"var":      {typ: "variable_declaration", name: "identifier", rule: nameFirst, declarator: "variable_declarator"},
+		"if":       {typ: "if_statement", body: "statement_block"},
+		"for":      {typ: "for_statement", body: "statement_block"},
+		"while":    {typ: "while_statement", body: "statement_block"},
+		"do":       {typ: "do_statement", body: "statement_block"},
+		"try":      {typ: "try_statement", body: "statement_block"},
+		"switch":   {typ: "switch_statement", body: "switch_body"},
+		"return":   {typ: "return_statement"},
+		"throw":    {typ: "throw_statement"},
+		"import":   {typ: "import_statement"},
+		"export":   {typ: "export_statement", prefix: true},
+	}
+	if typed {
+		decls["function"] = declSpec{typ: "function_declaration", name: "identifier", rule: nameFirst, body: "statement_block", noBody: "function_signature"}
+		decls["interface"] = declSpec{typ: "interface_declaration", name: "type_identifier", rule: nameFirst, body: "interface_body", mode: bodyOpaque}
+		decls["type"] = declSpec{typ: "type_alias_declaration", name: "type_identifier", rule: nameFirst}
+		decls["enum"] = declSpec{typ: "enum_declaration", name: "identifier", rule: nameFirst, body: "enum_body", mode: bodyOpaque}
+		decls["namespace"] = declSpec{typ: "internal_module", name: "identifier", rule: nameFirst, body: "statement_block"}
+		decls["module"] = declSpec{typ: "module", name: "identifier", rule: nameFirst, body: "statement_block"}
+	}
+
+	return &braceDialect{
+		lang:      lang,
+		root:      "program",
+		lex:       scriptLex,
+		asi:       true,
+		keywords:  wordSet("break", "case", "catch", "class", "const", "continue", "debugger", "default", "delete", "do", "else", "export", "extends", "finally", "for", "function", "if", "import", "in", "instanceof", "let", "new", "return", "switch", "throw", "try", "typeof", "var", "void", "while", "with", "yield", "await", "true", "false", "null", "undefined", "this", "super"),
+		modifiers: wordSet("async", "static", "get", "set", "public", "private", "protected", "readonly", "abstract", "override", "declare", "accessor"),
+		selfWords: wordSet("this", "super"),
+		decls:     decls,
+		labels:    map[string]string{"case": "switch_case", "default": "switch_default"},
+		nameSkip:  wordSet("*"),
+
+		ident:    "identifier",
+		property: "property_identifier",
+		member:   "member_expression",
+		call:     "call_expression",
+		newExpr:  "new_expression",
+		args:     "arguments",
+		params:   "formal_parameters",
+		block:    "statement_block",
+		object:   "object",
+		arrow:    "arrow_function",
+		funcExpr: "function_expression",
+
+		method:       "method_definition",
+		methodNoBody: methodSignature,
+		field:        fieldType,
+		expression:   "expression_statement",
+		assignment:   "assignment_expression",
+	}
+}
+
+var (
+	javascriptDialect = scriptDialect("javascript", false)
+	typescriptDialect = scriptDialect("typescript", true)
+	tsxDialect        = scriptDialect("tsx", true)
+)
+
+var javaDialect = &braceDialect{
+	lang:      "java",
+	root:      "program",
+	lex:       tripleQuoteLex,
+	keywords:  wordSet("abstract", "assert", "break", "case", "catch", "class", "continue", "default", "do", "else", "enum", "extends", "final", "finally", "for", "if", "implements", "import", "instanceof", "interface", "new", "package", "private", "protected", "public", "return", "static", "switch", "synchronized", "this", "super", "throw", "throws", "try", "while", "true", "false", "null"),
+	modifiers: wordSet("public", "private", "protected", "static", "final", "abstract", "synchronized", "native", "transient", "volatile", "strictfp", "default", "sealed"),
+	selfWords: wordSet("this", "super"),
+	decls: map[string]declSpec{
+		"class":        {typ: "class_declaration", name: "identifier", rule: nameFirst, body: "class_body", mode: bodyMembers},
+		"interface":    {typ: "interface_declaration", name: "identifier", rule: nameFirst, body: "interface_body", mode: bodyMembers},
+		"enum":         {typ: "enum_declaration", name: "identifier", rule: nameFirst, body: "enum_body", mode: bodyMembers},
+		"record":       {typ: "record_declaration", name: "identifier", rule: nameFirst, body: "class_body", mode: bodyMembers},
+		"package":      {typ: "package_declaration"},
+		"import":       {typ: "import_declaration"},
+		"if":           {typ: "if_statement", body: "block"},
+		"for":          {typ: "for_statement", body: "block"},
+		"while":        {typ: "while_statement", body: "block"},
+		"do":           {typ: "do_statement", body: "block"},
+		"try":          {typ: "try_statement", body: "block"},
+		"switch":       {typ: "switch_expression", body: "switch_block"},
+		"synchronized": {typ: "synchronized_statement", body: "block"},
+		"return":       {typ: "return_statement"},
+		"throw":        {typ: "throw_statement"},
+	},
+	labels: map[string]string{"case": "switch_label", "default": "switch_label"},
+
+	ident:    "identifier",
+	property: "identifier",
+	call:     "method_invocation",
+	newExpr:  "object_creation_expression",
+	args:     "argument_list",
+	params:   "formal_parameters",
+	block:    "block",
+
+	method:      "method_declaration",
+	constructor: "constructor_declaration",
+	field:       "field_declaration",
+	localDecl:   "local_variable_declaration",
+	expression:  "expression_statement",
+	assignment:  "assignment_expression",
+}
+
+var csharpDialect = &braceDialect{
+	lang:       "csharp",
+	root:       "compilation_unit",
+	lex:        tripleQuoteLex,
+	attributes: "[",
+	keywords:   wordSet("as", "base", "break", "case", "catch", "checked", "class", "const", "continue", "default", "delegate", "do", "else", "enum", "event", "explicit", "extern", "false", "finally", "fixed", "for", "foreach", "goto", "if", "implicit", "in", "interface", "internal", "is", "lock", "namespace", "new", "null", "operator", "out", "override", "params", "private", "protected", "public", "readonly", "ref", "return", "sealed", "sizeof", "stackalloc", "static", "struct", "switch", "this", "throw", "true", "try", "typeof", "unchecked", "unsafe", "using", "virtual", "volatile", "while"),
+	modifiers:  wordSet("public", "private", "protected", "internal", "static", "readonly", "sealed", "abstract", "virtual", "override", "async", "partial", "extern", "unsafe", "new", "volatile", "const", "required", "event"),
+	selfWords:  wordSet("this", "base"),
+	decls: map[string]declSpec{
+		"namespace": {typ: "namespace_declaration", name: "identifier", rule: nameFirst, body: "declaration_list", mode: bodyMembers, noBody: "file_scoped_namespace_declaration"},
+		"class":     {typ: "class_declaration", name: "identifier", rule: nameFirst, body: "declaration_list", mode: bodyMembers},
+		"struct":    {typ: "struct_declaration", name: "identifier", rule: nameFirst, body: "declaration_list", mode: bodyMembers},
+		"interface": {typ: "interface_declaration", name: "identifier", rule: nameFirst, body: "declaration_list", mode: bodyMembers},
+		"record":    {typ: "record_declaration", name: "identifier", rule: nameFirst, body: "declaration_list", mode: bodyMembers},
+		"enum":      {typ: "enum_declaration", name: "identifier", rule: nameFirst, body: "enum_member_declaration_list", mode: bodyOpaque},
+		"using":     {typ: "using_directive", parenType: "using_statement", body: "block"},
+		"if":        {typ: "if_statement", body: "block"},
+		"for":       {typ: "for_statement", body: "block"},
+		"foreach":   {typ: "foreach_statement", body: "block"},
+		"while":     {typ: "while_statement", body: "block"},
+		"do":        {typ: "do_statement", body: "block"},
+		"try":       {typ: "try_statement", body: "block"},
+		"switch":    {typ: "switch_statement", body: "switch_body"},
+		"lock":      {typ: "lock_statement", body: "block"},
+		"return":    {typ: "return_statement"},
+		"throw":     {typ: "throw_statement"},
+	},
+	labels: map[string]string{"case": "switch_label", "default": "switch_label"},
+
+	ident:    "identifier",
+	property: "identifier",
+	member:   "member_access_expression",
+	call:     "invocation_expression",
+	newExpr:  "object_creation_expression",
+	args:     "argument_list",
+	params:   "parameter_list",
+	block:    "block",
+	arrow:    "lambda_expression",
+
+	method:       "method_declaration",
+	constructor:  "constructor_declaration",
+	field:        "field_declaration",
+	propertyDecl: "property_declaration",
+	localDecl:    "local_declaration_statement",
+	expression:   "expression_statement",
+	assignment:   "assignment_expression",
+}
+
+func cFamilyDialect(lang string) *braceDialect {
+	decls := map[string]declSpec{
+		"struct":  {typ: "struct_specifier", name: "type_identifier", rule: nameFirst, body: "field_declaration_list", mode: bodyMembers, noBody: "declaration"},
+		"union":   {typ: "union_specifier", name: "type_identifier", rule: nameFirst, body: "field_declaration_list", mode: bodyMembers, noBody: "declaration"},
+		"enum":    {typ: "enum_specifier", name: "type_identifier", rule: nameFirst, body: "enumerator_list", mode: bodyOpaque, noBody: "declaration"},
+		"typedef": {typ: "type_definition", body: "field_declaration_list", mode: bodyMembers},
+		"if":      {typ: "if_statement", body: "compound_statement"},
+		"for":     {typ: "for_statement", body: "compound_statement"},
+		"while":   {typ: "while_statement", body: "compound_statement"},
+		"do":      {typ: "do_statement", body: "compound_statement"},
+		"switch":  {typ: "switch_statement", body: "compound_statement"},
+		"return":  {typ: "return_statement"},
+	}
+	labels := map[string]string{"case": "case_statement", "default": "case_statement"}
+	keywords := wordSet("break", "case", "continue", "default", "do", "else", "enum", "for", "goto", "if", "return", "sizeof", "struct", "switch", "typedef", "union", "while", "true", "false")
+	d := &braceDialect{
+		lang:          lang,
+		root:          "translation_unit",
+		lex:           cStyleLex,
+		keywords:      keywords,
+		modifiers:     wordSet("static", "extern", "inline", "const", "volatile", "register", "restrict", "_Noreturn"),
+		decls:         decls,
+		labels:        labels,
+		trailingAfter: true,
+		preprocessor:  true,
+
+		ident:    "identifier",
+		property: "field_identifier",
+		member:   "field_expression",
+		call:     "call_expression",
+		args:     "argument_list",
+		params:   "parameter_list",
+		block:    "compound_statement",
+
+		field:      "field_declaration",
+		function:   "function_definition",
+		declarator: "function_declarator",
+		localDecl:  "declaration",
+		expression: "expression_statement",
+		assignment: "assignment_expression",
+	}
+
+	if lang == "cpp" {
+		decls["class"] = declSpec{typ: "class_specifier", name: "type_identifier", rule: nameFirst, body: "field_declaration_list", mode: bodyMembers, noBody: "declaration"}
+		decls["namespace"] = declSpec{typ: "namespace_definition", name: "namespace_identifier", rule: nameFirst, body: "declaration_list"}
+		decls["template"] = declSpec{typ: "template_declaration", prefix: true}
+		decls["using"] = declSpec{typ: "using_declaration"}
+		decls["try"] = declSpec{typ: "try_statement", body: "compound_statement"}
+		for _, word := range []string{"class", "namespace", "template", "using", "try", "catch", "new", "delete", "this", "public", "private", "protected", "virtual", "operator", "nullptr"} {
+			keywords[word] = true
+		}
+		for _, word := range []string{"virtual", "explicit", "constexpr", "consteval", "mutable", "friend", "thread_local"} {
+			d.modifiers[word] = true
+		}
+		labels["public"] = "access_specifier"
+		labels["private"] = "access_specifier"
+		labels["protected"] = "access_specifier"
+		d.selfWords = wordSet("this")
+		d.newExpr = "new_expression"
+		d.method = "function_definition"
+		d.methodNoBody = "field_declaration"
+	}
+	return d
+}
+
+var (
+	cDialect   = cFamilyDialect("c")
+	cppDialect = cFamilyDialect("cpp")
+)
+
+var rustDialect = &braceDialect{
+	lang:        "rust",
+	root:        "source_file",
+	lex:         rustLex,
+	keywords:    wordSet("as", "async", "await", "break", "const", "continue", "crate", "dyn", "else", "enum", "extern", "false", "fn", "for", "if", "impl", "in", "let", "loop", "match", "mod", "move", "mut", "pub", "ref", "return", "static", "struct", "trait", "true", "type", "unsafe", "use", "where", "while", "self", "Self", "super"),
+	modifiers:   wordSet("pub", "async", "unsafe", "extern"),
+	conditional: wordSet("const", "default"),
+	selfWords:   wordSet("self", "Self", "super", "crate"),
+	decls: map[string]declSpec{
+		"fn":          {typ: "function_item", name: "identifier", rule: nameFirst, body: "block", noBody: "function_signature_item"},
+		"struct":      {typ: "struct_item", name: "type_identifier", rule: nameFirst, body: "field_declaration_list", mode: bodyOpaque},
+		"enum":        {typ: "enum_item", name: "type_identifier", rule: nameFirst, body: "enum_variant_list", mode: bodyOpaque},
+		"union":       {typ: "union_item", name: "type_identifier", rule: nameFirst, body: "field_declaration_list", mode: bodyOpaque},
+		"trait":       {typ: "trait_item", name: "type_identifier", rule: nameFirst, body: "declaration_list"},
+		"impl":        {typ: "impl_item", name: "type_identifier", rule: nameImpl, body: "declaration_list"},
+		"mod":         {typ: "mod_item", name: "identifier", rule: nameFirst, body: "declaration_list"},
+		"use":         {typ: "use_declaration"},
+		"const":       {typ: "const_item", name: "identifier", rule: nameFirst},
+		"static":      {typ: "static_item", name: "identifier", rule: nameFirst},
+		"type":        {typ: "type_item", name: "type_identifier", rule: nameFirst},
+		"let":         {typ: "let_declaration", name: "identifier", rule: nameFirst},
+		"macro_rules": {typ: "macro_definition", name: "identifier", rule: nameFirst, body: "token_tree", mode: bodyOpaque},
+		"if":          {typ: "if_expression", body: "block", wrap: "expression_statement"},
+		"while":       {typ: "while_expression", body: "block", wrap: "expression_statement"},
+		"loop":        {typ: "loop_expression", body: "block", wrap: "expression_statement"},
+		"for":         {typ: "for_expression", body: "block", wrap: "expression_statement"},
+		"match":       {typ: "match_expression", body: "match_block", mode: bodyOpaque, wrap: "expression_statement"},
+		"return":      {typ: "return_expression", wrap: "expression_statement"},
+	},
+	nameSkip: wordSet("mut", "ref", "!"),
+
+	ident:    "identifier",
+	property: "field_identifier",
+	member:   "field_expression",
+	call:     "call_expression",
+	macro:    "macro_invocation",
+	args:     "arguments",
+	params:   "parameters",
+	block:    "block",
+
+	expression: "expression_statement",
+	assignment: "assignment_expression",
+}
+
+var phpDialect = &braceDialect{
+	lang:       "php",
+	root:       "program",
+	lex:        phpLex,
+	attributes: "#[",
+	keywords:   wordSet("abstract", "and", "as", "break", "case", "catch", "class", "clone", "const", "continue", "declare", "default", "do", "echo", "else", "elseif", "empty", "enum", "extends", "final", "finally", "fn", "for", "foreach", "function", "global", "goto", "if", "implements", "include", "include_once", "instanceof", "insteadof", "interface", "isset", "list", "match", "namespace", "new", "print", "private", "protected", "public", "readonly", "require", "require_once", "return", "static", "switch", "throw", "trait", "try", "unset", "use", "var", "while", "yield", "true", "false", "null"),
+	modifiers:  wordSet("public", "private", "protected", "static", "final", "abstract", "readonly", "var"),
+	decls: map[string]declSpec{
+		"function":  {typ: "function_definition", name: "name", rule: nameFirst, body: "compound_statement"},
+		"class":     {typ: "class_declaration", name: "name", rule: nameFirst, body: "declaration_list", mode: bodyMembers},
+		"interface": {typ: "interface_declaration", name: "name", rule: nameFirst, body: "declaration_list", mode: bodyMembers},
+		"trait":     {typ: "trait_declaration", name: "name", rule: nameFirst, body: "declaration_list", mode: bodyMembers},
+		"enum":      {typ: "enum_declaration", name: "name", rule: nameFirst, body: "enum_declaration_list", mode: bodyMembers},
+		"namespace": {typ: "namespace_definition", name: "name", rule: nameFirst, body: "compound_statement"},
+		"use":       {typ: "namespace_use_declaration"},
+		"const":     {typ: "const_declaration", name: "name", rule: nameFirst},
+		"if":        {typ: "if_statement", body: "compound_statement"},
+		"foreach":   {typ: "foreach_statement", body: "compound_statement"},
+		"for":       {typ: "for_statement", body: "compound_statement"},
+		"while":     {typ: "while_statement", body: "compound_statement"},
+		"do":        {typ: "do_statement", body: "compound_statement"},
+		"try":       {typ: "try_statement", body: "compound_statement"},
+		"switch":    {typ: "switch_statement", body: "switch_block"},
+		"return":    {typ: "return_statement"},
+		"echo":      {typ: "echo_statement"},
+		"throw":     {typ: "expression_statement"},
+	},
+	memberDecls: map[string]declSpec{
+		"function": {typ: "method_declaration", name: "name", rule: nameFirst, body: "compound_statement"},
+		"use":      {typ: "use_declaration"},
+	},
+	labels:    map[string]string{"case": "case_statement", "default": "default_statement"},
+	nameSkip:  wordSet("&"),
+	selfWords: wordSet(),
+
+	ident:      "name",
+	property:   "name",
+	call:       "function_call_expression",
+	memberCall: "member_call_expression",
+	scopedCall: "scoped_call_expression",
+	newExpr:    "object_creation_expression",
+	funcExpr:   "anonymous_function",
+	args:       "arguments",
+	params:     "formal_parameters",
+	block:      "compound_statement",
+
+	field:      "property_declaration",
+	expression: "expression_statement",
+	assignment: "assignment_expression",
+}
+
+var kotlinDialect = &braceDialect{
+	lang:      "kotlin",
+	root:      "source_file",
+	lex:       tripleQuoteLex,
+	asi:       true,
+	keywords:  wordSet("as", "break", "class", "continue", "do", "else", "false", "for", "fun", "if", "in", "interface", "is", "null", "object", "package", "return", "super", "this", "throw", "true", "try", "typealias", "val", "var", "when", "while"),
+	modifiers: wordSet("public", "private", "protected", "internal", "open", "abstract", "final", "override", "sealed", "data", "inner", "enum", "annotation", "companion", "suspend", "inline", "tailrec", "operator", "infix", "external", "lateinit", "const", "vararg", "noinline", "crossinline", "value", "expect", "actual"),
+	selfWords: wordSet("this", "super"),
+	decls: map[string]declSpec{
+		"fun":         {typ: "function_declaration", name: "simple_identifier", rule: nameFirst, body: "function_body"},
+		"class":       {typ: "class_declaration", name: "type_identifier", rule: nameFirst, body: "class_body", mode: bodyMembers},
+		"interface":   {typ: "class_declaration", name: "type_identifier", rule: nameFirst, body: "class_body", mode: bodyMembers},
+		"object":      {typ: "object_declaration", name: "type_identifier", rule: nameFirst, body: "class_body", mode: bodyMembers},
+		"val":         {typ: "property_declaration", name: "simple_identifier", rule: nameFirst, declarator: "variable_declaration"},
+		"var":         {typ: "property_declaration", name: "simple_identifier", rule: nameFirst, declarator: "variable_declaration"},
+		"typealias":   {typ: "type_alias", name: "type_identifier", rule: nameFirst},
+		"import":      {typ: "import_header"},
+		"package":     {typ: "package_header"},
+		"if":          {typ: "if_expression", body: "control_structure_body"},
+		"when":        {typ: "when_expression", body: "when_entry", mode: bodyOpaque},
+		"try":         {typ: "try_expression", body: "statements"},
+		"for":         {typ: "for_statement", body: "control_structure_body"},
+		"while":       {typ: "while_statement", body: "control_structure_body"},
+		"do":          {typ: "do_while_statement", body: "control_structure_body"},
+		"return":      {typ: "jump_expression"},
+		"throw":       {typ: "jump_expression"},
+		"init":        {typ: "anonymous_initializer", body: "statements"},
+		"constructor": {typ: "secondary_constructor", body: "statements"},
+	},
+	nameSkip: wordSet(),
+
+	ident:    "simple_identifier",
+	property: "simple_identifier",
+	member:   "navigation_expression",
+	call:     "call_expression",
+	args:     "value_arguments",
+	params:   "function_value_parameters",
+	block:    "statements",
+	lambdas:  true,
+
+	assignment: "assignment",
+}
+
+var swiftDialect = &braceDialect{
+	lang:        "swift",
+	root:        "source_file",
+	lex:         tripleQuoteLex,
+	asi:         true,
+	keywords:    wordSet("as", "associatedtype", "break", "case", "catch", "class", "continue", "default", "defer", "deinit", "do", "else", "enum", "extension", "fallthrough", "false", "fileprivate", "for", "func", "guard", "if", "import", "in", "init", "inout", "internal", "is", "let", "nil", "open", "operator", "private", "protocol", "public", "repeat", "return", "self", "static", "struct", "subscript", "super", "switch", "throw", "throws", "true", "try", "typealias", "var", "where", "while"),
+	modifiers:   wordSet("public", "private", "fileprivate", "internal", "open", "static", "final", "override", "mutating", "nonmutating", "convenience", "required", "dynamic", "lazy", "weak", "unowned", "indirect", "optional", "nonisolated"),
+	conditional: wordSet("class"),
+	selfWords:   wordSet("self", "super"),
+	decls: map[string]declSpec{
+		"func":      {typ: "function_declaration", name: "simple_identifier", rule: nameFirst, body: "function_body", noBody: "protocol_function_declaration"},
+		"class":     {typ: "class_declaration", name: "type_identifier", rule: nameFirst, body: "class_body", mode: bodyMembers},
+		"struct":    {typ: "class_declaration", name: "type_identifier", rule: nameFirst, body: "class_body", mode: bodyMembers},
+		"enum":      {typ: "class_declaration", name: "type_identifier", rule: nameFirst, body: "enum_class_body", mode: bodyMembers},
+		"extension": {typ: "class_declaration", name: "type_identifier", rule: nameFirst, body: "class_body", mode: bodyMembers},
+		"actor":     {typ: "class_declaration", name: "type_identifier", rule: nameFirst, body: "class_body", mode: bodyMembers},
+		"protocol":  {typ: "protocol_declaration", name: "type_identifier", rule: nameFirst, body: "protocol_body", mode: bodyMembers},
+		"init":      {typ: "init_declaration", body: "function_body"},
+		"deinit":    {typ: "deinit_declaration", body: "function_body"},
+		"let":       {typ: "property_declaration", name: "simple_identifier", rule: nameFirst, declarator: "pattern"},
+		"var":       {typ: "property_declaration", name: "simple_identifier", rule: nameFirst, declarator: "pattern"},
+		"typealias": {typ: "typealias_declaration", name: "type_identifier", rule: nameFirst},
+		"import":    {typ: "import_declaration"},
+		"if":        {typ: "if_statement", body: "statements"},
+		"guard":     {typ: "guard_statement", body: "statements"},
+		"for":       {typ: "for_statement", body: "statements"},
+		"while":     {typ: "while_statement", body: "statements"},
+		"repeat":    {typ: "repeat_while_statement", body: "statements"},
+		"switch":    {typ: "switch_statement", mode: bodyOpaque},
+		"do":        {typ: "do_statement", body: "statements"},
+		"return":    {typ: "control_transfer_statement"},
+		"throw":     {typ: "control_transfer_statement"},
+	},
+	memberDecls: map[string]declSpec{
+		"case": {typ: "enum_entry", name: "simple_identifier", rule: nameFirst},
+	},
+	nameSkip: wordSet(),
+
+	ident:    "simple_identifier",
+	property: "simple_identifier",
+	member:   "navigation_expression",
+	call:     "call_expression",
+	args:     "value_arguments",
+	block:    "statements",
+	lambdas:  true,
+
+	assignment: "assignment",
+}
+
+var scalaDialect = &braceDialect{
+	lang:        "scala",
+	root:        "compilation_unit",
+	lex:         tripleQuoteLex,
+	asi:         true,
+	keywords:    wordSet("abstract", "case", "catch", "class", "def", "do", "else", "extends", "false", "final", "finally", "for", "if", "implicit", "import", "lazy", "match", "new", "null", "object", "override", "package", "private", "protected", "return", "sealed", "super", "this", "throw", "trait", "try", "true", "type", "val", "var", "while", "with", "yield"),
+	modifiers:   wordSet("private", "protected", "override", "final", "sealed", "abstract", "implicit", "lazy", "inline", "opaque"),
+	conditional: wordSet("case"),
+	selfWords:   wordSet("this", "super"),
+	decls: map[string]declSpec{
+		"def":     {typ: "function_definition", name: "identifier", rule: nameFirst, body: "block", noBody: "function_declaration"},
+		"class":   {typ: "class_definition", name: "identifier", rule: nameFirst, body: "template_body", mode: bodyMembers},
+		"object":  {typ: "object_definition", name: "identifier", rule: nameFirst, body: "template_body", mode: bodyMembers},
+		"trait":   {typ: "trait_definition", name: "identifier", rule: nameFirst, body: "template_body", mode: bodyMembers},
+		"val":     {typ: "val_definition", name: "identifier", rule: nameFirst, noBody: "val_declaration"},
+		"var":     {typ: "var_definition", name: "identifier", rule: nameFirst, noBody: "var_declaration"},
+		"type":    {typ: "type_definition", name: "type_identifier", rule: nameFirst},
+		"import":  {typ: "import_declaration"},
+		"package": {typ: "package_clause", body: "template_body"},
+		"if":      {typ: "if_expression", body: "block"},
+		"while":   {typ: "while_expression", body: "block"},
+		"for":     {typ: "for_expression", body: "block"},
+		"try":     {typ: "try_expression", body: "block"},
+	},
+	nameSkip: wordSet(),
+
+	ident:    "identifier",
+	property: "identifier",
+	member:   "field_expression",
+	call:     "call_expression",
+	newExpr:  "instance_expression",
+	args:     "arguments",
+	params:   "parameters",
+	block:    "block",
+	lambdas:  true,
+
+	assignment: "assignment_expression",
+}
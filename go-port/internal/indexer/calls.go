@@ -0,0 +1,71 @@
+package indexer
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Call is a call made by a chunk: the called name and the qualifiers
+// written before it, joined by ".", such as "s.store" for s.store.Save().
+type Call struct {
+	Qualifier string
+	Name      string
+}
+
+// callKeywords are names the call scan of symbols/extract.js ignores.
+var callKeywords = []string{
+	"if", "for", "while", "switch", "catch", "return", "function", "class", "new",
+	"await", "yield", "isset", "empty", "echo", "print", "require", "include",
+}
+
+// callName matches the called name of a call expression after any "a.",
+// "A::" or "$a->" qualifiers.
+var callName = regexp.MustCompile(`((?:\$?[A-Za-z_]\w*->|[A-Za-z_]\w*::|[A-Za-z_]\w*\.)*)([A-Za-z_]\w*)\s*\(`)
+
+var qualifierSeparator = strings.NewReplacer("->", ".", "::", ".", "$", "")
+
+// maxCallSnippet is how much of a call node is scanned for its name.
+const maxCallSnippet = 120
+
+// ExtractCalls ports collectCalls: the distinct calls of the call and
+// invocation nodes under node, in source order.
+func ExtractCalls(node *Node, source []byte) []Call {
+	var calls []Call
+	node.Walk(func(n *Node) bool {
+		if !strings.Contains(n.Type, "call") && !strings.Contains(n.Type, "invocation") {
+			return true
+		}
+		end := min(n.EndByte, n.StartByte+maxCallSnippet)
+		if call, ok := callOf(string(source[n.StartByte:end])); ok && !slices.Contains(calls, call) {
+			calls = append(calls, call)
+		}
+		return true
+	})
+	return calls
+}
+
+func callOf(snippet string) (Call, bool) {
+	snippet = strings.TrimSpace(snippet)
+	if !strings.Contains(snippet, "(") {
+		return Call{}, false
+	}
+	match := callName.FindStringSubmatch(snippet)
+	if match == nil || slices.Contains(callKeywords, match[2]) {
+		return Call{}, false
+	}
+	qualifier := strings.TrimRight(qualifierSeparator.Replace(match[1]), ".")
+	return Call{Qualifier: qualifier, Name: match[2]}, true
+}
+
+// CallNames returns the distinct names of calls, the symbol_calls of the
+// codemap.
+func CallNames(calls []Call) []string {
+	var names []string
+	for _, call := range calls {
+		if !slices.Contains(names, call.Name) {
+			names = append(names, call.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,199 @@
+package indexer
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/alessandrojcm/pampax-go/internal/chunks"
+)
+
+// ChunkTypeSection marks chunks of a Markdown document.
+const ChunkTypeSection = "section"
+
+// BreadcrumbSeparator joins the headings of a Markdown chunk symbol.
+const BreadcrumbSeparator = " > "
+
+var atxClosingSequence = regexp.MustCompile(`\s+#+\s*$`)
+
+// Markdown chunks a parsed Markdown document along its heading hierarchy.
+// A section that fits the optimal size is one chunk, subsections
+// included; a larger one yields its own text, then each subsection in
+// turn. Text is packed block by block, so code fences, lists and tables
+// are only split when a single block exceeds the maximum size. The symbol
+// of each chunk is its heading breadcrumb, e.g. "Guide > Install > Linux".
+func (c *Chunker) Markdown(name string, tree *Tree) []Chunk {
+	run := &markdownRun{Chunker: c, name: name, source: tree.Source}
+	for _, child := range tree.Root.Children {
+		if child.Type == "section" {
+			run.section(child, nil)
+		}
+	}
+	return run.chunks
+}
+
+type markdownRun struct {
+	*Chunker
+	name   string
+	source []byte
+	chunks []Chunk
+}
+
+func (r *markdownRun) section(section *Node, crumbs []string) {
+	var heading *Node
+	if len(section.Children) > 0 && headingTitle(section.Children[0], r.source) != "" {
+		heading = section.Children[0]
+		crumbs = append(crumbs[:len(crumbs):len(crumbs)], headingTitle(heading, r.source))
+	}
+
+	symbol := strings.Join(crumbs, BreadcrumbSeparator)
+	if symbol == "" {
+		symbol = path.Base(r.name)
+	}
+
+	if r.measure(r.text(section.StartByte, section.EndByte)) <= r.limits.Optimal {
+		r.pack(symbol, heading, []*Node{section}, section.EndByte)
+		return
+	}
+
+	var blocks, subsections []*Node
+	for _, child := range section.Children {
+		if child.Type == "section" {
+			subsections = append(subsections, child)
+		} else {
+			blocks = append(blocks, child)
+		}
+	}
+	ownEnd := section.EndByte
+	if len(subsections) > 0 {
+		ownEnd = subsections[0].StartByte
+	}
+	// A heading directly followed by subsections lives on in their symbols.
+	if len(blocks) > 1 || (len(blocks) == 1 && blocks[0] != heading) {
+		r.pack(symbol, heading, blocks, ownEnd)
+	}
+	for _, subsection := range subsections {
+		r.section(subsection, crumbs)
+	}
+}
+
+// pack emits blocks, each running up to the next block or end, in as few
+// chunks of at most the optimal size as possible. Chunks after the first
+// repeat the section heading for context.
+func (r *markdownRun) pack(symbol string, heading *Node, blocks []*Node, end int) {
+	type part struct {
+		start int
+		code  string
+	}
+	var parts []part
+	add := func(start int, code string) {
+		if code = strings.TrimRight(code, "\r\n"); strings.TrimSpace(code) != "" {
+			parts = append(parts, part{start, code})
+		}
+	}
+
+	currentStart, currentEnd := -1, -1
+	flush := func() {
+		if currentStart >= 0 {
+			add(currentStart, r.text(currentStart, currentEnd))
+		}
+		currentStart = -1
+	}
+	for i, block := range blocks {
+		blockEnd := end
+		if i+1 < len(blocks) {
+			blockEnd = blocks[i+1].StartByte
+		}
+
+		switch {
+		case r.measure(r.text(block.StartByte, blockEnd)) > r.limits.Max:
+			// Only a block no chunk can hold is cut, into line windows. A
+			// heading pending on its own opens the first window instead of
+			// making a chunk of its own.
+			prefixStart, prefix := -1, ""
+			if i > 0 && blocks[i-1] == heading && currentStart == heading.StartByte {
+				prefixStart, prefix = currentStart, r.text(currentStart, block.StartByte)
+				currentStart = -1
+			}
+			flush()
+			for j, window := range r.Windows(r.name, r.source[block.StartByte:blockEnd]) {
+				start := block.StartByte + lineOffset(r.source[block.StartByte:blockEnd], window.StartLine)
+				if j == 0 && prefixStart >= 0 {
+					add(prefixStart, prefix+r.text(block.StartByte, start)+window.Code)
+					continue
+				}
+				add(start, window.Code)
+			}
+		case currentStart < 0:
+			currentStart, currentEnd = block.StartByte, blockEnd
+		case r.measure(r.text(currentStart, blockEnd)) > r.limits.Optimal:
+			flush()
+			currentStart, currentEnd = block.StartByte, blockEnd
+		default:
+			currentEnd = blockEnd
+		}
+	}
+	flush()
+
+	for i, p := range parts {
+		partSymbol := symbol
+		if len(parts) > 1 {
+			partSymbol = fmt.Sprintf("%s_part%d", symbol, i+1)
+		}
+		startLine := lineAt(r.source, p.start)
+		endLine := startLine + strings.Count(p.code, "\n")
+		code := p.code
+		if i > 0 && heading != nil {
+			code = strings.TrimRight(heading.Text(r.source), "\r\n") + "\n\n" + code
+		}
+		r.chunks = append(r.chunks, Chunk{
+			Symbol:           partSymbol,
+			Code:             code,
+			SHA:              chunks.ComputeSHA(code),
+			ChunkType:        ChunkTypeSection,
+			NodeType:         "section",
+			StartLine:        startLine,
+			EndLine:          endLine,
+			IsSubdivision:    len(parts) > 1,
+			HasDocumentation: true,
+		})
+	}
+}
+
+func (r *markdownRun) text(start, end int) string {
+	return string(r.source[start:end])
+}
+
+// headingTitle returns the plain title of an ATX or setext heading, or ""
+// for any other node.
+func headingTitle(node *Node, source []byte) string {
+	switch node.Type {
+	case "atx_heading", "setext_heading":
+	default:
+		return ""
+	}
+	content := node.ChildByField("heading_content")
+	if content == nil {
+		return ""
+	}
+	title := strings.TrimSpace(content.Text(source))
+	if node.Type == "atx_heading" {
+		title = strings.TrimSpace(atxClosingSequence.ReplaceAllString(" "+title, ""))
+	}
+	return strings.Join(strings.Fields(title), " ")
+}
+
+// lineOffset returns the byte offset of the 1-based line in text.
+func lineOffset(text []byte, line int) int {
+	offset := 0
+	for ; line > 1; line-- {
+		i := bytes.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		offset += i + 1
+	}
+	return offset
+}
@@ -0,0 +1,465 @@
+package indexer
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/alessandrojcm/pampax-go/internal/chunks"
+	"github.com/alessandrojcm/pampax-go/internal/tags"
+	"github.com/alessandrojcm/pampax-go/internal/tokens"
+)
+
+// Chunk types, as stored in the codemap chunkType field.
+const (
+	ChunkTypeFunction = "function"
+	ChunkTypeMethod   = "method"
+	ChunkTypeClass    = "class"
+	// ChunkTypeWindow marks line windows of files chunked without syntax.
+	ChunkTypeWindow = "window"
+)
+
+// Chunk is one embeddable piece of a source file.
+type Chunk struct {
+	Symbol    string
+	Code      string
+	SHA       string
+	ChunkType string
+	// NodeType is the syntax node the chunk came from; merged small
+	// subdivisions use the parent type with a "_merged" suffix.
+	NodeType  string
+	StartLine int
+	EndLine   int
+	// IsSubdivision is set for statement-level parts, merged small
+	// subdivisions and file-level groups, whose symbol carries a "_part"
+	// suffix.
+	IsSubdivision    bool
+	HasParentContext bool
+	// HasDocumentation is set for chunks with a doc comment and for
+	// documentation chunks such as Markdown sections.
+	HasDocumentation bool
+	// DocComment is the comment documenting the chunk's node, see
+	// DocComment.
+	DocComment string
+	// Pampa holds the @pampax annotations found in DocComment.
+	Pampa PampaMetadata
+	// Tags are the annotated tags followed by the automatic ones, stored
+	// in the pampa_tags column.
+	Tags []string
+	// Signature is the chunk's declaration: name, parameters and return
+	// type.
+	Signature Signature
+	// Variables are the important variables the chunk declares.
+	Variables []Variable
+	// Calls are the calls the chunk makes, resolved to chunks by
+	// codemap.AttachSymbolGraph.
+	Calls []Call
+	// Declares lists the symbols the chunk defines, each function of a
+	// group chunk included.
+	Declares []Declaration
+	Scope    Scope
+	// GroupSymbols lists the symbols combined into a file-level group.
+	GroupSymbols []string
+}
+
+// ChunkStats counts the chunking decisions taken for a file.
+type ChunkStats struct {
+	TotalNodes        int
+	SkippedSmall      int
+	Subdivided        int
+	StatementFallback int
+	NormalChunks      int
+	MergedSmall       int
+	FileGrouped       int
+	FunctionsGrouped  int
+	Windows           int
+	Sections          int
+}
+
+// Add accumulates other into s.
+func (s *ChunkStats) Add(other ChunkStats) {
+	s.TotalNodes += other.TotalNodes
+	s.SkippedSmall += other.SkippedSmall
+	s.Subdivided += other.Subdivided
+	s.StatementFallback += other.StatementFallback
+	s.NormalChunks += other.NormalChunks
+	s.MergedSmall += other.MergedSmall
+	s.FileGrouped += other.FileGrouped
+	s.FunctionsGrouped += other.FunctionsGrouped
+	s.Windows += other.Windows
+	s.Sections += other.Sections
+}
+
+// ChunkerOptions configures a Chunker.
+type ChunkerOptions struct {
+	// Counter sizes code in token mode. Defaults to Profile.Counter().
+	Counter tokens.Counter
+	// OverlapLines, when positive, prefixes chunks cut out of a larger
+	// parent with the parent signature and that many preceding lines. The
+	// Node indexer does not do this, so enabling it changes chunk SHAs.
+	OverlapLines int
+	Grouping     GroupingProfile
+	// WindowOverlap is how much of a line window, in the profile unit, is
+	// repeated at the start of the next; 0 uses the profile overlap and a
+	// negative value disables it.
+	WindowOverlap int
+	// Tagger, when set, adds automatic tags to the chunks of ChunkFile.
+	Tagger *tags.Extractor
+}
+
+// Chunker splits parsed files into chunks, porting yieldChunk in
+// service.js and chunking/semantic-chunker.js: nodes over the maximum size
+// are subdivided into their semantic children (merging the small ones), or
+// cut into line windows when they have none. Files with many nodes are
+// first grouped as in chunking/file-grouper.js.
+type Chunker struct {
+	profile       tokens.Profile
+	limits        tokens.Limits
+	counter       tokens.Counter
+	overlapLines  int
+	grouping      GroupingProfile
+	windowOverlap int
+	tagger        *tags.Extractor
+}
+
+// NewChunker creates a Chunker sized by profile.
+func NewChunker(profile tokens.Profile, opts ChunkerOptions) *Chunker {
+	counter := opts.Counter
+	if counter == nil {
+		counter = profile.Counter()
+	}
+	return &Chunker{
+		profile:       profile,
+		limits:        profile.SizeLimits(),
+		counter:       counter,
+		overlapLines:  opts.OverlapLines,
+		grouping:      opts.Grouping,
+		windowOverlap: opts.WindowOverlap,
+		tagger:        opts.Tagger,
+	}
+}
+
+// Chunk returns the chunks of tree in the order the Node indexer emits them.
+func (c *Chunker) Chunk(tree *Tree, rule LangRule) ([]Chunk, ChunkStats) {
+	run := &chunkRun{Chunker: c, rule: rule, source: tree.Source}
+	for _, group := range run.group(CollectNodes(tree, rule)) {
+		if len(group) == 1 {
+			run.yield(group[0], nil)
+		} else {
+			run.combine(group)
+		}
+	}
+	return run.chunks, run.stats
+}
+
+// CollectNodes returns the nodes of tree whose type is one of the rule's
+// node types, in source order. An export_statement wrapping a declaration
+// is skipped in favor of the declaration itself.
+func CollectNodes(tree *Tree, rule LangRule) []*Node {
+	var nodes []*Node
+	var collect func(n *Node)
+	collect = func(n *Node) {
+		if n.Type == "export_statement" {
+			hasDeclaration := slices.ContainsFunc(n.Children, func(child *Node) bool {
+				switch child.Type {
+				case "function_declaration", "class_declaration", "method_definition":
+					return true
+				}
+				return false
+			})
+			if !hasDeclaration && slices.Contains(rule.NodeTypes, n.Type) {
+				nodes = append(nodes, n)
+				return
+			}
+			if hasDeclaration {
+				for _, child := range n.Children {
+					collect(child)
+				}
+				return
+			}
+		}
+
+		if slices.Contains(rule.NodeTypes, n.Type) {
+			nodes = append(nodes, n)
+		}
+		for _, child := range n.Children {
+			collect(child)
+		}
+	}
+
+	if tree != nil && tree.Root != nil {
+		collect(tree.Root)
+	}
+	return nodes
+}
+
+// FindSemanticSubdivisions returns the outermost descendants of node whose
+// types the rule lists as subdivisions of node's type.
+func FindSemanticSubdivisions(node *Node, rule LangRule) []*Node {
+	types := rule.SubdivisionTypes[node.Type]
+	if len(types) == 0 {
+		return nil
+	}
+
+	var candidates []*Node
+	var walk func(n *Node, depth int)
+	walk = func(n *Node, depth int) {
+		if depth > 0 && slices.Contains(types, n.Type) {
+			candidates = append(candidates, n)
+			return
+		}
+		for _, child := range n.Children {
+			walk(child, depth+1)
+		}
+	}
+	walk(node, 0)
+	return candidates
+}
+
+// ExtractSignature returns the text of node up to its first "{" followed by
+// " {", or its first line when it has no brace.
+func ExtractSignature(node *Node, source []byte) string {
+	code := node.Text(source)
+	if brace := strings.IndexByte(code, '{'); brace != -1 {
+		return strings.TrimSpace(code[:brace]) + " {"
+	}
+	first, _, _ := strings.Cut(code, "\n")
+	return first
+}
+
+// linesBefore returns the last n lines of source before node, the first of
+// which may be partial.
+func linesBefore(node *Node, source []byte, n int) string {
+	lines := strings.Split(string(source[:node.StartByte]), "\n")
+	return strings.Join(lines[max(len(lines)-n, 0):], "\n")
+}
+
+// measure sizes text in the profile unit.
+func (c *Chunker) measure(text string) int {
+	if c.profile.UseTokens {
+		return c.counter.Count(text)
+	}
+	return tokens.CharLen(text)
+}
+
+// chunkRun holds the state of chunking one file.
+type chunkRun struct {
+	*Chunker
+	rule   LangRule
+	source []byte
+	chunks []Chunk
+	stats  ChunkStats
+}
+
+// size measures code in the profile unit. Estimates are only accepted for
+// subdivision candidates, which are split further when too large anyway.
+func (r *chunkRun) size(code string, allowEstimate bool) int {
+	if !r.profile.UseTokens {
+		return tokens.CharLen(code)
+	}
+	return tokens.Analyze(code, r.limits, r.counter.Count, allowEstimate).Size
+}
+
+func (r *chunkRun) yield(node, parent *Node) {
+	r.stats.TotalNodes++
+
+	size := r.size(node.Text(r.source), false)
+	if size < r.limits.Min && parent != nil {
+		r.stats.SkippedSmall++
+		return
+	}
+
+	candidates := FindSemanticSubdivisions(node, r.rule)
+	switch {
+	case size > r.limits.Max && len(candidates) > 0:
+		r.stats.Subdivided++
+		r.subdivide(node, parent, candidates)
+	case size > r.limits.Max:
+		r.stats.StatementFallback++
+		for i, code := range r.statementChunks(node.Text(r.source)) {
+			r.emit(node, code, fmt.Sprint(i+1), parent)
+		}
+	default:
+		r.stats.NormalChunks++
+		r.emit(node, node.Text(r.source), "", parent)
+	}
+}
+
+// subdivide chunks the candidates of an oversized node separately, merging
+// those under the minimum size into one chunk when together they are big
+// enough or there are at least three of them.
+func (r *chunkRun) subdivide(node, parent *Node, candidates []*Node) {
+	var small []*Node
+	var smallCodes []string
+	smallSize := 0
+	for _, candidate := range candidates {
+		code := candidate.Text(r.source)
+		size := r.size(code, true)
+		if size >= r.limits.Min {
+			r.yield(candidate, node)
+			continue
+		}
+		small = append(small, candidate)
+		smallCodes = append(smallCodes, code)
+		smallSize += size
+	}
+	if len(small) == 0 {
+		return
+	}
+	if smallSize < r.limits.Min && len(small) < 3 {
+		r.stats.SkippedSmall += len(small)
+		return
+	}
+
+	r.stats.MergedSmall++
+	merged := &Node{
+		Type:      node.Type + "_merged",
+		StartByte: small[0].StartByte,
+		EndByte:   small[len(small)-1].EndByte,
+	}
+	r.emit(merged, strings.Join(smallCodes, "\n\n"), fmt.Sprintf("small_methods_%d", len(small)), parent)
+}
+
+// statementChunks ports yieldStatementChunks: code is cut into windows of
+// whole lines up to the maximum size, each starting with the last 20% of
+// the previous window's lines.
+func (r *chunkRun) statementChunks(code string) []string {
+	var out []string
+	var current []string
+	currentSize := 0
+	for _, line := range strings.Split(code, "\n") {
+		lineSize := r.measure(line)
+		if currentSize+lineSize > r.limits.Max && len(current) > 0 {
+			out = append(out, strings.Join(current, "\n"))
+			// Node keeps the window with slice(-overlap), and slice(-0)
+			// keeps every line, so windows under five lines carry over whole.
+			if overlap := len(current) / 5; overlap > 0 {
+				current = slices.Clone(current[len(current)-overlap:])
+			}
+			currentSize = r.measure(strings.Join(current, "\n"))
+		}
+		current = append(current, line)
+		currentSize += lineSize
+	}
+	if len(current) > 0 {
+		out = append(out, strings.Join(current, "\n"))
+	}
+	return out
+}
+
+func (r *chunkRun) emit(node *Node, code, suffix string, parent *Node) {
+	r.chunks = append(r.chunks, r.newChunk(node, code, suffix, parent))
+}
+
+func (r *chunkRun) newChunk(node *Node, code, suffix string, parent *Node) Chunk {
+	name := extractSymbolName(node, r.source)
+	symbol := name
+	if suffix != "" {
+		symbol += "_part" + suffix
+	}
+
+	if parent != nil && r.overlapLines > 0 {
+		code = ExtractSignature(parent, r.source) + "\n...\n" + linesBefore(node, r.source, r.overlapLines) + "\n" + code
+	}
+
+	chunkType := ChunkTypeFunction
+	if strings.Contains(node.Type, "class") {
+		chunkType = ChunkTypeClass
+	} else if strings.Contains(node.Type, "method") {
+		chunkType = ChunkTypeMethod
+	}
+	doc := DocComment(r.source, node, r.rule)
+	pampa := ExtractPampaMetadata(doc)
+	declared, scope := ExtractDeclaration(node, r.source, r.rule.Lang)
+	var declares []Declaration
+	if declared.Name != "" {
+		declares = []Declaration{declared}
+	}
+
+	return Chunk{
+		Symbol:           symbol,
+		Code:             code,
+		SHA:              chunks.ComputeSHA(code),
+		ChunkType:        chunkType,
+		NodeType:         node.Type,
+		StartLine:        node.StartLine(r.source),
+		EndLine:          node.EndLine(r.source),
+		IsSubdivision:    suffix != "",
+		HasParentContext: parent != nil,
+		HasDocumentation: doc != "",
+		DocComment:       doc,
+		Pampa:            pampa,
+		Tags:             pampa.Tags,
+		Signature:        ExtractSymbolSignature(node, r.source, name, r.rule.Lang),
+		Variables:        ExtractVariables(node, r.source, r.rule),
+		Calls:            ExtractCalls(node, r.source),
+		Declares:         declares,
+		Scope:            scope,
+	}
+}
+
+var (
+	symbolKeywords  = []string{"public", "private", "protected", "static", "function", "abstract", "final"}
+	anyKeywords     = []string{"public", "private", "protected", "static", "function", "class", "abstract", "final", "const", "var", "let"}
+	phpMethodName   = regexp.MustCompile(`(?:public|private|protected)?\s*(?:static)?\s*function\s+([a-zA-Z_][a-zA-Z0-9_]*)`)
+	jsFunctionName  = regexp.MustCompile(`function\s+([a-zA-Z_][a-zA-Z0-9_]*)`)
+	jsMethodName    = regexp.MustCompile(`([a-zA-Z_][a-zA-Z0-9_]*)\s*\([^)]*\)\s*\{`)
+	classNameInCode = regexp.MustCompile(`class\s+([a-zA-Z_][a-zA-Z0-9_]*)`)
+)
+
+// extractSymbolName ports the symbol lookup of processChunk. Merged chunks
+// get a childless pseudo-node, as in Node, so only the code regexes apply.
+func extractSymbolName(node *Node, source []byte) string {
+	text := func(n *Node) string { return n.Text(source) }
+
+	switch node.Type {
+	case "function_declaration", "function_definition":
+		if child := node.ChildOfType("identifier"); child != nil {
+			return text(child)
+		}
+	case "method_declaration", "method_definition":
+		if name := findIdentifier(node, source, []string{"name", "identifier", "property_identifier"}, symbolKeywords); name != "" {
+			return name
+		}
+	case "class_declaration":
+		for _, child := range node.Children {
+			switch child.Type {
+			case "identifier", "type_identifier", "name":
+				if name := text(child); name != "class" {
+					return name
+				}
+			}
+		}
+	}
+
+	if name := findIdentifier(node, source, []string{"identifier", "name", "property_identifier"}, anyKeywords); name != "" {
+		return name
+	}
+
+	code := text(node)
+	for _, re := range []*regexp.Regexp{phpMethodName, jsFunctionName, jsMethodName, classNameInCode} {
+		if match := re.FindStringSubmatch(code); match != nil {
+			return match[1]
+		}
+	}
+
+	// Node reports offsets in UTF-16 code units.
+	return fmt.Sprintf("%s_%d", node.Type, tokens.CharLen(string(source[:node.StartByte])))
+}
+
+// findIdentifier returns the first node of one of types, depth-first,
+// whose text is not a keyword.
+func findIdentifier(node *Node, source []byte, types, keywords []string) string {
+	if slices.Contains(types, node.Type) {
+		if name := node.Text(source); !slices.Contains(keywords, name) {
+			return name
+		}
+	}
+	for _, child := range node.Children {
+		if name := findIdentifier(child, source, types, keywords); name != "" {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,235 @@
+package indexer
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// LanguageOverride forces files matching Pattern to be parsed as Lang.
+// Patterns without a "/" match the base name (*.tpl), others the
+// slash-separated path relative to the project root (templates/*.html).
+// Lang is a language name (php) or an extension (.php).
+type LanguageOverride struct {
+	Pattern string
+	Lang    string
+}
+
+type compiledOverride struct {
+	pattern string
+	rule    LangRule
+}
+
+// Detector resolves the language of a file from, in order: the override
+// list, the file extension (as in Node), a shebang line and an editor
+// modeline.
+type Detector struct {
+	overrides []compiledOverride
+}
+
+// NewDetector validates overrides; earlier entries win.
+func NewDetector(overrides []LanguageOverride) (*Detector, error) {
+	d := &Detector{}
+	for _, override := range overrides {
+		if _, err := path.Match(override.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("language override %q: %w", override.Pattern, err)
+		}
+
+		rule, ok := RuleForExtension(override.Lang)
+		if !ok {
+			rule, ok = RuleForLang(override.Lang)
+		}
+		if !ok {
+			return nil, fmt.Errorf("language override %q: %w: %s", override.Pattern, ErrUnsupportedLanguage, override.Lang)
+		}
+		d.overrides = append(d.overrides, compiledOverride{pattern: override.Pattern, rule: rule})
+	}
+	return d, nil
+}
+
+// Detect returns the rule for relPath. head is the start of the file and
+// tail its end, or nil when head holds all of it; they are only consulted
+// when neither an override nor the extension decides.
+func (d *Detector) Detect(relPath string, head, tail []byte) (LangRule, bool) {
+	slashPath := filepath.ToSlash(relPath)
+	base := path.Base(slashPath)
+	for _, override := range d.overrides {
+		target := base
+		if strings.Contains(override.pattern, "/") {
+			target = slashPath
+		}
+		if ok, _ := path.Match(override.pattern, target); ok {
+			return override.rule, true
+		}
+	}
+
+	if rule, ok := RuleForPath(relPath); ok {
+		return rule, true
+	}
+	if lang := shebangLang(head); lang != "" {
+		return RuleForLang(lang)
+	}
+	if lang := modelineLang(head, tail); lang != "" {
+		return RuleForLang(lang)
+	}
+	return LangRule{}, false
+}
+
+// DetectLanguage is Detect with no overrides.
+func DetectLanguage(relPath string, head, tail []byte) (LangRule, bool) {
+	return (&Detector{}).Detect(relPath, head, tail)
+}
+
+var interpreterLangs = map[string]string{
+	"python":     "python",
+	"pypy":       "python",
+	"node":       "javascript",
+	"nodejs":     "javascript",
+	"deno":       "typescript",
+	"bun":        "typescript",
+	"ts-node":    "typescript",
+	"tsx":        "typescript",
+	"ruby":       "ruby",
+	"sh":         "bash",
+	"bash":       "bash",
+	"dash":       "bash",
+	"ksh":        "bash",
+	"zsh":        "bash",
+	"lua":        "lua",
+	"luajit":     "lua",
+	"php":        "php",
+	"elixir":     "elixir",
+	"runghc":     "haskell",
+	"runhaskell": "haskell",
+	"stack":      "haskell",
+	"ocaml":      "ocaml",
+	"scala":      "scala",
+	"swift":      "swift",
+	"kotlin":     "kotlin",
+}
+
+// interpreterVersion strips version suffixes such as python3.12.
+var interpreterVersion = regexp.MustCompile(`[0-9.]+$`)
+
+// shebangLang maps "#!/usr/bin/env -S python3 -u" and friends to a language.
+func shebangLang(head []byte) string {
+	if !bytes.HasPrefix(head, []byte("#!")) {
+		return ""
+	}
+	line, _, _ := bytes.Cut(head[2:], []byte("\n"))
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return ""
+	}
+
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			// Skip env options and VAR=value assignments.
+			if strings.HasPrefix(field, "-") || strings.Contains(field, "=") {
+				continue
+			}
+			interpreter = path.Base(field)
+			break
+		}
+	}
+	return interpreterLangs[interpreterVersion.ReplaceAllString(interpreter, "")]
+}
+
+// modelineLangs maps vim filetypes and emacs major modes to languages.
+var modelineLangs = map[string]string{
+	"python":          "python",
+	"ruby":            "ruby",
+	"javascript":      "javascript",
+	"js":              "javascript",
+	"js2":             "javascript",
+	"javascriptreact": "tsx",
+	"typescript":      "typescript",
+	"typescriptreact": "tsx",
+	"sh":              "bash",
+	"bash":            "bash",
+	"zsh":             "bash",
+	"shell-script":    "bash",
+	"lua":             "lua",
+	"elixir":          "elixir",
+	"haskell":         "haskell",
+	"ocaml":           "ocaml",
+	"tuareg":          "ocaml",
+	"caml":            "ocaml",
+	"php":             "php",
+	"java":            "java",
+	"cs":              "csharp",
+	"csharp":          "csharp",
+	"c":               "c",
+	"cpp":             "cpp",
+	"c++":             "cpp",
+	"rust":            "rust",
+	"go":              "go",
+	"kotlin":          "kotlin",
+	"scala":           "scala",
+	"swift":           "swift",
+	"html":            "html",
+	"mhtml":           "html",
+	"web":             "html",
+	"css":             "css",
+	"json":            "json",
+	"markdown":        "markdown",
+	"gfm":             "markdown",
+}
+
+var (
+	vimModeline   = regexp.MustCompile(`(?:^|\s)(?:vim?|ex):.*?\b(?:ft|filetype|syntax)=([A-Za-z0-9+_-]+)`)
+	emacsModeline = regexp.MustCompile(`-\*-(.*?)-\*-`)
+	emacsMode     = regexp.MustCompile(`(?:^|;)\s*mode:\s*([A-Za-z0-9+_-]+)`)
+)
+
+// modelineSpan is how many lines at the start and end of a file are
+// searched for modelines, matching vim's default 'modelines' setting.
+const modelineSpan = 5
+
+func modelineLang(head, tail []byte) string {
+	first := bytes.Split(bytes.TrimSuffix(head, []byte("\n")), []byte("\n"))
+	last := first
+	if tail != nil {
+		// The first line of tail is likely cut short.
+		last = bytes.Split(bytes.TrimSuffix(tail, []byte("\n")), []byte("\n"))[1:]
+	}
+	if len(first) > modelineSpan {
+		first = first[:modelineSpan]
+	}
+	if len(last) > modelineSpan {
+		last = last[len(last)-modelineSpan:]
+	}
+	for _, lines := range [][][]byte{first, last} {
+		for _, line := range lines {
+			if lang := lineModeline(line); lang != "" {
+				return lang
+			}
+		}
+	}
+	return ""
+}
+
+func lineModeline(line []byte) string {
+	if m := vimModeline.FindSubmatch(line); m != nil {
+		if lang := modelineLangs[strings.ToLower(string(m[1]))]; lang != "" {
+			return lang
+		}
+	}
+	if m := emacsModeline.FindSubmatch(line); m != nil {
+		// Either "-*- python -*-" or "-*- mode: python; coding: utf-8 -*-".
+		mode := bytes.TrimSpace(m[1])
+		if vars := emacsMode.FindSubmatch(mode); vars != nil {
+			mode = vars[1]
+		}
+		name := strings.TrimSuffix(strings.ToLower(string(mode)), "-mode")
+		if lang := modelineLangs[name]; lang != "" {
+			return lang
+		}
+	}
+	return ""
+}
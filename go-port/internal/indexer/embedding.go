@@ -0,0 +1,39 @@
+package indexer
+
+import (
+	"strings"
+
+	"github.com/alessandrojcm/pampax-go/internal/embedtext"
+)
+
+// EmbeddingFields returns the values an embedding text template renders
+// for the chunk. A docstring already inside the code is not repeated.
+func (c Chunk) EmbeddingFields(filePath, lang string) embedtext.Fields {
+	doc := c.DocComment
+	if strings.Contains(c.Code, doc) {
+		doc = ""
+	}
+	var variables []string
+	for _, variable := range c.Variables {
+		variables = append(variables, variable.Name)
+	}
+	return embedtext.Fields{
+		FilePath:    filePath,
+		Lang:        lang,
+		Symbol:      c.Symbol,
+		Signature:   c.Signature.Text,
+		DocComment:  doc,
+		Intent:      c.Pampa.Intent,
+		Description: c.Pampa.Description,
+		Tags:        c.Tags,
+		Variables:   variables,
+		Code:        c.Code,
+	}
+}
+
+// EmbeddingText renders the chunk with the default template, which is
+// generateEnhancedEmbeddingText, without a size budget.
+func (c Chunk) EmbeddingText() string {
+	text, _ := embedtext.Default.Render(c.EmbeddingFields("", ""), embedtext.Budget{})
+	return text
+}
@@ -0,0 +1,208 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/merkletrie"
+)
+
+// ErrNotGitRepository is returned when the project is not inside a git
+// work tree.
+var ErrNotGitRepository = errors.New("not a git repository")
+
+// gitRepo is the repository containing a project root, which may be a
+// subdirectory of the work tree.
+type gitRepo struct {
+	repo *git.Repository
+	// prefix is the project root relative to the work tree, slash-separated,
+	// with a trailing "/" unless empty.
+	prefix string
+}
+
+func openGitRepo(root string) (*gitRepo, error) {
+	repo, err := git.PlainOpenWithOptions(root, &git.PlainOpenOptions{DetectDotGit: true})
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		return nil, fmt.Errorf("open %s: %w", root, ErrNotGitRepository)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open git repository: %w", err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w: %w", root, ErrNotGitRepository, err)
+	}
+	top, err := filepath.EvalSymlinks(worktree.Filesystem.Root())
+	if err != nil {
+		return nil, fmt.Errorf("resolve work tree: %w", err)
+	}
+	project, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, fmt.Errorf("resolve project root: %w", err)
+	}
+	rel, err := filepath.Rel(top, project)
+	if err != nil {
+		return nil, fmt.Errorf("locate project in work tree: %w", err)
+	}
+
+	prefix := ""
+	if rel != "." {
+		prefix = filepath.ToSlash(rel) + "/"
+	}
+	return &gitRepo{repo: repo, prefix: prefix}, nil
+}
+
+// relative maps a repository path to the project root, reporting false
+// for paths outside it.
+func (g *gitRepo) relative(name string) (string, bool) {
+	if !strings.HasPrefix(name, g.prefix) {
+		return "", false
+	}
+	return name[len(g.prefix):], true
+}
+
+func (g *gitRepo) tree(rev string) (*object.Tree, error) {
+	hash, err := g.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", rev, err)
+	}
+	commit, err := g.repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("read commit %s: %w", rev, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("read tree of %s: %w", rev, err)
+	}
+	return tree, nil
+}
+
+// GitOptions configures GitFiles.
+type GitOptions struct {
+	WalkOptions
+	// IncludeUntracked also walks the tree for untracked files that are not
+	// git-ignored, as "git ls-files --cached --others --exclude-standard";
+	// tracked files are passed over without being read again.
+	IncludeUntracked bool
+}
+
+// GitFiles enumerates the indexable files tracked in the git index under
+// root, avoiding a full directory walk. Tracked files are indexed even if
+// a .gitignore matches them, as git does; the excludes, .pampaxignore and
+// file checks of Walk still apply.
+func GitFiles(ctx context.Context, root string, opts GitOptions) (WalkResult, error) {
+	g, err := openGitRepo(root)
+	if err != nil {
+		return WalkResult{}, err
+	}
+	index, err := g.repo.Storer.Index()
+	if err != nil {
+		return WalkResult{}, fmt.Errorf("read git index: %w", err)
+	}
+
+	var tracked []string
+	trackedSet := map[string]bool{}
+	var submodules []SkippedPath
+	for _, entry := range index.Entries {
+		rel, ok := g.relative(entry.Name)
+		// Unmerged paths appear once per conflict stage.
+		if !ok || trackedSet[rel] {
+			continue
+		}
+		trackedSet[rel] = true
+		if entry.Mode == filemode.Submodule {
+			submodules = append(submodules, SkippedPath{Path: rel, Dir: true, Reason: SkipSubmodule})
+			continue
+		}
+		tracked = append(tracked, rel)
+	}
+
+	result, err := FilterPaths(ctx, root, tracked, opts.WalkOptions)
+	if err != nil {
+		return WalkResult{}, err
+	}
+	result.Skipped = append(result.Skipped, submodules...)
+
+	if opts.IncludeUntracked {
+		// Tracked paths were checked above; the walk only reads the others.
+		walked, err := walk(ctx, root, opts.WalkOptions, trackedSet)
+		if err != nil {
+			return WalkResult{}, err
+		}
+		result.Files = append(result.Files, walked.Files...)
+		result.Skipped = append(result.Skipped, walked.Skipped...)
+	}
+
+	slices.SortFunc(result.Files, func(a, b WalkedFile) int { return strings.Compare(a.Path, b.Path) })
+	slices.SortFunc(result.Skipped, func(a, b SkippedPath) int { return strings.Compare(a.Path, b.Path) })
+	return result, nil
+}
+
+// FileChanges lists paths, relative to the project root, that differ
+// between two revisions. A rename is reported as a deletion plus a change.
+type FileChanges struct {
+	Changed []string
+	Deleted []string
+}
+
+// GitChanges compares the trees of two revisions (anything "git
+// rev-parse" accepts, e.g. a branch, tag, HEAD~3 or a commit hash). An
+// empty to means HEAD.
+func GitChanges(ctx context.Context, root, from, to string) (FileChanges, error) {
+	if to == "" {
+		to = "HEAD"
+	}
+
+	g, err := openGitRepo(root)
+	if err != nil {
+		return FileChanges{}, err
+	}
+	fromTree, err := g.tree(from)
+	if err != nil {
+		return FileChanges{}, err
+	}
+	toTree, err := g.tree(to)
+	if err != nil {
+		return FileChanges{}, err
+	}
+
+	changes, err := object.DiffTreeWithOptions(ctx, fromTree, toTree, nil)
+	if err != nil {
+		return FileChanges{}, fmt.Errorf("diff %s..%s: %w", from, to, err)
+	}
+
+	var result FileChanges
+	for _, change := range changes {
+		action, err := change.Action()
+		if err != nil {
+			return FileChanges{}, fmt.Errorf("diff %s..%s: %w", from, to, err)
+		}
+
+		switch action {
+		case merkletrie.Delete:
+			if rel, ok := g.relative(change.From.Name); ok {
+				result.Deleted = append(result.Deleted, rel)
+			}
+		case merkletrie.Insert, merkletrie.Modify:
+			if change.To.TreeEntry.Mode == filemode.Submodule {
+				continue
+			}
+			if rel, ok := g.relative(change.To.Name); ok {
+				result.Changed = append(result.Changed, rel)
+			}
+		}
+	}
+
+	slices.Sort(result.Changed)
+	slices.Sort(result.Deleted)
+	return result, nil
+}
@@ -0,0 +1,152 @@
+package indexer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Grouping defaults, matching chunking/file-grouper.js.
+const (
+	DefaultKeepSeparateMaxNodes = 10
+	DefaultGroupFlushRatio      = 0.9
+)
+
+// containerTypes are the nodes that form a group of their own, so a class
+// is never combined with the functions around it.
+var containerTypes = []string{
+	"class_declaration",
+	"class_definition",
+	"interface_declaration",
+	"module_declaration",
+	"namespace_declaration",
+	"trait_declaration",
+	"enum_declaration",
+}
+
+// GroupingProfile tunes how files with many nodes are combined into fewer,
+// larger chunks. The zero value is the Node behavior.
+type GroupingProfile struct {
+	// Disabled chunks every node on its own regardless of file size.
+	Disabled bool
+	// KeepSeparateMaxNodes is the node count up to which a file's nodes are
+	// chunked separately, keeping per-symbol chunks. 0 means
+	// DefaultKeepSeparateMaxNodes; negative groups every file.
+	KeepSeparateMaxNodes int
+	// FlushRatio is the fraction of the optimal size at which a combined
+	// group is closed. 0 means DefaultGroupFlushRatio.
+	FlushRatio float64
+}
+
+type nodeGroup struct {
+	nodes []*Node
+	size  int
+}
+
+// group ports groupNodesForChunking: consecutive non-container nodes are
+// packed into groups near the optimal size, never above the maximum,
+// while containers and groups already over the optimal size stand alone.
+// A group of one node is chunked normally; larger groups become a single
+// combined chunk.
+func (r *chunkRun) group(nodes []*Node) [][]*Node {
+	keepSeparate := r.grouping.KeepSeparateMaxNodes
+	if keepSeparate == 0 {
+		keepSeparate = DefaultKeepSeparateMaxNodes
+	}
+	flushRatio := r.grouping.FlushRatio
+	if flushRatio == 0 {
+		flushRatio = DefaultGroupFlushRatio
+	}
+
+	if r.grouping.Disabled || len(nodes) <= keepSeparate {
+		out := make([][]*Node, len(nodes))
+		for i, node := range nodes {
+			out[i] = []*Node{node}
+		}
+		return out
+	}
+
+	// Semantic groups: each container alone, the nodes between them together.
+	var sections []nodeGroup
+	var current nodeGroup
+	for _, node := range nodes {
+		size := r.size(node.Text(r.source), false)
+		if slices.Contains(containerTypes, node.Type) {
+			if len(current.nodes) > 0 {
+				sections = append(sections, current)
+			}
+			sections = append(sections, nodeGroup{nodes: []*Node{node}, size: size})
+			current = nodeGroup{}
+			continue
+		}
+		current.nodes = append(current.nodes, node)
+		current.size += size
+	}
+	if len(current.nodes) > 0 {
+		sections = append(sections, current)
+	}
+
+	var out [][]*Node
+	var combined nodeGroup
+	flush := func() {
+		if len(combined.nodes) > 0 {
+			out = append(out, combined.nodes)
+		}
+		combined = nodeGroup{}
+	}
+	for _, section := range sections {
+		switch {
+		case section.size > r.limits.Optimal:
+			flush()
+			out = append(out, section.nodes)
+		case combined.size+section.size > r.limits.Max:
+			flush()
+			combined = nodeGroup{nodes: slices.Clone(section.nodes), size: section.size}
+		default:
+			combined.nodes = append(combined.nodes, section.nodes...)
+			combined.size += section.size
+			if float64(combined.size) >= float64(r.limits.Optimal)*flushRatio {
+				flush()
+			}
+		}
+	}
+	flush()
+	return out
+}
+
+// combine emits the nodes of a group as one chunk, their code joined by
+// blank lines, ported from createCombinedChunk.
+func (r *chunkRun) combine(nodes []*Node) {
+	r.stats.TotalNodes += len(nodes)
+	r.stats.FileGrouped++
+	r.stats.FunctionsGrouped += len(nodes)
+
+	codes := make([]string, len(nodes))
+	symbols := make([]string, len(nodes))
+	var calls []Call
+	var declares []Declaration
+	for i, node := range nodes {
+		codes[i] = node.Text(r.source)
+		symbols[i] = extractSymbolName(node, r.source)
+		for _, call := range ExtractCalls(node, r.source) {
+			if !slices.Contains(calls, call) {
+				calls = append(calls, call)
+			}
+		}
+		if declared, _ := ExtractDeclaration(node, r.source, r.rule.Lang); declared.Name != "" {
+			declares = append(declares, declared)
+		}
+	}
+
+	first, last := nodes[0], nodes[len(nodes)-1]
+	pseudo := &Node{
+		Type:      fmt.Sprintf("%s_group_%d", first.Type, len(nodes)),
+		StartByte: first.StartByte,
+		EndByte:   last.EndByte,
+	}
+	chunk := r.newChunk(pseudo, strings.Join(codes, "\n\n"), fmt.Sprintf("group_%dfuncs", len(nodes)), nil)
+	chunk.GroupSymbols = symbols
+	chunk.Calls = calls
+	chunk.Declares = declares
+	r.chunks = append(r.chunks, chunk)
+}
@@ -0,0 +1,161 @@
+package indexer
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreFile holds the rules of one .gitignore-syntax file. base is the
+// slash-separated directory the file lives in, relative to the project root
+// ("" for the root); rules only apply below it. Files above the project
+// root set outer instead: the path from their directory down to the root,
+// with a trailing "/".
+type ignoreFile struct {
+	base   string
+	outer  string
+	reason SkipReason
+	rules  []ignoreRule
+}
+
+// parseIgnore compiles gitignore syntax: "#" comments, "!" negation, a
+// trailing "/" for directories only, patterns containing a "/" anchored to
+// base, and "*", "?", "[...]" and "**" wildcards.
+func parseIgnore(base string, reason SkipReason, data []byte) *ignoreFile {
+	file := &ignoreFile{base: base, reason: reason}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreLine(scanner.Text()); ok {
+			file.rules = append(file.rules, rule)
+		}
+	}
+	return file
+}
+
+func parseIgnoreLine(line string) (ignoreRule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	// Trailing spaces are ignored unless escaped.
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	var re strings.Builder
+	re.WriteString("^")
+	if !anchored {
+		re.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case strings.HasPrefix(line[i:], "**/") && (i == 0 || line[i-1] == '/'):
+			re.WriteString("(?:.*/)?")
+			i += 2
+		case line[i:] == "**" && (i == 0 || line[i-1] == '/'):
+			re.WriteString(".*")
+			i++
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(line[i+1:], ']')
+			if end < 0 {
+				re.WriteString(`\[`)
+				continue
+			}
+			class := line[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(line):
+			i++
+			re.WriteString(regexp.QuoteMeta(line[i : i+1]))
+		default:
+			re.WriteString(regexp.QuoteMeta(line[i : i+1]))
+		}
+	}
+	re.WriteString("$")
+
+	compiled, err := regexp.Compile(re.String())
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.re = compiled
+	return rule, true
+}
+
+// match reports whether the file's rules decide rel (relative to the
+// project root) and, if so, whether it is ignored. The last matching rule
+// wins, as in git.
+func (f *ignoreFile) match(rel string, isDir bool) (ignored, decided bool) {
+	if f.base != "" {
+		if !strings.HasPrefix(rel, f.base+"/") {
+			return false, false
+		}
+		rel = rel[len(f.base)+1:]
+	}
+	rel = f.outer + rel
+
+	for i := len(f.rules) - 1; i >= 0; i-- {
+		rule := f.rules[i]
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(rel) {
+			return !rule.negate, true
+		}
+	}
+	return false, false
+}
+
+// ignoreChain is the set of ignore files in effect for one directory,
+// outermost first. Deeper files take precedence over their parents.
+type ignoreChain []*ignoreFile
+
+func (c ignoreChain) match(rel string, isDir bool) (ignored bool, reason SkipReason) {
+	for i := len(c) - 1; i >= 0; i-- {
+		if ignored, decided := c[i].match(rel, isDir); decided {
+			return ignored, c[i].reason
+		}
+	}
+	return false, ""
+}
+
+// with returns a new chain extended by file, leaving c untouched so sibling
+// directories do not see each other's rules.
+func (c ignoreChain) with(file *ignoreFile) ignoreChain {
+	if file == nil || len(file.rules) == 0 {
+		return c
+	}
+	return append(c[:len(c):len(c)], file)
+}
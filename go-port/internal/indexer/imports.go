@@ -0,0 +1,165 @@
+package indexer
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Import is a name an import statement binds in a file.
+type Import struct {
+	// Path is the module as written: a Go import path, a JavaScript
+	// specifier, or a Python dotted module with its leading dots.
+	Path string
+	// Name is the imported member: "" for the whole module, "*" for all of
+	// its names and "default" for a JavaScript default import.
+	Name string
+	// Alias is the local name, "." for a Go dot import. It is empty for Go
+	// imports without an alias, which go by their package name.
+	Alias string
+}
+
+var (
+	goImportBlock  = regexp.MustCompile(`(?m)^import\s*\(([^)]*)\)`)
+	goImportSingle = regexp.MustCompile(`(?m)^import\s+([\w.]+\s+)?["` + "`" + `]([^"` + "`" + `]+)["` + "`" + `]`)
+	goImportSpec   = regexp.MustCompile(`^([\w.]+\s+)?["` + "`" + `]([^"` + "`" + `]+)["` + "`" + `]`)
+	goPackage      = regexp.MustCompile(`(?m)^package\s+(\w+)`)
+
+	scriptImport  = regexp.MustCompile(`(?m)^\s*import\s+(?:type\s+)?([^'";]+?)\s+from\s+['"]([^'"]+)['"]`)
+	scriptRequire = regexp.MustCompile(`(?:const|let|var)\s+(\w+|\{[^}]*\})\s*=\s*require\(\s*['"]([^'"]+)['"]\s*\)`)
+
+	pythonImport     = regexp.MustCompile(`(?m)^[ \t]*import[ \t]+([\w. \t,]+)`)
+	pythonFromImport = regexp.MustCompile(`(?m)^[ \t]*from[ \t]+(\.*[\w.]*)[ \t]+import[ \t]+(\([^)]*\)|[^\n#]+)`)
+	pythonComment    = regexp.MustCompile(`#[^\n]*`)
+)
+
+// ExtractImports returns the imports of a Go, JavaScript, TypeScript or
+// Python source, in source order, and nil for other languages.
+func ExtractImports(source []byte, lang string) []Import {
+	text := string(source)
+	switch lang {
+	case "go":
+		return goImports(text)
+	case "javascript", "typescript", "tsx":
+		return scriptImports(text)
+	case "python":
+		return pythonImports(text)
+	}
+	return nil
+}
+
+func goImports(text string) []Import {
+	var imports []Import
+	add := func(alias, path string) {
+		if alias = strings.TrimSpace(alias); alias != "_" {
+			imports = append(imports, Import{Path: path, Alias: alias})
+		}
+	}
+	for _, block := range goImportBlock.FindAllStringSubmatch(text, -1) {
+		for _, line := range strings.Split(block[1], "\n") {
+			line, _, _ = strings.Cut(strings.TrimSpace(line), "//")
+			if match := goImportSpec.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+				add(match[1], match[2])
+			}
+		}
+	}
+	for _, match := range goImportSingle.FindAllStringSubmatch(text, -1) {
+		add(match[1], match[2])
+	}
+	return imports
+}
+
+// GoPackageName returns the package clause of a Go source.
+func GoPackageName(source []byte) string {
+	if match := goPackage.FindSubmatch(source); match != nil {
+		return string(match[1])
+	}
+	return ""
+}
+
+func scriptImports(text string) []Import {
+	var imports []Import
+	for _, match := range scriptImport.FindAllStringSubmatch(text, -1) {
+		clause, path := strings.TrimSpace(match[1]), match[2]
+		if open := strings.IndexByte(clause, '{'); open != -1 {
+			imports = append(imports, namedScriptImports(path, clause[open:], " as ")...)
+			clause = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(clause[:open]), ","))
+		}
+		for _, part := range strings.Split(clause, ",") {
+			part = strings.TrimSpace(part)
+			if namespace, ok := strings.CutPrefix(part, "*"); ok {
+				if _, alias, ok := strings.Cut(namespace, " as "); ok {
+					imports = append(imports, Import{Path: path, Alias: strings.TrimSpace(alias)})
+				}
+			} else if part != "" {
+				imports = append(imports, Import{Path: path, Name: "default", Alias: part})
+			}
+		}
+	}
+	for _, match := range scriptRequire.FindAllStringSubmatch(text, -1) {
+		if strings.HasPrefix(match[1], "{") {
+			imports = append(imports, namedScriptImports(match[2], match[1], ":")...)
+		} else {
+			imports = append(imports, Import{Path: match[2], Alias: match[1]})
+		}
+	}
+	return imports
+}
+
+// namedScriptImports reads a "{ a, b as c }" list, whose renames use sep.
+func namedScriptImports(path, list, sep string) []Import {
+	var imports []Import
+	list = strings.Trim(strings.TrimSpace(list), "{}")
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(part), "type "))
+		if part == "" {
+			continue
+		}
+		name, alias, ok := strings.Cut(part, sep)
+		if !ok {
+			alias = name
+		}
+		imports = append(imports, Import{Path: path, Name: strings.TrimSpace(name), Alias: strings.TrimSpace(alias)})
+	}
+	return imports
+}
+
+func pythonImports(text string) []Import {
+	type located struct {
+		at int
+		Import
+	}
+	var found []located
+	for _, loc := range pythonImport.FindAllStringSubmatchIndex(text, -1) {
+		for _, part := range strings.Split(text[loc[2]:loc[3]], ",") {
+			path, alias, ok := strings.Cut(strings.TrimSpace(part), " as ")
+			if path = strings.TrimSpace(path); path == "" {
+				continue
+			}
+			if !ok {
+				alias = path
+			}
+			found = append(found, located{loc[0], Import{Path: path, Alias: strings.TrimSpace(alias)}})
+		}
+	}
+	for _, loc := range pythonFromImport.FindAllStringSubmatchIndex(text, -1) {
+		path, names := text[loc[2]:loc[3]], strings.Trim(text[loc[4]:loc[5]], "()")
+		for _, part := range strings.Split(pythonComment.ReplaceAllString(names, ""), ",") {
+			name, alias, ok := strings.Cut(strings.TrimSpace(part), " as ")
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			if !ok {
+				alias = name
+			}
+			found = append(found, located{loc[0], Import{Path: path, Name: name, Alias: strings.TrimSpace(alias)}})
+		}
+	}
+
+	slices.SortStableFunc(found, func(a, b located) int { return a.at - b.at })
+	imports := make([]Import, len(found))
+	for i, f := range found {
+		imports[i] = f.Import
+	}
+	return imports
+}
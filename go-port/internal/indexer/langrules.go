@@ -0,0 +1,263 @@
+package indexer
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// LangRule mirrors an entry of LANG_RULES in service.js: which syntax nodes
+// become chunks, which children a too-large node is split into, which nodes
+// hold variables, and where doc comments are found.
+type LangRule struct {
+	Lang             string
+	NodeTypes        []string
+	SubdivisionTypes map[string][]string
+	VariableTypes    []string
+	CommentPattern   *regexp.Regexp
+}
+
+// IsChunkNode reports whether nodeType is one of the rule's chunk node types.
+func (r LangRule) IsChunkNode(nodeType string) bool {
+	return slices.Contains(r.NodeTypes, nodeType)
+}
+
+var (
+	docBlockComment  = regexp.MustCompile(`/\*\*[\s\S]*?\*/`)
+	blockComment     = regexp.MustCompile(`/\*[\s\S]*?\*/`)
+	hashComment      = regexp.MustCompile(`(?m)#.*$`)
+	dashComment      = regexp.MustCompile(`(?m)--.*$`)
+	htmlComment      = regexp.MustCompile(`<!--[\s\S]*?-->`)
+	ocamlComment     = regexp.MustCompile(`\(\*[\s\S]*?\*\)`)
+	pythonDocstring  = regexp.MustCompile(`"""[\s\S]*?"""|'''[\s\S]*?'''`)
+	rustDocComment   = regexp.MustCompile(`///.*|/\*\*[\s\S]*?\*/`)
+	jsVariableTypes  = []string{"const_declaration", "let_declaration", "variable_declaration"}
+	cppNodeTypes     = []string{"function_definition", "class_specifier", "struct_specifier", "namespace_definition"}
+	cNodeTypes       = []string{"function_definition", "struct_specifier", "declaration"}
+	bashNodeTypes    = []string{"function_definition", "command"}
+	markdownNodes    = []string{"atx_heading", "setext_heading", "section", "fenced_code_block", "list_item"}
+	elixirNodeTypes  = []string{"call", "anonymous_function"}
+	htmlNodeTypes    = []string{"element", "start_tag", "script_element", "style_element"}
+	tsxNodeTypes     = []string{"function_declaration", "class_declaration", "export_statement", "lexical_declaration", "expression_statement"}
+	scriptNodeTypes  = []string{"function_declaration", "method_definition", "class_declaration", "export_statement", "lexical_declaration", "expression_statement"}
+	cppSubdivisions  = map[string][]string{"class_specifier": {"function_definition", "field_declaration"}, "struct_specifier": {"function_definition", "field_declaration"}, "namespace_definition": {"function_definition", "class_specifier", "struct_specifier"}}
+	scriptSubdivides = map[string][]string{
+		"class_declaration":    {"method_definition", "field_definition"},
+		"function_declaration": {"function_declaration", "if_statement", "try_statement"},
+		"method_definition":    {"function_declaration", "if_statement", "try_statement"},
+		"export_statement":     {"object", "function_declaration"},
+		"expression_statement": {"call_expression", "function"},
+	}
+	tsxSubdivides = map[string][]string{
+		"class_declaration":    {"method_definition", "field_definition"},
+		"export_statement":     {"object", "function_declaration"},
+		"expression_statement": {"call_expression", "function"},
+	}
+	bashSubdivides     = map[string][]string{"function_definition": {"command", "if_statement", "for_statement", "while_statement"}}
+	elixirSubdivides   = map[string][]string{"call": {"call", "anonymous_function"}}
+	htmlSubdivides     = map[string][]string{"element": {"element"}}
+	markdownSubdivides = map[string][]string{
+		"section":           {"atx_heading", "setext_heading", "paragraph", "fenced_code_block", "list", "block_quote"},
+		"list":              {"list_item"},
+		"fenced_code_block": {},
+	}
+)
+
+// langRules is keyed by lower-case file extension, like LANG_RULES.
+var langRules = map[string]LangRule{
+	".php": {
+		Lang:      "php",
+		NodeTypes: []string{"function_definition", "method_declaration"},
+		SubdivisionTypes: map[string][]string{
+			"class_declaration":   {"method_declaration", "function_definition"},
+			"function_definition": {"function_definition", "if_statement", "try_statement"},
+			"method_declaration":  {"function_definition", "if_statement", "try_statement"},
+		},
+		VariableTypes:  []string{"const_declaration", "assignment_expression"},
+		CommentPattern: docBlockComment,
+	},
+	".py": {
+		Lang:      "python",
+		NodeTypes: []string{"function_definition", "class_definition"},
+		SubdivisionTypes: map[string][]string{
+			"class_definition":    {"function_definition"},
+			"function_definition": {"function_definition", "if_statement", "try_statement", "with_statement"},
+		},
+		VariableTypes:  []string{"assignment", "expression_statement"},
+		CommentPattern: pythonDocstring,
+	},
+	".js":  {Lang: "javascript", NodeTypes: scriptNodeTypes, SubdivisionTypes: scriptSubdivides, VariableTypes: jsVariableTypes, CommentPattern: docBlockComment},
+	".jsx": {Lang: "tsx", NodeTypes: tsxNodeTypes, SubdivisionTypes: tsxSubdivides, VariableTypes: jsVariableTypes, CommentPattern: docBlockComment},
+	".ts":  {Lang: "typescript", NodeTypes: scriptNodeTypes, SubdivisionTypes: scriptSubdivides, VariableTypes: jsVariableTypes, CommentPattern: docBlockComment},
+	".tsx": {Lang: "tsx", NodeTypes: tsxNodeTypes, SubdivisionTypes: tsxSubdivides, VariableTypes: jsVariableTypes, CommentPattern: docBlockComment},
+	".go": {
+		Lang:           "go",
+		NodeTypes:      []string{"function_declaration", "method_declaration"},
+		VariableTypes:  []string{"const_declaration", "var_declaration"},
+		CommentPattern: blockComment,
+	},
+	".java": {
+		Lang:           "java",
+		NodeTypes:      []string{"method_declaration", "class_declaration"},
+		VariableTypes:  []string{"variable_declaration", "field_declaration"},
+		CommentPattern: docBlockComment,
+	},
+	".cs": {
+		Lang:      "csharp",
+		NodeTypes: []string{"method_declaration", "class_declaration", "struct_declaration", "interface_declaration"},
+		SubdivisionTypes: map[string][]string{
+			"class_declaration":     {"method_declaration", "property_declaration", "field_declaration"},
+			"struct_declaration":    {"method_declaration", "property_declaration", "field_declaration"},
+			"interface_declaration": {"method_declaration", "property_declaration"},
+			"method_declaration":    {"if_statement", "try_statement", "foreach_statement"},
+		},
+		VariableTypes:  []string{"variable_declaration", "field_declaration", "property_declaration"},
+		CommentPattern: docBlockComment,
+	},
+	".rs": {
+		Lang:      "rust",
+		NodeTypes: []string{"function_item", "impl_item", "struct_item", "enum_item", "trait_item", "mod_item"},
+		SubdivisionTypes: map[string][]string{
+			"impl_item":  {"function_item"},
+			"mod_item":   {"function_item", "struct_item", "enum_item", "trait_item"},
+			"trait_item": {"function_signature"},
+		},
+		VariableTypes:  []string{"let_declaration", "const_item", "static_item"},
+		CommentPattern: rustDocComment,
+	},
+	".rb": {
+		Lang:      "ruby",
+		NodeTypes: []string{"method", "class", "module", "singleton_method"},
+		SubdivisionTypes: map[string][]string{
+			"class":  {"method", "singleton_method"},
+			"module": {"method", "singleton_method"},
+		},
+		VariableTypes:  []string{"assignment", "instance_variable", "class_variable"},
+		CommentPattern: hashComment,
+	},
+	".cpp": {Lang: "cpp", NodeTypes: cppNodeTypes, SubdivisionTypes: cppSubdivisions, VariableTypes: []string{"declaration", "field_declaration"}, CommentPattern: blockComment},
+	".hpp": {Lang: "cpp", NodeTypes: cppNodeTypes, SubdivisionTypes: cppSubdivisions, VariableTypes: []string{"declaration", "field_declaration"}, CommentPattern: blockComment},
+	".cc":  {Lang: "cpp", NodeTypes: cppNodeTypes, SubdivisionTypes: cppSubdivisions, VariableTypes: []string{"declaration", "field_declaration"}, CommentPattern: blockComment},
+	".c":   {Lang: "c", NodeTypes: cNodeTypes, SubdivisionTypes: map[string][]string{"struct_specifier": {"field_declaration"}}, VariableTypes: []string{"declaration"}, CommentPattern: blockComment},
+	".h":   {Lang: "c", NodeTypes: cNodeTypes, SubdivisionTypes: map[string][]string{"struct_specifier": {"field_declaration"}}, VariableTypes: []string{"declaration"}, CommentPattern: blockComment},
+	".scala": {
+		Lang:      "scala",
+		NodeTypes: []string{"function_definition", "class_definition", "object_definition", "trait_definition"},
+		SubdivisionTypes: map[string][]string{
+			"class_definition":  {"function_definition", "val_definition", "var_declaration"},
+			"object_definition": {"function_definition", "val_definition"},
+			"trait_definition":  {"function_definition", "function_declaration"},
+		},
+		VariableTypes:  []string{"val_definition", "var_declaration"},
+		CommentPattern: docBlockComment,
+	},
+	".swift": {
+		Lang:      "swift",
+		NodeTypes: []string{"function_declaration", "class_declaration", "struct_declaration", "protocol_declaration"},
+		SubdivisionTypes: map[string][]string{
+			"class_declaration":    {"function_declaration", "property_declaration"},
+			"struct_declaration":   {"function_declaration", "property_declaration"},
+			"protocol_declaration": {"function_declaration"},
+		},
+		VariableTypes:  []string{"property_declaration", "variable_declaration"},
+		CommentPattern: docBlockComment,
+	},
+	".sh":   {Lang: "bash", NodeTypes: bashNodeTypes, SubdivisionTypes: bashSubdivides, VariableTypes: []string{"variable_assignment"}, CommentPattern: hashComment},
+	".bash": {Lang: "bash", NodeTypes: bashNodeTypes, SubdivisionTypes: bashSubdivides, VariableTypes: []string{"variable_assignment"}, CommentPattern: hashComment},
+	".kt": {
+		Lang:      "kotlin",
+		NodeTypes: []string{"function_declaration", "property_declaration", "class_declaration", "object_declaration"},
+		SubdivisionTypes: map[string][]string{
+			"class_declaration":    {"function_declaration", "property_declaration"},
+			"object_declaration":   {"function_declaration", "property_declaration"},
+			"function_declaration": {"if_expression", "when_expression", "try_expression"},
+		},
+		VariableTypes:  []string{"property_declaration", "variable_declaration"},
+		CommentPattern: docBlockComment,
+	},
+	".lua": {
+		Lang:             "lua",
+		NodeTypes:        []string{"function_declaration", "function_definition", "function_call", "table_constructor"},
+		SubdivisionTypes: map[string][]string{"function_definition": {"function_definition", "if_statement", "for_statement"}},
+		VariableTypes:    []string{"variable_declaration", "assignment_statement"},
+		CommentPattern:   dashComment,
+	},
+	".html": {Lang: "html", NodeTypes: htmlNodeTypes, SubdivisionTypes: htmlSubdivides, VariableTypes: []string{}, CommentPattern: htmlComment},
+	".htm":  {Lang: "html", NodeTypes: htmlNodeTypes, SubdivisionTypes: htmlSubdivides, VariableTypes: []string{}, CommentPattern: htmlComment},
+	".css": {
+		Lang:             "css",
+		NodeTypes:        []string{"rule_set", "declaration", "selector"},
+		SubdivisionTypes: map[string][]string{"rule_set": {"declaration"}},
+		VariableTypes:    []string{},
+		CommentPattern:   blockComment,
+	},
+	".json": {
+		Lang:             "json",
+		NodeTypes:        []string{"object", "array", "pair"},
+		SubdivisionTypes: map[string][]string{"object": {"pair"}, "array": {"object", "array"}},
+		VariableTypes:    []string{},
+	},
+	".ml": {
+		Lang:      "ocaml",
+		NodeTypes: []string{"value_definition", "type_definition", "module_definition", "let_binding"},
+		SubdivisionTypes: map[string][]string{
+			"module_definition": {"value_definition", "type_definition"},
+			"value_definition":  {"let_binding"},
+		},
+		VariableTypes:  []string{"let_binding", "value_definition"},
+		CommentPattern: ocamlComment,
+	},
+	".mli": {
+		Lang:             "ocaml",
+		NodeTypes:        []string{"value_specification", "type_definition", "module_definition"},
+		SubdivisionTypes: map[string][]string{"module_definition": {"value_specification", "type_definition"}},
+		VariableTypes:    []string{"value_specification"},
+		CommentPattern:   ocamlComment,
+	},
+	".hs": {
+		Lang:      "haskell",
+		NodeTypes: []string{"function", "type_signature", "data_declaration", "class_declaration"},
+		SubdivisionTypes: map[string][]string{
+			"class_declaration": {"function", "type_signature"},
+			"data_declaration":  {"constructor"},
+		},
+		VariableTypes:  []string{"signature", "bind"},
+		CommentPattern: dashComment,
+	},
+	".ex":       {Lang: "elixir", NodeTypes: elixirNodeTypes, SubdivisionTypes: elixirSubdivides, VariableTypes: []string{"identifier"}, CommentPattern: hashComment},
+	".exs":      {Lang: "elixir", NodeTypes: elixirNodeTypes, SubdivisionTypes: elixirSubdivides, VariableTypes: []string{"identifier"}, CommentPattern: hashComment},
+	".md":       {Lang: "markdown", NodeTypes: markdownNodes, SubdivisionTypes: markdownSubdivides, VariableTypes: []string{}, CommentPattern: htmlComment},
+	".markdown": {Lang: "markdown", NodeTypes: markdownNodes, SubdivisionTypes: markdownSubdivides, VariableTypes: []string{}, CommentPattern: htmlComment},
+}
+
+// RuleForExtension returns the rule for a file extension such as ".go".
+func RuleForExtension(ext string) (LangRule, bool) {
+	rule, ok := langRules[strings.ToLower(ext)]
+	return rule, ok
+}
+
+// RuleForPath returns the rule for path based on its extension.
+func RuleForPath(path string) (LangRule, bool) {
+	return RuleForExtension(filepath.Ext(path))
+}
+
+// RuleForLang returns the rule for a language name such as "python".
+func RuleForLang(lang string) (LangRule, bool) {
+	for _, ext := range SupportedExtensions() {
+		if rule := langRules[ext]; rule.Lang == lang {
+			return rule, true
+		}
+	}
+	return LangRule{}, false
+}
+
+// SupportedExtensions ports getSupportedLanguageExtensions, sorted.
+func SupportedExtensions() []string {
+	exts := make([]string, 0, len(langRules))
+	for ext := range langRules {
+		exts = append(exts, ext)
+	}
+	slices.Sort(exts)
+	return exts
+}
@@ -0,0 +1,517 @@
+package indexer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokIdent tokenKind = iota
+	tokNumber
+	tokString
+	tokComment
+	tokPunct
+	tokNewline
+)
+
+type token struct {
+	kind  tokenKind
+	start int
+	end   int
+	text  string
+}
+
+func (t token) is(text string) bool {
+	return (t.kind == tokIdent || t.kind == tokPunct) && t.text == text
+}
+
+// lexSpec describes the lexical surface the structural parsers need: enough
+// to skip comments and string contents reliably so brackets and keywords are
+// only seen where they are code.
+type lexSpec struct {
+	lineComments  []string
+	blockComments [][2]string
+	quotes        string
+	tripleQuotes  bool
+	// templateQuote opens strings with ${...} interpolation (JS backticks).
+	templateQuote byte
+	// identExtra lists non-alphanumeric bytes allowed inside identifiers.
+	identExtra string
+	// identSuffix lists bytes allowed once at the end of an identifier.
+	identSuffix   string
+	regexLiterals bool
+	// lifetimes lexes Rust 'a lifetimes instead of char literals.
+	lifetimes bool
+	// wordComments only starts line comments at the beginning of a word (shell).
+	wordComments bool
+	// phpTags skips text outside <?php ... ?> blocks.
+	phpTags bool
+	// hashAttributes keeps "#[" from starting a "#" line comment (PHP 8).
+	hashAttributes bool
+	// longBrackets lexes Lua [[...]] / [==[...]==] strings and comments.
+	longBrackets bool
+	heredocs     heredocStyle
+}
+
+type heredocStyle int
+
+const (
+	heredocNone heredocStyle = iota
+	// heredocRuby accepts <<~ID, <<-ID and <<ID with an upper-case or quoted ID.
+	heredocRuby
+	// heredocShell accepts <<WORD and <<-WORD, optionally spaced or quoted.
+	heredocShell
+)
+
+type heredoc struct {
+	word     string
+	indented bool
+}
+
+type lexer struct {
+	spec   *lexSpec
+	src    []byte
+	pos    int
+	tokens []token
+	// heredocs are skipped once the line that opened them ends.
+	heredocs []heredoc
+}
+
+func lex(src []byte, spec *lexSpec) []token {
+	l := &lexer{spec: spec, src: src}
+	if spec.phpTags {
+		l.skipPHPText()
+	}
+
+	for l.pos < len(l.src) {
+		l.next()
+	}
+	return l.tokens
+}
+
+func (l *lexer) emit(kind tokenKind, start int) {
+	l.tokens = append(l.tokens, token{kind: kind, start: start, end: l.pos, text: string(l.src[start:l.pos])})
+}
+
+func (l *lexer) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(string(l.src[l.pos:min(len(l.src), l.pos+len(prefix))]), prefix)
+}
+
+func (l *lexer) next() {
+	start := l.pos
+	c := l.src[l.pos]
+
+	switch {
+	case c == '\n':
+		l.pos++
+		if len(l.heredocs) > 0 {
+			l.skipHeredocBodies(start)
+			return
+		}
+		l.emit(tokNewline, start)
+		return
+	case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+		l.pos++
+		return
+	}
+
+	if l.spec.phpTags && l.hasPrefix("?>") {
+		l.pos += 2
+		l.skipPHPText()
+		return
+	}
+
+	for _, pair := range l.spec.blockComments {
+		if l.hasPrefix(pair[0]) {
+			l.skipBlockComment(pair)
+			l.emit(tokComment, start)
+			return
+		}
+	}
+
+	for _, prefix := range l.spec.lineComments {
+		if l.hasPrefix(prefix) && (!l.spec.wordComments || start == 0 || isSpace(l.src[start-1])) && !(l.spec.hashAttributes && l.hasPrefix("#[")) {
+			if l.spec.longBrackets {
+				l.pos += len(prefix)
+				if level := l.longBracketLevel(); level >= 0 {
+					l.skipLongBracket(level)
+					l.emit(tokComment, start)
+					return
+				}
+			}
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+			l.emit(tokComment, start)
+			return
+		}
+	}
+
+	switch {
+	case l.spec.heredocs != heredocNone && l.hasPrefix("<<") && l.heredocStart():
+		l.emit(tokString, start)
+	case l.spec.longBrackets && c == '[' && l.longBracketLevel() >= 0:
+		l.skipLongBracket(l.longBracketLevel())
+		l.emit(tokString, start)
+	case l.spec.tripleQuotes && (l.hasPrefix(`"""`) || l.hasPrefix(`'''`)):
+		l.skipTripleString()
+		l.emit(tokString, start)
+	case l.spec.templateQuote != 0 && c == l.spec.templateQuote:
+		l.skipTemplate()
+		l.emit(tokString, start)
+	case c == '\'' && l.spec.lifetimes && l.isLifetime():
+		l.pos++
+		l.skipIdent()
+		l.emit(tokIdent, start)
+	case strings.IndexByte(l.spec.quotes, c) >= 0:
+		l.skipString(c)
+		l.emit(tokString, start)
+	case c == '/' && l.spec.regexLiterals && l.regexAllowed():
+		l.skipRegex()
+		l.emit(tokString, start)
+	case isDigit(c):
+		l.skipNumber()
+		l.emit(tokNumber, start)
+	case l.isIdentStart():
+		l.skipIdent()
+		l.emit(tokIdent, start)
+	default:
+		_, size := utf8.DecodeRune(l.src[l.pos:])
+		l.pos += size
+		l.emit(tokPunct, start)
+	}
+}
+
+func (l *lexer) skipPHPText() {
+	for l.pos < len(l.src) {
+		if l.hasPrefix("<?php") {
+			l.pos += len("<?php")
+			return
+		}
+		if l.hasPrefix("<?=") || l.hasPrefix("<?") {
+			l.pos += 2
+			return
+		}
+		l.pos++
+	}
+}
+
+// longBracketLevel returns the number of "=" in a long bracket opening at
+// pos ("[[" is level 0), or -1 when there is none.
+func (l *lexer) longBracketLevel() int {
+	if l.pos >= len(l.src) || l.src[l.pos] != '[' {
+		return -1
+	}
+
+	i := l.pos + 1
+	for i < len(l.src) && l.src[i] == '=' {
+		i++
+	}
+	if i < len(l.src) && l.src[i] == '[' {
+		return i - l.pos - 1
+	}
+	return -1
+}
+
+func (l *lexer) skipLongBracket(level int) {
+	closing := "]" + strings.Repeat("=", level) + "]"
+	l.pos += level + 2
+	for l.pos < len(l.src) && !l.hasPrefix(closing) {
+		l.pos++
+	}
+	l.pos = min(len(l.src), l.pos+len(closing))
+}
+
+// heredocStart consumes a heredoc marker such as <<~EOS or <<-'EOF' and
+// queues its body to be skipped at the end of the line.
+func (l *lexer) heredocStart() bool {
+	i := l.pos + 2
+	if i < len(l.src) && l.src[i] == '<' {
+		return false
+	}
+
+	indented := false
+	if i < len(l.src) && (l.src[i] == '-' || l.src[i] == '~' && l.spec.heredocs == heredocRuby) {
+		indented = true
+		i++
+	}
+	if l.spec.heredocs == heredocShell {
+		for i < len(l.src) && (l.src[i] == ' ' || l.src[i] == '\t') {
+			i++
+		}
+	}
+	if i >= len(l.src) {
+		return false
+	}
+
+	quote := l.src[i]
+	quoted := quote == '\'' || quote == '"'
+	if quoted {
+		i++
+	}
+	wordStart := i
+	for i < len(l.src) && isIdentByte(l.src[i]) {
+		i++
+	}
+	word := string(l.src[wordStart:i])
+	if word == "" {
+		return false
+	}
+	if quoted {
+		if i >= len(l.src) || l.src[i] != quote {
+			return false
+		}
+		i++
+	} else if l.spec.heredocs == heredocRuby && !indented && (word[0] < 'A' || word[0] > 'Z') {
+		// a << b is a shift or append, not a heredoc.
+		return false
+	}
+
+	l.pos = i
+	l.heredocs = append(l.heredocs, heredoc{word: word, indented: indented})
+	return true
+}
+
+// skipHeredocBodies lexes the pending heredoc bodies, together with the
+// newline that precedes them, as one string token so the statement that
+// opened them continues to the terminator line.
+func (l *lexer) skipHeredocBodies(start int) {
+	for _, doc := range l.heredocs {
+		for l.pos < len(l.src) {
+			end := l.pos
+			for end < len(l.src) && l.src[end] != '\n' {
+				end++
+			}
+			line := string(l.src[l.pos:end])
+			if doc.indented {
+				line = strings.TrimLeft(line, " \t")
+			}
+			l.pos = min(end+1, len(l.src))
+			if strings.TrimRight(line, "\r") == doc.word {
+				break
+			}
+		}
+	}
+	l.heredocs = nil
+
+	// Leave the terminator's newline to end the statement.
+	if l.pos > start+1 && l.src[l.pos-1] == '\n' {
+		l.pos--
+	}
+	l.emit(tokString, start)
+}
+
+func (l *lexer) skipBlockComment(pair [2]string) {
+	l.pos += len(pair[0])
+	for l.pos < len(l.src) && !l.hasPrefix(pair[1]) {
+		l.pos++
+	}
+	l.pos = min(len(l.src), l.pos+len(pair[1]))
+}
+
+func (l *lexer) skipString(quote byte) {
+	l.pos++
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\\':
+			l.pos += 2
+			continue
+		case c == quote:
+			l.pos++
+			return
+		case c == '\n' && quote != '"' && quote != '`':
+			// Unterminated char/quote literal: stop at the line end so a stray
+			// apostrophe (e.g. in shell or comments) cannot swallow the file.
+			return
+		}
+		l.pos++
+	}
+	l.pos = min(l.pos, len(l.src))
+}
+
+func (l *lexer) skipTripleString() {
+	delim := string(l.src[l.pos : l.pos+3])
+	l.pos += 3
+	for l.pos < len(l.src) {
+		if l.src[l.pos] == '\\' {
+			l.pos += 2
+			continue
+		}
+		if l.hasPrefix(delim) {
+			l.pos += 3
+			return
+		}
+		l.pos++
+	}
+	l.pos = min(l.pos, len(l.src))
+}
+
+func (l *lexer) skipTemplate() {
+	quote := l.src[l.pos]
+	l.pos++
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\\':
+			l.pos += 2
+			continue
+		case c == quote:
+			l.pos++
+			return
+		case c == '$' && l.pos+1 < len(l.src) && l.src[l.pos+1] == '{':
+			l.pos += 2
+			l.skipInterpolation()
+			continue
+		}
+		l.pos++
+	}
+	l.pos = min(l.pos, len(l.src))
+}
+
+func (l *lexer) skipInterpolation() {
+	depth := 1
+	for l.pos < len(l.src) && depth > 0 {
+		c := l.src[l.pos]
+		switch {
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		case c == l.spec.templateQuote:
+			l.skipTemplate()
+			continue
+		case c == '"' || c == '\'':
+			l.skipString(c)
+			continue
+		}
+		l.pos++
+	}
+}
+
+func (l *lexer) skipRegex() {
+	l.pos++
+	inClass := false
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\\':
+			l.pos += 2
+			continue
+		case c == '\n':
+			return
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case c == '/' && !inClass:
+			l.pos++
+			for l.pos < len(l.src) && isIdentByte(l.src[l.pos]) {
+				l.pos++
+			}
+			return
+		}
+		l.pos++
+	}
+	l.pos = min(l.pos, len(l.src))
+}
+
+// regexAllowed reports whether a slash starts a regex literal rather than a
+// division, judged by the previous significant token.
+func (l *lexer) regexAllowed() bool {
+	if l.pos+1 < len(l.src) && (l.src[l.pos+1] == '/' || l.src[l.pos+1] == '*') {
+		return false
+	}
+
+	for i := len(l.tokens) - 1; i >= 0; i-- {
+		prev := l.tokens[i]
+		switch prev.kind {
+		case tokNewline, tokComment:
+			continue
+		case tokNumber, tokString:
+			return false
+		case tokIdent:
+			switch prev.text {
+			case "return", "typeof", "case", "do", "else", "in", "of", "new", "delete", "void", "throw", "yield", "await":
+				return true
+			}
+			return false
+		case tokPunct:
+			// "</" closes a JSX element.
+			if prev.is("<") && prev.end == l.pos {
+				return false
+			}
+			return !strings.Contains(")]}", prev.text)
+		}
+	}
+	return true
+}
+
+func (l *lexer) isLifetime() bool {
+	if l.pos+1 >= len(l.src) || !isIdentByte(l.src[l.pos+1]) {
+		return false
+	}
+
+	// 'a' is a char literal, 'a without a closing quote is a lifetime.
+	end := l.pos + 1
+	for end < len(l.src) && isIdentByte(l.src[end]) {
+		end++
+	}
+	return end >= len(l.src) || l.src[end] != '\''
+}
+
+func (l *lexer) skipNumber() {
+	for l.pos < len(l.src) && (isIdentByte(l.src[l.pos]) || l.src[l.pos] == '.' && l.pos+1 < len(l.src) && isDigit(l.src[l.pos+1])) {
+		l.pos++
+	}
+}
+
+func (l *lexer) isIdentStart() bool {
+	c := l.src[l.pos]
+	if c == '_' || c < utf8.RuneSelf && unicode.IsLetter(rune(c)) || strings.IndexByte(l.spec.identExtra, c) >= 0 {
+		return true
+	}
+
+	r, _ := utf8.DecodeRune(l.src[l.pos:])
+	return c >= utf8.RuneSelf && unicode.IsLetter(r)
+}
+
+func (l *lexer) skipIdent() {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if isIdentByte(c) || strings.IndexByte(l.spec.identExtra, c) >= 0 {
+			l.pos++
+			continue
+		}
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRune(l.src[l.pos:])
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				l.pos += size
+				continue
+			}
+		}
+		break
+	}
+
+	if l.pos < len(l.src) && strings.IndexByte(l.spec.identSuffix, l.src[l.pos]) >= 0 {
+		// Ruby/Elixir predicates (empty?, save!) but not != or ?: operators.
+		if l.pos+1 >= len(l.src) || l.src[l.pos+1] != '=' && l.src[l.pos+1] != ':' {
+			l.pos++
+		}
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,62 @@
+package indexer
+
+import (
+	"unicode/utf16"
+
+	"github.com/alessandrojcm/pampax-go/internal/codemap"
+)
+
+// Metadata returns the codemap entry of the chunk as a chunk of file in
+// lang, as embedAndStore fills it; the provider fields are the caller's.
+func (c Chunk) Metadata(file, lang string) codemap.ChunkMetadata {
+	symbol := c.Symbol
+	return codemap.ChunkMetadata{
+		File:             file,
+		Symbol:           &symbol,
+		SHA:              c.SHA,
+		Lang:             lang,
+		ChunkType:        c.ChunkType,
+		HasPampaTags:     len(c.Tags) > 0,
+		HasIntent:        c.Pampa.Intent != "",
+		HasDocumentation: c.HasDocumentation,
+		VariableCount:    len(c.Variables),
+		SymbolSignature:  c.Signature.Text,
+		SymbolParameters: c.Signature.Parameters,
+		SymbolReturn:     c.Signature.Return,
+		SymbolCalls:      CallNames(c.Calls),
+		GroupSymbols:     c.GroupSymbols,
+	}
+}
+
+// ID returns the codemap key and code_chunks id of the chunk in file,
+// "file:symbol:sha8" as in the Node indexer.
+func (c Chunk) ID(file string) string {
+	return file + ":" + c.Symbol + ":" + c.SHA[:min(8, len(c.SHA))]
+}
+
+// ContextInfo is the context_info column of a chunk.
+type ContextInfo struct {
+	NodeType         string `json:"nodeType"`
+	StartLine        int    `json:"startLine"`
+	EndLine          int    `json:"endLine"`
+	CodeLength       int    `json:"codeLength"`
+	HasDocumentation bool   `json:"hasDocumentation"`
+	VariableCount    int    `json:"variableCount"`
+	IsSubdivision    bool   `json:"isSubdivision"`
+	HasParentContext bool   `json:"hasParentContext"`
+}
+
+// ContextInfo returns the chunk's context_info. CodeLength counts UTF-16
+// units, like the JavaScript string length Node stores.
+func (c Chunk) ContextInfo() ContextInfo {
+	return ContextInfo{
+		NodeType:         c.NodeType,
+		StartLine:        c.StartLine,
+		EndLine:          c.EndLine,
+		CodeLength:       len(utf16.Encode([]rune(c.Code))),
+		HasDocumentation: c.HasDocumentation,
+		VariableCount:    len(c.Variables),
+		IsSubdivision:    c.IsSubdivision,
+		HasParentContext: c.HasParentContext,
+	}
+}
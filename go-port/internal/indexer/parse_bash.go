@@ -0,0 +1,340 @@
+package indexer
+
+var bashSyntax = &scriptSyntax{
+	lex: &lexSpec{
+		lineComments: []string{"#"},
+		wordComments: true,
+		quotes:       "\"'`",
+		identExtra:   "-",
+		heredocs:     heredocShell,
+	},
+	keywords:   wordSet("if", "then", "else", "elif", "fi", "case", "esac", "for", "select", "while", "until", "do", "done", "in", "function", "time"),
+	blocks:     bashBlocks,
+	caseParens: true,
+	continuers: `\|&`,
+}
+
+// bashCommandStarts lists tokens after which a word is in command position.
+var bashCommandStarts = wordSet(";", "&", "|", "(", "{", "then", "do", "else", "elif", "!", "time")
+
+func bashBlocks(toks []token, i int) (opens, closes bool) {
+	if !commandPosition(toks, i, bashCommandStarts) {
+		return false, false
+	}
+
+	switch toks[i].text {
+	case "if", "case", "for", "select", "while", "until":
+		return true, false
+	case "fi", "esac", "done":
+		return false, true
+	}
+	return false, false
+}
+
+var bashDeclarations = wordSet("export", "local", "declare", "readonly", "typeset")
+
+// bashClauseWords introduce the next part of a compound command and are
+// stripped before parsing the command that follows them.
+var bashClauseWords = wordSet("then", "do", "else", "elif")
+
+type bashParse struct {
+	*scriptParse
+}
+
+// parseBash builds tree-sitter-bash statements: function definitions,
+// commands, pipelines and the compound if/for/while/case commands.
+func parseBash(source []byte) (*Tree, error) {
+	p := &bashParse{newScriptParse(bashSyntax, source)}
+	root := newNode("program", 0, len(source))
+	p.body(root, 0, len(p.toks))
+	return &Tree{Lang: "bash", Source: source, Root: root, HasError: p.hasError}, nil
+}
+
+func (p *bashParse) body(parent *Node, lo, hi int) {
+	for _, stmt := range p.statements(lo, hi, p.caseSeparator) {
+		parent.add(p.statement(stmt[0], stmt[1]))
+	}
+}
+
+// caseSeparator splits at ";;" (and ";&") so case items end their command.
+func (p *bashParse) caseSeparator(i int) bool {
+	return p.toks[i].is("&") && i > 0 && p.toks[i-1].is(";") && p.adjacent(i-1, i)
+}
+
+func (p *bashParse) statement(lo, last int) *Node {
+	// An operator with no command after it, as in "a |".
+	last = min(last, len(p.toks)-1)
+	if lo > last {
+		p.hasError = true
+		return nil
+	}
+	for bashClauseWords[p.toks[lo].text] && p.toks[lo].kind == tokIdent {
+		if lo == last {
+			return nil
+		}
+		lo++
+	}
+
+	if op := p.operator(lo, last, "&", "|"); op > lo {
+		// a && b, a || b
+		list := p.span("list", lo, last)
+		list.add(p.statement(lo, op-1), p.statement(op+2, last))
+		return list
+	}
+	if op := p.operator(lo, last, "|"); op > lo {
+		pipeline := p.span("pipeline", lo, last)
+		pipeline.add(p.statement(lo, op-1), p.statement(op+1, last))
+		return pipeline
+	}
+	return p.command(lo, last)
+}
+
+// operator returns the first top-level occurrence of the doubled operator
+// (&&, ||) or of a lone "|", or -1.
+func (p *bashParse) operator(lo, last int, ops ...string) int {
+	for j := lo; j <= last; j++ {
+		if m := p.match[j]; m > j && m <= last {
+			j = m
+			continue
+		}
+
+		tok := p.toks[j]
+		if len(ops) == 2 {
+			if j < last && tok.is(ops[0]) && p.toks[j+1].is(ops[0]) && p.adjacent(j, j+1) || j < last && tok.is(ops[1]) && p.toks[j+1].is(ops[1]) && p.adjacent(j, j+1) {
+				return j
+			}
+			continue
+		}
+		if tok.is(ops[0]) && !(j < last && p.toks[j+1].is(ops[0]) && p.adjacent(j, j+1)) && !(j > lo && p.toks[j-1].is(ops[0]) && p.adjacent(j-1, j)) && !(j > lo && p.toks[j-1].is(">") && p.adjacent(j-1, j)) {
+			return j
+		}
+	}
+	return -1
+}
+
+func (p *bashParse) command(lo, last int) *Node {
+	tok := p.toks[lo]
+
+	if p.isOpener(lo) {
+		return p.compound(lo, last)
+	}
+	if fn := p.function(lo, last); fn != nil {
+		return fn
+	}
+
+	switch {
+	case tok.is("{") && p.match[lo] > lo:
+		node := p.span("compound_statement", lo, last)
+		p.body(node, lo+1, p.close(lo, last+1))
+		return node
+	case tok.is("(") && p.match[lo] > lo:
+		node := p.span("subshell", lo, last)
+		p.body(node, lo+1, p.close(lo, last+1))
+		return node
+	case tok.is("["):
+		node := p.span("test_command", lo, last)
+		p.substitutions(node, lo, last)
+		return node
+	case tok.kind == tokIdent && bashDeclarations[tok.text]:
+		node := p.span("declaration_command", lo, last)
+		for j := lo + 1; j <= last; j = p.wordEnd(j, last) + 1 {
+			if assignment := p.assignment(j, last); assignment != nil {
+				node.add(assignment)
+			} else if p.toks[j].kind == tokIdent {
+				node.add(newNode("variable_name", p.toks[j].start, p.toks[j].end))
+			}
+		}
+		return node
+	}
+
+	if assignment := p.assignment(lo, last); assignment != nil && p.wordEnd(lo, last) == last {
+		return assignment
+	}
+
+	node := p.span("command", lo, last)
+	start := lo
+	for start <= last {
+		assignment := p.assignment(start, last)
+		if assignment == nil {
+			break
+		}
+		node.add(assignment)
+		start = p.wordEnd(start, last) + 1
+	}
+	if start <= last {
+		end := p.wordEnd(start, last)
+		name := p.span("command_name", start, end)
+		node.addField("name", name.add(p.span("word", start, end)))
+		p.substitutions(node, end+1, last)
+	}
+	return node
+}
+
+// wordEnd returns the last token of the shell word starting at i: adjacent
+// tokens up to a separator or redirection.
+func (p *bashParse) wordEnd(i, last int) int {
+	for j := i; j <= last; j++ {
+		if m := p.match[j]; m > j && m <= last && p.toks[j].kind == tokPunct {
+			j = m
+		}
+		if j == last || !p.adjacent(j, j+1) {
+			return j
+		}
+		next := p.toks[j+1]
+		if next.is(";") || next.is("|") || next.is("&") || next.is("<") || next.is(">") {
+			return j
+		}
+	}
+	return last
+}
+
+// assignment returns a variable_assignment for NAME=value starting at i.
+func (p *bashParse) assignment(i, last int) *Node {
+	if i+1 > last || p.toks[i].kind != tokIdent || !p.toks[i+1].is("=") || !p.adjacent(i, i+1) {
+		return nil
+	}
+
+	end := p.wordEnd(i, last)
+	node := p.span("variable_assignment", i, end)
+	node.addField("name", newNode("variable_name", p.toks[i].start, p.toks[i].end))
+	p.substitutions(node, i+2, end)
+	return node
+}
+
+// substitutions adds the $(...) command substitutions in [lo, last].
+func (p *bashParse) substitutions(parent *Node, lo, last int) {
+	for j := lo; j <= last; j++ {
+		m := p.match[j]
+		if m <= j || m > last {
+			continue
+		}
+		if p.toks[j].is("(") && j > 0 && p.toks[j-1].is("$") && p.adjacent(j-1, j) && !p.at(j+1, m, "(") {
+			node := newNode("command_substitution", p.toks[j-1].start, p.toks[m].end)
+			p.body(node, j+1, m)
+			parent.add(node)
+		} else {
+			p.substitutions(parent, j+1, m-1)
+		}
+		j = m
+	}
+}
+
+// function recognises name() { ... } and function name [()] { ... }.
+func (p *bashParse) function(lo, last int) *Node {
+	name := lo
+	if p.toks[lo].is("function") {
+		name++
+	}
+	if name > last || p.toks[name].kind != tokIdent || p.syn.keywords[p.toks[name].text] {
+		return nil
+	}
+
+	body := name + 1
+	if p.at(body, last+1, "(") && p.at(body+1, last+1, ")") {
+		body += 2
+	} else if name == lo {
+		return nil
+	}
+	body = p.sig(body, last+1)
+	if body > last || !(p.toks[body].is("{") || p.toks[body].is("(")) || p.match[body] < body {
+		return nil
+	}
+
+	node := p.span("function_definition", lo, last)
+	node.addField("name", p.span("word", name, name))
+	end := p.close(body, last+1)
+	bodyType := "compound_statement"
+	if p.toks[body].is("(") {
+		bodyType = "subshell"
+	}
+	block := p.span(bodyType, body, end)
+	p.body(block, body+1, end)
+	node.addField("body", block)
+	return node
+}
+
+// compound builds if/case/for/while statements opened at lo.
+func (p *bashParse) compound(lo, last int) *Node {
+	end := p.close(lo, last+1)
+	word := p.toks[lo].text
+
+	switch word {
+	case "if":
+		node := p.span("if_statement", lo, last)
+		p.body(node, lo+1, end)
+		return node
+	case "case":
+		return p.caseStatement(lo, end, last)
+	}
+
+	nodeType := "while_statement"
+	if word == "for" || word == "select" {
+		nodeType = "for_statement"
+		if p.at(lo+1, end, "(") && p.at(lo+2, end, "(") {
+			nodeType = "c_style_for_statement"
+		}
+	}
+	node := p.span(nodeType, lo, last)
+
+	do := lo + 1
+	for do < end && !(p.toks[do].is("do") && commandPosition(p.toks, do, bashCommandStarts)) {
+		if m := p.match[do]; m > do && m < end {
+			do = m
+		}
+		do++
+	}
+	switch {
+	case nodeType == "for_statement" && p.toks[lo+1].kind == tokIdent:
+		node.addField("variable", p.span("variable_name", lo+1, lo+1))
+		p.substitutions(node, lo+2, do-1)
+	case nodeType == "while_statement":
+		p.body(node, lo+1, do)
+	}
+	if do < end {
+		group := p.span("do_group", do, end)
+		p.body(group, do+1, end)
+		node.addField("body", group)
+	} else {
+		p.hasError = true
+	}
+	return node
+}
+
+func (p *bashParse) caseStatement(lo, end, last int) *Node {
+	node := p.span("case_statement", lo, last)
+	in := lo + 1
+	for in < end && !p.toks[in].is("in") {
+		in++
+	}
+	if in > lo+1 {
+		value := p.span("word", lo+1, in-1)
+		p.substitutions(value, lo+1, in-1)
+		node.addField("value", value)
+	}
+
+	// Items are "pattern) commands ;;".
+	start := p.sig(in+1, end)
+	for start < end {
+		stop := start
+		for stop < end && !(p.toks[stop].is(";") && stop+1 < end && p.toks[stop+1].is(";") && p.adjacent(stop, stop+1)) {
+			if m := p.match[stop]; m > stop && m < end {
+				stop = m
+			}
+			stop++
+		}
+
+		itemLast := p.lastSig(start, min(stop+2, end))
+		item := p.span("case_item", start, itemLast)
+		paren := start
+		for paren < stop && !p.toks[paren].is(")") {
+			paren++
+		}
+		if paren > start {
+			item.addField("value", p.span("word", start, paren-1))
+		}
+		p.body(item, paren+1, stop)
+		node.add(item)
+		start = p.sig(min(stop+2, end), end)
+	}
+	return node
+}
@@ -0,0 +1,1439 @@
+package indexer
+
+import "strings"
+
+type bodyMode int
+
+const (
+	bodyStatements bodyMode = iota
+	bodyMembers
+	bodyOpaque
+)
+
+type nameRule int
+
+const (
+	nameNone nameRule = iota
+	// nameFirst takes the first identifier after the keyword, following a.b
+	// chains so Kotlin extension functions resolve to the member name.
+	nameFirst
+	// nameImpl takes the implemented type of a Rust impl block.
+	nameImpl
+)
+
+// declSpec describes a declaration or statement introduced by a keyword.
+type declSpec struct {
+	typ  string
+	name string
+	rule nameRule
+	body string
+	mode bodyMode
+	// noBody replaces typ when neither a body nor an initializer follows
+	// (TS overloads, Rust trait signatures, Scala abstract defs).
+	noBody string
+	// parenType replaces typ when the keyword is directly followed by "(".
+	parenType string
+	// declarator wraps the name and initializer (JS variable_declarator).
+	declarator string
+	// wrap encloses the node in a statement node (Rust expression_statement).
+	wrap string
+	// prefix marks keywords that prefix another statement (export, template).
+	prefix bool
+}
+
+// braceDialect configures the structural parser for one brace-delimited
+// language using the node type names of its tree-sitter grammar.
+type braceDialect struct {
+	lang string
+	root string
+	lex  *lexSpec
+	// asi lets newlines terminate statements (JS, Kotlin, Swift, Scala).
+	asi bool
+
+	keywords  map[string]bool
+	modifiers map[string]bool
+	// conditional words are modifiers only before a declaration keyword
+	// (Rust "const fn", Swift "class func", Scala "case class").
+	conditional   map[string]bool
+	selfWords     map[string]bool
+	decls         map[string]declSpec
+	memberDecls   map[string]declSpec
+	labels        map[string]string
+	nameSkip      map[string]bool
+	trailingAfter bool
+	attributes    string
+	preprocessor  bool
+
+	ident      string
+	property   string
+	member     string
+	call       string
+	memberCall string
+	scopedCall string
+	newExpr    string
+	macro      string
+	args       string
+	params     string
+	block      string
+	object     string
+	arrow      string
+	funcExpr   string
+	lambdas    bool
+
+	method       string
+	methodNoBody string
+	constructor  string
+	field        string
+	propertyDecl string
+	function     string
+	declarator   string
+	localDecl    string
+	expression   string
+	assignment   string
+}
+
+func braceParser(d *braceDialect) Parser {
+	return ParserFunc(func(source []byte) (*Tree, error) {
+		p := newBraceParse(d, source)
+		root := newNode(d.root, 0, len(source))
+		p.parseBody(root, 0, len(p.toks), bodyStatements)
+		return &Tree{Lang: d.lang, Source: source, Root: root, HasError: p.hasError}, nil
+	})
+}
+
+type braceParse struct {
+	d        *braceDialect
+	toks     []token
+	match    []int
+	hasError bool
+}
+
+func newBraceParse(d *braceDialect, source []byte) *braceParse {
+	p := &braceParse{d: d}
+	for _, tok := range lex(source, d.lex) {
+		if tok.kind != tokComment {
+			p.toks = append(p.toks, tok)
+		}
+	}
+	p.match, p.hasError = matchBrackets(p.toks)
+	return p
+}
+
+// matchBrackets pairs (), [] and {} tokens. Unmatched brackets are reported
+// as an error and map to -1.
+func matchBrackets(toks []token) ([]int, bool) {
+	match := make([]int, len(toks))
+	var stack []int
+	hasError := false
+
+	for i, tok := range toks {
+		match[i] = -1
+		if tok.kind != tokPunct {
+			continue
+		}
+
+		switch tok.text {
+		case "(", "[", "{":
+			stack = append(stack, i)
+		case ")", "]", "}":
+			open := map[string]string{")": "(", "]": "[", "}": "{"}[tok.text]
+			for len(stack) > 0 && toks[stack[len(stack)-1]].text != open {
+				stack = stack[:len(stack)-1]
+				hasError = true
+			}
+			if len(stack) == 0 {
+				hasError = true
+				continue
+			}
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			match[top], match[i] = i, top
+		}
+	}
+
+	return match, hasError || len(stack) > 0
+}
+
+// close returns the bracket matching the opener at j, clamped to hi-1.
+func (p *braceParse) close(j, hi int) int {
+	if m := p.match[j]; m > j && m < hi {
+		return m
+	}
+	return hi - 1
+}
+
+func (p *braceParse) sig(i, hi int) int {
+	for i < hi && p.toks[i].kind == tokNewline {
+		i++
+	}
+	return i
+}
+
+func (p *braceParse) prevSig(i, lo int) int {
+	for i--; i >= lo; i-- {
+		if p.toks[i].kind != tokNewline {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p *braceParse) at(i, hi int, text string) bool {
+	return i >= 0 && i < hi && p.toks[i].is(text)
+}
+
+func (p *braceParse) adjacent(a, b int) bool {
+	return p.toks[a].end == p.toks[b].start
+}
+
+func (p *braceParse) isIdent(i, hi int) bool {
+	return i >= 0 && i < hi && p.toks[i].kind == tokIdent && !p.d.keywords[p.toks[i].text]
+}
+
+// arrowAt reports whether tokens at j form "=>".
+func (p *braceParse) arrowAt(j, hi int) bool {
+	return p.at(j, hi, "=") && p.at(j+1, hi, ">") && p.adjacent(j, j+1)
+}
+
+// assignOp reports whether the "=" at j is an assignment rather than part of
+// ==, !=, <=, >= or =>.
+func (p *braceParse) assignOp(j, hi int) bool {
+	if !p.at(j, hi, "=") {
+		return false
+	}
+	if j+1 < hi && p.adjacent(j, j+1) && (p.toks[j+1].is("=") || p.toks[j+1].is(">")) {
+		return false
+	}
+	if j > 0 && p.adjacent(j-1, j) && strings.Contains("=!<>", p.toks[j-1].text) {
+		return false
+	}
+	return true
+}
+
+// exprBrace reports whether the "{" at j opens an expression (object
+// literal, lambda, initializer) rather than a declaration or statement body.
+func (p *braceParse) exprBrace(j, lo int) bool {
+	prev := p.prevSig(j, lo)
+	if prev < 0 {
+		return false
+	}
+
+	tok := p.toks[prev]
+	if tok.kind == tokIdent {
+		switch tok.text {
+		case "return", "default", "yield", "await", "throw", "case", "in", "of", "typeof":
+			return true
+		}
+		return false
+	}
+	if tok.kind != tokPunct {
+		return false
+	}
+	if tok.text == ">" && prev > lo && p.adjacent(prev-1, prev) && (p.toks[prev-1].is("=") || p.toks[prev-1].is("-")) {
+		return true
+	}
+	return strings.Contains("=(,:?[!&|+-*%^~", tok.text)
+}
+
+// skipModifiers returns the first token after modifiers, annotations,
+// decorators and attributes.
+func (p *braceParse) skipModifiers(i, hi int) int {
+	for i = p.sig(i, hi); i < hi; i = p.sig(i, hi) {
+		tok := p.toks[i]
+
+		switch {
+		case tok.kind == tokIdent && p.isModifier(i, hi):
+			i++
+			if p.at(i, hi, "(") && tok.text == "pub" {
+				i = p.close(i, hi) + 1
+			}
+			if i < hi && p.toks[i].kind == tokString {
+				i++
+			}
+		case tok.is("@") && p.isIdent(i+1, hi) && p.toks[i+1].text != "interface":
+			i = p.chainEnd(i+1, hi) + 1
+			if p.at(i, hi, "(") {
+				i = p.close(i, hi) + 1
+			}
+		case p.d.attributes == "[" && tok.is("[") && p.match[i] > i:
+			i = p.close(i, hi) + 1
+		case p.d.attributes == "#[" && tok.is("#") && p.at(i+1, hi, "["):
+			i = p.close(i+1, hi) + 1
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func (p *braceParse) isModifier(i, hi int) bool {
+	text := p.toks[i].text
+	if p.d.conditional[text] {
+		next := p.sig(i+1, hi)
+		if next >= hi {
+			return false
+		}
+		_, decl := p.d.decls[p.toks[next].text]
+		return decl || p.d.modifiers[p.toks[next].text] || p.d.conditional[p.toks[next].text]
+	}
+	return p.d.modifiers[text] && i+1 < hi && !p.modifierUsedAsName(i+1, hi)
+}
+
+func (p *braceParse) modifierUsedAsName(next, hi int) bool {
+	next = p.sig(next, hi)
+	if next >= hi {
+		return true
+	}
+
+	tok := p.toks[next]
+	if tok.kind == tokPunct {
+		return !tok.is("@") && !tok.is("*") && !tok.is("#") && !tok.is("[") && !tok.is("{")
+	}
+	return false
+}
+
+// chainEnd returns the last identifier of a member chain starting at j
+// (a.b, a?.b, a->b, a::b).
+func (p *braceParse) chainEnd(j, hi int) int {
+	k := j
+	for {
+		n := p.connector(k+1, hi)
+		if n == 0 || !p.isChainIdent(k+1+n, hi) {
+			return k
+		}
+		k += 1 + n
+	}
+}
+
+func (p *braceParse) isChainIdent(i, hi int) bool {
+	return i < hi && p.toks[i].kind == tokIdent
+}
+
+// connector returns the token length of a member access operator at i.
+func (p *braceParse) connector(i, hi int) int {
+	switch {
+	case p.at(i, hi, "."):
+		return 1
+	case p.at(i, hi, "?") && p.at(i+1, hi, ".") && p.adjacent(i, i+1):
+		return 2
+	case p.at(i, hi, "?") && p.at(i+1, hi, "-") && p.at(i+2, hi, ">") && p.adjacent(i, i+1) && p.adjacent(i+1, i+2):
+		return 3
+	case p.at(i, hi, "-") && p.at(i+1, hi, ">") && p.adjacent(i, i+1):
+		return 2
+	case p.at(i, hi, ":") && p.at(i+1, hi, ":") && p.adjacent(i, i+1):
+		return 2
+	}
+	return 0
+}
+
+func (p *braceParse) chainHas(j, k int, op string) bool {
+	for i := j; i < k; i++ {
+		if p.toks[i].kind == tokPunct && strings.Contains(op, p.toks[i].text) {
+			return true
+		}
+	}
+	return false
+}
+
+// skipAngles skips a balanced <...> group starting at i.
+func (p *braceParse) skipAngles(i, hi int) int {
+	if !p.at(i, hi, "<") {
+		return i
+	}
+
+	depth := 0
+	for j := i; j < hi; j++ {
+		switch {
+		case p.toks[j].is("<"):
+			depth++
+		case p.toks[j].is(">"):
+			depth--
+			if depth == 0 {
+				return j + 1
+			}
+		case p.toks[j].is("{") || p.toks[j].is(";"):
+			return i
+		}
+	}
+	return i
+}
+
+// parseBody parses the statements or members in tokens [lo, hi) into parent.
+func (p *braceParse) parseBody(parent *Node, lo, hi int, mode bodyMode) {
+	if mode == bodyOpaque {
+		p.scanExpr(parent, lo, hi)
+		return
+	}
+
+	for i := lo; ; {
+		i = p.sig(i, hi)
+		for i < hi && p.toks[i].is(";") {
+			i = p.sig(i+1, hi)
+		}
+		if i >= hi {
+			return
+		}
+
+		end := p.statementEnd(i, hi)
+		if node := p.statement(i, end, mode); node != nil {
+			parent.add(node)
+		}
+		i = end
+	}
+}
+
+// statementEnd returns the exclusive end of the statement starting at lo.
+func (p *braceParse) statementEnd(lo, hi int) int {
+	d := p.d
+	head := p.skipModifiers(lo, hi)
+	if head >= hi {
+		return hi
+	}
+
+	if d.preprocessor && p.toks[lo].is("#") {
+		return p.lineEnd(lo, hi)
+	}
+	if d.lang == "rust" && p.toks[lo].is("#") {
+		next := lo + 1
+		if p.at(next, hi, "!") {
+			next++
+		}
+		if p.at(next, hi, "[") {
+			return p.close(next, hi) + 1
+		}
+	}
+	if _, ok := d.labels[p.toks[head].text]; ok && p.toks[head].kind == tokIdent {
+		if colon := p.labelColon(head, hi); colon >= 0 {
+			return colon + 1
+		}
+	}
+
+	sawAssign := false
+	for j := lo; j < hi; j++ {
+		tok := p.toks[j]
+
+		switch {
+		case tok.kind == tokNewline:
+			if d.asi && p.endsAtNewline(lo, head, j, hi) {
+				return j
+			}
+		case tok.is(";"):
+			return j + 1
+		case tok.is("(") || tok.is("["):
+			j = p.close(j, hi)
+		case tok.is("{"):
+			m := p.close(j, hi)
+			if sawAssign || p.exprBrace(j, lo) {
+				j = m
+				continue
+			}
+
+			next := p.sig(m+1, hi)
+			if next < hi && p.continuesAfterBlock(lo, head, m, next) {
+				j = m
+				continue
+			}
+			if next < hi && p.toks[next].is(";") && d.trailingAfter {
+				return next + 1
+			}
+			return m + 1
+		case tok.is("}"):
+			return max(j, lo+1)
+		case p.assignOp(j, hi):
+			sawAssign = true
+		}
+	}
+	return hi
+}
+
+func (p *braceParse) lineEnd(lo, hi int) int {
+	for j := lo; j < hi; j++ {
+		if p.toks[j].kind == tokNewline && !(j > lo && p.toks[j-1].is("\\")) {
+			return j
+		}
+	}
+	return hi
+}
+
+func (p *braceParse) labelColon(head, hi int) int {
+	for j := head + 1; j < hi; j++ {
+		tok := p.toks[j]
+		switch {
+		case tok.is(":"):
+			if p.at(j+1, hi, ":") && p.adjacent(j, j+1) {
+				j++
+				continue
+			}
+			return j
+		case tok.is("(") || tok.is("["):
+			j = p.close(j, hi)
+		case tok.is("{") || tok.is(";") || tok.is("}") || tok.is("-") || tok.kind == tokNewline && p.toks[head].text != "case":
+			return -1
+		}
+	}
+	return -1
+}
+
+func (p *braceParse) continuesAfterBlock(lo, head, closing, next int) bool {
+	tok := p.toks[next]
+	switch tok.text {
+	case "else", "catch", "finally":
+		return tok.kind == tokIdent
+	case "while":
+		return p.toks[head].is("do")
+	}
+
+	if !p.d.trailingAfter {
+		return false
+	}
+
+	// C/C++: "struct S { ... } s;" and "typedef struct { ... } T;".
+	sameLine := true
+	for i := closing + 1; i < next; i++ {
+		if p.toks[i].kind == tokNewline {
+			sameLine = false
+		}
+	}
+	return sameLine && tok.kind == tokIdent || p.toks[head].is("typedef")
+}
+
+func (p *braceParse) endsAtNewline(lo, head, j, hi int) bool {
+	prev := p.prevSig(j, lo)
+	if prev < 0 || p.skipModifiers(lo, j) >= j {
+		return false
+	}
+
+	prevTok := p.toks[prev]
+	if prevTok.kind == tokPunct {
+		if prevTok.is(">") && prev > lo && p.adjacent(prev-1, prev) && p.toks[prev-1].is("=") {
+			return false
+		}
+		if strings.Contains(".,=+-*/%&|^<!?:([{", prevTok.text) {
+			return false
+		}
+		if prevTok.is(")") && p.controlHead(head) && !p.hasBlock(head, prev) {
+			return false
+		}
+	}
+	if prevTok.kind == tokIdent {
+		switch prevTok.text {
+		case "else", "extends", "implements", "in", "of", "instanceof", "new", "typeof", "await", "async", "do", "try":
+			return false
+		}
+		if prev == head && p.controlHead(head) {
+			return false
+		}
+	}
+
+	next := p.sig(j, hi)
+	if next >= hi {
+		return true
+	}
+	nextTok := p.toks[next]
+	if nextTok.kind == tokPunct {
+		if nextTok.is(".") || nextTok.is("?") && p.at(next+1, hi, ".") {
+			return false
+		}
+		if strings.Contains(")]},:=+-*/%&|^<>", nextTok.text) && !nextTok.is("-") && !nextTok.is("+") {
+			return false
+		}
+	}
+	if nextTok.kind == tokIdent {
+		switch nextTok.text {
+		case "else", "catch", "finally", "where":
+			return false
+		}
+	}
+	return true
+}
+
+func (p *braceParse) controlHead(head int) bool {
+	switch p.toks[head].text {
+	case "if", "for", "while", "else", "foreach", "guard", "when":
+		return true
+	}
+	return false
+}
+
+func (p *braceParse) hasBlock(lo, hi int) bool {
+	for j := lo; j < hi; j++ {
+		if p.toks[j].is("{") {
+			return true
+		}
+		if p.toks[j].is("(") {
+			j = p.close(j, hi)
+		}
+	}
+	return false
+}
+
+// lastSig returns the last non-newline token index in [lo, hi).
+func (p *braceParse) lastSig(lo, hi int) int {
+	for j := hi - 1; j >= lo; j-- {
+		if p.toks[j].kind != tokNewline {
+			return j
+		}
+	}
+	return lo
+}
+
+// statement builds the node for tokens [lo, hi).
+func (p *braceParse) statement(lo, hi int, mode bodyMode) *Node {
+	d := p.d
+	last := p.lastSig(lo, hi)
+	head := p.skipModifiers(lo, last+1)
+	first := p.toks[lo]
+
+	if d.preprocessor && first.is("#") {
+		return p.preprocessorNode(lo, last)
+	}
+	if d.lang == "rust" && first.is("#") && lo+1 <= last && (p.toks[lo+1].is("[") || p.toks[lo+1].is("!")) {
+		if p.toks[lo+1].is("!") {
+			return newNode("inner_attribute_item", first.start, p.toks[last].end)
+		}
+		return newNode("attribute_item", first.start, p.toks[last].end)
+	}
+	if head > last {
+		return newNode(d.expression, first.start, p.toks[last].end)
+	}
+
+	headTok := p.toks[head]
+	if headTok.kind == tokIdent {
+		if label, ok := d.labels[headTok.text]; ok && p.at(last, last+1, ":") {
+			node := newNode(label, first.start, p.toks[last].end)
+			p.scanExpr(node, head+1, last)
+			return node
+		}
+
+		if mode == bodyMembers {
+			if spec, ok := d.memberDecls[headTok.text]; ok {
+				return p.declNode(spec, lo, head, last, mode)
+			}
+		}
+		if spec, ok := d.decls[headTok.text]; ok && p.keywordHead(spec, head, last) && !p.returnsType(spec, lo, head, last) {
+			return p.declNode(spec, lo, head, last, mode)
+		}
+	}
+
+	return p.fallbackNode(lo, head, last, mode)
+}
+
+// keywordHead rejects keyword matches that are really identifiers, such as
+// a TypeScript variable named "type" or a property named "module".
+func (p *braceParse) keywordHead(spec declSpec, head, last int) bool {
+	if spec.rule != nameFirst || head == last {
+		return true
+	}
+
+	next := p.sig(head+1, last+1)
+	if next > last {
+		return true
+	}
+	tok := p.toks[next]
+	if tok.kind == tokPunct {
+		return strings.Contains("*<([{&!@$", tok.text)
+	}
+	return true
+}
+
+// returnsType detects C-style functions whose return type starts with a
+// declaration keyword, e.g. "struct node *make(void) { ... }".
+func (p *braceParse) returnsType(spec declSpec, lo, head, last int) bool {
+	if p.d.function == "" || spec.mode != bodyMembers {
+		return false
+	}
+	open, body, _ := p.shape(lo, head, last)
+	return open >= 0 && (body > open || body < 0)
+}
+
+func (p *braceParse) preprocessorNode(lo, last int) *Node {
+	node := newNode("preproc_call", p.toks[lo].start, p.toks[last].end)
+	if lo+1 > last {
+		return node
+	}
+
+	switch p.toks[lo+1].text {
+	case "include":
+		node.Type = "preproc_include"
+	case "define":
+		node.Type = "preproc_def"
+		if p.isChainIdent(lo+2, last+1) {
+			node.addField("name", newNode("identifier", p.toks[lo+2].start, p.toks[lo+2].end))
+			if p.at(lo+3, last+1, "(") && p.adjacent(lo+2, lo+3) {
+				node.Type = "preproc_function_def"
+			}
+		}
+	}
+	return node
+}
+
+// declNode builds a keyword-introduced declaration or statement.
+func (p *braceParse) declNode(spec declSpec, lo, head, last int, mode bodyMode) *Node {
+	d := p.d
+	hi := last + 1
+	typ := spec.typ
+	if spec.parenType != "" && p.at(p.sig(head+1, hi), hi, "(") {
+		typ = spec.parenType
+	}
+
+	node := newNode(typ, p.toks[lo].start, p.toks[last].end)
+	if spec.prefix {
+		inner := p.sig(head+1, hi)
+		if p.at(inner, hi, "default") {
+			inner = p.sig(inner+1, hi)
+		}
+		inner = p.sig(p.skipAngles(inner, hi), hi)
+		if inner <= last {
+			p.prefixed(node, inner, last, mode)
+		}
+		return node
+	}
+
+	nameIdx := p.declName(spec, head, hi)
+	target := node
+	segStart := head + 1
+	if nameIdx >= 0 {
+		if spec.declarator != "" {
+			end := last
+			if p.toks[end].is(";") && end > nameIdx {
+				end--
+			}
+			target = newNode(spec.declarator, p.toks[p.nameStart(nameIdx, head)].start, p.toks[end].end)
+		}
+		p.addName(target, spec.name, nameIdx, head)
+		segStart = nameIdx + 1
+	}
+
+	hasBody, sawAssign, hasParams := false, false, false
+	for j := segStart; j < hi; j++ {
+		tok := p.toks[j]
+		switch {
+		case tok.is("(") && nameIdx >= 0 && !hasParams && !sawAssign && d.params != "":
+			m := p.close(j, hi)
+			target.addField("parameters", newNode(d.params, tok.start, p.toks[m].end))
+			hasParams = true
+			j = m
+			segStart = m + 1
+		case tok.is("(") || tok.is("["):
+			j = p.close(j, hi)
+		case p.assignOp(j, hi):
+			sawAssign = true
+		case tok.is("{") && !sawAssign && !p.exprBrace(j, lo):
+			p.scanExpr(target, segStart, j)
+			m := p.close(j, hi)
+			bodyType := spec.body
+			if bodyType == "" || hasBody {
+				bodyType = d.block
+			}
+			body := newNode(bodyType, tok.start, p.toks[m].end)
+			bodyMode := spec.mode
+			if hasBody {
+				bodyMode = bodyStatements
+			}
+			p.parseBody(body, j+1, m, bodyMode)
+			target.addField("body", body)
+			hasBody = true
+			j = m
+			segStart = m + 1
+		}
+	}
+	p.scanExpr(target, segStart, hi)
+
+	if target != node {
+		node.add(target)
+	}
+	if !hasBody && !sawAssign && spec.noBody != "" {
+		node.Type = spec.noBody
+	}
+	if spec.wrap != "" {
+		wrapper := newNode(spec.wrap, node.StartByte, node.EndByte)
+		return wrapper.add(node)
+	}
+	return node
+}
+
+// prefixed parses the statement following export/template into node.
+func (p *braceParse) prefixed(node *Node, inner, last int, mode bodyMode) {
+	d := p.d
+	hi := last + 1
+
+	if p.toks[inner].is("{") && d.object != "" {
+		clause := newNode("export_clause", p.toks[inner].start, p.toks[p.close(inner, hi)].end)
+		node.add(clause)
+		p.scanExpr(node, p.close(inner, hi)+1, hi)
+		return
+	}
+
+	child := p.statement(inner, hi, mode)
+	if child == nil {
+		return
+	}
+	if child.Type == d.expression && len(child.Children) > 0 {
+		for _, grandchild := range child.Children {
+			node.add(grandchild)
+		}
+		return
+	}
+	node.addField("declaration", child)
+}
+
+func (p *braceParse) declName(spec declSpec, head, hi int) int {
+	switch spec.rule {
+	case nameFirst:
+		j := p.sig(head+1, hi)
+		for j < hi && (p.d.nameSkip[p.toks[j].text] || p.toks[j].kind == tokNewline) {
+			j++
+		}
+		j = p.sig(p.skipAngles(j, hi), hi)
+		if !p.isIdent(j, hi) {
+			return -1
+		}
+		return p.chainEnd(j, hi)
+	case nameImpl:
+		j := p.skipAngles(p.sig(head+1, hi), hi)
+		name := -1
+		for ; j < hi && !p.toks[j].is("{"); j++ {
+			switch {
+			case p.toks[j].is("for") && name >= 0:
+				name = -1
+			case p.toks[j].is("<"):
+				j = p.skipAngles(j, hi) - 1
+			case p.toks[j].kind == tokIdent && !p.d.keywords[p.toks[j].text] && name < 0:
+				name = p.chainEnd(j, hi)
+				j = name
+			}
+		}
+		return name
+	}
+	return -1
+}
+
+// nameStart walks back from a chain's last identifier to its first.
+func (p *braceParse) nameStart(nameIdx, lo int) int {
+	start := nameIdx
+	for start-2 >= lo && p.toks[start-1].is(".") && p.toks[start-2].kind == tokIdent {
+		start -= 2
+	}
+	return start
+}
+
+func (p *braceParse) addName(parent *Node, nameType string, nameIdx, lo int) {
+	for i := p.nameStart(nameIdx, lo); i < nameIdx; i += 2 {
+		parent.add(newNode(p.d.ident, p.toks[i].start, p.toks[i].end))
+	}
+	tok := p.toks[nameIdx]
+	if nameType == "" || strings.HasPrefix(tok.text, "$") {
+		nameType = p.identType(nameIdx)
+	}
+	parent.addField("name", newNode(nameType, tok.start, tok.end))
+}
+
+// shape locates the first depth-0 "(", statement body "{" and assignment.
+func (p *braceParse) shape(lo, head, last int) (open, body, assign int) {
+	open, body, assign = -1, -1, -1
+	hi := last + 1
+	for j := head; j < hi; j++ {
+		tok := p.toks[j]
+		switch {
+		case tok.is("(") || tok.is("["):
+			if tok.is("(") && open < 0 && assign < 0 && body < 0 {
+				open = j
+			}
+			j = p.close(j, hi)
+		case p.assignOp(j, hi) && assign < 0 && body < 0:
+			assign = j
+		case p.arrowAt(j, hi) && assign < 0 && body < 0:
+			assign = j
+		case tok.is("{"):
+			if body < 0 && assign < 0 && !p.exprBrace(j, lo) {
+				body = j
+			}
+			j = p.close(j, hi)
+		}
+	}
+	return open, body, assign
+}
+
+// identBefore returns the identifier naming a call or declaration whose
+// parameter list opens at open, skipping generic arguments.
+func (p *braceParse) identBefore(open, lo int) int {
+	j := p.prevSig(open, lo)
+	if j >= lo && p.toks[j].is(">") {
+		depth := 0
+		for ; j >= lo; j-- {
+			if p.toks[j].is(">") {
+				depth++
+			} else if p.toks[j].is("<") {
+				depth--
+				if depth == 0 {
+					j--
+					break
+				}
+			}
+		}
+	}
+	if j >= lo && (p.toks[j].kind == tokIdent || p.toks[j].kind == tokString) {
+		return j
+	}
+	return -1
+}
+
+func (p *braceParse) fallbackNode(lo, head, last int, mode bodyMode) *Node {
+	d := p.d
+	hi := last + 1
+	start, end := p.toks[lo].start, p.toks[last].end
+	open, body, assign := p.shape(lo, head, last)
+
+	if p.toks[head].is("{") && body == head {
+		block := newNode(d.block, start, end)
+		p.parseBody(block, head+1, p.close(head, hi), bodyStatements)
+		if mode == bodyMembers && lo != head && d.lang != "csharp" {
+			block.Type = "class_static_block"
+		}
+		return block
+	}
+
+	if mode == bodyMembers && (d.method != "" || d.field != "") {
+		if open >= 0 && (assign < 0 || open < assign) && d.method != "" {
+			name := p.identBefore(open, head)
+			typ := d.method
+			if d.constructor != "" && name == head {
+				typ = d.constructor
+			}
+			if body < 0 && d.methodNoBody != "" && !p.arrowAt(assign, hi) {
+				typ = d.methodNoBody
+			}
+			return p.functionNode(typ, lo, head, last, name, open, body)
+		}
+
+		if d.field != "" {
+			typ := d.field
+			if body >= 0 && open < 0 && d.propertyDecl != "" {
+				typ = d.propertyDecl
+			}
+			node := newNode(typ, start, end)
+			name := p.fieldName(head, last, assign, body)
+			from := head
+			if name >= 0 {
+				p.addName(node, d.property, name, head)
+				from = name + 1
+			}
+			if body >= 0 {
+				p.scanExpr(node, from, body)
+				m := p.close(body, hi)
+				accessors := newNode("accessor_list", p.toks[body].start, p.toks[m].end)
+				p.scanExpr(accessors, body+1, m)
+				node.add(accessors)
+				from = m + 1
+			}
+			p.scanExpr(node, from, hi)
+			return node
+		}
+	}
+
+	if d.function != "" && open >= 0 && body > open && (assign < 0 || assign > body) {
+		name := p.identBefore(open, head)
+		if name > head || name == head && d.lang == "cpp" {
+			return p.functionNode(d.function, lo, head, last, name, open, body)
+		}
+	}
+
+	if d.localDecl != "" {
+		if name := p.declarationName(head, last); name >= 0 {
+			node := newNode(d.localDecl, start, end)
+			p.addName(node, d.ident, name, head)
+			p.scanExpr(node, name+1, hi)
+			return node
+		}
+	}
+
+	exprType := d.expression
+	if exprType == "" {
+		exprType = "expression"
+	}
+	node := newNode(exprType, start, end)
+	if d.assignment != "" && assign >= 0 && p.assignOp(assign, hi) {
+		exprEnd := last
+		if p.toks[exprEnd].is(";") && exprEnd > assign {
+			exprEnd--
+		}
+		assignment := newNode(d.assignment, start, p.toks[exprEnd].end)
+		p.scanExpr(assignment, lo, assign)
+		p.scanExpr(assignment, assign+1, exprEnd+1)
+		if len(assignment.Children) > 0 {
+			assignment.Children[0].Field = "left"
+		}
+		if d.expression == "" {
+			return assignment
+		}
+		return node.add(assignment)
+	}
+	p.scanExpr(node, lo, hi)
+	if d.expression == "" && len(node.Children) == 1 {
+		node.Children[0].Parent = nil
+		return node.Children[0]
+	}
+	return node
+}
+
+// functionNode builds a method or function with an optional body.
+func (p *braceParse) functionNode(typ string, lo, head, last, name, open, body int) *Node {
+	d := p.d
+	hi := last + 1
+	node := newNode(typ, p.toks[lo].start, p.toks[last].end)
+	closeParams := p.close(open, hi)
+	params := newNode(d.params, p.toks[open].start, p.toks[closeParams].end)
+
+	target := node
+	if d.declarator != "" && name >= 0 {
+		start := name
+		for start-3 >= head && p.connector(start-2, hi) == 2 && p.toks[start-2].is(":") {
+			start -= 3
+		}
+		target = newNode(d.declarator, p.toks[start].start, p.toks[closeParams].end)
+		for i := start; i < name; i += 3 {
+			target.add(newNode("namespace_identifier", p.toks[i].start, p.toks[i].end))
+		}
+		target.addField("declarator", newNode(p.identType(name), p.toks[name].start, p.toks[name].end))
+		target.addField("parameters", params)
+		node.addField("declarator", target)
+	} else {
+		if name >= 0 {
+			nameType := d.property
+			if typ == d.function || typ == d.constructor || nameType == "" {
+				nameType = p.identType(name)
+			}
+			if d.lang == "php" || d.lang == "java" || d.lang == "csharp" {
+				nameType = p.identType(name)
+			}
+			node.addField("name", newNode(nameType, p.toks[name].start, p.toks[name].end))
+		}
+		node.addField("parameters", params)
+	}
+
+	from := closeParams + 1
+	if body >= 0 {
+		p.scanExpr(node, from, body)
+		m := p.close(body, hi)
+		block := newNode(d.block, p.toks[body].start, p.toks[m].end)
+		p.parseBody(block, body+1, m, bodyStatements)
+		node.addField("body", block)
+		from = m + 1
+	}
+	p.scanExpr(node, from, hi)
+	return node
+}
+
+// fieldName picks the declared name of a field or property.
+func (p *braceParse) fieldName(head, last, assign, body int) int {
+	stop := last
+	if assign >= 0 {
+		stop = assign - 1
+	} else if body >= 0 {
+		stop = body - 1
+	}
+
+	if p.d.object != "" {
+		if p.toks[head].is("#") && head+1 <= stop {
+			return head + 1
+		}
+		if p.toks[head].kind == tokIdent || p.toks[head].kind == tokString {
+			return head
+		}
+		return -1
+	}
+
+	for j := stop; j >= head; j-- {
+		if p.toks[j].kind == tokIdent && !p.d.keywords[p.toks[j].text] {
+			if j > head && p.toks[j-1].is(":") && !p.toks[head].is("$") {
+				continue
+			}
+			return j
+		}
+	}
+	return -1
+}
+
+// declarationName recognizes "Type name = ..." style local declarations and
+// returns the declared name.
+func (p *braceParse) declarationName(head, last int) int {
+	hi := last + 1
+	j := head
+	types := 0
+	prevIdent := false
+	for ; j < hi; j++ {
+		tok := p.toks[j]
+		switch {
+		case tok.kind == tokIdent:
+			if p.d.keywords[tok.text] && !p.d.modifiers[tok.text] {
+				return -1
+			}
+			if prevIdent && types > 0 || types > 0 && j > head && strings.Contains("*&>]?", p.toks[j-1].text) && p.toks[j-1].kind == tokPunct {
+				if next := j + 1; next >= hi || strings.Contains("=;,[(:", p.toks[next].text) && p.toks[next].kind == tokPunct {
+					if next < hi && p.toks[next].is("(") && p.d.lang != "c" && p.d.lang != "cpp" {
+						return -1
+					}
+					if next < hi && p.toks[next].is(":") && p.at(next+1, hi, ":") {
+						return -1
+					}
+					return j
+				}
+			}
+			types++
+			prevIdent = true
+		case tok.is("<"):
+			end := p.skipAngles(j, hi)
+			if end == j {
+				return -1
+			}
+			j = end - 1
+			prevIdent = true
+		case tok.is("[") && p.at(j+1, hi, "]"):
+			j++
+			prevIdent = false
+		case tok.is("*") || tok.is("&") || tok.is("?"):
+			prevIdent = false
+		case p.connector(j, hi) > 0 && tok.kind == tokPunct:
+			j += p.connector(j, hi) - 1
+			prevIdent = false
+			types--
+		default:
+			return -1
+		}
+	}
+	return -1
+}
+
+func (p *braceParse) identType(i int) string {
+	text := p.toks[i].text
+	if p.d.selfWords[text] {
+		return text
+	}
+	if strings.HasPrefix(text, "$") {
+		return "variable_name"
+	}
+	return p.d.ident
+}
+
+// scanExpr adds expression-level nodes (calls, lambdas, objects and
+// identifiers) found in tokens [lo, hi) to parent.
+func (p *braceParse) scanExpr(parent *Node, lo, hi int) {
+	for j := lo; j < hi; j++ {
+		tok := p.toks[j]
+		switch {
+		case tok.kind == tokIdent:
+			j = p.scanIdent(parent, j, hi)
+		case tok.is("{"):
+			m := p.close(j, hi)
+			p.exprBlock(parent, j, m, hi)
+			j = m
+		case tok.is("(") && p.d.arrow != "":
+			m := p.close(j, hi)
+			if p.arrowAt(m+1, hi) {
+				j = p.arrowFunction(parent, j, m, hi)
+			}
+		}
+	}
+}
+
+func (p *braceParse) scanIdent(parent *Node, j, hi int) int {
+	d := p.d
+	tok := p.toks[j]
+
+	if d.keywords[tok.text] && !d.selfWords[tok.text] {
+		switch {
+		case tok.text == "function" && d.funcExpr != "":
+			return p.functionExpression(parent, j, hi)
+		case tok.text == "new" && d.newExpr != "":
+			return p.newExpression(parent, j, hi)
+		}
+		return j
+	}
+
+	if d.arrow != "" && p.arrowAt(j+1, hi) {
+		return p.arrowFunction(parent, j, j, hi)
+	}
+
+	k := p.chainEnd(j, hi)
+	after := k + 1
+
+	if d.macro != "" && p.at(after, hi, "!") && p.adjacent(k, after) && after+1 < hi && strings.Contains("([{", p.toks[after+1].text) && p.toks[after+1].kind == tokPunct {
+		m := p.close(after+1, hi)
+		macro := newNode(d.macro, tok.start, p.toks[m].end)
+		p.emitChain(macro, j, k, "macro")
+		args := newNode("token_tree", p.toks[after+1].start, p.toks[m].end)
+		p.scanExpr(args, after+2, m)
+		parent.add(macro.add(args))
+		return m
+	}
+
+	if p.at(after, hi, "(") {
+		m := p.close(after, hi)
+		call := newNode(p.callType(j, k), tok.start, p.toks[m].end)
+		p.emitChain(call, j, k, "function")
+		args := newNode(d.args, p.toks[after].start, p.toks[m].end)
+		p.scanExpr(args, after+1, m)
+		call.addField("arguments", args)
+		if d.lambdas && p.at(m+1, hi, "{") {
+			lambdaEnd := p.close(m+1, hi)
+			p.exprBlock(call, m+1, lambdaEnd, hi)
+			call.EndByte = p.toks[lambdaEnd].end
+			m = lambdaEnd
+		}
+		parent.add(call)
+		return m
+	}
+
+	if d.lambdas && p.at(after, hi, "{") && !d.keywords[p.toks[k].text] {
+		m := p.close(after, hi)
+		call := newNode(p.callType(j, k), tok.start, p.toks[m].end)
+		p.emitChain(call, j, k, "function")
+		p.exprBlock(call, after, m, hi)
+		parent.add(call)
+		return m
+	}
+
+	p.emitChain(parent, j, k, "")
+	return k
+}
+
+func (p *braceParse) callType(j, k int) string {
+	d := p.d
+	switch {
+	case d.memberCall != "" && p.chainHas(j, k, "-"):
+		return d.memberCall
+	case d.scopedCall != "" && p.chainHas(j, k, ":"):
+		return d.scopedCall
+	}
+	return d.call
+}
+
+// emitChain adds the identifiers of the member chain [j, k] to parent,
+// wrapped in the dialect's member expression node when it has several parts.
+func (p *braceParse) emitChain(parent *Node, j, k int, field string) {
+	d := p.d
+	if j == k {
+		parent.addField(field, newNode(p.identType(j), p.toks[j].start, p.toks[j].end))
+		return
+	}
+
+	target := parent
+	if d.member != "" {
+		target = newNode(d.member, p.toks[j].start, p.toks[k].end)
+		parent.addField(field, target)
+	}
+
+	target.add(newNode(p.identType(j), p.toks[j].start, p.toks[j].end))
+	for i := j + 1; i <= k; i++ {
+		if p.toks[i].kind != tokIdent {
+			continue
+		}
+		nodeType := d.property
+		if strings.HasPrefix(p.toks[i].text, "$") {
+			nodeType = "variable_name"
+		}
+		target.add(newNode(nodeType, p.toks[i].start, p.toks[i].end))
+	}
+}
+
+// exprBlock handles a "{" inside an expression: an object literal for
+// dialects that have them, otherwise a block of statements (lambda bodies).
+func (p *braceParse) exprBlock(parent *Node, j, m, hi int) {
+	d := p.d
+	prev := p.prevSig(j, 0)
+	lambda := d.object == "" || prev >= 0 && (p.toks[prev].is(")") || p.toks[prev].is(">") && p.arrowAt(prev-1, hi))
+
+	if !lambda {
+		p.objectLiteral(parent, j, m)
+		return
+	}
+
+	blockType := d.block
+	if d.lambdas {
+		blockType = "lambda_literal"
+		if d.lang == "scala" {
+			blockType = "block"
+		}
+	}
+	block := newNode(blockType, p.toks[j].start, p.toks[m].end)
+	p.parseBody(block, j+1, m, bodyStatements)
+	parent.add(block)
+}
+
+func (p *braceParse) objectLiteral(parent *Node, j, m int) {
+	object := newNode(p.d.object, p.toks[j].start, p.toks[m].end)
+	parent.add(object)
+
+	start := j + 1
+	for i := start; i <= m; i++ {
+		if i < m && !p.toks[i].is(",") {
+			if p.toks[i].is("(") || p.toks[i].is("[") || p.toks[i].is("{") {
+				i = p.close(i, m)
+			}
+			continue
+		}
+		p.objectEntry(object, start, i)
+		start = i + 1
+	}
+}
+
+func (p *braceParse) objectEntry(object *Node, lo, hi int) {
+	lo = p.sig(lo, hi)
+	if lo >= hi {
+		return
+	}
+	last := p.lastSig(lo, hi)
+
+	key := lo
+	for key < hi && p.toks[key].kind == tokIdent && (p.toks[key].text == "async" || p.toks[key].text == "get" || p.toks[key].text == "set") && !p.at(key+1, hi, "(") && !p.at(key+1, hi, ":") {
+		key++
+	}
+	if p.at(key, hi, "*") {
+		key++
+	}
+
+	if key+1 < hi && (p.toks[key].kind == tokIdent || p.toks[key].kind == tokString || p.toks[key].kind == tokNumber) && p.toks[key+1].is(":") {
+		pair := newNode("pair", p.toks[lo].start, p.toks[last].end)
+		keyType := "property_identifier"
+		if p.toks[key].kind == tokString {
+			keyType = "string"
+		}
+		pair.addField("key", newNode(keyType, p.toks[key].start, p.toks[key].end))
+		p.scanExpr(pair, key+2, hi)
+		object.add(pair)
+		return
+	}
+
+	if key < hi && p.toks[key].kind == tokIdent && p.at(key+1, hi, "(") {
+		closeParams := p.close(key+1, hi)
+		if body := p.sig(closeParams+1, hi); p.at(body, hi, "{") {
+			method := newNode("method_definition", p.toks[lo].start, p.toks[last].end)
+			method.addField("name", newNode(p.d.property, p.toks[key].start, p.toks[key].end))
+			method.addField("parameters", newNode(p.d.params, p.toks[key+1].start, p.toks[closeParams].end))
+			m := p.close(body, hi)
+			block := newNode(p.d.block, p.toks[body].start, p.toks[m].end)
+			p.parseBody(block, body+1, m, bodyStatements)
+			method.addField("body", block)
+			object.add(method)
+			return
+		}
+	}
+
+	if lo == last && p.toks[lo].kind == tokIdent {
+		object.add(newNode("shorthand_property_identifier", p.toks[lo].start, p.toks[lo].end))
+		return
+	}
+	p.scanExpr(object, lo, hi)
+}
+
+func (p *braceParse) functionExpression(parent *Node, j, hi int) int {
+	d := p.d
+	open := j + 1
+	for open < hi && !p.toks[open].is("(") && !p.toks[open].is("{") {
+		open++
+	}
+	if !p.at(open, hi, "(") {
+		return j
+	}
+
+	closeParams := p.close(open, hi)
+	body := p.sig(closeParams+1, hi)
+	for body < hi && !p.toks[body].is("{") && !p.toks[body].is(";") {
+		body++
+	}
+	if !p.at(body, hi, "{") {
+		return closeParams
+	}
+
+	m := p.close(body, hi)
+	node := newNode(d.funcExpr, p.toks[j].start, p.toks[m].end)
+	if name := p.prevSig(open, j+1); name > j && p.toks[name].kind == tokIdent {
+		node.addField("name", newNode(d.ident, p.toks[name].start, p.toks[name].end))
+	}
+	node.addField("parameters", newNode(d.params, p.toks[open].start, p.toks[closeParams].end))
+	block := newNode(d.block, p.toks[body].start, p.toks[m].end)
+	p.parseBody(block, body+1, m, bodyStatements)
+	node.addField("body", block)
+	parent.add(node)
+	return m
+}
+
+func (p *braceParse) newExpression(parent *Node, j, hi int) int {
+	d := p.d
+	name := p.sig(j+1, hi)
+	if !p.isChainIdent(name, hi) {
+		return j
+	}
+
+	k := p.chainEnd(name, hi)
+	after := p.skipAngles(k+1, hi)
+	node := newNode(d.newExpr, p.toks[j].start, p.toks[k].end)
+	p.emitChain(node, name, k, "constructor")
+	if p.at(after, hi, "(") {
+		m := p.close(after, hi)
+		args := newNode(d.args, p.toks[after].start, p.toks[m].end)
+		p.scanExpr(args, after+1, m)
+		node.addField("arguments", args)
+		node.EndByte = p.toks[m].end
+		k = m
+		if p.at(m+1, hi, "{") {
+			body := p.close(m+1, hi)
+			classBody := newNode("class_body", p.toks[m+1].start, p.toks[body].end)
+			p.parseBody(classBody, m+2, body, bodyMembers)
+			node.add(classBody)
+			node.EndByte = p.toks[body].end
+			k = body
+		}
+	}
+	parent.add(node)
+	return k
+}
+
+// arrowFunction builds an arrow function whose parameters span [j, paramsEnd]
+// and whose "=>" follows paramsEnd.
+func (p *braceParse) arrowFunction(parent *Node, j, paramsEnd, hi int) int {
+	d := p.d
+	start := j
+	if prev := p.prevSig(j, 0); prev >= 0 && p.toks[prev].is("async") {
+		start = prev
+	}
+
+	node := newNode(d.arrow, p.toks[start].start, p.toks[paramsEnd].end)
+	if j == paramsEnd {
+		node.addField("parameter", newNode(d.ident, p.toks[j].start, p.toks[j].end))
+	} else {
+		node.addField("parameters", newNode(d.params, p.toks[j].start, p.toks[paramsEnd].end))
+	}
+
+	bodyStart := p.sig(paramsEnd+3, hi)
+	if bodyStart >= hi {
+		parent.add(node)
+		return paramsEnd + 2
+	}
+
+	if p.toks[bodyStart].is("{") {
+		m := p.close(bodyStart, hi)
+		block := newNode(d.block, p.toks[bodyStart].start, p.toks[m].end)
+		p.parseBody(block, bodyStart+1, m, bodyStatements)
+		node.addField("body", block)
+		node.EndByte = p.toks[m].end
+		parent.add(node)
+		return m
+	}
+
+	end := bodyStart
+	for ; end < hi; end++ {
+		tok := p.toks[end]
+		if tok.is(",") || tok.is(";") {
+			break
+		}
+		if tok.is("(") || tok.is("[") || tok.is("{") {
+			end = p.close(end, hi)
+		}
+	}
+	last := p.lastSig(bodyStart, end)
+	p.scanExpr(node, bodyStart, last+1)
+	node.EndByte = p.toks[last].end
+	parent.add(node)
+	return last
+}
@@ -0,0 +1,202 @@
+package indexer
+
+var elixirKeywords = wordSet("after", "and", "catch", "do", "else", "end", "false", "fn", "in", "nil", "not", "or", "rescue", "true", "when")
+
+var elixirSyntax = &scriptSyntax{
+	lex: &lexSpec{
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		tripleQuotes: true,
+		identSuffix:  "?!",
+	},
+	keywords:   elixirKeywords,
+	blocks:     elixirBlocks,
+	continuers: `\,=|+-*/<>.`,
+	leaders:    "|.",
+
+	call:      "call",
+	callee:    "target",
+	ident:     "identifier",
+	member:    "dot",
+	memberOps: []string{"."},
+	property:  "identifier",
+	args:      "arguments",
+	identType: elixirIdentType,
+	blockArgs: true,
+}
+
+func elixirBlocks(toks []token, i int) (opens, closes bool) {
+	if memberAccess(toks, i) {
+		return false, false
+	}
+
+	switch toks[i].text {
+	case "do", "fn":
+		return true, false
+	case "end":
+		return false, true
+	}
+	return false, false
+}
+
+func elixirIdentType(text string) string {
+	if text[0] >= 'A' && text[0] <= 'Z' {
+		return "alias"
+	}
+	return "identifier"
+}
+
+type elixirParse struct {
+	*scriptParse
+}
+
+// parseElixir follows tree-sitter-elixir, where definitions are plain calls:
+// defmodule Foo do ... end is a call with an alias argument and a do_block.
+func parseElixir(source []byte) (*Tree, error) {
+	p := &elixirParse{newScriptParse(elixirSyntax, source)}
+	p.opener = p.block
+	root := newNode("source", 0, len(source))
+	p.body(root, 0, len(p.toks))
+	return &Tree{Lang: "elixir", Source: source, Root: root, HasError: p.hasError}, nil
+}
+
+var elixirClauses = wordSet("else", "rescue", "catch", "after")
+
+// body adds the statements of [lo, hi) to parent. Statements following an
+// else/rescue/catch/after keyword are nested in an else_block (etc.).
+func (p *elixirParse) body(parent *Node, lo, hi int) {
+	target := parent
+	for _, stmt := range p.statements(lo, hi, nil) {
+		first := p.toks[stmt[0]]
+		if first.kind == tokIdent && elixirClauses[first.text] {
+			target = p.span(first.text+"_block", stmt[0], stmt[1])
+			parent.add(target)
+			if stmt[0] < stmt[1] {
+				target.add(p.statement(stmt[0]+1, stmt[1]))
+			}
+			continue
+		}
+
+		node := p.statement(stmt[0], stmt[1])
+		target.add(node)
+		target.EndByte = max(target.EndByte, node.EndByte)
+	}
+}
+
+func (p *elixirParse) statement(lo, last int) *Node {
+	if p.toks[lo].is("@") && lo < last && p.toks[lo+1].kind == tokIdent {
+		node := p.span("unary_operator", lo, last)
+		return node.addField("operand", p.statement(lo+1, last))
+	}
+
+	if call := p.command(lo, last); call != nil {
+		return call
+	}
+
+	holder := p.span("binary_operator", lo, last)
+	p.scanCalls(holder, lo, last+1)
+	if len(holder.Children) == 1 && !p.hasOperator(lo, last) {
+		only := holder.Children[0]
+		only.Parent = nil
+		only.StartByte, only.EndByte = holder.StartByte, holder.EndByte
+		return only
+	}
+	return holder
+}
+
+// hasOperator reports whether [lo, last] has a top-level operator such as =
+// or |>.
+func (p *elixirParse) hasOperator(lo, last int) bool {
+	for j := lo; j <= last; j++ {
+		if m := p.match[j]; m > j && m <= last {
+			j = m
+			continue
+		}
+		if tok := p.toks[j]; tok.kind == tokPunct && !tok.is(".") || tok.is("and") || tok.is("or") || tok.is("in") {
+			return true
+		}
+	}
+	return false
+}
+
+// command builds a call statement: foo(args), foo args, either optionally
+// followed by a do ... end block.
+func (p *elixirParse) command(lo, last int) *Node {
+	if !p.isWord(lo, last+1) {
+		return nil
+	}
+
+	k := p.chainEnd(lo, last+1)
+	after := k + 1
+	call := p.span("call", lo, last)
+	p.callee(call, lo, k)
+
+	switch {
+	case p.at(after, last+1, "(") && p.adjacent(k, after):
+		m := p.close(after, last+1)
+		args := p.span("arguments", after, m)
+		p.scanCalls(args, after+1, m)
+		call.addField("arguments", args)
+		if m < last {
+			if p.hasOperator(m+1, last) && !p.isOpener(m+1) {
+				return nil
+			}
+			p.scanCalls(call, m+1, last+1)
+		}
+		return call
+	case after <= last && p.argumentStart(after):
+		end := last
+		for j := after; j <= last; j++ {
+			if p.isOpener(j) && p.toks[j].is("do") {
+				end = j - 1
+				break
+			}
+			if m := p.match[j]; m > j && m <= last {
+				j = m
+			}
+		}
+		if end >= after {
+			args := p.span("arguments", after, end)
+			p.scanCalls(args, after, end+1)
+			call.addField("arguments", args)
+		}
+		if end < last {
+			p.scanCalls(call, end+1, last+1)
+		}
+		return call
+	case after <= last && p.isOpener(after) && p.toks[after].is("do"):
+		p.scanCalls(call, after, last+1)
+		return call
+	}
+	return nil
+}
+
+// argumentStart reports whether toks[i] can begin the arguments of a call
+// without parentheses (IO.puts "x", defmodule Foo do).
+func (p *elixirParse) argumentStart(i int) bool {
+	tok := p.toks[i]
+	switch tok.kind {
+	case tokString, tokNumber:
+		return true
+	case tokIdent:
+		return !elixirKeywords[tok.text] || tok.is("fn") || tok.is("true") || tok.is("false") || tok.is("nil")
+	case tokPunct:
+		return tok.is(":") || tok.is("[") || tok.is("{") || tok.is("%") || tok.is("&") || tok.is("@") || tok.is("^")
+	}
+	return false
+}
+
+// block builds a do_block or anonymous_function for the opener at j and
+// returns the index of its "end".
+func (p *elixirParse) block(parent *Node, j, hi int) int {
+	end := p.close(j, hi)
+	nodeType := "do_block"
+	if p.toks[j].is("fn") {
+		nodeType = "anonymous_function"
+	}
+
+	node := p.span(nodeType, j, end)
+	p.body(node, j+1, end)
+	parent.add(node)
+	return end
+}
@@ -0,0 +1,229 @@
+package indexer
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	gotoken "go/token"
+)
+
+// parseGo maps the go/ast tree onto tree-sitter-go node types. Declarations
+// start at their keyword (doc comments are separate), matching tree-sitter.
+func parseGo(source []byte) (*Tree, error) {
+	tree := &Tree{Lang: "go", Source: source, Root: newNode("source_file", 0, len(source))}
+
+	fset := gotoken.NewFileSet()
+	file, err := parser.ParseFile(fset, "", source, parser.SkipObjectResolution)
+	if err != nil {
+		tree.HasError = true
+	}
+	if file == nil {
+		return tree, nil
+	}
+	// Without a package clause go/parser gives up before recording any
+	// position, so there is nothing to map.
+	if file.Package == gotoken.NoPos {
+		if err == nil {
+			err = errors.New("missing package clause")
+		}
+		return nil, err
+	}
+
+	base := fset.File(file.Package).Base()
+	offset := func(pos gotoken.Pos) int {
+		return min(max(int(pos)-base, 0), len(source))
+	}
+
+	// stack holds the mapped node for each open ast node (nil when the ast
+	// node has no tree-sitter counterpart).
+	stack := []*Node{tree.Root}
+	parentNode := func() *Node {
+		for i := len(stack) - 1; i >= 0; i-- {
+			if stack[i] != nil {
+				return stack[i]
+			}
+		}
+		return tree.Root
+	}
+
+	if file.Name != nil && file.Name.Name != "_" {
+		clause := newNode("package_clause", offset(file.Package), offset(file.Name.End()))
+		tree.Root.add(clause.add(newNode("package_identifier", offset(file.Name.Pos()), offset(file.Name.End()))))
+	}
+
+	fields := map[ast.Node]string{}
+	identTypes := map[*ast.Ident]string{}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+
+		if n == file {
+			stack = append(stack, nil)
+			return true
+		}
+		if n == file.Name {
+			return false
+		}
+
+		noteGoFields(n, fields, identTypes)
+
+		nodeType := goNodeType(n, identTypes)
+		if nodeType == "" {
+			stack = append(stack, nil)
+			return true
+		}
+
+		node := newNode(nodeType, offset(n.Pos()), offset(n.End()))
+		parentNode().addField(fields[n], node)
+		stack = append(stack, node)
+		return true
+	})
+
+	return tree, nil
+}
+
+func noteGoFields(n ast.Node, fields map[ast.Node]string, identTypes map[*ast.Ident]string) {
+	switch n := n.(type) {
+	case *ast.FuncDecl:
+		fields[n.Name] = "name"
+		if n.Recv != nil {
+			fields[n.Recv] = "receiver"
+			identTypes[n.Name] = "field_identifier"
+		}
+		fields[n.Type.Params] = "parameters"
+		if n.Type.Results != nil {
+			fields[n.Type.Results] = "result"
+		}
+		if n.Body != nil {
+			fields[n.Body] = "body"
+		}
+	case *ast.TypeSpec:
+		fields[n.Name] = "name"
+		fields[n.Type] = "type"
+		identTypes[n.Name] = "type_identifier"
+	case *ast.ValueSpec:
+		for _, name := range n.Names {
+			fields[name] = "name"
+		}
+	case *ast.CallExpr:
+		fields[n.Fun] = "function"
+	case *ast.SelectorExpr:
+		fields[n.Sel] = "field"
+		identTypes[n.Sel] = "field_identifier"
+	case *ast.FuncLit:
+		if n.Body != nil {
+			fields[n.Body] = "body"
+		}
+	case *ast.Field:
+		for _, name := range n.Names {
+			fields[name] = "name"
+		}
+	}
+}
+
+var goLiteralTypes = map[gotoken.Token]string{
+	gotoken.INT:    "int_literal",
+	gotoken.FLOAT:  "float_literal",
+	gotoken.IMAG:   "imaginary_literal",
+	gotoken.CHAR:   "rune_literal",
+	gotoken.STRING: "interpreted_string_literal",
+}
+
+func goNodeType(n ast.Node, identTypes map[*ast.Ident]string) string {
+	switch n := n.(type) {
+	case *ast.FuncDecl:
+		if n.Recv != nil {
+			return "method_declaration"
+		}
+		return "function_declaration"
+	case *ast.GenDecl:
+		switch n.Tok {
+		case gotoken.IMPORT:
+			return "import_declaration"
+		case gotoken.CONST:
+			return "const_declaration"
+		case gotoken.VAR:
+			return "var_declaration"
+		case gotoken.TYPE:
+			return "type_declaration"
+		}
+	case *ast.ImportSpec:
+		return "import_spec"
+	case *ast.TypeSpec:
+		return "type_spec"
+	case *ast.ValueSpec:
+		return "var_spec"
+	case *ast.FieldList:
+		return "parameter_list"
+	case *ast.Field:
+		return "parameter_declaration"
+	case *ast.BlockStmt:
+		return "block"
+	case *ast.IfStmt:
+		return "if_statement"
+	case *ast.ForStmt, *ast.RangeStmt:
+		return "for_statement"
+	case *ast.SwitchStmt:
+		return "expression_switch_statement"
+	case *ast.TypeSwitchStmt:
+		return "type_switch_statement"
+	case *ast.SelectStmt:
+		return "select_statement"
+	case *ast.CaseClause:
+		if n.List == nil {
+			return "default_case"
+		}
+		return "expression_case"
+	case *ast.CommClause:
+		return "communication_case"
+	case *ast.ReturnStmt:
+		return "return_statement"
+	case *ast.DeferStmt:
+		return "defer_statement"
+	case *ast.GoStmt:
+		return "go_statement"
+	case *ast.ExprStmt:
+		return "expression_statement"
+	case *ast.AssignStmt:
+		if n.Tok == gotoken.DEFINE {
+			return "short_var_declaration"
+		}
+		return "assignment_statement"
+	case *ast.IncDecStmt:
+		if n.Tok == gotoken.INC {
+			return "inc_statement"
+		}
+		return "dec_statement"
+	case *ast.SendStmt:
+		return "send_statement"
+	case *ast.LabeledStmt:
+		return "labeled_statement"
+	case *ast.BranchStmt:
+		return n.Tok.String() + "_statement"
+	case *ast.CallExpr:
+		return "call_expression"
+	case *ast.FuncLit:
+		return "func_literal"
+	case *ast.CompositeLit:
+		return "composite_literal"
+	case *ast.SelectorExpr:
+		return "selector_expression"
+	case *ast.StructType:
+		return "struct_type"
+	case *ast.InterfaceType:
+		return "interface_type"
+	case *ast.BasicLit:
+		return goLiteralTypes[n.Kind]
+	case *ast.Ident:
+		if nodeType, ok := identTypes[n]; ok {
+			return nodeType
+		}
+		return "identifier"
+	case *ast.BadDecl, *ast.BadExpr, *ast.BadStmt:
+		return "ERROR"
+	}
+	return ""
+}
@@ -0,0 +1,469 @@
+package indexer
+
+import "unicode"
+
+// The Haskell and OCaml parsers split declarations by layout: a declaration
+// starts at a token that begins a line at the enclosing block's indentation,
+// and everything indented further belongs to it.
+
+var haskellSyntax = &scriptSyntax{
+	lex: &lexSpec{
+		lineComments:  []string{"--"},
+		blockComments: [][2]string{{"{-", "-}"}},
+		quotes:        `"'`,
+		identSuffix:   "'",
+	},
+	keywords: wordSet("case", "class", "data", "deriving", "do", "else", "if", "import", "in", "infix", "infixl", "infixr", "instance", "let", "module", "newtype", "of", "then", "type", "where"),
+}
+
+var ocamlSyntax = &scriptSyntax{
+	lex: &lexSpec{
+		blockComments: [][2]string{{"(*", "*)"}},
+		quotes:        `"'`,
+		lifetimes:     true,
+	},
+	keywords: wordSet("and", "as", "begin", "class", "do", "done", "else", "end", "exception", "external", "for", "fun", "function", "functor", "if", "in", "include", "inherit", "let", "match", "method", "module", "mutable", "of", "open", "rec", "sig", "struct", "then", "to", "try", "type", "val", "when", "while", "with"),
+	blocks:   ocamlBlocks,
+}
+
+func ocamlBlocks(toks []token, i int) (opens, closes bool) {
+	switch toks[i].text {
+	case "struct", "sig", "begin", "object", "do":
+		return true, false
+	case "end", "done":
+		return false, true
+	}
+	return false, false
+}
+
+type layoutParse struct {
+	*scriptParse
+}
+
+// items splits [lo, hi) into declarations that start at tokens beginning a
+// line at column col or less. split may force extra boundaries.
+func (p *layoutParse) items(lo, hi, col int, starts func(i int) bool) [][2]int {
+	var out [][2]int
+	start := -1
+	for j := lo; j < hi; j++ {
+		if p.toks[j].kind == tokNewline {
+			continue
+		}
+
+		lineStart := j == 0 || p.toks[j-1].kind == tokNewline
+		if start < 0 || lineStart && p.column(j) <= col && (starts == nil || starts(j)) {
+			if start >= 0 {
+				out = append(out, [2]int{start, p.lastSig(start, j)})
+			}
+			start = j
+		}
+		if m := p.match[j]; m > j && m < hi {
+			j = m
+		}
+	}
+	if start >= 0 {
+		out = append(out, [2]int{start, p.lastSig(start, hi)})
+	}
+	return out
+}
+
+// topLevel returns the index of the first depth-0 token in [lo, last]
+// satisfying want, or -1.
+func (p *layoutParse) topLevel(lo, last int, want func(i int) bool) int {
+	for j := lo; j <= last; j++ {
+		if want(j) {
+			return j
+		}
+		if m := p.match[j]; m > j && m <= last {
+			j = m
+		}
+	}
+	return -1
+}
+
+func (p *layoutParse) doubleColon(i int) bool {
+	return p.toks[i].is(":") && i+1 < len(p.toks) && p.toks[i+1].is(":") && p.adjacent(i, i+1)
+}
+
+func upperWord(text string) bool {
+	for _, r := range text {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
+
+// parseHaskell follows tree-sitter-haskell: a header, an imports node and a
+// declarations node holding function, bind, signature, data_type and class
+// declarations.
+func parseHaskell(source []byte) (*Tree, error) {
+	p := &layoutParse{newScriptParse(haskellSyntax, source)}
+	root := newNode("haskell", 0, len(source))
+
+	lo := p.sig(0, len(p.toks))
+	if p.at(lo, len(p.toks), "module") {
+		where := lo
+		for where < len(p.toks) && !p.toks[where].is("where") {
+			where++
+		}
+		if where == len(p.toks) {
+			p.hasError = true
+			where--
+		}
+		header := p.span("header", lo, where)
+		if lo+1 < where {
+			header.addField("module", p.span("module", lo+1, p.chainEndDotted(lo+1, where)))
+		}
+		root.add(header)
+		lo = where + 1
+	}
+
+	var imports, declarations *Node
+	first := p.sig(lo, len(p.toks))
+	if first >= len(p.toks) {
+		return &Tree{Lang: "haskell", Source: source, Root: root, HasError: p.hasError}, nil
+	}
+	for _, item := range p.items(first, len(p.toks), p.column(first), nil) {
+		if p.toks[item[0]].is("import") {
+			if imports == nil {
+				imports = p.span("imports", item[0], item[1])
+				root.add(imports)
+			}
+			imports.add(p.haskellImport(item[0], item[1]))
+			imports.EndByte = p.toks[item[1]].end
+			continue
+		}
+
+		if declarations == nil {
+			declarations = p.span("declarations", item[0], item[1])
+			root.add(declarations)
+		}
+		declarations.add(p.haskellDecl(item[0], item[1]))
+		declarations.EndByte = p.toks[item[1]].end
+	}
+	return &Tree{Lang: "haskell", Source: source, Root: root, HasError: p.hasError}, nil
+}
+
+// chainEndDotted returns the last token of a dotted module name at i.
+func (p *layoutParse) chainEndDotted(i, hi int) int {
+	for i+2 < hi && p.toks[i+1].is(".") && p.adjacent(i, i+1) && p.toks[i+2].kind == tokIdent {
+		i += 2
+	}
+	return i
+}
+
+func (p *layoutParse) haskellImport(lo, last int) *Node {
+	node := p.span("import", lo, last)
+	name := lo + 1
+	if p.at(name, last+1, "qualified") {
+		name++
+	}
+	if name <= last && p.toks[name].kind == tokIdent {
+		node.addField("module", p.span("module", name, p.chainEndDotted(name, last+1)))
+	}
+	return node
+}
+
+var haskellDecls = map[string]string{
+	"data":     "data_type",
+	"newtype":  "newtype",
+	"type":     "type_synomym",
+	"class":    "class",
+	"instance": "instance",
+	"deriving": "deriving_instance",
+	"infix":    "fixity",
+	"infixl":   "fixity",
+	"infixr":   "fixity",
+}
+
+func (p *layoutParse) haskellDecl(lo, last int) *Node {
+	tok := p.toks[lo]
+	if nodeType, ok := haskellDecls[tok.text]; ok && tok.kind == tokIdent {
+		node := p.span(nodeType, lo, last)
+		if name := p.haskellTypeName(lo+1, last); name >= 0 {
+			node.addField("name", p.span("name", name, name))
+		}
+		if nodeType == "class" || nodeType == "instance" {
+			if where := p.topLevel(lo, last, func(i int) bool { return p.toks[i].is("where") }); where >= 0 && where < last {
+				p.nested(node, nodeType+"_declarations", "declarations", where+1, last)
+			}
+		}
+		return node
+	}
+
+	if sig := p.topLevel(lo, last, p.doubleColon); sig >= 0 && p.topLevel(lo, sig, func(i int) bool { return p.toks[i].is("=") }) < 0 {
+		node := p.span("signature", lo, last)
+		if tok.kind == tokIdent {
+			node.addField("name", p.span("variable", lo, lo))
+		}
+		return node
+	}
+
+	// Equations: name patterns | guards = body [where binds]
+	rhs := p.topLevel(lo, last, func(i int) bool { return p.toks[i].is("=") || p.toks[i].is("|") })
+	nodeType := "bind"
+	if rhs > lo+1 || rhs < 0 {
+		nodeType = "function"
+	}
+	node := p.span(nodeType, lo, last)
+
+	name := lo
+	if tok.is("(") && p.match[lo] > lo {
+		// (<+>) a b = ...
+		name = p.match[lo]
+		node.addField("name", p.span("prefix_id", lo, name))
+	} else if tok.kind == tokIdent {
+		node.addField("name", p.span("variable", lo, lo))
+	}
+	if nodeType == "function" && rhs > name+1 {
+		node.addField("patterns", p.span("patterns", name+1, rhs-1))
+	}
+	if rhs >= 0 {
+		matchEnd := last
+		where := p.topLevel(rhs, last, func(i int) bool { return p.toks[i].is("where") })
+		if where > rhs {
+			matchEnd = p.lastSig(rhs, where)
+		}
+		node.addField("match", p.span("match", rhs, matchEnd))
+		if where > rhs && where < last {
+			p.nested(node, "local_binds", "binds", where+1, last)
+		}
+	}
+	return node
+}
+
+// haskellTypeName returns the declared type or class name in [lo, last]:
+// the first upper-case word before "=" or "where", skipping contexts.
+func (p *layoutParse) haskellTypeName(lo, last int) int {
+	context := p.topLevel(lo, last, func(i int) bool {
+		return p.toks[i].is("=") && i+1 <= last && p.toks[i+1].is(">") && p.adjacent(i, i+1)
+	})
+	if context >= 0 {
+		lo = context + 2
+	}
+
+	for j := lo; j <= last; j++ {
+		tok := p.toks[j]
+		if tok.is("=") || tok.is("where") {
+			return -1
+		}
+		if tok.kind == tokIdent && upperWord(tok.text) {
+			return j
+		}
+	}
+	return -1
+}
+
+// nested parses the indented declarations in [lo, last] into a node of the
+// given type attached to parent under field.
+func (p *layoutParse) nested(parent *Node, nodeType, field string, lo, last int) {
+	first := p.sig(lo, last+1)
+	if first > last {
+		return
+	}
+
+	block := p.span(nodeType, first, last)
+	for _, item := range p.items(first, last+1, p.column(first), nil) {
+		block.add(p.haskellDecl(item[0], item[1]))
+	}
+	parent.addField(field, block)
+}
+
+// parseOCaml follows tree-sitter-ocaml: structure items (value_definition,
+// type_definition, module_definition, ...) at the top of a compilation_unit
+// and inside struct ... end / sig ... end.
+func parseOCaml(source []byte) (*Tree, error) {
+	p := &layoutParse{newScriptParse(ocamlSyntax, source)}
+	root := newNode("compilation_unit", 0, len(source))
+	p.structure(root, 0, len(p.toks))
+	return &Tree{Lang: "ocaml", Source: source, Root: root, HasError: p.hasError}, nil
+}
+
+var ocamlItems = wordSet("let", "type", "module", "open", "include", "val", "external", "exception", "class")
+
+func (p *layoutParse) structure(parent *Node, lo, hi int) {
+	first := p.sig(lo, hi)
+	if first >= hi {
+		return
+	}
+
+	col := p.column(first)
+	starts := func(i int) bool {
+		return ocamlItems[p.toks[i].text] && p.toks[i].kind == tokIdent || i > 0 && p.toks[i-1].is(";") && i > 1 && p.toks[i-2].is(";")
+	}
+	for _, item := range p.items(first, hi, col, starts) {
+		lo, last := item[0], item[1]
+		// Explicit ;; terminators are not part of the item.
+		for last > lo && p.toks[last].is(";") {
+			last--
+		}
+		if p.toks[lo].is(";") {
+			continue
+		}
+		parent.add(p.ocamlItem(lo, last))
+	}
+}
+
+func (p *layoutParse) ocamlItem(lo, last int) *Node {
+	tok := p.toks[lo]
+	switch tok.text {
+	case "let":
+		node := p.span("value_definition", lo, last)
+		start := lo + 1
+		if p.at(start, last+1, "rec") {
+			start++
+		}
+		for _, binding := range p.bindings(start, last, true) {
+			node.add(p.letBinding(binding[0], binding[1]))
+		}
+		return node
+	case "type":
+		node := p.span("type_definition", lo, last)
+		start := lo + 1
+		if p.at(start, last+1, "nonrec") {
+			start++
+		}
+		for _, binding := range p.bindings(start, last, false) {
+			typeBinding := p.span("type_binding", binding[0], binding[1])
+			if name := p.typeName(binding[0], binding[1]); name >= 0 {
+				typeBinding.addField("name", p.span("type_constructor", name, name))
+			}
+			node.add(typeBinding)
+		}
+		return node
+	case "module":
+		return p.moduleItem(lo, last)
+	case "open", "include":
+		node := p.span(tok.text+"_module", lo, last)
+		if lo < last {
+			node.addField("module", p.span("module_path", lo+1, last))
+		}
+		return node
+	case "val", "external":
+		nodeType := "value_specification"
+		if tok.text == "external" {
+			nodeType = "external"
+		}
+		node := p.span(nodeType, lo, last)
+		if lo < last {
+			node.add(p.span("value_name", lo+1, lo+1))
+		}
+		return node
+	case "exception":
+		node := p.span("exception_definition", lo, last)
+		if lo < last {
+			decl := p.span("constructor_declaration", lo+1, last)
+			node.add(decl.add(p.span("constructor_name", lo+1, lo+1)))
+		}
+		return node
+	case "class":
+		node := p.span("class_definition", lo, last)
+		binding := p.span("class_binding", lo+1, last)
+		if name := p.topLevel(lo+1, last, func(i int) bool { return p.toks[i].kind == tokIdent && !p.syn.keywords[p.toks[i].text] }); name >= 0 {
+			binding.addField("name", p.span("class_name", name, name))
+		}
+		return node.add(binding)
+	}
+	return p.span("expression_item", lo, last)
+}
+
+// bindings splits "a = ... and b = ..." at top-level "and", ignoring the
+// "and" of nested let ... in expressions when lets is set.
+func (p *layoutParse) bindings(lo, last int, lets bool) [][2]int {
+	var out [][2]int
+	start, depth := lo, 0
+	for j := lo; j <= last; j++ {
+		tok := p.toks[j]
+		switch {
+		case lets && tok.is("let"):
+			depth++
+		case lets && tok.is("in") && depth > 0:
+			depth--
+		case tok.is("and") && depth == 0:
+			if j > start {
+				out = append(out, [2]int{start, p.lastSig(start, j)})
+			}
+			start = j + 1
+		}
+		if m := p.match[j]; m > j && m <= last {
+			j = m
+		}
+	}
+	if start <= last {
+		out = append(out, [2]int{p.sig(start, last+1), last})
+	}
+	return out
+}
+
+func (p *layoutParse) letBinding(lo, last int) *Node {
+	node := p.span("let_binding", lo, last)
+	tok := p.toks[lo]
+	switch {
+	case tok.kind == tokIdent && !upperWord(tok.text):
+		node.addField("pattern", p.span("value_name", lo, lo))
+	case tok.is("(") && p.match[lo] == lo+1:
+		node.addField("pattern", p.span("unit", lo, lo+1))
+	}
+
+	return node
+}
+
+// typeName returns the constructor name of a type binding, skipping type
+// parameters such as 'a or ('a, 'b).
+func (p *layoutParse) typeName(lo, last int) int {
+	for j := lo; j <= last; j++ {
+		tok := p.toks[j]
+		switch {
+		case tok.is("(") && p.match[j] > j:
+			j = p.match[j]
+		case tok.kind == tokIdent && tok.text[0] != '\'':
+			return j
+		case tok.is("="):
+			return -1
+		}
+	}
+	return -1
+}
+
+func (p *layoutParse) moduleItem(lo, last int) *Node {
+	nameIdx := lo + 1
+	if p.at(nameIdx, last+1, "type") {
+		node := p.span("module_type_definition", lo, last)
+		nameIdx++
+		if nameIdx <= last {
+			node.addField("name", p.span("module_type_name", nameIdx, nameIdx))
+		}
+		p.moduleBody(node, nameIdx, last)
+		return node
+	}
+
+	node := p.span("module_definition", lo, last)
+	if p.at(nameIdx, last+1, "rec") {
+		nameIdx++
+	}
+	binding := p.span("module_binding", nameIdx, last)
+	if nameIdx <= last {
+		binding.addField("name", p.span("module_name", nameIdx, nameIdx))
+	}
+	p.moduleBody(binding, nameIdx, last)
+	return node.add(binding)
+}
+
+// moduleBody adds the struct ... end or sig ... end body found after the
+// module name at name.
+func (p *layoutParse) moduleBody(parent *Node, name, last int) {
+	open := p.topLevel(name, last, func(i int) bool {
+		return (p.toks[i].is("struct") || p.toks[i].is("sig")) && p.match[i] > i
+	})
+	if open < 0 {
+		return
+	}
+
+	end := p.close(open, last+1)
+	nodeType := "structure"
+	if p.toks[open].is("sig") {
+		nodeType = "signature"
+	}
+	body := p.span(nodeType, open, end)
+	p.structure(body, open+1, end)
+	parent.addField("body", body)
+}
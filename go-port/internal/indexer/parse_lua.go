@@ -0,0 +1,193 @@
+package indexer
+
+var luaSyntax = &scriptSyntax{
+	lex: &lexSpec{
+		lineComments: []string{"--"},
+		quotes:       `"'`,
+		longBrackets: true,
+	},
+	keywords: wordSet("and", "break", "do", "else", "elseif", "end", "false", "for", "function", "goto", "if", "in", "local", "nil", "not", "or", "repeat", "return", "then", "true", "until", "while"),
+	blocks:   luaBlocks,
+	// Lua statements need no separators; a newline after an operator or
+	// comma never ends one.
+	continuers: ",=+-*/%^<>~.&|#",
+
+	call:         "function_call",
+	callee:       "name",
+	ident:        "identifier",
+	member:       "dot_index_expression",
+	memberOps:    []string{".", ":"},
+	methodMember: "method_index_expression",
+	property:     "identifier",
+	args:         "arguments",
+	table:        "table_constructor",
+	bareArgs:     true,
+}
+
+func luaBlocks(toks []token, i int) (opens, closes bool) {
+	if memberAccess(toks, i) {
+		return false, false
+	}
+
+	switch toks[i].text {
+	case "end", "until":
+		return false, true
+	case "function", "if", "while", "for", "repeat":
+		return true, false
+	case "do":
+		return !loopDo(toks, i, "while", "for"), false
+	}
+	return false, false
+}
+
+var luaStatements = map[string]string{
+	"if":     "if_statement",
+	"while":  "while_statement",
+	"for":    "for_statement",
+	"repeat": "repeat_statement",
+	"do":     "do_statement",
+	"return": "return_statement",
+	"break":  "break_statement",
+	"goto":   "goto_statement",
+}
+
+type luaParse struct {
+	*scriptParse
+}
+
+// parseLua builds tree-sitter-lua statements: function declarations,
+// local variable declarations, assignments and function calls.
+func parseLua(source []byte) (*Tree, error) {
+	p := &luaParse{newScriptParse(luaSyntax, source)}
+	p.opener = p.expressionBlock
+	root := newNode("chunk", 0, len(source))
+	p.body(root, 0, len(p.toks))
+	return &Tree{Lang: "lua", Source: source, Root: root, HasError: p.hasError}, nil
+}
+
+func (p *luaParse) body(parent *Node, lo, hi int) {
+	for _, stmt := range p.statements(lo, hi, nil) {
+		parent.add(p.statement(stmt[0], stmt[1]))
+	}
+}
+
+// block wraps the statements of [lo, hi) in a block node.
+func (p *luaParse) block(parent *Node, lo, hi int) {
+	stmts := p.statements(lo, hi, nil)
+	if len(stmts) == 0 {
+		return
+	}
+
+	block := newNode("block", p.toks[stmts[0][0]].start, p.toks[stmts[len(stmts)-1][1]].end)
+	for _, stmt := range stmts {
+		block.add(p.statement(stmt[0], stmt[1]))
+	}
+	parent.addField("body", block)
+}
+
+func (p *luaParse) statement(lo, last int) *Node {
+	tok := p.toks[lo]
+	head := lo
+	if tok.is("local") && lo < last {
+		head++
+	}
+
+	if p.toks[head].is("function") && p.isOpener(head) {
+		return p.function("function_declaration", lo, head, p.close(head, last+1))
+	}
+
+	if nodeType, ok := luaStatements[tok.text]; ok && tok.kind == tokIdent {
+		node := p.span(nodeType, lo, last)
+		if p.isOpener(lo) {
+			end := p.close(lo, last+1)
+			header := p.headerEnd(lo, end)
+			p.scanCalls(node, lo+1, header)
+			p.block(node, header+1, end)
+			// repeat ... until cond
+			p.scanCalls(node, end+1, last+1)
+		} else {
+			p.scanCalls(node, lo+1, last+1)
+		}
+		return node
+	}
+
+	if tok.is("local") {
+		node := p.span("variable_declaration", lo, last)
+		target := node
+		if p.assignment(lo, last+1) >= 0 {
+			target = p.span("assignment_statement", head, last)
+			node.add(target)
+		}
+		p.scanCalls(target, head, last+1)
+		return node
+	}
+
+	if p.assignment(lo, last+1) >= 0 {
+		node := p.span("assignment_statement", lo, last)
+		p.scanCalls(node, lo, last+1)
+		return node
+	}
+
+	holder := p.span("expression_statement", lo, last)
+	p.scanCalls(holder, lo, last+1)
+	if len(holder.Children) == 1 {
+		// Calls are statements in their own right.
+		only := holder.Children[0]
+		only.Parent = nil
+		return only
+	}
+	return holder
+}
+
+// headerEnd returns the "then" or "do" ending the header of the block at j,
+// or j itself for blocks without a header.
+func (p *luaParse) headerEnd(j, end int) int {
+	if p.toks[j].is("repeat") || p.toks[j].is("do") {
+		return j
+	}
+	for i := j + 1; i < end; i++ {
+		if p.toks[i].is("then") || p.toks[i].is("do") {
+			return i
+		}
+		if m := p.match[i]; m > i && m < end {
+			i = m
+		}
+	}
+	return j
+}
+
+// function builds a function declaration or definition whose "function"
+// keyword is at head and whose "end" is at end.
+func (p *luaParse) function(nodeType string, lo, head, end int) *Node {
+	node := p.span(nodeType, lo, end)
+	open := head + 1
+	if p.isWord(open, end) {
+		k := p.chainEnd(open, end)
+		p.chain(node, open, k, "name")
+		open = k + 1
+	}
+
+	bodyStart := open
+	if p.at(open, end, "(") {
+		m := p.close(open, end)
+		node.addField("parameters", p.span("parameters", open, m))
+		bodyStart = m + 1
+	}
+	p.block(node, bodyStart, end)
+	return node
+}
+
+// expressionBlock handles keyword blocks inside expressions, i.e. anonymous
+// functions.
+func (p *luaParse) expressionBlock(parent *Node, j, hi int) int {
+	end := p.close(j, hi)
+	if p.toks[j].is("function") {
+		parent.add(p.function("function_definition", j, j, end))
+		return end
+	}
+
+	node := p.span(luaStatements[p.toks[j].text], j, end)
+	p.scanCalls(node, j+1, end)
+	parent.add(node)
+	return end
+}
@@ -0,0 +1,412 @@
+package indexer
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// mdLine is one source line inside a container: start is where the
+// container's content begins (after any "> " or list indentation), end
+// includes the trailing newline.
+type mdLine struct {
+	start int
+	end   int
+}
+
+var (
+	mdATXHeading = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]|$)`)
+	mdFence      = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	mdThematic   = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	mdSetext     = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	mdListItem   = regexp.MustCompile(`^( {0,3})([-+*]|\d{1,9}[.)])(?:[ \t]+|$)`)
+	mdQuote      = regexp.MustCompile(`^ {0,3}> ?`)
+	mdHTMLBlock  = regexp.MustCompile(`^ {0,3}</?[A-Za-z][A-Za-z0-9-]*(?:[ \t/>]|$)|^ {0,3}<!--`)
+	mdLinkRef    = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:[ \t]*\S`)
+	mdTableDelim = regexp.MustCompile(`^ {0,3}\|?[ \t]*:?-+:?[ \t]*(?:\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+)
+
+var mdListMarkers = map[byte]string{
+	'-': "list_marker_minus",
+	'+': "list_marker_plus",
+	'*': "list_marker_star",
+	'.': "list_marker_dot",
+	')': "list_marker_parenthesis",
+}
+
+type markdownParse struct {
+	src []byte
+}
+
+// parseMarkdown builds a tree-sitter-markdown document: blocks are grouped
+// into section nodes nested by heading level, each section running up to
+// the next heading of the same or a higher level.
+func parseMarkdown(source []byte) (*Tree, error) {
+	p := &markdownParse{src: source}
+
+	var lines []mdLine
+	for start := 0; start < len(source); {
+		end := len(source)
+		if i := bytes.IndexByte(source[start:], '\n'); i >= 0 {
+			end = start + i + 1
+		}
+		lines = append(lines, mdLine{start, end})
+		start = end
+	}
+
+	root := newNode("document", 0, len(source))
+	p.sections(root, p.blocks(lines))
+	return &Tree{Lang: "markdown", Source: source, Root: root}, nil
+}
+
+// sections nests the top-level blocks under section nodes.
+func (p *markdownParse) sections(root *Node, blocks []*Node) {
+	type open struct {
+		level   int
+		section *Node
+	}
+	var stack []open
+	closeTo := func(level, end int) {
+		for len(stack) > 0 && stack[len(stack)-1].level >= level {
+			stack[len(stack)-1].section.EndByte = end
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	for _, block := range blocks {
+		level := p.headingLevel(block)
+		if level > 0 {
+			closeTo(level, block.StartByte)
+		}
+		if level > 0 || len(stack) == 0 {
+			section := newNode("section", block.StartByte, block.EndByte)
+			if len(stack) == 0 {
+				root.add(section)
+			} else {
+				stack[len(stack)-1].section.add(section)
+			}
+			stack = append(stack, open{level, section})
+		}
+		stack[len(stack)-1].section.add(block)
+	}
+	closeTo(0, len(p.src))
+}
+
+func (p *markdownParse) headingLevel(block *Node) int {
+	switch block.Type {
+	case "atx_heading":
+		return int(block.Children[0].Type[len("atx_h")] - '0')
+	case "setext_heading":
+		if block.ChildOfType("setext_h1_underline") != nil {
+			return 1
+		}
+		return 2
+	}
+	return 0
+}
+
+func (p *markdownParse) text(line mdLine) []byte {
+	return bytes.TrimRight(p.src[line.start:line.end], "\r\n")
+}
+
+func (p *markdownParse) blank(line mdLine) bool {
+	return len(bytes.TrimSpace(p.src[line.start:line.end])) == 0
+}
+
+// indentEnd returns the offset of the first non-blank byte of line.
+func (p *markdownParse) indentEnd(line mdLine) int {
+	i := line.start
+	for i < line.end && (p.src[i] == ' ' || p.src[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+// inline returns the inline node for a line's content without its newline.
+func (p *markdownParse) inline(start, end int) *Node {
+	for end > start && isSpace(p.src[end-1]) {
+		end--
+	}
+	return newNode("inline", start, end)
+}
+
+// interrupts reports whether line starts a block that ends a paragraph.
+func (p *markdownParse) interrupts(line mdLine) bool {
+	text := p.text(line)
+	return p.blank(line) || mdATXHeading.Match(text) || mdFence.Match(text) ||
+		mdThematic.Match(text) || mdQuote.Match(text) || mdListItem.Match(text) || mdHTMLBlock.Match(text)
+}
+
+// blocks parses the block structure of lines.
+func (p *markdownParse) blocks(lines []mdLine) []*Node {
+	var out []*Node
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		text := p.text(line)
+		var node *Node
+		next := i + 1
+
+		switch {
+		case p.blank(line):
+			i++
+			continue
+		case mdATXHeading.Match(text):
+			node = p.atxHeading(line)
+		case mdFence.Match(text):
+			node, next = p.fencedCode(lines, i)
+		case mdThematic.Match(text):
+			node = newNode("thematic_break", p.indentEnd(line), line.end)
+		case mdQuote.Match(text):
+			node, next = p.blockQuote(lines, i)
+		case mdListItem.Match(text):
+			node, next = p.list(lines, i)
+		case mdHTMLBlock.Match(text):
+			next = p.until(lines, i, p.blank)
+			node = newNode("html_block", p.indentEnd(line), lines[next-1].end)
+		case p.indentEnd(line)-line.start >= 4:
+			next = p.until(lines, i, func(l mdLine) bool {
+				return !p.blank(l) && p.indentEnd(l)-l.start < 4
+			})
+			for next > i+1 && p.blank(lines[next-1]) {
+				next--
+			}
+			node = newNode("indented_code_block", line.start, lines[next-1].end)
+		case mdLinkRef.Match(text):
+			node = newNode("link_reference_definition", p.indentEnd(line), line.end)
+		case bytes.Contains(text, []byte("|")) && i+1 < len(lines) && mdTableDelim.Match(p.text(lines[i+1])):
+			node, next = p.table(lines, i)
+		default:
+			node, next = p.paragraph(lines, i)
+		}
+
+		out = append(out, node)
+		i = next
+	}
+	return out
+}
+
+// until returns the index of the first line after i for which stop holds.
+func (p *markdownParse) until(lines []mdLine, i int, stop func(mdLine) bool) int {
+	j := i + 1
+	for j < len(lines) && !stop(lines[j]) {
+		j++
+	}
+	return j
+}
+
+func (p *markdownParse) atxHeading(line mdLine) *Node {
+	m := mdATXHeading.FindSubmatchIndex(p.text(line))
+	markerStart, markerEnd := line.start+m[2], line.start+m[3]
+
+	node := newNode("atx_heading", markerStart, line.end)
+	node.add(newNode("atx_h"+string(rune('0'+markerEnd-markerStart))+"_marker", markerStart, markerEnd))
+
+	content := markerEnd
+	for content < line.end && (p.src[content] == ' ' || p.src[content] == '\t') {
+		content++
+	}
+	if inline := p.inline(content, line.end); inline.EndByte > inline.StartByte {
+		node.addField("heading_content", inline)
+	}
+	return node
+}
+
+func (p *markdownParse) fencedCode(lines []mdLine, i int) (*Node, int) {
+	line := lines[i]
+	text := p.text(line)
+	m := mdFence.FindSubmatchIndex(text)
+	fence := text[m[2]:m[3]]
+	open := newNode("fenced_code_block_delimiter", line.start+m[2], line.start+m[3])
+
+	node := newNode("fenced_code_block", open.StartByte, line.end)
+	node.add(open)
+	if info := bytes.TrimSpace(text[m[3]:]); len(info) > 0 {
+		infoStart := line.start + m[3] + bytes.Index(text[m[3]:], info)
+		lang := bytes.IndexAny(info, " \t")
+		if lang < 0 {
+			lang = len(info)
+		}
+		infoNode := newNode("info_string", infoStart, infoStart+len(info))
+		node.add(infoNode.add(newNode("language", infoStart, infoStart+lang)))
+	}
+
+	closing := p.until(lines, i, func(l mdLine) bool {
+		t := bytes.TrimSpace(p.text(l))
+		return len(t) >= len(fence) && t[0] == fence[0] && len(bytes.Trim(t, string(fence[:1]))) == 0
+	})
+	if closing > i+1 {
+		node.add(newNode("code_fence_content", lines[i+1].start, lines[closing-1].end))
+	}
+	if closing == len(lines) {
+		node.EndByte = lines[closing-1].end
+		return node, closing
+	}
+
+	last := lines[closing]
+	start := p.indentEnd(last)
+	node.add(newNode("fenced_code_block_delimiter", start, start+len(bytes.TrimSpace(p.text(last)))))
+	node.EndByte = last.end
+	return node, closing + 1
+}
+
+func (p *markdownParse) blockQuote(lines []mdLine, i int) (*Node, int) {
+	node := newNode("block_quote", p.indentEnd(lines[i]), lines[i].end)
+
+	var inner []mdLine
+	j := i
+	for ; j < len(lines); j++ {
+		line := lines[j]
+		text := p.text(line)
+		if m := mdQuote.FindIndex(text); m != nil {
+			if j == i {
+				marker := p.indentEnd(line)
+				node.add(newNode("block_quote_marker", marker, marker+1))
+			}
+			inner = append(inner, mdLine{line.start + m[1], line.end})
+			continue
+		}
+		// Lazy continuation of a quoted paragraph.
+		if j > i && !p.blank(lines[j-1]) && !p.interrupts(line) {
+			inner = append(inner, line)
+			continue
+		}
+		break
+	}
+
+	node.add(p.blocks(inner)...)
+	node.EndByte = lines[j-1].end
+	return node, j
+}
+
+// list groups consecutive items that use the same kind of marker.
+func (p *markdownParse) list(lines []mdLine, i int) (*Node, int) {
+	node := newNode("list", p.indentEnd(lines[i]), lines[i].end)
+	kind := p.markerKind(lines[i])
+
+	j := i
+	for j < len(lines) {
+		item, next := p.listItem(lines, j)
+		node.add(item)
+		node.EndByte = item.EndByte
+		j = next
+
+		k := j
+		for k < len(lines) && p.blank(lines[k]) {
+			k++
+		}
+		if k == len(lines) || !mdListItem.Match(p.text(lines[k])) || p.markerKind(lines[k]) != kind {
+			break
+		}
+		j = k
+	}
+	return node, j
+}
+
+func (p *markdownParse) markerKind(line mdLine) byte {
+	m := mdListItem.FindSubmatchIndex(p.text(line))
+	return p.src[line.start+m[5]-1]
+}
+
+func (p *markdownParse) listItem(lines []mdLine, i int) (*Node, int) {
+	line := lines[i]
+	m := mdListItem.FindSubmatchIndex(p.text(line))
+	markerStart, markerEnd := line.start+m[4], line.start+m[5]
+	width := m[1]
+	if width == len(p.text(line)) {
+		// An empty item: content lines are indented past the marker.
+		width = m[5] + 1
+	}
+
+	item := newNode("list_item", markerStart, line.end)
+	item.add(newNode(mdListMarkers[p.src[markerEnd-1]], markerStart, line.start+m[1]))
+
+	inner := []mdLine{{line.start + m[1], line.end}}
+	j := i + 1
+	for ; j < len(lines); j++ {
+		next := lines[j]
+		indent := p.indentEnd(next) - next.start
+		switch {
+		case p.blank(next):
+			inner = append(inner, next)
+		case indent >= width:
+			inner = append(inner, mdLine{next.start + width, next.end})
+		case !p.blank(lines[j-1]) && !p.interrupts(next):
+			// Lazy continuation of the item's paragraph.
+			inner = append(inner, next)
+		default:
+			goto done
+		}
+	}
+done:
+	for j > i+1 && p.blank(lines[j-1]) {
+		j--
+		inner = inner[:len(inner)-1]
+	}
+
+	item.add(p.blocks(inner)...)
+	item.EndByte = lines[j-1].end
+	return item, j
+}
+
+func (p *markdownParse) table(lines []mdLine, i int) (*Node, int) {
+	end := p.until(lines, i+1, func(l mdLine) bool {
+		return p.blank(l) || !bytes.Contains(p.text(l), []byte("|"))
+	})
+
+	node := newNode("pipe_table", p.indentEnd(lines[i]), lines[end-1].end)
+	for j := i; j < end; j++ {
+		rowType := "pipe_table_row"
+		switch j {
+		case i:
+			rowType = "pipe_table_header"
+		case i + 1:
+			rowType = "pipe_table_delimiter_row"
+		}
+		start := p.indentEnd(lines[j])
+		node.add(newNode(rowType, start, start+len(bytes.TrimSpace(p.text(lines[j])))))
+	}
+	return node, end
+}
+
+// paragraph consumes lines up to a blank line or an interrupting block; a
+// following === or --- underline turns it into a setext heading.
+func (p *markdownParse) paragraph(lines []mdLine, i int) (*Node, int) {
+	start := p.indentEnd(lines[i])
+	j := i + 1
+	for j < len(lines) {
+		text := p.text(lines[j])
+		if m := mdSetext.FindSubmatch(text); m != nil {
+			paragraph := newNode("paragraph", start, lines[j-1].end)
+			paragraph.add(p.inline(start, lines[j-1].end))
+
+			underline := "setext_h1_underline"
+			if m[1][0] == '-' {
+				underline = "setext_h2_underline"
+			}
+			marker := p.indentEnd(lines[j])
+			node := newNode("setext_heading", start, lines[j].end)
+			node.addField("heading_content", paragraph)
+			node.add(newNode(underline, marker, marker+len(bytes.TrimSpace(text))))
+			return node, j + 1
+		}
+		if p.interrupts(lines[j]) && !(mdListItem.Match(text) && !p.listInterrupts(lines[j])) {
+			break
+		}
+		j++
+	}
+
+	node := newNode("paragraph", start, lines[j-1].end)
+	node.add(p.inline(start, lines[j-1].end))
+	return node, j
+}
+
+// listInterrupts applies CommonMark's rule that only a bullet or a list
+// starting at 1 with content may interrupt a paragraph.
+func (p *markdownParse) listInterrupts(line mdLine) bool {
+	text := p.text(line)
+	m := mdListItem.FindSubmatchIndex(text)
+	marker := text[m[4]:m[5]]
+	if m[1] == len(text) {
+		return false
+	}
+	return len(marker) == 1 || string(marker[:len(marker)-1]) == "1"
+}
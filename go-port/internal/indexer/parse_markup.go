@@ -0,0 +1,585 @@
+package indexer
+
+import (
+	"bytes"
+	"strings"
+)
+
+var htmlVoidElements = wordSet("area", "base", "br", "col", "embed", "hr", "img", "input", "keygen", "link", "meta", "param", "source", "track", "wbr")
+
+// htmlImplicitClose lists, per tag, the open elements its start tag closes
+// (<li> ends the previous <li>, a block element ends an open <p>).
+var htmlImplicitClose = map[string]map[string]bool{
+	"li":         wordSet("li"),
+	"dt":         wordSet("dt", "dd"),
+	"dd":         wordSet("dt", "dd"),
+	"tr":         wordSet("tr", "td", "th"),
+	"td":         wordSet("td", "th"),
+	"th":         wordSet("td", "th"),
+	"option":     wordSet("option"),
+	"p":          wordSet("p"),
+	"div":        wordSet("p"),
+	"ul":         wordSet("p"),
+	"ol":         wordSet("p"),
+	"table":      wordSet("p"),
+	"section":    wordSet("p"),
+	"h1":         wordSet("p"),
+	"h2":         wordSet("p"),
+	"h3":         wordSet("p"),
+	"h4":         wordSet("p"),
+	"h5":         wordSet("p"),
+	"h6":         wordSet("p"),
+	"pre":        wordSet("p"),
+	"form":       wordSet("p"),
+	"header":     wordSet("p"),
+	"footer":     wordSet("p"),
+	"nav":        wordSet("p"),
+	"article":    wordSet("p"),
+	"aside":      wordSet("p"),
+	"blockquote": wordSet("p"),
+}
+
+// htmlOptionalEnd lists elements whose end tag may be omitted without error.
+var htmlOptionalEnd = wordSet("html", "head", "body", "p", "li", "dt", "dd", "tr", "td", "th", "option", "thead", "tbody", "tfoot", "colgroup")
+
+type htmlParse struct {
+	src      []byte
+	pos      int
+	hasError bool
+	stack    []*Node
+	names    []string
+}
+
+// parseHTML builds a tree-sitter-html document: element nodes with start_tag
+// and end_tag children, script_element/style_element with raw_text, text and
+// comment nodes.
+func parseHTML(source []byte) (*Tree, error) {
+	p := &htmlParse{src: source}
+	root := newNode("document", 0, len(source))
+	p.stack, p.names = []*Node{root}, []string{""}
+
+	for p.pos < len(p.src) {
+		switch {
+		case p.hasPrefix("<!--"):
+			p.comment()
+		case p.hasPrefix("</"):
+			p.endTag()
+		case p.hasPrefix("<!"):
+			end := p.indexFrom(">")
+			p.top().add(newNode("doctype", p.pos, end))
+			p.pos = end
+		case p.hasPrefix("<") && p.pos+1 < len(p.src) && isLetter(p.src[p.pos+1]):
+			p.element()
+		default:
+			p.text()
+		}
+	}
+
+	for len(p.stack) > 1 {
+		if !htmlOptionalEnd[p.names[len(p.names)-1]] {
+			p.hasError = true
+		}
+		p.pop()
+	}
+	return &Tree{Lang: "html", Source: source, Root: root, HasError: p.hasError}, nil
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func (p *htmlParse) hasPrefix(prefix string) bool {
+	return bytes.HasPrefix(p.src[p.pos:], []byte(prefix))
+}
+
+// indexFrom returns the offset just past the next occurrence of s, or the
+// end of input.
+func (p *htmlParse) indexFrom(s string) int {
+	if i := bytes.Index(p.src[p.pos:], []byte(s)); i >= 0 {
+		return p.pos + i + len(s)
+	}
+	p.hasError = true
+	return len(p.src)
+}
+
+func (p *htmlParse) top() *Node {
+	return p.stack[len(p.stack)-1]
+}
+
+func (p *htmlParse) add(node *Node) {
+	p.top().add(node)
+	p.extend(node.EndByte)
+}
+
+// extend grows the open elements to cover end.
+func (p *htmlParse) extend(end int) {
+	for _, open := range p.stack[1:] {
+		open.EndByte = max(open.EndByte, end)
+	}
+}
+
+func (p *htmlParse) pop() {
+	p.stack = p.stack[:len(p.stack)-1]
+	p.names = p.names[:len(p.names)-1]
+}
+
+func (p *htmlParse) comment() {
+	start := p.pos
+	p.pos = p.indexFrom("-->")
+	p.add(newNode("comment", start, p.pos))
+}
+
+func (p *htmlParse) text() {
+	start := p.pos
+	next := bytes.IndexByte(p.src[p.pos+1:], '<')
+	if next < 0 {
+		p.pos = len(p.src)
+	} else {
+		p.pos += next + 1
+	}
+
+	text := p.src[start:p.pos]
+	lead := len(text) - len(bytes.TrimLeft(text, " \t\r\n"))
+	trail := len(text) - len(bytes.TrimRight(text, " \t\r\n"))
+	if lead < len(text) {
+		p.add(newNode("text", start+lead, p.pos-trail))
+	}
+}
+
+// tag parses a start or end tag at pos and returns its node, lower-cased
+// name and whether it is self-closing.
+func (p *htmlParse) tag(nodeType string, nameStart int) (*Node, string, bool) {
+	start := p.pos
+	p.pos = nameStart
+	for p.pos < len(p.src) && !isSpace(p.src[p.pos]) && p.src[p.pos] != '>' && p.src[p.pos] != '/' {
+		p.pos++
+	}
+	name := newNode("tag_name", nameStart, p.pos)
+	node := newNode(nodeType, start, p.pos)
+	node.add(name)
+
+	selfClosing := false
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case isSpace(c):
+			p.pos++
+		case c == '>':
+			p.pos++
+			node.EndByte = p.pos
+			return node, strings.ToLower(string(p.src[nameStart:name.EndByte])), selfClosing
+		case c == '/':
+			selfClosing = true
+			p.pos++
+		default:
+			selfClosing = false
+			node.add(p.attribute())
+		}
+	}
+
+	p.hasError = true
+	node.EndByte = p.pos
+	return node, strings.ToLower(string(p.src[nameStart:name.EndByte])), selfClosing
+}
+
+func (p *htmlParse) attribute() *Node {
+	start := p.pos
+	for p.pos < len(p.src) && !isSpace(p.src[p.pos]) && !strings.ContainsRune("=>/", rune(p.src[p.pos])) {
+		p.pos++
+	}
+	if p.pos == start {
+		p.pos++
+	}
+	node := newNode("attribute", start, p.pos)
+	node.add(newNode("attribute_name", start, p.pos))
+
+	if p.pos >= len(p.src) || p.src[p.pos] != '=' {
+		return node
+	}
+	p.pos++
+
+	valueStart := p.pos
+	if p.pos < len(p.src) && (p.src[p.pos] == '"' || p.src[p.pos] == '\'') {
+		quote := p.src[p.pos]
+		end := bytes.IndexByte(p.src[p.pos+1:], quote)
+		if end < 0 {
+			p.hasError = true
+			p.pos = len(p.src)
+		} else {
+			p.pos += end + 2
+		}
+		quoted := newNode("quoted_attribute_value", valueStart, p.pos)
+		if p.pos-valueStart > 2 {
+			quoted.add(newNode("attribute_value", valueStart+1, p.pos-1))
+		}
+		node.add(quoted)
+	} else {
+		for p.pos < len(p.src) && !isSpace(p.src[p.pos]) && p.src[p.pos] != '>' {
+			p.pos++
+		}
+		node.add(newNode("attribute_value", valueStart, p.pos))
+	}
+	node.EndByte = p.pos
+	return node
+}
+
+func (p *htmlParse) element() {
+	startTag, name, selfClosing := p.tag("start_tag", p.pos+1)
+
+	if closes := htmlImplicitClose[name]; closes != nil {
+		for len(p.stack) > 1 && closes[p.names[len(p.names)-1]] {
+			p.pop()
+		}
+	}
+
+	element := newNode("element", startTag.StartByte, startTag.EndByte)
+	switch {
+	case selfClosing:
+		startTag.Type = "self_closing_tag"
+		p.add(element.add(startTag))
+		return
+	case htmlVoidElements[name]:
+		p.add(element.add(startTag))
+		return
+	case name == "script" || name == "style":
+		element.Type = name + "_element"
+		element.add(startTag)
+		p.rawText(element, name)
+		p.add(element)
+		return
+	}
+
+	p.add(element.add(startTag))
+	p.stack = append(p.stack, element)
+	p.names = append(p.names, name)
+}
+
+// rawText consumes script or style content up to and including its end tag.
+func (p *htmlParse) rawText(element *Node, name string) {
+	start := p.pos
+	end := len(p.src)
+	if i := bytes.Index(bytes.ToLower(p.src[p.pos:]), []byte("</"+name)); i >= 0 {
+		end = p.pos + i
+	}
+	if end > start {
+		element.add(newNode("raw_text", start, end))
+	}
+
+	p.pos = end
+	if end == len(p.src) {
+		p.hasError = true
+		element.EndByte = end
+		return
+	}
+	endTag, _, _ := p.tag("end_tag", p.pos+2)
+	element.add(endTag)
+	element.EndByte = endTag.EndByte
+}
+
+func (p *htmlParse) endTag() {
+	endTag, name, _ := p.tag("end_tag", p.pos+2)
+
+	open := -1
+	for i := len(p.names) - 1; i > 0; i-- {
+		if p.names[i] == name {
+			open = i
+			break
+		}
+	}
+	if open < 0 {
+		p.hasError = true
+		p.top().add(newNode("ERROR", endTag.StartByte, endTag.EndByte))
+		return
+	}
+
+	for len(p.stack)-1 > open {
+		if !htmlOptionalEnd[p.names[len(p.names)-1]] {
+			p.hasError = true
+		}
+		p.pop()
+	}
+	element := p.top()
+	element.add(endTag)
+	p.extend(endTag.EndByte)
+	p.pop()
+}
+
+var cssLex = &lexSpec{
+	blockComments: [][2]string{{"/*", "*/"}},
+	quotes:        `"'`,
+	identExtra:    "-",
+}
+
+var cssAtRules = map[string]string{
+	"media":             "media_statement",
+	"supports":          "supports_statement",
+	"keyframes":         "keyframes_statement",
+	"-webkit-keyframes": "keyframes_statement",
+	"import":            "import_statement",
+	"charset":           "charset_statement",
+	"namespace":         "namespace_statement",
+}
+
+type cssParse struct {
+	toks     []token
+	match    []int
+	hasError bool
+}
+
+// parseCSS builds a tree-sitter-css stylesheet of rule_set (selectors +
+// block of declarations), media_statement and other at-rules.
+func parseCSS(source []byte) (*Tree, error) {
+	p := &cssParse{}
+	for _, tok := range lex(source, cssLex) {
+		if tok.kind != tokComment && tok.kind != tokNewline {
+			p.toks = append(p.toks, tok)
+		}
+	}
+	p.match, p.hasError = matchBrackets(p.toks)
+
+	root := newNode("stylesheet", 0, len(source))
+	p.items(root, 0, len(p.toks), false)
+	return &Tree{Lang: "css", Source: source, Root: root, HasError: p.hasError}, nil
+}
+
+func (p *cssParse) span(nodeType string, lo, last int) *Node {
+	return newNode(nodeType, p.toks[lo].start, p.toks[last].end)
+}
+
+// stop returns the first top-level ";", "{" or "}" in [lo, hi), or hi.
+func (p *cssParse) stop(lo, hi int) int {
+	for j := lo; j < hi; j++ {
+		tok := p.toks[j]
+		if tok.is(";") || tok.is("{") || tok.is("}") {
+			return j
+		}
+		if m := p.match[j]; m > j && m < hi && !tok.is("{") {
+			j = m
+		}
+	}
+	return hi
+}
+
+// items parses rules in [lo, hi). In a declaration block (decls) items
+// without a following "{" are declarations.
+func (p *cssParse) items(parent *Node, lo, hi int, decls bool) {
+	for j := lo; j < hi; {
+		if p.toks[j].is(";") {
+			j++
+			continue
+		}
+
+		stop := p.stop(j, hi)
+		switch {
+		case p.toks[j].is("@"):
+			j = p.atRule(parent, j, stop, hi)
+		case stop < hi && p.toks[stop].is("{"):
+			m := p.close(stop, hi)
+			rule := p.span("rule_set", j, m)
+			if stop > j {
+				rule.add(p.span("selectors", j, stop-1))
+			}
+			rule.add(p.block("block", stop, m, true))
+			parent.add(rule)
+			j = m + 1
+		case decls && stop == j:
+			// A "}" left over from mismatched brackets.
+			p.hasError = true
+			parent.add(p.span("ERROR", j, j))
+			j++
+		case decls:
+			last := min(stop, hi-1)
+			if stop == hi || !p.toks[stop].is(";") {
+				last = stop - 1
+			}
+			decl := p.span("declaration", j, last)
+			if p.toks[j].kind == tokIdent {
+				decl.add(p.span("property_name", j, j))
+			}
+			parent.add(decl)
+			j = last + 1
+		default:
+			// Stray tokens outside any rule.
+			p.hasError = true
+			last := min(stop, hi-1)
+			parent.add(p.span("ERROR", j, last))
+			j = last + 1
+		}
+	}
+}
+
+func (p *cssParse) close(j, hi int) int {
+	if m := p.match[j]; m > j && m < hi {
+		return m
+	}
+	return hi - 1
+}
+
+func (p *cssParse) block(nodeType string, open, closing int, decls bool) *Node {
+	block := p.span(nodeType, open, closing)
+	p.items(block, open+1, closing, decls)
+	return block
+}
+
+func (p *cssParse) atRule(parent *Node, j, stop, hi int) int {
+	name := ""
+	if j+1 < hi && p.toks[j+1].kind == tokIdent {
+		name = p.toks[j+1].text
+	}
+	nodeType, ok := cssAtRules[name]
+	if !ok {
+		nodeType = "at_rule"
+	}
+
+	if stop < hi && p.toks[stop].is("{") {
+		m := p.close(stop, hi)
+		node := p.span(nodeType, j, m)
+		node.add(newNode("at_keyword", p.toks[j].start, p.toks[min(j+1, stop-1)].end))
+		if nodeType == "keyframes_statement" {
+			if j+2 < stop {
+				node.add(p.span("keyframes_name", j+2, j+2))
+			}
+			list := p.span("keyframe_block_list", stop, m)
+			for k := stop + 1; k < m; {
+				open := p.stop(k, m)
+				if open >= m || !p.toks[open].is("{") {
+					break
+				}
+				end := p.close(open, m)
+				frame := p.span("keyframe_block", k, end)
+				frame.add(p.block("block", open, end, true))
+				list.add(frame)
+				k = end + 1
+			}
+			node.add(list)
+		} else {
+			node.add(p.block("block", stop, m, nodeType == "at_rule"))
+		}
+		parent.add(node)
+		return m + 1
+	}
+
+	last := min(stop, hi-1)
+	node := p.span(nodeType, j, last)
+	node.add(newNode("at_keyword", p.toks[j].start, p.toks[min(j+1, last)].end))
+	parent.add(node)
+	return last + 1
+}
+
+var jsonLex = &lexSpec{
+	lineComments:  []string{"//"},
+	blockComments: [][2]string{{"/*", "*/"}},
+	quotes:        `"`,
+}
+
+type jsonParse struct {
+	toks     []token
+	pos      int
+	hasError bool
+}
+
+// parseJSON builds a tree-sitter-json document of object, pair, array and
+// scalar nodes.
+func parseJSON(source []byte) (*Tree, error) {
+	p := &jsonParse{}
+	for _, tok := range lex(source, jsonLex) {
+		if tok.kind != tokComment && tok.kind != tokNewline {
+			p.toks = append(p.toks, tok)
+		}
+	}
+
+	root := newNode("document", 0, len(source))
+	for p.pos < len(p.toks) {
+		root.add(p.value())
+	}
+	return &Tree{Lang: "json", Source: source, Root: root, HasError: p.hasError}, nil
+}
+
+func (p *jsonParse) peek(text string) bool {
+	return p.pos < len(p.toks) && p.toks[p.pos].is(text)
+}
+
+func (p *jsonParse) value() *Node {
+	if p.pos >= len(p.toks) {
+		p.hasError = true
+		return nil
+	}
+
+	tok := p.toks[p.pos]
+	p.pos++
+	switch {
+	case tok.is("{"):
+		return p.container("object", tok, "}", true)
+	case tok.is("["):
+		return p.container("array", tok, "]", false)
+	case tok.kind == tokString:
+		return newNode("string", tok.start, tok.end)
+	case tok.kind == tokNumber:
+		return newNode("number", tok.start, tok.end)
+	case tok.is("-") && p.pos < len(p.toks) && p.toks[p.pos].kind == tokNumber:
+		p.pos++
+		return newNode("number", tok.start, p.toks[p.pos-1].end)
+	case tok.is("true") || tok.is("false") || tok.is("null"):
+		return newNode(tok.text, tok.start, tok.end)
+	}
+
+	p.hasError = true
+	return newNode("ERROR", tok.start, tok.end)
+}
+
+func (p *jsonParse) container(nodeType string, open token, closing string, pairs bool) *Node {
+	node := newNode(nodeType, open.start, open.end)
+	for p.pos < len(p.toks) && !p.peek(closing) {
+		if p.peek(",") {
+			p.pos++
+			continue
+		}
+		if p.peek("}") || p.peek("]") {
+			// Mismatched closer: leave it to the enclosing container.
+			p.hasError = true
+			return node
+		}
+
+		var item *Node
+		if pairs {
+			item = p.pair()
+		} else {
+			item = p.value()
+		}
+		node.add(item)
+		if item != nil {
+			node.EndByte = item.EndByte
+		}
+	}
+
+	if !p.peek(closing) {
+		p.hasError = true
+		return node
+	}
+	node.EndByte = p.toks[p.pos].end
+	p.pos++
+	return node
+}
+
+func (p *jsonParse) pair() *Node {
+	key := p.value()
+	if key == nil {
+		return nil
+	}
+	pair := newNode("pair", key.StartByte, key.EndByte)
+	pair.addField("key", key)
+	if key.Type != "string" {
+		p.hasError = true
+	}
+
+	if !p.peek(":") {
+		p.hasError = true
+		return pair
+	}
+	p.pos++
+	if value := p.value(); value != nil {
+		pair.addField("value", value)
+		pair.EndByte = value.EndByte
+	}
+	return pair
+}
@@ -0,0 +1,287 @@
+package indexer
+
+var pythonSyntax = &scriptSyntax{
+	lex: &lexSpec{
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		tripleQuotes: true,
+	},
+	keywords:   wordSet("False", "None", "True", "and", "as", "assert", "async", "await", "break", "class", "continue", "def", "del", "elif", "else", "except", "finally", "for", "from", "global", "if", "import", "in", "is", "lambda", "nonlocal", "not", "or", "pass", "raise", "return", "try", "while", "with", "yield"),
+	continuers: `\`,
+
+	call:      "call",
+	callee:    "function",
+	ident:     "identifier",
+	member:    "attribute",
+	memberOps: []string{"."},
+	property:  "identifier",
+	args:      "argument_list",
+}
+
+// pythonClauses maps compound statement keywords to the clauses that may
+// continue them at the same indentation.
+var pythonClauses = map[string]map[string]string{
+	"if":    {"elif": "elif_clause", "else": "else_clause"},
+	"for":   {"else": "else_clause"},
+	"while": {"else": "else_clause"},
+	"try":   {"except": "except_clause", "else": "else_clause", "finally": "finally_clause"},
+}
+
+var pythonCompound = map[string]string{
+	"if":    "if_statement",
+	"for":   "for_statement",
+	"while": "while_statement",
+	"try":   "try_statement",
+	"with":  "with_statement",
+	"match": "match_statement",
+	"case":  "case_clause",
+}
+
+var pythonSimple = map[string]string{
+	"import":   "import_statement",
+	"from":     "import_from_statement",
+	"return":   "return_statement",
+	"raise":    "raise_statement",
+	"pass":     "pass_statement",
+	"break":    "break_statement",
+	"continue": "continue_statement",
+	"del":      "delete_statement",
+	"assert":   "assert_statement",
+	"global":   "global_statement",
+	"nonlocal": "nonlocal_statement",
+}
+
+type pyLine struct {
+	lo, last int
+	indent   int
+}
+
+type pythonParse struct {
+	*scriptParse
+}
+
+// parsePython builds the statement tree from logical lines and indentation,
+// the way the tree-sitter-python grammar nests blocks.
+func parsePython(source []byte) (*Tree, error) {
+	p := &pythonParse{newScriptParse(pythonSyntax, source)}
+	root := newNode("module", 0, len(source))
+
+	var lines []pyLine
+	for _, stmt := range p.statements(0, len(p.toks), nil) {
+		lines = append(lines, pyLine{lo: stmt[0], last: stmt[1], indent: p.column(stmt[0])})
+	}
+	if len(lines) > 0 && lines[0].indent > 0 {
+		p.hasError = true
+	}
+
+	p.suite(root, lines)
+	return &Tree{Lang: "python", Source: source, Root: root, HasError: p.hasError}, nil
+}
+
+func (p *pythonParse) suite(parent *Node, lines []pyLine) {
+	for i := 0; i < len(lines); {
+		var node *Node
+		node, i = p.statement(lines, i)
+		parent.add(node)
+	}
+}
+
+// body returns the lines indented under lines[i] and the index after them.
+func (p *pythonParse) body(lines []pyLine, i int) ([]pyLine, int) {
+	j := i + 1
+	for j < len(lines) && lines[j].indent > lines[i].indent {
+		j++
+	}
+	return lines[i+1 : j], j
+}
+
+// statement builds the statement starting at lines[i] and returns the index
+// of the next unconsumed line.
+func (p *pythonParse) statement(lines []pyLine, i int) (*Node, int) {
+	line := lines[i]
+	head := line.lo
+	if p.toks[head].is("async") && head < line.last {
+		head++
+	}
+	word := p.toks[head].text
+
+	switch {
+	case p.toks[head].is("@"):
+		return p.decorated(lines, i)
+	case word == "def" || word == "class":
+		return p.definition(lines, i, head)
+	}
+
+	if nodeType, ok := pythonCompound[word]; ok && p.toks[head].kind == tokIdent {
+		if colon := p.headerColon(head, line.last); colon >= 0 {
+			node, next := p.clause(nodeType, lines, i, head, colon)
+			clauses := pythonClauses[word]
+			for next < len(lines) && lines[next].indent == line.indent {
+				clauseType, ok := clauses[p.toks[lines[next].lo].text]
+				if !ok {
+					break
+				}
+				colon := p.headerColon(lines[next].lo, lines[next].last)
+				if colon < 0 {
+					p.hasError = true
+					break
+				}
+				var clause *Node
+				clause, next = p.clause(clauseType, lines, next, lines[next].lo, colon)
+				field := ""
+				if clauseType == "elif_clause" || clauseType == "else_clause" && word == "if" {
+					field = "alternative"
+				}
+				node.addField(field, clause)
+				node.EndByte = clause.EndByte
+			}
+			return node, next
+		}
+	}
+
+	body, next := p.body(lines, i)
+	if len(body) > 0 {
+		// Indented lines under a simple statement.
+		p.hasError = true
+	}
+	node := p.simple(line.lo, line.last)
+	for _, extra := range body {
+		node.add(p.simple(extra.lo, extra.last))
+	}
+	return node, next
+}
+
+// headerColon returns the first top-level ":" of a compound statement
+// header, or -1.
+func (p *pythonParse) headerColon(head, last int) int {
+	for j := head + 1; j <= last; j++ {
+		if m := p.match[j]; m > j && m <= last {
+			j = m
+			continue
+		}
+		switch {
+		case p.toks[j].is("lambda"):
+			return -1
+		case p.toks[j].is(":") && !(j+1 <= last && p.toks[j+1].is("=") && p.adjacent(j, j+1)):
+			return j
+		}
+	}
+	return -1
+}
+
+// clause builds a node whose header ends at colon, followed by either the
+// rest of the line or an indented block.
+func (p *pythonParse) clause(nodeType string, lines []pyLine, i, head, colon int) (*Node, int) {
+	line := lines[i]
+	node := newNode(nodeType, p.toks[line.lo].start, p.toks[colon].end)
+	p.scanCalls(node, head+1, colon)
+
+	body, next := p.body(lines, i)
+	block := p.block(line, colon, body)
+	if block != nil {
+		node.addField("body", block)
+		node.EndByte = block.EndByte
+	} else {
+		p.hasError = true
+	}
+	return node, next
+}
+
+func (p *pythonParse) block(header pyLine, colon int, body []pyLine) *Node {
+	if colon < header.last {
+		// if x: return y
+		block := p.span("block", colon+1, header.last)
+		for _, stmt := range p.statements(colon+1, header.last+1, nil) {
+			block.add(p.simple(stmt[0], stmt[1]))
+		}
+		return block
+	}
+	if len(body) == 0 {
+		return nil
+	}
+
+	block := newNode("block", p.toks[body[0].lo].start, p.toks[body[len(body)-1].last].end)
+	p.suite(block, body)
+	return block
+}
+
+func (p *pythonParse) definition(lines []pyLine, i, head int) (*Node, int) {
+	line := lines[i]
+	nodeType := "function_definition"
+	if p.toks[head].is("class") {
+		nodeType = "class_definition"
+	}
+
+	colon := p.headerColon(head, line.last)
+	if colon < 0 {
+		p.hasError = true
+		colon = line.last
+	}
+
+	node := newNode(nodeType, p.toks[line.lo].start, p.toks[colon].end)
+	name := head + 1
+	if p.isWord(name, colon+1) {
+		node.addField("name", p.identNode(name))
+	}
+	if open := name + 1; p.at(open, colon, "(") {
+		m := p.close(open, colon)
+		if nodeType == "function_definition" {
+			node.addField("parameters", p.span("parameters", open, m))
+		} else {
+			superclasses := p.span("argument_list", open, m)
+			p.scanCalls(superclasses, open+1, m)
+			node.addField("superclasses", superclasses)
+		}
+	}
+
+	body, next := p.body(lines, i)
+	if block := p.block(line, colon, body); block != nil {
+		node.addField("body", block)
+		node.EndByte = block.EndByte
+	} else {
+		p.hasError = true
+	}
+	return node, next
+}
+
+func (p *pythonParse) decorated(lines []pyLine, i int) (*Node, int) {
+	node := newNode("decorated_definition", p.toks[lines[i].lo].start, p.toks[lines[i].last].end)
+	for i < len(lines) && p.toks[lines[i].lo].is("@") {
+		decorator := p.span("decorator", lines[i].lo, lines[i].last)
+		p.scanCalls(decorator, lines[i].lo+1, lines[i].last+1)
+		node.add(decorator)
+		i++
+	}
+	if i >= len(lines) {
+		p.hasError = true
+		return node, i
+	}
+
+	definition, next := p.statement(lines, i)
+	node.addField("definition", definition)
+	node.EndByte = definition.EndByte
+	return node, next
+}
+
+// simple builds a simple statement spanning tokens [lo, last].
+func (p *pythonParse) simple(lo, last int) *Node {
+	tok := p.toks[lo]
+	if nodeType, ok := pythonSimple[tok.text]; ok && tok.kind == tokIdent {
+		node := p.span(nodeType, lo, last)
+		p.scanCalls(node, lo+1, last+1)
+		return node
+	}
+
+	node := p.span("expression_statement", lo, last)
+	if eq := p.assignment(lo, last+1); eq >= 0 {
+		nodeType := "assignment"
+		if p.augmented(eq, lo) {
+			nodeType = "augmented_assignment"
+		}
+		assignment := p.span(nodeType, lo, last)
+		p.scanCalls(assignment, lo, last+1)
+		return node.add(assignment)
+	}
+	p.scanCalls(node, lo, last+1)
+	return node
+}
@@ -0,0 +1,416 @@
+package indexer
+
+import "strings"
+
+var rubyKeywords = wordSet("alias", "and", "begin", "BEGIN", "break", "case", "class", "def", "defined?", "do", "else", "elsif", "end", "END", "ensure", "false", "for", "if", "in", "module", "next", "nil", "not", "or", "redo", "rescue", "retry", "return", "super", "then", "true", "undef", "unless", "until", "when", "while", "yield")
+
+// rubyStarts lists tokens after which if/unless/while/until start a block
+// rather than acting as statement modifiers (x = if cond ... end).
+var rubyStarts = wordSet("=", "(", "[", "{", ",", "|", "&", "then", "do", "else", "begin", "and", "or", "not", "in", "when", "elsif")
+
+var rubySyntax = &scriptSyntax{
+	lex: &lexSpec{
+		lineComments:  []string{"#"},
+		blockComments: [][2]string{{"=begin", "=end"}},
+		quotes:        `"'`,
+		identExtra:    "@$",
+		identSuffix:   "?!",
+		heredocs:      heredocRuby,
+	},
+	keywords:   rubyKeywords,
+	blocks:     rubyBlocks,
+	continuers: `\,.|&+-*/=`,
+	leaders:    ".&",
+
+	call:       "call",
+	callee:     "method",
+	ident:      "identifier",
+	memberOps:  []string{".", "&.", "::"},
+	property:   "identifier",
+	args:       "argument_list",
+	identType:  rubyIdentType,
+	dotCalls:   true,
+	blockArgs:  true,
+	braceBlock: "block",
+}
+
+func rubyBlocks(toks []token, i int) (opens, closes bool) {
+	if memberAccess(toks, i) || i > 0 && toks[i-1].is(":") && toks[i-1].end == toks[i].start {
+		return false, false
+	}
+
+	switch toks[i].text {
+	case "end":
+		return false, true
+	case "def":
+		return !endlessDef(toks, i), false
+	case "class", "module", "begin", "case":
+		return true, false
+	case "if", "unless", "while", "until", "for":
+		return commandPosition(toks, i, rubyStarts), false
+	case "do":
+		return !loopDo(toks, i, "while", "until", "for"), false
+	}
+	return false, false
+}
+
+// loopDo reports whether the "do" at i is the optional separator of a loop
+// header on the same line (while x do ... end).
+func loopDo(toks []token, i int, loops ...string) bool {
+	for j := i - 1; j >= 0; j-- {
+		tok := toks[j]
+		if tok.kind == tokNewline || tok.is(";") {
+			return false
+		}
+		for _, loop := range loops {
+			if tok.kind == tokIdent && tok.text == loop && !memberAccess(toks, j) {
+				return true
+			}
+		}
+		if tok.is("do") || tok.is("then") {
+			return false
+		}
+	}
+	return false
+}
+
+// endlessDef reports whether the def at i is a one-line def name(args) = expr.
+func endlessDef(toks []token, i int) bool {
+	j := i + 1
+	if j+1 < len(toks) && toks[j+1].is(".") {
+		j += 2
+	}
+	name := j
+	j++
+	if j < len(toks) && toks[j].is("(") {
+		depth := 0
+		for ; j < len(toks); j++ {
+			if toks[j].is("(") {
+				depth++
+			} else if toks[j].is(")") {
+				if depth--; depth == 0 {
+					j++
+					break
+				}
+			}
+		}
+	}
+	if j >= len(toks) || !toks[j].is("=") || j == name+1 && toks[name].end == toks[j].start {
+		// def x=(value) is a setter, not an endless def.
+		return false
+	}
+	return j+1 >= len(toks) || toks[j].end != toks[j+1].start || !toks[j+1].is("=")
+}
+
+func rubyIdentType(text string) string {
+	switch {
+	case strings.HasPrefix(text, "@@"):
+		return "class_variable"
+	case strings.HasPrefix(text, "@"):
+		return "instance_variable"
+	case strings.HasPrefix(text, "$"):
+		return "global_variable"
+	case text == "self":
+		return "self"
+	case text[0] >= 'A' && text[0] <= 'Z':
+		return "constant"
+	}
+	return "identifier"
+}
+
+// rubyClauses are keywords that continue a block's statements (if ...
+// elsif ... else ... end); each is emitted as a node covering its line.
+var rubyClauses = map[string]string{
+	"elsif":  "elsif",
+	"else":   "else",
+	"when":   "when",
+	"in":     "in_clause",
+	"then":   "then",
+	"rescue": "rescue",
+	"ensure": "ensure",
+}
+
+var rubyJumps = map[string]string{
+	"return": "return",
+	"break":  "break",
+	"next":   "next",
+	"yield":  "yield",
+	"redo":   "redo",
+	"retry":  "retry",
+}
+
+type rubyParse struct {
+	*scriptParse
+}
+
+// parseRuby pairs def/class/module/do ... end blocks up front and builds
+// statements with tree-sitter-ruby node types (method, class, call, ...).
+func parseRuby(source []byte) (*Tree, error) {
+	p := &rubyParse{newScriptParse(rubySyntax, source)}
+	p.opener = p.block
+	root := newNode("program", 0, len(source))
+	p.body(root, 0, len(p.toks))
+	return &Tree{Lang: "ruby", Source: source, Root: root, HasError: p.hasError}, nil
+}
+
+func (p *rubyParse) body(parent *Node, lo, hi int) {
+	for _, stmt := range p.statements(lo, hi, nil) {
+		parent.add(p.statement(stmt[0], stmt[1]))
+	}
+}
+
+// bodyStatement wraps the statements of [lo, hi) in a body_statement node.
+func (p *rubyParse) bodyStatement(parent *Node, lo, hi int) {
+	stmts := p.statements(lo, hi, nil)
+	if len(stmts) == 0 {
+		return
+	}
+
+	body := newNode("body_statement", p.toks[stmts[0][0]].start, p.toks[stmts[len(stmts)-1][1]].end)
+	for _, stmt := range stmts {
+		body.add(p.statement(stmt[0], stmt[1]))
+	}
+	parent.addField("body", body)
+}
+
+func (p *rubyParse) statement(lo, last int) *Node {
+	tok := p.toks[lo]
+
+	if p.isOpener(lo) {
+		holder := &Node{}
+		end := p.block(holder, lo, last+1)
+		block := holder.Children[0]
+		block.Parent = nil
+		if end < last {
+			block.EndByte = p.toks[last].end
+			p.scanCalls(block, end+1, last+1)
+		}
+		return block
+	}
+
+	if tok.is("def") {
+		if eq := p.assignment(lo, last+1); eq >= 0 {
+			return p.method(lo, eq+1, last+1, last)
+		}
+	}
+
+	if tok.kind == tokIdent {
+		if nodeType, ok := rubyClauses[tok.text]; ok {
+			node := p.span(nodeType, lo, last)
+			p.scanCalls(node, lo+1, last+1)
+			return node
+		}
+		if nodeType, ok := rubyJumps[tok.text]; ok {
+			node := p.span(nodeType, lo, last)
+			p.scanCalls(node, lo+1, last+1)
+			return node
+		}
+	}
+
+	if eq := p.assignment(lo, last+1); eq >= 0 {
+		nodeType := "assignment"
+		if p.augmented(eq, lo) {
+			nodeType = "operator_assignment"
+		}
+		node := p.span(nodeType, lo, last)
+		p.scanCalls(node, lo, last+1)
+		return node
+	}
+
+	if call := p.command(lo, last); call != nil {
+		return call
+	}
+
+	holder := p.span("binary", lo, last)
+	p.scanCalls(holder, lo, last+1)
+	if len(holder.Children) == 1 && p.modifier(lo, last) == "" {
+		only := holder.Children[0]
+		only.Parent = nil
+		only.StartByte, only.EndByte = holder.StartByte, holder.EndByte
+		return only
+	}
+	if modifier := p.modifier(lo, last); modifier != "" {
+		holder.Type = modifier
+	}
+	return holder
+}
+
+// modifier returns the node type of a trailing if/unless/while/until/rescue
+// modifier in the statement, or "".
+func (p *rubyParse) modifier(lo, last int) string {
+	for j := lo + 1; j <= last; j++ {
+		if m := p.match[j]; m > j && m <= last {
+			j = m
+			continue
+		}
+		tok := p.toks[j]
+		switch {
+		case tok.kind != tokIdent || memberAccess(p.toks, j):
+		case tok.text == "if" || tok.text == "unless" || tok.text == "while" || tok.text == "until" || tok.text == "rescue":
+			return tok.text + "_modifier"
+		}
+	}
+	return ""
+}
+
+// command recognises a call without parentheses, such as require "x" or
+// attr_reader :name, and returns nil for anything else.
+func (p *rubyParse) command(lo, last int) *Node {
+	if !p.isWord(lo, last+1) {
+		return nil
+	}
+
+	k := p.chainEnd(lo, last+1)
+	after := k + 1
+	if after > last || !p.argumentStart(k, after) {
+		return nil
+	}
+
+	end := last
+	for j := after; j <= last; j++ {
+		if p.isOpener(j) && p.toks[j].is("do") {
+			end = j - 1
+			break
+		}
+		if m := p.match[j]; m > j && m <= last {
+			j = m
+		}
+	}
+	if p.modifier(lo, end) != "" {
+		return nil
+	}
+
+	call := p.span("call", lo, last)
+	p.callee(call, lo, k)
+	if end >= after {
+		args := p.span("argument_list", after, end)
+		p.scanCalls(args, after, end+1)
+		call.addField("arguments", args)
+	}
+	if end < last {
+		p.scanCalls(call, end+1, last+1)
+	}
+	return call
+}
+
+func (p *rubyParse) argumentStart(k, after int) bool {
+	tok := p.toks[after]
+	if p.adjacent(k, after) && !tok.is(":") {
+		return false
+	}
+
+	switch tok.kind {
+	case tokString, tokNumber:
+		return true
+	case tokIdent:
+		return !rubyKeywords[tok.text] || tok.text == "nil" || tok.text == "true" || tok.text == "false" || tok.is("do") && p.isOpener(after)
+	case tokPunct:
+		// Symbols, splats and literals, but not binary operators.
+		return tok.is(":") || tok.is("[") || tok.is("*") && p.adjacent(after, after+1) || tok.is("-") && after+1 < len(p.toks) && p.toks[after+1].kind == tokNumber && p.adjacent(after, after+1)
+	}
+	return false
+}
+
+// block builds the node for the keyword block opening at j, adds it to
+// parent and returns the index of its "end".
+func (p *rubyParse) block(parent *Node, j, hi int) int {
+	end := p.close(j, hi)
+	word := p.toks[j].text
+	header := p.headerEnd(j, end)
+
+	var node *Node
+	switch word {
+	case "def":
+		node = p.method(j, header, end, end)
+	case "class", "module":
+		node = p.span(word, j, end)
+		name := j + 1
+		switch {
+		case word == "class" && p.at(name, end, "<"):
+			node.Type = "singleton_class"
+			p.scanCalls(node, name+2, header)
+		case name < header && p.toks[name].kind == tokIdent:
+			k := p.chainEnd(name, header)
+			nameNode := p.identNode(k)
+			if k > name {
+				nameNode = p.span("scope_resolution", name, k)
+				nameNode.add(p.identNode(name), p.identNode(k))
+			}
+			node.addField("name", nameNode)
+			if p.at(k+1, header, "<") {
+				superclass := p.span("superclass", k+1, header-1)
+				p.scanCalls(superclass, k+2, header)
+				node.addField("superclass", superclass)
+			}
+		}
+		p.bodyStatement(node, header+1, end)
+	case "do":
+		node = p.span("do_block", j, end)
+		start := j + 1
+		if p.at(start, end, "|") {
+			closing := start + 1
+			for closing < end && !p.toks[closing].is("|") {
+				closing++
+			}
+			node.addField("parameters", p.span("block_parameters", start, closing))
+			start = closing + 1
+		}
+		p.bodyStatement(node, start, end)
+	default:
+		// if, unless, while, until, for, case and begin keep their keyword
+		// as the node type.
+		node = p.span(word, j, end)
+		p.scanCalls(node, j+1, header)
+		p.body(node, header, end)
+	}
+
+	parent.add(node)
+	return end
+}
+
+// headerEnd returns the index that ends the header of the block opened at j:
+// the first newline, ";", "then" or loop "do" at top level.
+func (p *rubyParse) headerEnd(j, end int) int {
+	for i := j + 1; i < end; i++ {
+		tok := p.toks[i]
+		if tok.kind == tokNewline || tok.is(";") || tok.is("then") || tok.is("do") && !p.isOpener(i) {
+			return i
+		}
+		if m := p.match[i]; m > i && m < end {
+			i = m
+		}
+	}
+	return end
+}
+
+// method builds a method whose body is [header, bodyEnd) and which ends at
+// token last ("end", or the last token of an endless def).
+func (p *rubyParse) method(j, header, bodyEnd, last int) *Node {
+	node := p.span("method", j, last)
+	name := j + 1
+	if name+1 < header && p.toks[name+1].is(".") {
+		node.Type = "singleton_method"
+		node.addField("object", p.identNode(name))
+		name += 2
+	}
+	if name < header {
+		nameType := "identifier"
+		if p.toks[name].kind == tokPunct {
+			nameType = "operator"
+		}
+		node.addField("name", newNode(nameType, p.toks[name].start, p.toks[name].end))
+	}
+
+	params := name + 1
+	switch {
+	case p.at(params, bodyEnd, "("):
+		m := p.close(params, bodyEnd)
+		node.addField("parameters", p.span("method_parameters", params, m))
+		header = max(header, m+1)
+	case params < header && !p.toks[params].is("="):
+		node.addField("parameters", p.span("method_parameters", params, header-1))
+	}
+
+	p.bodyStatement(node, header, bodyEnd)
+	return node
+}
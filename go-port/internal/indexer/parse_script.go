@@ -0,0 +1,458 @@
+package indexer
+
+import "strings"
+
+// scriptSyntax configures the token machinery shared by the line-oriented
+// parsers (Python, Ruby, Lua, Elixir and Bash).
+type scriptSyntax struct {
+	lex      *lexSpec
+	keywords map[string]bool
+	// blocks classifies toks[i] as a keyword block opener ("def", "do") or
+	// closer ("end", "fi"). It is called once per identifier in source order,
+	// so implementations may keep state. Nil for indentation-based languages.
+	blocks func(toks []token, i int) (opens, closes bool)
+	// caseParens tolerates unmatched ")" inside keyword blocks (shell case
+	// patterns).
+	caseParens bool
+	// continuers lists punctuation that continues a statement onto the next
+	// line when it ends one.
+	continuers string
+	// leaders lists punctuation that continues the previous statement when it
+	// starts a line (Ruby and Elixir method chains and pipes).
+	leaders string
+
+	call   string
+	callee string
+	ident  string
+	// member wraps dotted callees; memberOps lists the joining operators.
+	member    string
+	memberOps []string
+	// methodMember replaces member when the last joint is ":" (Lua a:b()).
+	methodMember string
+	property     string
+	args         string
+	// table is the node type of "{...}" literals, if they are emitted.
+	table string
+	// bareArgs treats an adjacent string or table as call arguments (Lua).
+	bareArgs bool
+	// identType overrides ident per token (Ruby @ivars, Elixir aliases).
+	identType func(text string) string
+	// dotCalls treats member chains without arguments as calls (Ruby).
+	dotCalls bool
+	// blockArgs attaches a following do ... end block to a call, and
+	// braceBlock names the node for a following {...} block.
+	blockArgs  bool
+	braceBlock string
+}
+
+type scriptParse struct {
+	syn      *scriptSyntax
+	src      []byte
+	toks     []token
+	match    []int
+	hasError bool
+	// opener builds the node for a keyword block met inside an expression
+	// and returns the index of its closer.
+	opener func(parent *Node, j, hi int) int
+}
+
+func newScriptParse(syn *scriptSyntax, src []byte) *scriptParse {
+	p := &scriptParse{syn: syn, src: src}
+	for _, tok := range lex(src, syn.lex) {
+		if tok.kind != tokComment {
+			p.toks = append(p.toks, tok)
+		}
+	}
+	p.pair()
+	return p
+}
+
+// pair matches brackets and keyword blocks. Unbalanced input sets hasError
+// and leaves the affected tokens unmatched (-1).
+func (p *scriptParse) pair() {
+	p.match = make([]int, len(p.toks))
+	var stack []int
+	closesBracket := map[string]string{")": "(", "]": "[", "}": "{"}
+
+	for i, tok := range p.toks {
+		p.match[i] = -1
+
+		var opens, closes bool
+		want := ""
+		switch {
+		case tok.kind == tokPunct && (tok.text == "(" || tok.text == "[" || tok.text == "{"):
+			opens = true
+		case tok.kind == tokPunct && closesBracket[tok.text] != "":
+			closes, want = true, closesBracket[tok.text]
+		case tok.kind == tokIdent && p.syn.blocks != nil:
+			opens, closes = p.syn.blocks(p.toks, i)
+		}
+
+		if opens {
+			stack = append(stack, i)
+			continue
+		}
+		if !closes {
+			continue
+		}
+
+		if want == "(" && p.syn.caseParens && len(stack) > 0 && p.toks[stack[len(stack)-1]].kind == tokIdent {
+			continue
+		}
+		for len(stack) > 0 && !p.closes(stack[len(stack)-1], want) {
+			stack = stack[:len(stack)-1]
+			p.hasError = true
+		}
+		if len(stack) == 0 {
+			p.hasError = true
+			continue
+		}
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		p.match[top], p.match[i] = i, top
+	}
+
+	if len(stack) > 0 {
+		p.hasError = true
+	}
+}
+
+// closes reports whether a closer expecting bracket want ("" for keyword
+// closers) pairs with the opener at i.
+func (p *scriptParse) closes(i int, want string) bool {
+	if want == "" {
+		return p.toks[i].kind == tokIdent
+	}
+	return p.toks[i].text == want
+}
+
+// close returns the token matching the opener at j, clamped to hi-1.
+func (p *scriptParse) close(j, hi int) int {
+	if m := p.match[j]; m > j && m < hi {
+		return m
+	}
+	return hi - 1
+}
+
+func (p *scriptParse) at(i, hi int, text string) bool {
+	return i >= 0 && i < hi && p.toks[i].is(text)
+}
+
+// adjacent reports whether tokens a and b touch; false when either is
+// past the end, as the token after a trailing operator is.
+func (p *scriptParse) adjacent(a, b int) bool {
+	return a >= 0 && b < len(p.toks) && p.toks[a].end == p.toks[b].start
+}
+
+func (p *scriptParse) isWord(i, hi int) bool {
+	return i >= 0 && i < hi && p.toks[i].kind == tokIdent && !p.syn.keywords[p.toks[i].text]
+}
+
+func (p *scriptParse) isOpener(i int) bool {
+	return p.toks[i].kind == tokIdent && p.match[i] > i
+}
+
+func (p *scriptParse) sig(i, hi int) int {
+	for i < hi && p.toks[i].kind == tokNewline {
+		i++
+	}
+	return i
+}
+
+func (p *scriptParse) lastSig(lo, hi int) int {
+	for hi--; hi > lo && p.toks[hi].kind == tokNewline; hi-- {
+	}
+	return hi
+}
+
+// column returns the 0-based byte column of toks[i].
+func (p *scriptParse) column(i int) int {
+	start := p.toks[i].start
+	return start - (strings.LastIndexByte(string(p.src[:start]), '\n') + 1)
+}
+
+func (p *scriptParse) span(nodeType string, lo, last int) *Node {
+	return newNode(nodeType, p.toks[lo].start, p.toks[last].end)
+}
+
+// statements splits [lo, hi) into statements at newlines and ";" outside
+// brackets and keyword blocks. Each range is returned as [first, last]
+// token indexes.
+func (p *scriptParse) statements(lo, hi int, separator func(i int) bool) [][2]int {
+	var out [][2]int
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			out = append(out, [2]int{start, p.lastSig(start, end)})
+			start = -1
+		}
+	}
+
+	for j := lo; j < hi; j++ {
+		tok := p.toks[j]
+		if tok.kind == tokNewline {
+			if start >= 0 && !p.continues(j, hi) {
+				flush(j)
+			}
+			continue
+		}
+		if separator != nil && separator(j) || tok.is(";") {
+			flush(j)
+			continue
+		}
+
+		if start < 0 {
+			start = j
+		}
+		if m := p.match[j]; m > j && m < hi {
+			j = m
+		}
+	}
+	flush(hi)
+	return out
+}
+
+// continues reports whether the newline at j continues the statement.
+func (p *scriptParse) continues(j, hi int) bool {
+	if j > 0 {
+		prev := p.toks[j-1]
+		if prev.kind == tokPunct && strings.Contains(p.syn.continuers, prev.text) {
+			return true
+		}
+	}
+
+	next := p.sig(j, hi)
+	if next < hi && p.syn.leaders != "" {
+		tok := p.toks[next]
+		return tok.kind == tokPunct && strings.Contains(p.syn.leaders, tok.text) && !(next+1 < hi && tok.is(".") && p.toks[next+1].is("."))
+	}
+	return false
+}
+
+// assignment returns the index of the top-level "=" in [lo, hi) that is an
+// assignment (not ==, !=, <=, >=, => or =~), or -1.
+func (p *scriptParse) assignment(lo, hi int) int {
+	for j := lo; j < hi; j++ {
+		if m := p.match[j]; m > j && m < hi {
+			j = m
+			continue
+		}
+		if !p.toks[j].is("=") {
+			continue
+		}
+		if j+1 < hi && p.adjacent(j, j+1) && strings.Contains("=>~", p.toks[j+1].text) {
+			j++
+			continue
+		}
+		if j > lo && p.adjacent(j-1, j) && strings.Contains("=!<>", p.toks[j-1].text) && !p.shiftAssign(j, lo) {
+			continue
+		}
+		return j
+	}
+	return -1
+}
+
+// augmented reports whether the assignment at j is an operator assignment
+// such as += or <<=.
+func (p *scriptParse) augmented(j, lo int) bool {
+	if j <= lo || !p.adjacent(j-1, j) {
+		return false
+	}
+	prev := p.toks[j-1]
+	return prev.kind == tokPunct && strings.Contains("+-*/%&|^@<>", prev.text)
+}
+
+func (p *scriptParse) shiftAssign(j, lo int) bool {
+	return j-2 >= lo && p.adjacent(j-2, j-1) && (p.toks[j-1].is("<") && p.toks[j-2].is("<") || p.toks[j-1].is(">") && p.toks[j-2].is(">"))
+}
+
+// scanCalls adds calls, identifiers and tables found in [lo, hi) to parent.
+func (p *scriptParse) scanCalls(parent *Node, lo, hi int) {
+	for j := lo; j < hi; j++ {
+		tok := p.toks[j]
+		switch {
+		case p.isOpener(j) && p.opener != nil:
+			j = p.opener(parent, j, hi)
+		case p.isWord(j, hi):
+			j = p.scanWord(parent, j, hi)
+		case tok.kind == tokPunct && p.match[j] > j:
+			m := p.close(j, hi)
+			target := parent
+			if tok.is("{") && p.syn.table != "" {
+				target = p.span(p.syn.table, j, m)
+				parent.add(target)
+			}
+			p.scanCalls(target, j+1, m)
+			j = m
+		}
+	}
+}
+
+// chainEnd returns the last token of the member chain starting at j.
+func (p *scriptParse) chainEnd(j, hi int) int {
+	for {
+		next := p.memberOp(j+1, hi)
+		if next < 0 || next >= hi || p.toks[next].kind != tokIdent {
+			return j
+		}
+		j = next
+	}
+}
+
+// memberOp returns the token after the member operator starting at i, or -1.
+func (p *scriptParse) memberOp(i, hi int) int {
+	for _, op := range p.syn.memberOps {
+		j, text := i, ""
+		for j < hi && p.toks[j].kind == tokPunct && len(text) < len(op) && (j == i || p.adjacent(j-1, j)) {
+			text += p.toks[j].text
+			j++
+		}
+		if text == op {
+			return j
+		}
+	}
+	return -1
+}
+
+// scanWord handles the identifier at j: a call when arguments follow,
+// otherwise a plain identifier or member expression. It returns the last
+// consumed token.
+func (p *scriptParse) scanWord(parent *Node, j, hi int) int {
+	syn := p.syn
+	k := p.chainEnd(j, hi)
+	after := k + 1
+
+	switch {
+	case p.at(after, hi, "(") && p.match[after] > after:
+		m := p.close(after, hi)
+		call := p.span(syn.call, j, m)
+		p.callee(call, j, k)
+		args := p.span(syn.args, after, m)
+		p.scanCalls(args, after+1, m)
+		parent.add(call.addField("arguments", args))
+		return p.attachBlock(call, m, hi)
+	case syn.blockArgs && after < hi && p.isOpener(after) && p.toks[after].is("do"),
+		syn.dotCalls && k > j:
+		call := p.span(syn.call, j, k)
+		p.callee(call, j, k)
+		parent.add(call)
+		return p.attachBlock(call, k, hi)
+	case syn.bareArgs && after < hi && p.adjacentArgs(k, after):
+		end := after
+		if p.toks[after].is("{") {
+			end = p.close(after, hi)
+		}
+		call := p.span(syn.call, j, end)
+		p.callee(call, j, k)
+		args := p.span(syn.args, after, end)
+		p.scanCalls(args, after, end+1)
+		parent.add(call.addField("arguments", args))
+		return end
+	}
+
+	p.chain(parent, j, k, "")
+	return k
+}
+
+// attachBlock adds a do ... end or {...} block following the call ending at
+// last to the call and returns the last consumed token.
+func (p *scriptParse) attachBlock(call *Node, last, hi int) int {
+	next := last + 1
+	if !p.syn.blockArgs || next >= hi || p.match[next] <= next {
+		return last
+	}
+
+	switch {
+	case p.toks[next].is("do") && p.opener != nil:
+		last = p.opener(call, next, hi)
+	case p.toks[next].is("{") && p.syn.braceBlock != "":
+		last = p.close(next, hi)
+		block := p.span(p.syn.braceBlock, next, last)
+		p.scanCalls(block, next+1, last)
+		call.addField("block", block)
+	default:
+		return last
+	}
+	call.EndByte = p.toks[last].end
+	return last
+}
+
+func (p *scriptParse) adjacentArgs(k, after int) bool {
+	tok := p.toks[after]
+	return tok.kind == tokString || tok.is("{") && p.match[after] > after && p.toks[k].end <= tok.start
+}
+
+func (p *scriptParse) callee(call *Node, j, k int) {
+	p.chain(call, j, k, p.syn.callee)
+}
+
+// chain adds the identifier or member expression spanning [j, k].
+func (p *scriptParse) chain(parent *Node, j, k int, field string) {
+	syn := p.syn
+	if j == k {
+		parent.addField(field, p.identNode(j))
+		return
+	}
+	if syn.member == "" {
+		// Flat receiver/method fields (Ruby calls).
+		receiver := p.identNode(j)
+		if k > p.chainStep(j) {
+			receiver = p.span(syn.call, j, p.prevIdent(k))
+			p.chain(receiver, j, p.prevIdent(k), syn.callee)
+		}
+		parent.addField("receiver", receiver)
+		parent.addField(field, newNode(syn.property, p.toks[k].start, p.toks[k].end))
+		return
+	}
+
+	memberType := syn.member
+	if syn.methodMember != "" && p.toks[k-1].is(":") {
+		memberType = syn.methodMember
+	}
+	member := p.span(memberType, j, k)
+	member.add(p.identNode(j))
+	for i := j + 1; i <= k; i++ {
+		if p.toks[i].kind == tokIdent {
+			member.add(newNode(syn.property, p.toks[i].start, p.toks[i].end))
+		}
+	}
+	parent.addField(field, member)
+}
+
+// chainStep returns the identifier following the one at j in a chain.
+func (p *scriptParse) chainStep(j int) int {
+	return p.memberOp(j+1, len(p.toks))
+}
+
+func (p *scriptParse) prevIdent(k int) int {
+	for k--; p.toks[k].kind != tokIdent; k-- {
+	}
+	return k
+}
+
+func (p *scriptParse) identNode(i int) *Node {
+	nodeType := p.syn.ident
+	if p.syn.identType != nil {
+		nodeType = p.syn.identType(p.toks[i].text)
+	}
+	return newNode(nodeType, p.toks[i].start, p.toks[i].end)
+}
+
+// commandPosition reports whether toks[i] starts a statement: it follows the
+// start of input, a newline, ";" or one of the given words or punctuation.
+func commandPosition(toks []token, i int, after map[string]bool) bool {
+	if i == 0 {
+		return true
+	}
+	prev := toks[i-1]
+	return prev.kind == tokNewline || prev.is(";") || (prev.kind == tokIdent || prev.kind == tokPunct) && after[prev.text]
+}
+
+// memberAccess reports whether the word at i is used as a method or key
+// (x.end, end:) rather than as a keyword.
+func memberAccess(toks []token, i int) bool {
+	if i > 0 && toks[i-1].is(".") && toks[i-1].end == toks[i].start {
+		return true
+	}
+	return i+1 < len(toks) && toks[i+1].is(":") && toks[i].end == toks[i+1].start && !(i+2 < len(toks) && toks[i+2].is(":"))
+}
@@ -0,0 +1,98 @@
+package indexer
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrUnsupportedLanguage is returned when no parser is registered for a language.
+var ErrUnsupportedLanguage = errors.New("unsupported language")
+
+// Parser turns source text into a syntax tree for one language.
+//
+// The built-in parsers are pure Go so the binary stays CGO-free: Go sources
+// use go/parser, everything else uses structural parsers that emit the node
+// types and byte ranges of the corresponding tree-sitter grammar for the
+// constructs the indexer consumes (declarations, bodies, statements, calls
+// and identifiers). Like tree-sitter they never reject input; malformed code
+// yields a best-effort tree with Tree.HasError set. The exception is a Go
+// source without a package clause, which go/parser cannot position.
+type Parser interface {
+	Parse(source []byte) (*Tree, error)
+}
+
+// ParserFunc adapts a function to the Parser interface.
+type ParserFunc func(source []byte) (*Tree, error)
+
+func (f ParserFunc) Parse(source []byte) (*Tree, error) {
+	return f(source)
+}
+
+var parsers = map[string]Parser{
+	"go":         ParserFunc(parseGo),
+	"javascript": braceParser(javascriptDialect),
+	"typescript": braceParser(typescriptDialect),
+	"tsx":        braceParser(tsxDialect),
+	"java":       braceParser(javaDialect),
+	"csharp":     braceParser(csharpDialect),
+	"c":          braceParser(cDialect),
+	"cpp":        braceParser(cppDialect),
+	"rust":       braceParser(rustDialect),
+	"php":        braceParser(phpDialect),
+	"kotlin":     braceParser(kotlinDialect),
+	"scala":      braceParser(scalaDialect),
+	"swift":      braceParser(swiftDialect),
+	"python":     ParserFunc(parsePython),
+	"ruby":       ParserFunc(parseRuby),
+	"lua":        ParserFunc(parseLua),
+	"elixir":     ParserFunc(parseElixir),
+	"bash":       ParserFunc(parseBash),
+	"haskell":    ParserFunc(parseHaskell),
+	"ocaml":      ParserFunc(parseOCaml),
+	"html":       ParserFunc(parseHTML),
+	"css":        ParserFunc(parseCSS),
+	"json":       ParserFunc(parseJSON),
+	"markdown":   ParserFunc(parseMarkdown),
+}
+
+// RegisterParser installs or replaces the parser for lang, e.g. to plug in a
+// wasm tree-sitter runtime for a language.
+func RegisterParser(lang string, parser Parser) {
+	parsers[lang] = parser
+}
+
+// ParserFor returns the parser registered for lang.
+func ParserFor(lang string) (Parser, bool) {
+	parser, ok := parsers[lang]
+	return parser, ok
+}
+
+// Languages lists the languages that have a parser, sorted.
+func Languages() []string {
+	langs := make([]string, 0, len(parsers))
+	for lang := range parsers {
+		langs = append(langs, lang)
+	}
+	slices.Sort(langs)
+	return langs
+}
+
+// Parse parses source with the parser registered for lang.
+func Parse(lang string, source []byte) (*Tree, error) {
+	parser, ok := ParserFor(lang)
+	if !ok {
+		return nil, fmt.Errorf("parse %s: %w", lang, ErrUnsupportedLanguage)
+	}
+
+	tree, err := parser.Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", lang, err)
+	}
+	return tree, nil
+}
+
+// ParseFile parses source using the language of rule.
+func ParseFile(rule LangRule, source []byte) (*Tree, error) {
+	return Parse(rule.Lang, source)
+}
@@ -0,0 +1,437 @@
+package indexer
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/alessandrojcm/pampax-go/internal/codemap"
+)
+
+// CallGraph resolves the calls of Go, JavaScript, TypeScript and Python
+// chunks through the imports of their files, so a call reaches the
+// definition it imports rather than any symbol of the same name: package
+// paths and aliases for Go, relative specifiers for JavaScript, dotted
+// modules for Python, and the receiver or class for calls on the instance.
+// Calls with several candidates are reported instead of resolved. It
+// implements codemap.CallResolver.
+type CallGraph struct {
+	goModule string
+	files    map[string]*graphFile
+	// paths keeps the files in the order they were added, so lookups that
+	// scan them are stable.
+	paths []string
+}
+
+type graphFile struct {
+	path    string
+	family  string
+	pkg     string
+	module  string
+	imports []Import
+	chunks  map[string]Chunk
+	defs    []graphDef
+}
+
+type graphDef struct {
+	sha   string
+	name  string
+	owner string
+}
+
+const (
+	familyGo     = "go"
+	familyScript = "script"
+	familyPython = "python"
+)
+
+var scriptExtensions = []string{".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs", ".mts", ".cts"}
+
+// NewCallGraph creates a CallGraph for a repository whose go.mod declares
+// goModule, which may be empty.
+func NewCallGraph(goModule string) *CallGraph {
+	return &CallGraph{goModule: goModule, files: map[string]*graphFile{}}
+}
+
+var goModuleLine = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+
+// GoModulePath returns the module path of the go.mod at the root of repo,
+// or "" when there is none.
+func GoModulePath(repo string) string {
+	data, err := os.ReadFile(filepath.Join(repo, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	if match := goModuleLine.FindSubmatch(data); match != nil {
+		return string(match[1])
+	}
+	return ""
+}
+
+func graphFamily(lang string) string {
+	switch lang {
+	case "go":
+		return familyGo
+	case "javascript", "typescript", "tsx":
+		return familyScript
+	case "python":
+		return familyPython
+	}
+	return ""
+}
+
+// ResolvesCalls reports whether a CallGraph resolves the calls of lang
+// sources; the calls of other languages keep the symbol-name lookup.
+func ResolvesCalls(lang string) bool {
+	return graphFamily(lang) != ""
+}
+
+func graphPath(file string) string {
+	return strings.TrimPrefix(filepath.ToSlash(file), "./")
+}
+
+// AddFile records the imports and chunks of file, a lang source relative
+// to the repository root. Files of other languages are ignored.
+func (g *CallGraph) AddFile(file, lang string, source []byte, chunks []Chunk) {
+	family := graphFamily(lang)
+	if family == "" {
+		return
+	}
+	file = graphPath(file)
+	f := &graphFile{path: file, family: family, imports: ExtractImports(source, lang), chunks: map[string]Chunk{}}
+	switch family {
+	case familyGo:
+		f.pkg = GoPackageName(source)
+	case familyPython:
+		f.module = pythonModule(file)
+	}
+	for _, chunk := range chunks {
+		f.chunks[chunk.SHA] = chunk
+		for _, decl := range chunk.Declares {
+			// Parts of a subdivided declaration all declare it; calls reach
+			// the first.
+			if !slices.ContainsFunc(f.defs, func(d graphDef) bool { return d.name == decl.Name && d.owner == decl.Owner }) {
+				f.defs = append(f.defs, graphDef{sha: chunk.SHA, name: decl.Name, owner: decl.Owner})
+			}
+		}
+	}
+	if _, exists := g.files[file]; !exists {
+		g.paths = append(g.paths, file)
+	}
+	g.files[file] = f
+}
+
+// ResolveCalls implements codemap.CallResolver.
+func (g *CallGraph) ResolveCalls(entry codemap.ChunkMetadata) ([]string, []codemap.AmbiguousCall, bool) {
+	f := g.files[graphPath(entry.File)]
+	if f == nil {
+		return nil, nil, false
+	}
+	chunk, ok := f.chunks[entry.SHA]
+	if !ok {
+		return nil, nil, false
+	}
+
+	var targets []string
+	var ambiguous []codemap.AmbiguousCall
+	for _, call := range chunk.Calls {
+		var shas []string
+		for _, def := range g.candidates(f, chunk, call) {
+			if !slices.Contains(shas, def.sha) {
+				shas = append(shas, def.sha)
+			}
+		}
+		switch {
+		case len(shas) == 1:
+			if shas[0] != entry.SHA && !slices.Contains(targets, shas[0]) {
+				targets = append(targets, shas[0])
+			}
+		case len(shas) > 1:
+			written := call.Name
+			if call.Qualifier != "" {
+				written = call.Qualifier + "." + call.Name
+			}
+			ambiguous = append(ambiguous, codemap.AmbiguousCall{File: f.path, Symbol: chunk.Symbol, Call: written, Candidates: shas})
+		}
+	}
+	return targets, ambiguous, true
+}
+
+func (g *CallGraph) candidates(f *graphFile, chunk Chunk, call Call) []graphDef {
+	switch f.family {
+	case familyGo:
+		return g.goCandidates(f, chunk, call)
+	case familyScript:
+		return g.scriptCandidates(f, chunk, call)
+	default:
+		return g.pythonCandidates(f, chunk, call)
+	}
+}
+
+type ownerMatch func(owner string) bool
+
+func topLevel(owner string) bool { return owner == "" }
+func member(owner string) bool   { return owner != "" }
+
+func ownedBy(name string) ownerMatch {
+	return func(owner string) bool { return owner == name }
+}
+
+func defsNamed(files []*graphFile, name string, match ownerMatch) []graphDef {
+	var defs []graphDef
+	for _, f := range files {
+		for _, def := range f.defs {
+			if def.name == name && match(def.owner) {
+				defs = append(defs, def)
+			}
+		}
+	}
+	return defs
+}
+
+func (g *CallGraph) filesWhere(match func(*graphFile) bool) []*graphFile {
+	var files []*graphFile
+	for _, p := range g.paths {
+		if f := g.files[p]; match(f) {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+func (g *CallGraph) goPackage(dir string) []*graphFile {
+	return g.filesWhere(func(f *graphFile) bool { return f.family == familyGo && path.Dir(f.path) == dir })
+}
+
+// goImportDir returns the repository directory of a Go import path, from
+// the module path or, without one, the longest directory the path ends in.
+func (g *CallGraph) goImportDir(importPath string) (string, bool) {
+	if g.goModule != "" {
+		if importPath == g.goModule {
+			return ".", true
+		}
+		if rest, ok := strings.CutPrefix(importPath, g.goModule+"/"); ok {
+			return rest, len(g.goPackage(rest)) > 0
+		}
+		return "", false
+	}
+	best := ""
+	for _, f := range g.filesWhere(func(f *graphFile) bool { return f.family == familyGo }) {
+		dir := path.Dir(f.path)
+		if (importPath == dir || strings.HasSuffix(importPath, "/"+dir)) && len(dir) > len(best) {
+			best = dir
+		}
+	}
+	return best, best != ""
+}
+
+func (g *CallGraph) goCandidates(f *graphFile, chunk Chunk, call Call) []graphDef {
+	dir := path.Dir(f.path)
+	if call.Qualifier == "" {
+		if defs := defsNamed(g.goPackage(dir), call.Name, topLevel); len(defs) > 0 {
+			return defs
+		}
+		var defs []graphDef
+		for _, imp := range f.imports {
+			if importDir, ok := g.goImportDir(imp.Path); ok && imp.Alias == "." {
+				defs = append(defs, defsNamed(g.goPackage(importDir), call.Name, topLevel)...)
+			}
+		}
+		return defs
+	}
+
+	if call.Qualifier == chunk.Scope.Self && chunk.Scope.Self != "" {
+		return defsNamed(g.goPackage(dir), call.Name, ownedBy(chunk.Scope.Owner))
+	}
+	scope := g.goPackage(dir)
+	for _, imp := range f.imports {
+		importDir, resolved := g.goImportDir(imp.Path)
+		alias := imp.Alias
+		if alias == "" {
+			alias = path.Base(imp.Path)
+			if pkg := g.goPackage(importDir); resolved && len(pkg) > 0 && pkg[0].pkg != "" {
+				alias = pkg[0].pkg
+			}
+		}
+		if alias == call.Qualifier {
+			if !resolved {
+				return nil
+			}
+			return defsNamed(g.goPackage(importDir), call.Name, topLevel)
+		}
+		if resolved {
+			scope = append(scope, g.goPackage(importDir)...)
+		}
+	}
+	// A method on a value of unknown type: any method of that name in the
+	// package or the packages it imports.
+	return defsNamed(scope, call.Name, member)
+}
+
+// scriptModule returns the file a relative JavaScript specifier imports
+// from f, trying the extensions and index files the bundlers do.
+func (g *CallGraph) scriptModule(f *graphFile, spec string) *graphFile {
+	if !strings.HasPrefix(spec, ".") {
+		return nil
+	}
+	base := path.Join(path.Dir(f.path), spec)
+	tries := []string{base}
+	if ext := path.Ext(base); slices.Contains(scriptExtensions, ext) {
+		tries = append(tries, strings.TrimSuffix(base, ext))
+	}
+	for _, try := range slices.Clone(tries) {
+		for _, ext := range scriptExtensions {
+			tries = append(tries, try+ext, try+"/index"+ext)
+		}
+	}
+	for _, try := range tries {
+		if target := g.files[try]; target != nil && target.family == familyScript {
+			return target
+		}
+	}
+	return nil
+}
+
+func (g *CallGraph) scriptCandidates(f *graphFile, chunk Chunk, call Call) []graphDef {
+	head, rest, _ := strings.Cut(call.Qualifier, ".")
+	self := []*graphFile{f}
+
+	if call.Qualifier == "" {
+		for _, imp := range f.imports {
+			if imp.Alias != call.Name {
+				continue
+			}
+			target := g.scriptModule(f, imp.Path)
+			if target == nil {
+				return nil
+			}
+			name := imp.Name
+			if name == "" || name == "default" {
+				name = imp.Alias
+			}
+			return defsNamed([]*graphFile{target}, name, topLevel)
+		}
+		return defsNamed(self, call.Name, topLevel)
+	}
+
+	if call.Qualifier == chunk.Scope.Self && chunk.Scope.Self != "" {
+		return defsNamed(self, call.Name, ownedBy(chunk.Scope.Owner))
+	}
+	imported := []*graphFile{f}
+	for _, imp := range f.imports {
+		target := g.scriptModule(f, imp.Path)
+		if imp.Alias == head {
+			switch {
+			case target == nil:
+				return nil
+			case imp.Name == "" && rest == "":
+				return defsNamed([]*graphFile{target}, call.Name, topLevel)
+			case imp.Name == "default":
+				return defsNamed([]*graphFile{target}, call.Name, member)
+			default:
+				return defsNamed([]*graphFile{target}, call.Name, ownedBy(imp.Name))
+			}
+		}
+		if target != nil {
+			imported = append(imported, target)
+		}
+	}
+	if defs := defsNamed(self, call.Name, ownedBy(head)); len(defs) > 0 {
+		return defs
+	}
+	return defsNamed(imported, call.Name, member)
+}
+
+// pythonModule returns the dotted module of a Python file.
+func pythonModule(file string) string {
+	module := strings.ReplaceAll(strings.TrimSuffix(file, ".py"), "/", ".")
+	return strings.TrimSuffix(strings.TrimSuffix(module, "__init__"), ".")
+}
+
+// pythonModules returns the files of a module imported from f. Relative
+// modules start from f's package; absolute ones match a module or, in src
+// layouts, the end of one.
+func (g *CallGraph) pythonModules(f *graphFile, module string) []*graphFile {
+	if trimmed := strings.TrimLeft(module, "."); trimmed != module {
+		parts := strings.Split(f.module, ".")
+		if !strings.HasSuffix(f.path, "__init__.py") {
+			parts = parts[:len(parts)-1]
+		}
+		up := len(module) - len(trimmed) - 1
+		if up > len(parts) {
+			return nil
+		}
+		parts = parts[:len(parts)-up]
+		if trimmed != "" {
+			parts = append(parts, trimmed)
+		}
+		absolute := strings.Join(parts, ".")
+		return g.filesWhere(func(m *graphFile) bool { return m.family == familyPython && m.module == absolute })
+	}
+
+	if exact := g.filesWhere(func(m *graphFile) bool { return m.family == familyPython && m.module == module }); len(exact) > 0 {
+		return exact
+	}
+	return g.filesWhere(func(m *graphFile) bool {
+		return m.family == familyPython && strings.HasSuffix(m.module, "."+module)
+	})
+}
+
+func joinModule(module, name string) string {
+	if strings.HasSuffix(module, ".") {
+		return module + name
+	}
+	return module + "." + name
+}
+
+func (g *CallGraph) pythonCandidates(f *graphFile, chunk Chunk, call Call) []graphDef {
+	head, rest, _ := strings.Cut(call.Qualifier, ".")
+	self := []*graphFile{f}
+
+	if call.Qualifier == "" {
+		for _, imp := range f.imports {
+			if imp.Name != "" && imp.Name != "*" && imp.Alias == call.Name {
+				return defsNamed(g.pythonModules(f, imp.Path), imp.Name, topLevel)
+			}
+		}
+		if defs := defsNamed(self, call.Name, topLevel); len(defs) > 0 {
+			return defs
+		}
+		var defs []graphDef
+		for _, imp := range f.imports {
+			if imp.Name == "*" {
+				defs = append(defs, defsNamed(g.pythonModules(f, imp.Path), call.Name, topLevel)...)
+			}
+		}
+		return defs
+	}
+
+	if call.Qualifier == chunk.Scope.Self && chunk.Scope.Self != "" {
+		return defsNamed(self, call.Name, ownedBy(chunk.Scope.Owner))
+	}
+	imported := []*graphFile{f}
+	for _, imp := range f.imports {
+		switch {
+		case imp.Name == "" && (call.Qualifier == imp.Alias || strings.HasPrefix(call.Qualifier, imp.Alias+".")):
+			module := imp.Path + strings.TrimPrefix(call.Qualifier, imp.Alias)
+			return defsNamed(g.pythonModules(f, module), call.Name, topLevel)
+		case imp.Name != "" && imp.Alias == head:
+			submodule := joinModule(imp.Path, imp.Name)
+			if rest != "" {
+				submodule += "." + rest
+			}
+			if modules := g.pythonModules(f, submodule); len(modules) > 0 {
+				return defsNamed(modules, call.Name, topLevel)
+			}
+			return defsNamed(g.pythonModules(f, imp.Path), call.Name, ownedBy(imp.Name))
+		}
+		imported = append(imported, g.pythonModules(f, imp.Path)...)
+	}
+	if defs := defsNamed(self, call.Name, ownedBy(head)); len(defs) > 0 {
+		return defs
+	}
+	return defsNamed(imported, call.Name, member)
+}
@@ -0,0 +1,359 @@
+package indexer
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Signature describes a chunk's declaration, as extractSymbolMetadata in
+// symbols/extract.js does for the codemap symbol_signature,
+// symbol_parameters and symbol_return fields.
+type Signature struct {
+	// Text reads "name(params) : return", with the type parameters after
+	// the name and, for Go methods, the receiver before it; classes and
+	// other type declarations read "class Name", "struct Name", ...
+	Text       string
+	Parameters []string
+	Return     string
+}
+
+// maxParameters caps Signature.Parameters, as in symbols/extract.js.
+const maxParameters = 12
+
+// typeDeclarationKinds name the nodes whose signature is their kind and
+// name; Node only does this for classes.
+var typeDeclarationKinds = []string{"class", "struct", "interface", "trait", "impl", "enum", "module", "mod", "namespace"}
+
+// prefixReturnLangs write the return type before the function name.
+var prefixReturnLangs = []string{"java", "csharp", "c", "cpp"}
+
+var (
+	declarationModifiers = []string{
+		"public", "private", "protected", "internal", "static", "final", "abstract", "synchronized",
+		"native", "default", "async", "override", "virtual", "sealed", "extern", "inline", "unsafe",
+		"partial", "readonly", "new", "constexpr", "explicit", "friend", "strictfp", "transient", "volatile",
+	}
+	leadingAnnotation = regexp.MustCompile(`^(?:@[\w.]+(?:\([^)]*\))?|\[[^\]]*\])\s*`)
+	clauseStart       = regexp.MustCompile(`(?:^|\s)(?:where|throws)\b`)
+	suffixReturn      = regexp.MustCompile(`^(?:(?:async|throws|rethrows)\s+)*(?::|->)\s*`)
+	textualReturn     = []*regexp.Regexp{
+		regexp.MustCompile(`^\s*:\s*([A-Za-z0-9_\\\[\]<>|?]+)`),
+		regexp.MustCompile(`^\s*->\s*([A-Za-z0-9_\\\[\]<>|?]+)`),
+	}
+)
+
+// ExtractSymbolSignature returns the signature of node, a chunk named
+// symbol in a lang file. It reads the name, parameters and body fields of
+// the syntax tree, and falls back to the text scan of symbols/extract.js
+// for nodes without them, such as merged chunks.
+func ExtractSymbolSignature(node *Node, source []byte, symbol, lang string) Signature {
+	symbol = strings.TrimSpace(symbol)
+	if symbol == "" {
+		return Signature{}
+	}
+	// export_statement and decorated_definition wrap the declaration.
+	for _, field := range []string{"declaration", "definition"} {
+		if inner := node.ChildByField(field); inner != nil {
+			node = inner
+		}
+	}
+	// The chunk symbol keeps Node's first-identifier naming, which for Go
+	// methods is the receiver; the signature uses the declared name.
+	if name := node.ChildByField("name"); name != nil {
+		symbol = name.Text(source)
+	}
+	if kind := typeDeclarationKind(node.Type); kind != "" {
+		return Signature{Text: kind + " " + symbol}
+	}
+
+	var sig Signature
+	var typeParams, receiver string
+	if params := findField(node, "parameters"); params != nil {
+		sig.Parameters = splitParameters(trimParens(params.Text(source)))
+		name := findField(node, "name")
+		if name == nil {
+			name = findField(node, "declarator")
+		}
+		if name != nil && name.EndByte <= params.StartByte {
+			typeParams = strings.TrimSpace(string(source[name.EndByte:params.StartByte]))
+			if !strings.HasPrefix(typeParams, "<") && !strings.HasPrefix(typeParams, "[") {
+				typeParams = ""
+			}
+		}
+		if slices.Contains(prefixReturnLangs, lang) && name != nil {
+			var generics string
+			generics, sig.Return = prefixReturnType(string(source[node.StartByte:name.StartByte]))
+			if typeParams == "" {
+				typeParams = generics
+			}
+		} else {
+			sig.Return = suffixReturnType(node, params, source, lang)
+		}
+		if recv := node.ChildByField("receiver"); recv != nil && lang == "go" {
+			receiver = recv.Text(source)
+		}
+	} else {
+		sig.Parameters, sig.Return = textualSignature(node.Text(source))
+	}
+
+	sig.Text = symbol + typeParams + "(" + strings.Join(sig.Parameters, ", ") + ")"
+	if receiver != "" {
+		sig.Text = receiver + " " + sig.Text
+	}
+	if sig.Return != "" {
+		sig.Text += " : " + sig.Return
+	}
+	return sig
+}
+
+func typeDeclarationKind(nodeType string) string {
+	kind, _, _ := strings.Cut(nodeType, "_")
+	if strings.Contains(nodeType, "class") {
+		return "class"
+	}
+	if slices.Contains(typeDeclarationKinds, kind) {
+		return kind
+	}
+	return ""
+}
+
+// findField returns the field child of n, looking into C declarators,
+// which nest the name and parameters one level down.
+func findField(n *Node, field string) *Node {
+	if child := n.ChildByField(field); child != nil {
+		return child
+	}
+	if declarator := n.ChildByField("declarator"); declarator != nil && field != "declarator" {
+		return findField(declarator, field)
+	}
+	return nil
+}
+
+func trimParens(text string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "(") && strings.HasSuffix(text, ")") {
+		text = text[1 : len(text)-1]
+	}
+	return strings.TrimSpace(text)
+}
+
+// splitParameters splits a parameter list at top-level commas and
+// normalizes each parameter as normalizeParameter does: defaults and
+// leading pointer or reference markers are dropped.
+func splitParameters(text string) []string {
+	var params []string
+	depth, angle, start := 0, 0, 0
+	add := func(raw string) {
+		if param := normalizeParameter(raw); param != "" && len(params) < maxParameters {
+			params = append(params, param)
+		}
+	}
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case '<':
+			angle++
+		case '>':
+			if angle > 0 && (i == 0 || (text[i-1] != '-' && text[i-1] != '=')) {
+				angle--
+			}
+		case ',':
+			if depth == 0 && angle == 0 {
+				add(text[start:i])
+				start = i + 1
+			}
+		}
+	}
+	add(text[start:])
+	return params
+}
+
+func normalizeParameter(param string) string {
+	for i := 0; i < len(param); i++ {
+		if param[i] != '=' {
+			continue
+		}
+		next := i+1 < len(param) && (param[i+1] == '=' || param[i+1] == '>')
+		prev := i > 0 && strings.ContainsRune("<>!=", rune(param[i-1]))
+		if !next && !prev {
+			param = param[:i]
+			break
+		}
+	}
+	return strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(param), "*&"))
+}
+
+// suffixReturnType reads the type between the parameters and the body:
+// Go results, "-> T" and ": T" annotations.
+func suffixReturnType(node, params *Node, source []byte, lang string) string {
+	end := node.EndByte
+	if body := node.ChildByField("body"); body != nil && body.StartByte >= params.EndByte {
+		end = body.StartByte
+	}
+	text := strings.TrimSpace(string(source[params.EndByte:end]))
+	if loc := clauseStart.FindStringIndex(text); loc != nil {
+		text = text[:loc[0]]
+	}
+	text = strings.TrimSpace(strings.TrimRight(text, "{:"))
+	text = strings.TrimSpace(strings.TrimSuffix(text, "=>"))
+	if lang == "go" {
+		return text
+	}
+	if loc := suffixReturn.FindStringIndex(text); loc != nil {
+		return strings.TrimSpace(text[loc[1]:])
+	}
+	return ""
+}
+
+// prefixReturnType reads the type written before the name, skipping
+// annotations, modifiers and a generic parameter list, which it returns.
+func prefixReturnType(prefix string) (generics, ret string) {
+	prefix = strings.Join(strings.Fields(prefix), " ")
+	for {
+		if loc := leadingAnnotation.FindStringIndex(prefix); loc != nil {
+			prefix = prefix[loc[1]:]
+			continue
+		}
+		word, rest, _ := strings.Cut(prefix, " ")
+		if slices.Contains(declarationModifiers, word) {
+			prefix = strings.TrimSpace(rest)
+			continue
+		}
+		break
+	}
+	if strings.HasPrefix(prefix, "<") {
+		if end := closingAngle(prefix); end > 0 {
+			generics, prefix = prefix[:end+1], strings.TrimSpace(prefix[end+1:])
+		}
+	}
+	return generics, prefix
+}
+
+func closingAngle(text string) int {
+	depth := 0
+	for i, r := range text {
+		switch r {
+		case '<':
+			depth++
+		case '>':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// textualSignature is buildSignature of symbols/extract.js: the first
+// parenthesized list of the first 400 bytes, and a ": T" or "-> T" after it.
+func textualSignature(code string) ([]string, string) {
+	snippet := code[:min(len(code), 400)]
+	open := strings.IndexByte(snippet, '(')
+	if open == -1 {
+		return nil, ""
+	}
+	depth := 0
+	for i := open; i < len(snippet); i++ {
+		switch snippet[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				params := splitParameters(snippet[open+1 : i])
+				after := snippet[i+1 : min(len(snippet), i+81)]
+				for _, re := range textualReturn {
+					if match := re.FindStringSubmatch(after); match != nil {
+						return params, match[1]
+					}
+				}
+				return params, ""
+			}
+		}
+	}
+	return nil, ""
+}
+
+// Declaration is a symbol a chunk defines: a function or type, or a
+// method of Owner.
+type Declaration struct {
+	Name  string
+	Owner string
+}
+
+// Scope is what the calls of a chunk's body made on Self reach: the members
+// of Owner.
+type Scope struct {
+	// Owner is the receiver type of a Go method, or the class a method or
+	// class body belongs to.
+	Owner string
+	// Self is the name the instance goes by: the Go receiver, "this", or
+	// the first parameter of a Python method.
+	Self string
+}
+
+var goReceiver = regexp.MustCompile(`^\(\s*(?:(\w+)\s+)?\*?\s*(\w+)`)
+
+// ExtractDeclaration returns what node declares, read from its name field,
+// and the scope of its body. The declaration is empty for nodes that
+// declare nothing, such as merged chunks.
+func ExtractDeclaration(node *Node, source []byte, lang string) (Declaration, Scope) {
+	for _, field := range []string{"declaration", "definition"} {
+		if inner := node.ChildByField(field); inner != nil {
+			node = inner
+		}
+	}
+	nameNode := node.ChildByField("name")
+	if nameNode == nil {
+		return Declaration{}, Scope{}
+	}
+	decl := Declaration{Name: nameNode.Text(source)}
+
+	switch lang {
+	case "go":
+		if recv := node.ChildByField("receiver"); recv != nil {
+			if match := goReceiver.FindStringSubmatch(recv.Text(source)); match != nil {
+				decl.Owner = match[2]
+				return decl, Scope{Owner: match[2], Self: match[1]}
+			}
+		}
+	case "javascript", "typescript", "tsx", "python":
+		self := "this"
+		if lang == "python" {
+			self = "self"
+		}
+		if strings.Contains(node.Type, "class") {
+			return decl, Scope{Owner: decl.Name, Self: self}
+		}
+		decl.Owner = enclosingClass(node, source)
+		if decl.Owner == "" {
+			return decl, Scope{}
+		}
+		if params := node.ChildByField("parameters"); params != nil && lang == "python" {
+			self = ""
+			if first := splitParameters(trimParens(params.Text(source))); len(first) > 0 {
+				self, _, _ = strings.Cut(first[0], ":")
+				self = strings.TrimSpace(self)
+			}
+		}
+		return decl, Scope{Owner: decl.Owner, Self: self}
+	}
+	return decl, Scope{}
+}
+
+// enclosingClass returns the name of the class node is a member of.
+func enclosingClass(node *Node, source []byte) string {
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		if strings.Contains(parent.Type, "function") || strings.Contains(parent.Type, "method") {
+			return ""
+		}
+		// class_body and the like carry no name; the declaration does.
+		if name := parent.ChildByField("name"); name != nil && strings.Contains(parent.Type, "class") {
+			return name.Text(source)
+		}
+	}
+	return ""
+}
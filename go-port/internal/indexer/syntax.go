@@ -0,0 +1,114 @@
+package indexer
+
+// Node is a syntax tree node shaped like a tree-sitter node: Type uses the
+// grammar's node type names and StartByte/EndByte delimit the node text, so
+// chunk boundaries (and therefore chunk SHAs) line up with the Node indexer.
+type Node struct {
+	Type      string
+	StartByte int
+	EndByte   int
+	Field     string
+	Children  []*Node
+	Parent    *Node
+}
+
+// Tree is a parsed source file. HasError mirrors tree-sitter's
+// rootNode.hasError: the tree is usable but some input did not parse cleanly.
+type Tree struct {
+	Lang     string
+	Source   []byte
+	Root     *Node
+	HasError bool
+}
+
+// Text returns the source text covered by n.
+func (n *Node) Text(source []byte) string {
+	if n == nil {
+		return ""
+	}
+	return string(source[n.StartByte:n.EndByte])
+}
+
+// ChildByField returns the first direct child tagged with field, mirroring
+// tree-sitter's childForFieldName.
+func (n *Node) ChildByField(field string) *Node {
+	if n == nil {
+		return nil
+	}
+
+	for _, child := range n.Children {
+		if child.Field == field {
+			return child
+		}
+	}
+	return nil
+}
+
+// ChildOfType returns the first direct child with the given type.
+func (n *Node) ChildOfType(nodeType string) *Node {
+	if n == nil {
+		return nil
+	}
+
+	for _, child := range n.Children {
+		if child.Type == nodeType {
+			return child
+		}
+	}
+	return nil
+}
+
+// Walk visits n and its descendants depth-first in source order. Returning
+// false from visit skips the node's children.
+func (n *Node) Walk(visit func(*Node) bool) {
+	if n == nil || !visit(n) {
+		return
+	}
+
+	for _, child := range n.Children {
+		child.Walk(visit)
+	}
+}
+
+// StartLine returns the 1-based line of the node start.
+func (n *Node) StartLine(source []byte) int {
+	return lineAt(source, n.StartByte)
+}
+
+// EndLine returns the 1-based line of the node end.
+func (n *Node) EndLine(source []byte) int {
+	return lineAt(source, n.EndByte)
+}
+
+func lineAt(source []byte, offset int) int {
+	line := 1
+	for i := 0; i < offset && i < len(source); i++ {
+		if source[i] == '\n' {
+			line++
+		}
+	}
+	return line
+}
+
+func newNode(nodeType string, start, end int) *Node {
+	return &Node{Type: nodeType, StartByte: start, EndByte: end}
+}
+
+func (n *Node) add(children ...*Node) *Node {
+	for _, child := range children {
+		if child == nil {
+			continue
+		}
+		child.Parent = n
+		n.Children = append(n.Children, child)
+	}
+	return n
+}
+
+func (n *Node) addField(field string, child *Node) *Node {
+	if child == nil {
+		return n
+	}
+	child.Field = field
+	return n.add(child)
+}
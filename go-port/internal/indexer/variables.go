@@ -0,0 +1,57 @@
+package indexer
+
+import (
+	"regexp"
+	"slices"
+)
+
+// Variable is an important variable declared in a chunk, stored as JSON in
+// the variables_used column.
+type Variable struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// importantVariable ports isImportantVariable: configuration-like, client
+// and ALL_CAPS constants, exported constants and static finals.
+var importantVariable = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)const\s+\w*(config|setting|option|endpoint|url|key|secret|token)\w*`),
+	regexp.MustCompile(`(?i)const\s+\w*(api|service|client|provider)\w*`),
+	regexp.MustCompile(`const\s+[A-Z_]{3,}`),
+	regexp.MustCompile(`export\s+const`),
+	regexp.MustCompile(`static\s+(final\s+)?[A-Z_]+`),
+}
+
+// maxVariableValue is the length at which a variable's text is cut.
+const maxVariableValue = 100
+
+// ExtractVariables ports extractImportantVariables: the nodes under node
+// of the rule's variable types whose text looks important.
+func ExtractVariables(node *Node, source []byte, rule LangRule) []Variable {
+	var variables []Variable
+	node.Walk(func(n *Node) bool {
+		if !slices.Contains(rule.VariableTypes, n.Type) {
+			return true
+		}
+		text := n.Text(source)
+		if !slices.ContainsFunc(importantVariable, func(re *regexp.Regexp) bool { return re.MatchString(text) }) {
+			return true
+		}
+
+		name := "unknown"
+		found := false
+		n.Walk(func(child *Node) bool {
+			if !found && (child.Type == "identifier" || child.Type == "type_identifier") {
+				name, found = child.Text(source), true
+			}
+			return !found
+		})
+		if runes := []rune(text); len(runes) > maxVariableValue {
+			text = string(runes[:maxVariableValue]) + "..."
+		}
+		variables = append(variables, Variable{Type: n.Type, Name: name, Value: text})
+		return true
+	})
+	return variables
+}
@@ -0,0 +1,557 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// SkipReason explains why Walk left a path out of the index.
+type SkipReason string
+
+const (
+	SkipExcluded     SkipReason = "excluded"
+	SkipGitignore    SkipReason = "gitignore"
+	SkipPampaxignore SkipReason = "pampaxignore"
+	SkipSymlink      SkipReason = "symlink"
+	SkipTooLarge     SkipReason = "too-large"
+	SkipBinary       SkipReason = "binary"
+	SkipMinified     SkipReason = "minified"
+	SkipUnsupported  SkipReason = "unsupported-language"
+	SkipUnreadable   SkipReason = "unreadable"
+	SkipSubmodule    SkipReason = "submodule"
+)
+
+// IgnoreFileName is the project-level ignore file, in .gitignore syntax.
+const IgnoreFileName = ".pampaxignore"
+
+// DefaultExcludes mirrors the ignore list of the Node indexer's file glob,
+// including its dot:false default that leaves hidden paths out.
+var DefaultExcludes = []string{
+	".*",
+	"vendor/",
+	"node_modules/",
+	"storage/",
+	"dist/",
+	"build/",
+	"tmp/",
+	"temp/",
+	"Library/",
+	"System/",
+	"examples/",
+	"assets/",
+	"pampa.codemap.json",
+	"pampa.codemap.json.backup-*",
+	"package-lock.json",
+	"yarn.lock",
+	"pnpm-lock.yaml",
+	"*.json",
+	"*.sh",
+}
+
+// alwaysExcluded are never indexed, whatever the configured excludes; the
+// last pattern is the codemap with its backups and temp files.
+var alwaysExcluded = []string{".git/", ".pampa/", ".pampax/", "/pampa.codemap.json*"}
+
+// DefaultMaxFileSize is the largest file Walk indexes when no limit is set.
+const DefaultMaxFileSize = 1 << 20
+
+// sniffSize is how much of each file is read for binary, minified and
+// shebang/modeline detection.
+const sniffSize = 8 << 10
+
+// WalkOptions configures Walk.
+type WalkOptions struct {
+	// Excludes are .gitignore-syntax patterns applied to the whole tree
+	// before any ignore file; they cannot be re-included.
+	Excludes []string
+	// MaxFileSize skips larger files; 0 means DefaultMaxFileSize and a
+	// negative value disables the limit.
+	MaxFileSize int64
+	// FollowSymlinks indexes symlinked files and directories whose targets
+	// stay inside the project; otherwise symlinks are skipped.
+	FollowSymlinks bool
+	// Detector resolves file languages; nil uses DetectLanguage.
+	Detector *Detector
+	// IncludeUnknown keeps text files with no language rule, for the
+	// line-window chunker; their WalkedFile.Rule is the zero value.
+	IncludeUnknown bool
+}
+
+// WalkedFile is a file Walk selected for indexing.
+type WalkedFile struct {
+	// Path is slash-separated and relative to the project root.
+	Path string
+	Size int64
+	Rule LangRule
+}
+
+// SkippedPath is a file or directory Walk left out, with the reason.
+type SkippedPath struct {
+	Path   string
+	Dir    bool
+	Reason SkipReason
+	Detail string
+}
+
+// WalkResult lists selected files and skipped paths, both sorted by path.
+type WalkResult struct {
+	Files   []WalkedFile
+	Skipped []SkippedPath
+}
+
+type walker struct {
+	ctx      context.Context
+	realRoot string
+	opts     WalkOptions
+	excludes *ignoreFile
+	pampax   *ignoreFile
+	visited  map[string]bool
+	// listed are paths already taken care of, which the walk passes over
+	// without reading them.
+	listed map[string]bool
+	result WalkResult
+}
+
+// Walk enumerates the indexable files under root. .gitignore files (also
+// those above root, up to the work tree top) and .git/info/exclude apply
+// as in git; .pampaxignore at the root is
+// consulted after them and wins when it matches, so it can both hide
+// tracked files and re-include git-ignored ones.
+func Walk(ctx context.Context, root string, opts WalkOptions) (WalkResult, error) {
+	return walk(ctx, root, opts, nil)
+}
+
+// walk is Walk leaving out the listed paths.
+func walk(ctx context.Context, root string, opts WalkOptions, listed map[string]bool) (WalkResult, error) {
+	w, err := newWalker(ctx, root, opts)
+	if err != nil {
+		return WalkResult{}, err
+	}
+	w.listed = listed
+
+	chain, err := outerIgnores(root)
+	if err != nil {
+		return WalkResult{}, err
+	}
+	if err := w.dir("", root, chain); err != nil {
+		return WalkResult{}, err
+	}
+	return w.sorted(), nil
+}
+
+// FilterPaths applies Walk's excludes, .pampaxignore and file checks to an
+// explicit list of slash-separated paths relative to root, without reading
+// .gitignore files (the paths are taken to be wanted, e.g. tracked by git).
+func FilterPaths(ctx context.Context, root string, paths []string, opts WalkOptions) (WalkResult, error) {
+	w, err := newWalker(ctx, root, opts)
+	if err != nil {
+		return WalkResult{}, err
+	}
+
+	for _, rel := range paths {
+		if err := ctx.Err(); err != nil {
+			return WalkResult{}, err
+		}
+		if ignored, reason := w.ignoredPath(rel); ignored {
+			w.skip(rel, false, reason, "")
+			continue
+		}
+
+		abs := filepath.Join(root, filepath.FromSlash(rel))
+		if info, err := os.Lstat(abs); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+			target, ok := w.symlink(rel, abs)
+			if !ok {
+				continue
+			}
+			if target.IsDir() {
+				w.skip(rel, true, SkipSymlink, "directory link")
+				continue
+			}
+		}
+		w.file(rel, abs)
+	}
+	return w.sorted(), nil
+}
+
+// Matcher applies Walk's excludes, .pampaxignore and .gitignore rules to
+// single paths, for callers that learn about paths one at a time such as
+// the file watcher. .gitignore files are read on each call, so edits to
+// them apply right away.
+type Matcher struct {
+	root  string
+	w     *walker
+	outer ignoreChain
+}
+
+// NewMatcher creates a Matcher for the project at root.
+func NewMatcher(root string, opts WalkOptions) (*Matcher, error) {
+	w, err := newWalker(context.Background(), root, opts)
+	if err != nil {
+		return nil, err
+	}
+	outer, err := outerIgnores(root)
+	if err != nil {
+		return nil, err
+	}
+	return &Matcher{root: root, w: w, outer: outer}, nil
+}
+
+// Ignored reports whether Walk would leave out rel, a slash-separated path
+// relative to the root, because it or one of its parent directories is
+// ignored. File checks (size, binary, language) are not applied.
+func (m *Matcher) Ignored(rel string, isDir bool) (bool, error) {
+	chain, err := m.withGitignore(m.outer, "")
+	if err != nil {
+		return false, err
+	}
+	for i := range len(rel) {
+		if rel[i] != '/' {
+			continue
+		}
+		if ignored, _ := m.w.ignored(rel[:i], true, chain); ignored {
+			return true, nil
+		}
+		if chain, err = m.withGitignore(chain, rel[:i]); err != nil {
+			return false, err
+		}
+	}
+	ignored, _ := m.w.ignored(rel, isDir, chain)
+	return ignored, nil
+}
+
+func (m *Matcher) withGitignore(chain ignoreChain, dir string) (ignoreChain, error) {
+	gitignore, err := readIgnoreFile(filepath.Join(m.root, filepath.FromSlash(dir), ".gitignore"), dir, SkipGitignore)
+	if err != nil {
+		return nil, err
+	}
+	return chain.with(gitignore), nil
+}
+
+func newWalker(ctx context.Context, root string, opts WalkOptions) (*walker, error) {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, fmt.Errorf("resolve project root: %w", err)
+	}
+	if opts.MaxFileSize == 0 {
+		opts.MaxFileSize = DefaultMaxFileSize
+	}
+	if opts.Detector == nil {
+		opts.Detector = &Detector{}
+	}
+
+	pampax, err := readIgnoreFile(filepath.Join(root, IgnoreFileName), "", SkipPampaxignore)
+	if err != nil {
+		return nil, err
+	}
+
+	return &walker{
+		ctx:      ctx,
+		realRoot: realRoot,
+		opts:     opts,
+		excludes: parseIgnore("", SkipExcluded, []byte(strings.Join(append(alwaysExcluded, opts.Excludes...), "\n"))),
+		pampax:   pampax,
+		visited:  map[string]bool{realRoot: true},
+	}, nil
+}
+
+func (w *walker) sorted() WalkResult {
+	slices.SortFunc(w.result.Files, func(a, b WalkedFile) int { return strings.Compare(a.Path, b.Path) })
+	slices.SortFunc(w.result.Skipped, func(a, b SkippedPath) int { return strings.Compare(a.Path, b.Path) })
+	return w.result
+}
+
+// outerIgnores returns the ignore files that apply to root from above it:
+// .git/info/exclude and the .gitignore files between the work tree top and
+// root. Outside a git work tree there are none.
+func outerIgnores(root string) (ignoreChain, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("resolve project root: %w", err)
+	}
+
+	var dirs []string
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+		if filepath.Dir(dir) == dir {
+			return nil, nil
+		}
+	}
+
+	var chain ignoreChain
+	for i := len(dirs) - 1; i >= 0; i-- {
+		outer := ""
+		if i > 0 {
+			rel, err := filepath.Rel(dirs[i], abs)
+			if err != nil {
+				return nil, fmt.Errorf("resolve project root: %w", err)
+			}
+			outer = filepath.ToSlash(rel) + "/"
+		}
+
+		if i == len(dirs)-1 {
+			exclude, err := readIgnoreFile(filepath.Join(dirs[i], ".git", "info", "exclude"), "", SkipGitignore)
+			if err != nil {
+				return nil, err
+			}
+			if exclude != nil {
+				exclude.outer = outer
+			}
+			chain = chain.with(exclude)
+		}
+		// The root's own .gitignore is read by the walk itself.
+		if i == 0 {
+			break
+		}
+		gitignore, err := readIgnoreFile(filepath.Join(dirs[i], ".gitignore"), "", SkipGitignore)
+		if err != nil {
+			return nil, err
+		}
+		if gitignore != nil {
+			gitignore.outer = outer
+		}
+		chain = chain.with(gitignore)
+	}
+	return chain, nil
+}
+
+// readIgnoreFile parses an ignore file, returning nil when it does not exist.
+func readIgnoreFile(file, base string, reason SkipReason) (*ignoreFile, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", file, err)
+	}
+	return parseIgnore(base, reason, data), nil
+}
+
+func (w *walker) skip(rel string, dir bool, reason SkipReason, detail string) {
+	w.result.Skipped = append(w.result.Skipped, SkippedPath{Path: rel, Dir: dir, Reason: reason, Detail: detail})
+}
+
+// ignored applies the global excludes, then .pampaxignore, then the
+// .gitignore chain.
+func (w *walker) ignored(rel string, isDir bool, chain ignoreChain) (bool, SkipReason) {
+	if ignored, _ := w.excludes.match(rel, isDir); ignored {
+		return true, SkipExcluded
+	}
+	if w.pampax != nil {
+		if ignored, decided := w.pampax.match(rel, isDir); decided {
+			return ignored, SkipPampaxignore
+		}
+	}
+	return chain.match(rel, isDir)
+}
+
+// ignoredPath applies ignored to rel and each of its parent directories,
+// for paths that were not reached by walking.
+func (w *walker) ignoredPath(rel string) (bool, SkipReason) {
+	for i := range len(rel) {
+		if rel[i] != '/' {
+			continue
+		}
+		if ignored, reason := w.ignored(rel[:i], true, nil); ignored {
+			return true, reason
+		}
+	}
+	return w.ignored(rel, false, nil)
+}
+
+func (w *walker) dir(rel, abs string, chain ignoreChain) error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+
+	gitignore, err := readIgnoreFile(filepath.Join(abs, ".gitignore"), rel, SkipGitignore)
+	if err != nil {
+		return err
+	}
+	chain = chain.with(gitignore)
+
+	entries, err := os.ReadDir(abs)
+	if err != nil {
+		if rel == "" {
+			return fmt.Errorf("read project root: %w", err)
+		}
+		w.skip(rel, true, SkipUnreadable, err.Error())
+		return nil
+	}
+
+	for _, entry := range entries {
+		childRel := path.Join(rel, entry.Name())
+		if w.listed[childRel] {
+			continue
+		}
+		childAbs := filepath.Join(abs, entry.Name())
+		isDir := entry.IsDir()
+
+		if entry.Type()&fs.ModeSymlink != 0 {
+			target, ok := w.symlink(childRel, childAbs)
+			if !ok {
+				continue
+			}
+			isDir = target.IsDir()
+		}
+
+		if ignored, reason := w.ignored(childRel, isDir, chain); ignored {
+			w.skip(childRel, isDir, reason, "")
+			continue
+		}
+
+		if isDir {
+			if err := w.dir(childRel, childAbs, chain); err != nil {
+				return err
+			}
+			continue
+		}
+		w.file(childRel, childAbs)
+	}
+	return nil
+}
+
+// symlink applies the symlink policy, returning the target's info when the
+// link should be followed.
+func (w *walker) symlink(rel, abs string) (fs.FileInfo, bool) {
+	if !w.opts.FollowSymlinks {
+		w.skip(rel, false, SkipSymlink, "symlinks are not followed")
+		return nil, false
+	}
+
+	target, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		w.skip(rel, false, SkipSymlink, "broken link")
+		return nil, false
+	}
+	inside, err := filepath.Rel(w.realRoot, target)
+	if err != nil || inside == ".." || strings.HasPrefix(inside, ".."+string(filepath.Separator)) {
+		w.skip(rel, false, SkipSymlink, "target outside project")
+		return nil, false
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		w.skip(rel, false, SkipUnreadable, err.Error())
+		return nil, false
+	}
+	if info.IsDir() {
+		if w.visited[target] {
+			w.skip(rel, true, SkipSymlink, "cycle")
+			return nil, false
+		}
+		w.visited[target] = true
+	}
+	return info, true
+}
+
+func (w *walker) file(rel, abs string) {
+	info, err := os.Stat(abs)
+	if err != nil {
+		w.skip(rel, false, SkipUnreadable, err.Error())
+		return
+	}
+	if w.opts.MaxFileSize > 0 && info.Size() > w.opts.MaxFileSize {
+		w.skip(rel, false, SkipTooLarge, fmt.Sprintf("%d bytes", info.Size()))
+		return
+	}
+
+	head, tail, err := readEnds(abs, info.Size())
+	if err != nil {
+		w.skip(rel, false, SkipUnreadable, err.Error())
+		return
+	}
+	if binary, mime := isBinary(head); binary {
+		w.skip(rel, false, SkipBinary, mime)
+		return
+	}
+
+	rule, ok := w.opts.Detector.Detect(rel, head, tail)
+	if !ok && !w.opts.IncludeUnknown {
+		w.skip(rel, false, SkipUnsupported, "")
+		return
+	}
+	if isMinified(rel, head) {
+		w.skip(rel, false, SkipMinified, "")
+		return
+	}
+
+	w.result.Files = append(w.result.Files, WalkedFile{Path: rel, Size: info.Size(), Rule: rule})
+}
+
+// readEnds reads the first sniffSize bytes of a file and, when it is
+// longer, the last sniffSize too, where modelines may also be.
+func readEnds(abs string, size int64) (head, tail []byte, err error) {
+	f, err := os.Open(abs)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	head = make([]byte, sniffSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, nil, err
+	}
+	head = head[:n]
+	if size <= sniffSize || n < sniffSize {
+		return head, nil, nil
+	}
+
+	tail = make([]byte, sniffSize)
+	n, err = f.ReadAt(tail, size-sniffSize)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, err
+	}
+	return head, tail[:n], nil
+}
+
+// isBinary reports whether content is not text, using mimetype's detection
+// hierarchy (every text format descends from text/plain).
+func isBinary(head []byte) (bool, string) {
+	if len(head) == 0 {
+		return false, ""
+	}
+	detected := mimetype.Detect(head)
+	for m := detected; m != nil; m = m.Parent() {
+		if m.Is("text/plain") {
+			return false, ""
+		}
+	}
+	return true, detected.String()
+}
+
+// minifiedLine is the line length beyond which a file with few line breaks
+// is treated as generated or minified.
+const minifiedLine = 1000
+
+func isMinified(rel string, head []byte) bool {
+	base := path.Base(rel)
+	if strings.Contains(base, ".min.") || strings.Contains(base, "-min.") {
+		return true
+	}
+
+	lines := bytes.Count(head, []byte("\n")) + 1
+	if len(head) < minifiedLine || len(head)/lines < minifiedLine/4 {
+		return false
+	}
+	for line := range bytes.SplitSeq(head, []byte("\n")) {
+		if len(line) > minifiedLine {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package indexer
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alessandrojcm/pampax-go/internal/chunks"
+	"github.com/alessandrojcm/pampax-go/internal/tags"
+)
+
+// TextLang is the language reported for files indexed without a rule.
+const TextLang = "text"
+
+// ChunkFile chunks source by syntax when rule has a parser (Markdown by
+// headings), falling back to line windows when the language is unknown,
+// parsing fails or no node yields a chunk (e.g. a file of only top-level
+// statements). With a Tagger, chunk tags are completed with the ones it
+// derives from the file name, symbol and code.
+func (c *Chunker) ChunkFile(name string, rule LangRule, source []byte) ([]Chunk, ChunkStats) {
+	var tree *Tree
+	if rule.Lang != "" {
+		tree, _ = ParseFile(rule, source)
+	}
+	return c.ChunkParsed(name, rule, source, tree)
+}
+
+// ChunkParsed is ChunkFile for a source parsed beforehand; tree is nil when
+// its language has no parser or parsing failed.
+func (c *Chunker) ChunkParsed(name string, rule LangRule, source []byte, tree *Tree) ([]Chunk, ChunkStats) {
+	out, stats := c.chunkFile(name, rule, source, tree)
+	if c.tagger != nil {
+		for i := range out {
+			out[i].Tags = tags.Merge(out[i].Tags, c.tagger.Extract(name, out[i].Symbol, out[i].Code))
+		}
+	}
+	return out, stats
+}
+
+func (c *Chunker) chunkFile(name string, rule LangRule, source []byte, tree *Tree) ([]Chunk, ChunkStats) {
+	if tree != nil {
+		if rule.Lang == "markdown" {
+			if out := c.Markdown(name, tree); len(out) > 0 {
+				return out, ChunkStats{Sections: len(out)}
+			}
+		} else if out, stats := c.Chunk(tree, rule); len(out) > 0 {
+			return out, stats
+		}
+	}
+
+	out := c.Windows(name, source)
+	return out, ChunkStats{Windows: len(out)}
+}
+
+// Windows cuts source into windows of whole lines of about the optimal
+// chunk size, consecutive windows sharing up to the overlap size of lines.
+// A window is cut at the best boundary in its second half, preferring a
+// blank line, then a closing brace, then a statement end, as
+// findLastCompleteBoundary does.
+func (c *Chunker) Windows(name string, source []byte) []Chunk {
+	overlap := c.windowOverlap
+	if overlap == 0 {
+		overlap = c.limits.Overlap
+	}
+
+	lines := strings.SplitAfter(string(source), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	sizes := make([]int, len(lines))
+	for i, line := range lines {
+		sizes[i] = c.measure(line)
+	}
+
+	type window struct {
+		code       string
+		start, end int
+	}
+	var windows []window
+	add := func(code string, start, end int) {
+		code = strings.TrimRight(code, "\n")
+		if strings.TrimSpace(code) != "" {
+			windows = append(windows, window{code: code, start: start + 1, end: end})
+		}
+	}
+
+	for start := 0; start < len(lines); {
+		if sizes[start] > c.limits.Max {
+			for _, piece := range splitLine(lines[start], c.limits.Optimal, c.limits.Max, c.measure) {
+				add(piece, start, start+1)
+			}
+			start++
+			continue
+		}
+
+		end, size := start, 0
+		for end < len(lines) && (end == start || size+sizes[end] <= c.limits.Optimal) && sizes[end] <= c.limits.Max {
+			size += sizes[end]
+			end++
+		}
+		if end == len(lines) {
+			add(strings.Join(lines[start:end], ""), start, end)
+			break
+		}
+		// Only a window closed by the size budget needs a better cut and an
+		// overlap; one stopped by an overlong line ends right before it.
+		if sizes[end] > c.limits.Max {
+			add(strings.Join(lines[start:end], ""), start, end)
+			start = end
+			continue
+		}
+		end = cutWindow(lines, start, end)
+		add(strings.Join(lines[start:end], ""), start, end)
+
+		next, shared := end, 0
+		for next > start+1 && overlap > 0 && shared+sizes[next-1] <= overlap {
+			shared += sizes[next-1]
+			next--
+		}
+		start = next
+	}
+
+	base := path.Base(name)
+	out := make([]Chunk, len(windows))
+	for i, w := range windows {
+		symbol := base
+		if len(windows) > 1 {
+			symbol = fmt.Sprintf("%s_part%d", base, i+1)
+		}
+		out[i] = Chunk{
+			Symbol:    symbol,
+			Code:      w.code,
+			SHA:       chunks.ComputeSHA(w.code),
+			ChunkType: ChunkTypeWindow,
+			StartLine: w.start,
+			EndLine:   w.end,
+		}
+	}
+	return out
+}
+
+// cutWindow returns the end of the window lines[start:end], moved back to
+// the best boundary in its second half.
+func cutWindow(lines []string, start, end int) int {
+	best, bestRank := end, boundaryRank(lines[end-1])
+	for i := end - 2; i >= start+(end-start)/2; i-- {
+		if rank := boundaryRank(lines[i]); rank < bestRank {
+			best, bestRank = i+1, rank
+		}
+	}
+	return best
+}
+
+// boundaryRank scores a cut after line, lower being better.
+func boundaryRank(line string) int {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case trimmed == "":
+		return 0
+	case strings.HasPrefix(trimmed, "}") || trimmed == "end":
+		return 1
+	case strings.HasSuffix(trimmed, ";"):
+		return 2
+	default:
+		return 3
+	}
+}
+
+// splitLine cuts a line too long for any window into pieces of at most
+// target units, shrinking a piece until it also fits limit.
+func splitLine(line string, target, limit int, measure func(string) int) []string {
+	var pieces []string
+	for line != "" {
+		n := min(len(line), target*4)
+		for n > 1 && measure(line[:n]) > min(target, limit) {
+			n /= 2
+		}
+		for n < len(line) && !utf8.RuneStart(line[n]) {
+			n++
+		}
+		pieces = append(pieces, line[:n])
+		line = line[n:]
+	}
+	return pieces
+}
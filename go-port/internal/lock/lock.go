@@ -0,0 +1,152 @@
+// Package lock implements the advisory lock file that keeps two pampax
+// processes from writing the same index at once.
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrLocked is matched by the error Acquire returns while another live
+// process holds the lock.
+var ErrLocked = errors.New("index is locked by another process")
+
+// unreadableGrace is how long a lock file that cannot be parsed is taken to
+// be one another process is still writing, rather than a leftover.
+const unreadableGrace = 5 * time.Second
+
+// Info is the content of a lock file.
+type Info struct {
+	PID       int       `json:"pid"`
+	Hostname  string    `json:"hostname"`
+	StartedAt time.Time `json:"startedAt"`
+	Command   string    `json:"command,omitempty"`
+}
+
+// LockedError reports the process holding a lock.
+type LockedError struct {
+	Path   string
+	Holder Info
+}
+
+func (e *LockedError) Error() string {
+	holder := e.Holder
+	return fmt.Sprintf("index is locked by %q (pid %d on %s, started %s); remove %s if that process is gone",
+		holder.Command, holder.PID, holder.Hostname, holder.StartedAt.Format(time.RFC3339), e.Path)
+}
+
+func (e *LockedError) Is(target error) bool {
+	return target == ErrLocked
+}
+
+// Lock is a held lock file.
+type Lock struct {
+	path string
+	info Info
+}
+
+// Acquire creates the lock file at path for command. A lock left by a
+// process of this host that is no longer running is replaced; any other
+// existing lock fails with a *LockedError.
+func Acquire(path, command string) (*Lock, error) {
+	hostname, _ := os.Hostname()
+	info := Info{PID: os.Getpid(), Hostname: hostname, StartedAt: time.Now().UTC(), Command: command}
+	payload, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create lock directory: %w", err)
+	}
+
+	// Two attempts: the second follows the removal of a stale lock.
+	for range 2 {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			_, werr := file.Write(payload)
+			if cerr := file.Close(); werr == nil {
+				werr = cerr
+			}
+			if werr != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("write lock %s: %w", path, werr)
+			}
+			return &Lock{path: path, info: info}, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("create lock %s: %w", path, err)
+		}
+
+		holder, stale, err := inspect(path, hostname)
+		if err != nil {
+			return nil, err
+		}
+		if !stale {
+			return nil, &LockedError{Path: path, Holder: holder}
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("remove stale lock %s: %w", path, err)
+		}
+	}
+	holder, _ := Read(path)
+	return nil, &LockedError{Path: path, Holder: holder}
+}
+
+// Read returns the content of the lock file at path.
+func Read(path string) (Info, error) {
+	var info Info
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return info, err
+	}
+	if err := json.Unmarshal(payload, &info); err != nil {
+		return info, fmt.Errorf("parse lock %s: %w", path, err)
+	}
+	return info, nil
+}
+
+// inspect reads the lock at path and reports whether it was left behind by
+// a crashed process. Locks of other hosts are never stale, since their
+// processes cannot be checked.
+func inspect(path, hostname string) (Info, bool, error) {
+	info, err := Read(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return info, true, nil
+	}
+	if err != nil {
+		stat, serr := os.Stat(path)
+		if serr != nil {
+			return info, errors.Is(serr, fs.ErrNotExist), nil
+		}
+		return info, time.Since(stat.ModTime()) > unreadableGrace, nil
+	}
+	if info.Hostname != hostname {
+		return info, false, nil
+	}
+	return info, info.PID <= 0 || !processAlive(info.PID), nil
+}
+
+// Info returns what the lock file records.
+func (l *Lock) Info() Info {
+	return l.info
+}
+
+// Release removes the lock file unless another process replaced it.
+func (l *Lock) Release() error {
+	holder, err := Read(l.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err == nil && (holder.PID != l.info.PID || !holder.StartedAt.Equal(l.info.StartedAt)) {
+		return nil
+	}
+	if err := os.Remove(l.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("release lock %s: %w", l.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+// Package mcp is a Model Context Protocol server over stdio: JSON-RPC 2.0
+// messages, one per line, serving the tools registered with AddTool.
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ProtocolVersion is the MCP revision the server speaks when the client
+// does not ask for one.
+const ProtocolVersion = "2024-11-05"
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Tool is a tool the server offers. Handler receives the call arguments and
+// returns the text sent back; its errors are reported to the client as tool
+// errors, not protocol errors.
+type Tool struct {
+	Name        string
+	Description string
+	// InputSchema is the JSON schema of the arguments.
+	InputSchema map[string]any
+	Handler     func(ctx context.Context, args json.RawMessage) (string, error)
+}
+
+// Server serves tools to one client.
+type Server struct {
+	name    string
+	version string
+	tools   []Tool
+}
+
+// NewServer creates a server that introduces itself as name and version.
+func NewServer(name, version string) *Server {
+	return &Server{name: name, version: version}
+}
+
+// AddTool registers a tool; a tool of the same name is replaced.
+func (s *Server) AddTool(tool Tool) {
+	for i := range s.tools {
+		if s.tools[i].Name == tool.Name {
+			s.tools[i] = tool
+			return
+		}
+	}
+	s.tools = append(s.tools, tool)
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type textContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type toolResult struct {
+	Content []textContent `json:"content"`
+	IsError bool          `json:"isError,omitempty"`
+}
+
+// Serve reads requests from r and answers them in order on w until r is
+// exhausted or ctx is done.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	enc := json.NewEncoder(w)
+	send := func(resp response) error {
+		resp.JSONRPC = "2.0"
+		if resp.ID == nil {
+			resp.ID = json.RawMessage("null")
+		}
+		return enc.Encode(resp)
+	}
+
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			if err := send(response{Error: &rpcError{Code: codeParseError, Message: err.Error()}}); err != nil {
+				return fmt.Errorf("write response: %w", err)
+			}
+			continue
+		}
+		// Notifications have no id and get no response. An explicit null id
+		// is not a notification: MCP requests must not use it.
+		if len(req.ID) == 0 {
+			s.handle(ctx, req)
+			continue
+		}
+		if string(req.ID) == "null" {
+			if err := send(response{Error: &rpcError{Code: codeInvalidRequest, Message: "request id must not be null"}}); err != nil {
+				return fmt.Errorf("write response: %w", err)
+			}
+			continue
+		}
+		result, rpcErr := s.handle(ctx, req)
+		if err := send(response{ID: req.ID, Result: result, Error: rpcErr}); err != nil {
+			return fmt.Errorf("write response: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read request: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) handle(ctx context.Context, req request) (any, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+		version := params.ProtocolVersion
+		if version == "" {
+			version = ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": s.name, "version": s.version},
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		tools := make([]map[string]any, len(s.tools))
+		for i, tool := range s.tools {
+			tools[i] = map[string]any{"name": tool.Name, "description": tool.Description, "inputSchema": tool.InputSchema}
+		}
+		return map[string]any{"tools": tools}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		for _, tool := range s.tools {
+			if tool.Name != params.Name {
+				continue
+			}
+			if len(params.Arguments) == 0 {
+				params.Arguments = json.RawMessage("{}")
+			}
+			text, err := tool.Handler(ctx, params.Arguments)
+			if err != nil {
+				return toolResult{Content: []textContent{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+			}
+			return toolResult{Content: []textContent{{Type: "text", Text: text}}}, nil
+		}
+		return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown tool %q", params.Name)}
+	case "":
+		return nil, &rpcError{Code: codeInvalidRequest, Message: "missing method"}
+	}
+	if strings.HasPrefix(req.Method, "notifications/") {
+		return nil, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not found", req.Method)}
+}
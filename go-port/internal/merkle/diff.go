@@ -0,0 +1,120 @@
+package merkle
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Changes are the files that differ between two trees, each list sorted.
+type Changes struct {
+	Added    []string
+	Modified []string
+	Removed  []string
+}
+
+// Empty reports whether nothing changed.
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Modified) == 0 && len(c.Removed) == 0
+}
+
+// Diff compares two trees by content hash. Directories whose hashes match
+// are skipped without visiting their files.
+func Diff(before, after *Tree) Changes {
+	var c Changes
+	diffDir("", before.root, after.root, &c)
+	slices.Sort(c.Added)
+	slices.Sort(c.Modified)
+	slices.Sort(c.Removed)
+	return c
+}
+
+func diffDir(prefix string, before, after *dir, c *Changes) {
+	if before.sum() == after.sum() {
+		return
+	}
+	for name, entry := range after.files {
+		old, ok := before.files[name]
+		switch {
+		case !ok:
+			c.Added = append(c.Added, prefix+name)
+		case old.ShaFile != entry.ShaFile:
+			c.Modified = append(c.Modified, prefix+name)
+		}
+	}
+	for name := range before.files {
+		if _, ok := after.files[name]; !ok {
+			c.Removed = append(c.Removed, prefix+name)
+		}
+	}
+	for name, child := range after.dirs {
+		old, ok := before.dirs[name]
+		if !ok {
+			old = newDir()
+		}
+		diffDir(prefix+name+"/", old, child, c)
+	}
+	for name, child := range before.dirs {
+		if _, ok := after.dirs[name]; !ok {
+			diffDir(prefix+name+"/", child, newDir(), c)
+		}
+	}
+}
+
+// Scan builds the tree of files, slash-separated paths under root. A file
+// whose size and modification time match its entry in prev keeps that
+// entry without being read; any other file is hashed, and keeps its
+// previous ChunkShas only if its content did not change. Files that no
+// longer exist are left out.
+func Scan(root string, files []string, prev *Tree) (*Tree, error) {
+	if prev == nil {
+		prev = New()
+	}
+	tree := New()
+	for _, rel := range files {
+		entry, _, ok, err := ScanFile(root, rel, prev)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			tree.Set(rel, entry)
+		}
+	}
+	return tree, nil
+}
+
+// ScanFile is Scan for a single file. It also returns the content of the
+// file when it had to be read, and ok is false when the file does not
+// exist.
+func ScanFile(root, rel string, prev *Tree) (entry Entry, data []byte, ok bool, err error) {
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Entry{}, nil, false, nil
+	}
+	if err != nil {
+		return Entry{}, nil, false, fmt.Errorf("stat %s: %w", rel, err)
+	}
+	size, modTime := info.Size(), info.ModTime().UnixMilli()
+
+	old, known := prev.Get(rel)
+	if known && old.ShaFile != "" && old.Size == size && old.ModTime == modTime {
+		return old, nil, true, nil
+	}
+
+	data, err = os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Entry{}, nil, false, nil
+	}
+	if err != nil {
+		return Entry{}, nil, false, fmt.Errorf("read %s: %w", rel, err)
+	}
+	entry = Entry{ShaFile: FastHash(data), Size: size, ModTime: modTime}
+	if known && old.ShaFile == entry.ShaFile {
+		entry.ChunkShas = old.ChunkShas
+	}
+	return entry, data, true, nil
+}
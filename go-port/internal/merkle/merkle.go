@@ -0,0 +1,302 @@
+// Package merkle tracks file content hashes for incremental updates. The
+// file format is Node's merkle.json, a flat map of project paths to
+// entries; the directory levels are rebuilt from those paths in memory so
+// unchanged subtrees compare by a single hash.
+package merkle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Entry is the merkle.json record of one file.
+type Entry struct {
+	ShaFile   string   `json:"shaFile"`
+	ChunkShas []string `json:"chunkShas"`
+	// Size and ModTime (Unix milliseconds) let Scan reuse ShaFile without
+	// reading the file. Node ignores them and drops them when it rewrites
+	// an entry.
+	Size    int64 `json:"size,omitempty"`
+	ModTime int64 `json:"mtimeMs,omitempty"`
+}
+
+// Tree is the hash tree of a project's files, keyed by slash-separated
+// paths relative to the project root. A directory's hash covers the names
+// and hashes of everything below it.
+type Tree struct {
+	root *dir
+}
+
+type dir struct {
+	dirs  map[string]*dir
+	files map[string]Entry
+	// hash is empty while stale.
+	hash string
+}
+
+func newDir() *dir {
+	return &dir{dirs: map[string]*dir{}, files: map[string]Entry{}}
+}
+
+// New returns an empty tree.
+func New() *Tree {
+	return &Tree{root: newDir()}
+}
+
+func splitPath(rel string) ([]string, string) {
+	parts := strings.Split(strings.Trim(rel, "/"), "/")
+	return parts[:len(parts)-1], parts[len(parts)-1]
+}
+
+// Set records the entry of the file at rel.
+func (t *Tree) Set(rel string, entry Entry) {
+	dirs, name := splitPath(rel)
+	d := t.root
+	d.hash = ""
+	for _, part := range dirs {
+		child, ok := d.dirs[part]
+		if !ok {
+			child = newDir()
+			d.dirs[part] = child
+		}
+		d = child
+		d.hash = ""
+	}
+	d.files[name] = entry
+}
+
+// Get returns the entry of the file at rel.
+func (t *Tree) Get(rel string) (Entry, bool) {
+	d := t.dir(rel)
+	if d == nil {
+		return Entry{}, false
+	}
+	_, name := splitPath(rel)
+	entry, ok := d.files[name]
+	return entry, ok
+}
+
+// dir returns the directory holding the file at rel.
+func (t *Tree) dir(rel string) *dir {
+	dirs, _ := splitPath(rel)
+	d := t.root
+	for _, part := range dirs {
+		if d = d.dirs[part]; d == nil {
+			return nil
+		}
+	}
+	return d
+}
+
+// Remove ports removeMerkleEntry: it deletes the file at rel and reports
+// whether it was there. Directories left empty are dropped.
+func (t *Tree) Remove(rel string) bool {
+	dirs, name := splitPath(rel)
+	path := []*dir{t.root}
+	for _, part := range dirs {
+		next := path[len(path)-1].dirs[part]
+		if next == nil {
+			return false
+		}
+		path = append(path, next)
+	}
+	if _, ok := path[len(path)-1].files[name]; !ok {
+		return false
+	}
+	delete(path[len(path)-1].files, name)
+	for i := len(path) - 1; i >= 0; i-- {
+		path[i].hash = ""
+		if i > 0 && len(path[i].files) == 0 && len(path[i].dirs) == 0 {
+			delete(path[i-1].dirs, dirs[i-1])
+		}
+	}
+	return true
+}
+
+// Files returns the paths of every file in the tree, sorted.
+func (t *Tree) Files() []string {
+	var files []string
+	t.root.walk("", func(rel string, _ Entry) { files = append(files, rel) })
+	slices.Sort(files)
+	return files
+}
+
+// Len returns the number of files in the tree.
+func (t *Tree) Len() int {
+	n := 0
+	t.root.walk("", func(string, Entry) { n++ })
+	return n
+}
+
+func (d *dir) walk(prefix string, fn func(rel string, entry Entry)) {
+	for name, entry := range d.files {
+		fn(prefix+name, entry)
+	}
+	for name, child := range d.dirs {
+		child.walk(prefix+name+"/", fn)
+	}
+}
+
+// Hash returns the hash of the directory at rel, "" being the project
+// root, and false when the tree has no such directory.
+func (t *Tree) Hash(rel string) (string, bool) {
+	d := t.root
+	if rel = strings.Trim(rel, "/"); rel != "" {
+		for _, part := range strings.Split(rel, "/") {
+			if d = d.dirs[part]; d == nil {
+				return "", false
+			}
+		}
+	}
+	return d.sum(), true
+}
+
+// sum hashes the sorted names and hashes of d's files and subdirectories,
+// computing stale subdirectories first.
+func (d *dir) sum() string {
+	if d.hash != "" {
+		return d.hash
+	}
+	var b strings.Builder
+	for _, name := range sortedKeys(d.files) {
+		b.WriteString(name + "\x00" + d.files[name].ShaFile + "\n")
+	}
+	for _, name := range sortedKeys(d.dirs) {
+		b.WriteString(name + "/\x00" + d.dirs[name].sum() + "\n")
+	}
+	d.hash = FastHash([]byte(b.String()))
+	return d.hash
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Clone ports cloneMerkle.
+func (t *Tree) Clone() *Tree {
+	clone := New()
+	t.root.walk("", func(rel string, entry Entry) {
+		entry.ChunkShas = slices.Clone(entry.ChunkShas)
+		clone.Set(rel, entry)
+	})
+	return clone
+}
+
+// MarshalJSON writes the flat Node layout.
+func (t *Tree) MarshalJSON() ([]byte, error) {
+	flat := map[string]Entry{}
+	t.root.walk("", func(rel string, entry Entry) {
+		if entry.ChunkShas == nil {
+			entry.ChunkShas = []string{}
+		}
+		flat[rel] = entry
+	})
+	return json.Marshal(flat)
+}
+
+// UnmarshalJSON reads the flat Node layout. Like loadMerkle, it treats a
+// payload that is not an object as empty and skips entries that are not
+// objects, so their files count as changed.
+func (t *Tree) UnmarshalJSON(payload []byte) error {
+	t.root = newDir()
+	var flat map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &flat); err != nil {
+		return nil
+	}
+	for rel, raw := range flat {
+		var entry Entry
+		if err := json.Unmarshal(raw, &entry); err != nil || rel == "" {
+			continue
+		}
+		t.Set(rel, entry)
+	}
+	return nil
+}
+
+// Load ports loadMerkle: it reads the merkle.json at path, and a missing or
+// malformed file gives an empty tree.
+func Load(path string) (*Tree, error) {
+	payload, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read merkle file: %w", err)
+	}
+	tree := New()
+	// Node starts over from an empty tree when the file does not parse.
+	_ = json.Unmarshal(payload, tree)
+	return tree, nil
+}
+
+// Save ports saveMerkle.
+func Save(path string, tree *Tree) error {
+	payload, err := json.MarshalIndent(tree, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal merkle: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create merkle directory: %w", err)
+	}
+
+	// Write a sibling file and rename it over merkle.json, so a crash
+	// leaves either the old tree or the new one.
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-")
+	if err != nil {
+		return fmt.Errorf("create merkle temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(payload); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write merkle file: %w", err)
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("chmod merkle file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close merkle file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replace merkle file: %w", err)
+	}
+	return nil
+}
+
+// ToPosixPath ports toPosixPath.
+func ToPosixPath(rel string) string {
+	return filepath.ToSlash(rel)
+}
+
+// NormalizeToProjectPath ports normalizeToProjectPath: the slash-separated
+// path of file relative to base, resolving a relative file against base.
+// It returns false for an empty file or one outside base.
+func NormalizeToProjectPath(base, file string) (string, bool) {
+	if file == "" {
+		return "", false
+	}
+	absBase, err := filepath.Abs(base)
+	if err != nil {
+		return "", false
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(absBase, file)
+	}
+	rel, err := filepath.Rel(absBase, file)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return ToPosixPath(rel), true
+}
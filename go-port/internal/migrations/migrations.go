@@ -0,0 +1,104 @@
+// Package migrations embeds the dbmate migrations of the index database and
+// applies them without the dbmate binary.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// Migration is one dbmate migration file.
+type Migration struct {
+	// Version is the timestamp prefix of the file name.
+	Version string
+	Name    string
+	Up      string
+	Down    string
+}
+
+// All returns the embedded migrations in version order.
+func All() ([]Migration, error) {
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	migrations := make([]Migration, 0, len(names))
+	for _, name := range names {
+		data, err := files.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		version, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: name has no version prefix", name)
+		}
+		up, down, err := split(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", name, err)
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, Up: up, Down: down})
+	}
+	return migrations, nil
+}
+
+// split separates the "-- migrate:up" and "-- migrate:down" sections.
+func split(source string) (up, down string, err error) {
+	_, rest, ok := strings.Cut(source, "-- migrate:up")
+	if !ok {
+		return "", "", fmt.Errorf("missing -- migrate:up")
+	}
+	up, down, _ = strings.Cut(rest, "-- migrate:down")
+	return strings.TrimSpace(up), strings.TrimSpace(down), nil
+}
+
+// Apply runs the migrations not yet recorded in schema_migrations, each in
+// its own transaction, keeping the table layout dbmate uses.
+func Apply(ctx context.Context, db *sql.DB) error {
+	migrations, err := All()
+	if err != nil {
+		return err
+	}
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version VARCHAR(128) PRIMARY KEY)`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	for _, migration := range migrations {
+		if err := apply(ctx, db, migration); err != nil {
+			return fmt.Errorf("migration %s: %w", migration.Name, err)
+		}
+	}
+	return nil
+}
+
+func apply(ctx context.Context, db *sql.DB, migration Migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var applied int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations WHERE version = ?`, migration.Version).Scan(&applied); err != nil {
+		return err
+	}
+	if applied > 0 {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES (?)`, migration.Version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
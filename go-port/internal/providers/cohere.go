@@ -0,0 +1,66 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultCohereBaseURL is the Cohere API root.
+const DefaultCohereBaseURL = "https://api.cohere.ai/v1"
+
+// CohereOptions configures a Cohere provider.
+type CohereOptions struct {
+	APIKey  string
+	BaseURL string
+	// Model defaults to embed-english-v3.0.
+	Model      string
+	Dimensions int
+	Client     *http.Client
+}
+
+// Cohere embeds texts with the Cohere embed API as search documents.
+type Cohere struct {
+	opts CohereOptions
+}
+
+// NewCohere creates a Cohere provider.
+func NewCohere(opts CohereOptions) *Cohere {
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultCohereBaseURL
+	}
+	opts.BaseURL = strings.TrimRight(opts.BaseURL, "/")
+	if opts.Model == "" {
+		opts.Model = "embed-english-v3.0"
+	}
+	return &Cohere{opts: opts}
+}
+
+func (p *Cohere) Name() string  { return "Cohere" }
+func (p *Cohere) Model() string { return p.opts.Model }
+
+// Dimensions is 1024, embed-english-v3.0's size, unless overridden.
+func (p *Cohere) Dimensions() int {
+	if p.opts.Dimensions > 0 {
+		return p.opts.Dimensions
+	}
+	return 1024
+}
+
+// BatchLimits stays under the API's 96 texts per request.
+func (p *Cohere) BatchLimits() BatchLimits {
+	return BatchLimits{MaxBatchSize: 96, MaxConcurrency: 2}
+}
+
+func (p *Cohere) EmbedBatch(ctx context.Context, texts []string) ([][]float64, error) {
+	var resp struct {
+		Embeddings [][]float64 `json:"embeddings"`
+	}
+	headers := map[string]string{"Authorization": "Bearer " + p.opts.APIKey}
+	body := map[string]any{"texts": texts, "model": p.opts.Model, "input_type": "search_document"}
+	if err := postJSON(ctx, p.opts.Client, p.opts.BaseURL+"/embed", headers, body, &resp); err != nil {
+		return nil, fmt.Errorf("cohere embed: %w", err)
+	}
+	return resp.Embeddings, nil
+}
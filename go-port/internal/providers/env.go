@@ -0,0 +1,57 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoProvider reports that "auto" found no configured provider.
+var ErrNoProvider = errors.New("no embedding provider configured: set OPENAI_API_KEY or COHERE_API_KEY, or choose --provider ollama")
+
+// Names lists the provider names FromEnv accepts.
+var Names = []string{"auto", "openai", "ollama", "cohere"}
+
+// FromEnv ports createEmbeddingProvider: it builds the named provider from
+// the environment variables the Node providers read. "auto" picks OpenAI
+// when OPENAI_API_KEY is set, then Cohere when COHERE_API_KEY is. The local
+// Transformers.js provider has no Go counterpart. A positive dimensions
+// overrides the size the provider reports, like PAMPAX_DIMENSIONS.
+func FromEnv(name string, dimensions int, getenv func(string) string) (EmbeddingProvider, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "openai":
+		model := getenv("PAMPAX_OPENAI_EMBEDDING_MODEL")
+		if model == "" {
+			model = getenv("OPENAI_MODEL")
+		}
+		return NewOpenAI(OpenAIOptions{
+			APIKey:     getenv("OPENAI_API_KEY"),
+			BaseURL:    getenv("OPENAI_BASE_URL"),
+			Model:      model,
+			Dimensions: dimensions,
+		}), nil
+	case "ollama":
+		return NewOllama(OllamaOptions{
+			Host:       getenv("OLLAMA_HOST"),
+			Model:      getenv("PAMPAX_OLLAMA_MODEL"),
+			Dimensions: dimensions,
+		}), nil
+	case "cohere":
+		return NewCohere(CohereOptions{
+			APIKey:     getenv("COHERE_API_KEY"),
+			Model:      getenv("PAMPAX_COHERE_MODEL"),
+			Dimensions: dimensions,
+		}), nil
+	case "transformers", "local":
+		return nil, fmt.Errorf("provider %q is not available in the Go port; use openai, ollama or cohere", name)
+	case "", "auto":
+		switch {
+		case getenv("OPENAI_API_KEY") != "":
+			return FromEnv("openai", dimensions, getenv)
+		case getenv("COHERE_API_KEY") != "":
+			return FromEnv("cohere", dimensions, getenv)
+		}
+		return nil, ErrNoProvider
+	}
+	return nil, fmt.Errorf("unknown provider %q (want one of %s)", name, strings.Join(Names, ", "))
+}
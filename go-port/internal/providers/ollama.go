@@ -0,0 +1,67 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultOllamaHost is where a local Ollama server listens.
+const DefaultOllamaHost = "http://127.0.0.1:11434"
+
+// OllamaOptions configures an Ollama provider.
+type OllamaOptions struct {
+	Host string
+	// Model defaults to nomic-embed-text.
+	Model      string
+	Dimensions int
+	Client     *http.Client
+}
+
+// Ollama embeds texts with a local Ollama server.
+type Ollama struct {
+	opts OllamaOptions
+}
+
+// NewOllama creates an Ollama provider.
+func NewOllama(opts OllamaOptions) *Ollama {
+	if opts.Host == "" {
+		opts.Host = DefaultOllamaHost
+	}
+	if !strings.Contains(opts.Host, "://") {
+		opts.Host = "http://" + opts.Host
+	}
+	opts.Host = strings.TrimRight(opts.Host, "/")
+	if opts.Model == "" {
+		opts.Model = "nomic-embed-text"
+	}
+	return &Ollama{opts: opts}
+}
+
+func (p *Ollama) Name() string  { return "Ollama" }
+func (p *Ollama) Model() string { return p.opts.Model }
+
+// Dimensions is 768, nomic-embed-text's size, unless overridden.
+func (p *Ollama) Dimensions() int {
+	if p.opts.Dimensions > 0 {
+		return p.opts.Dimensions
+	}
+	return 768
+}
+
+// BatchLimits sends small batches one at a time to the local model.
+func (p *Ollama) BatchLimits() BatchLimits {
+	return BatchLimits{MaxBatchSize: 32, MaxConcurrency: 1}
+}
+
+func (p *Ollama) EmbedBatch(ctx context.Context, texts []string) ([][]float64, error) {
+	var resp struct {
+		Embeddings [][]float64 `json:"embeddings"`
+	}
+	body := map[string]any{"model": p.opts.Model, "input": texts}
+	if err := postJSON(ctx, p.opts.Client, p.opts.Host+"/api/embed", nil, body, &resp); err != nil {
+		return nil, fmt.Errorf("ollama embed: %w", err)
+	}
+	return resp.Embeddings, nil
+}
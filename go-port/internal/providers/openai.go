@@ -0,0 +1,87 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// DefaultOpenAIBaseURL is the OpenAI API root; OpenAI-compatible servers
+// are used by changing it.
+const DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAIOptions configures an OpenAI provider.
+type OpenAIOptions struct {
+	APIKey  string
+	BaseURL string
+	// Model defaults to text-embedding-3-large.
+	Model string
+	// Dimensions overrides the size reported for the model.
+	Dimensions int
+	Client     *http.Client
+}
+
+// OpenAI embeds texts with the OpenAI embeddings API.
+type OpenAI struct {
+	opts OpenAIOptions
+}
+
+// NewOpenAI creates an OpenAI provider.
+func NewOpenAI(opts OpenAIOptions) *OpenAI {
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultOpenAIBaseURL
+	}
+	opts.BaseURL = strings.TrimRight(opts.BaseURL, "/")
+	if opts.Model == "" {
+		opts.Model = "text-embedding-3-large"
+	}
+	return &OpenAI{opts: opts}
+}
+
+func (p *OpenAI) Name() string  { return "OpenAI" }
+func (p *OpenAI) Model() string { return p.opts.Model }
+
+// Dimensions mirrors OpenAIProvider.getDimensions.
+func (p *OpenAI) Dimensions() int {
+	switch {
+	case p.opts.Dimensions > 0:
+		return p.opts.Dimensions
+	case strings.Contains(p.opts.Model, "3-small"):
+		return 1536
+	case strings.Contains(p.opts.Model, "3-large"):
+		return 3072
+	}
+	return 1536
+}
+
+// BatchLimits stays under the API's 2048 inputs and 300k tokens per
+// request.
+func (p *OpenAI) BatchLimits() BatchLimits {
+	return BatchLimits{MaxBatchSize: 128, MaxBatchTokens: 250_000, MaxConcurrency: 4}
+}
+
+func (p *OpenAI) EmbedBatch(ctx context.Context, texts []string) ([][]float64, error) {
+	var resp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	headers := map[string]string{}
+	if p.opts.APIKey != "" {
+		headers["Authorization"] = "Bearer " + p.opts.APIKey
+	}
+	body := map[string]any{"model": p.opts.Model, "input": texts}
+	if err := postJSON(ctx, p.opts.Client, p.opts.BaseURL+"/embeddings", headers, body, &resp); err != nil {
+		return nil, fmt.Errorf("openai embeddings: %w", err)
+	}
+
+	sort.SliceStable(resp.Data, func(i, j int) bool { return resp.Data[i].Index < resp.Data[j].Index })
+	out := make([][]float64, len(resp.Data))
+	for i, item := range resp.Data {
+		out[i] = item.Embedding
+	}
+	return out, nil
+}
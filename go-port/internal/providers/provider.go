@@ -0,0 +1,22 @@
+package providers
+
+import "context"
+
+// BatchLimits describes how many inputs a provider accepts per request and how
+// many requests may be in flight at once. A zero MaxBatchTokens means no token
+// budget; zero MaxBatchSize or MaxConcurrency fall back to one at a time.
+type BatchLimits struct {
+	MaxBatchSize   int
+	MaxBatchTokens int
+	MaxConcurrency int
+}
+
+// EmbeddingProvider generates vector embeddings for chunk text.
+type EmbeddingProvider interface {
+	Name() string
+	Model() string
+	Dimensions() int
+	BatchLimits() BatchLimits
+	// EmbedBatch returns one vector per input text, in the same order.
+	EmbedBatch(ctx context.Context, texts []string) ([][]float64, error)
+}
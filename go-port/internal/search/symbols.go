@@ -0,0 +1,208 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/alessandrojcm/pampax-go/internal/codemap"
+)
+
+// Boost weights of ranking/boostSymbols.js.
+const (
+	SignatureMatchBoost = 0.3
+	NeighborMatchBoost  = 0.15
+	MaxSymbolBoost      = 0.45
+)
+
+// Result is one ranked chunk of a search.
+type Result struct {
+	// ID is the chunk's codemap key.
+	ID    string
+	Score float64
+	// SymbolBoost is the score ApplySymbolBoost added, from the sources
+	// "signature" and "neighbor".
+	SymbolBoost            float64
+	SymbolBoostSources     []string
+	SymbolMatchStrength    float64
+	SymbolNeighborStrength float64
+}
+
+var (
+	nonIdentifier = regexp.MustCompile(`[^A-Za-z0-9_]`)
+	camelHump     = regexp.MustCompile(`([a-z])([A-Z])`)
+	wordSeparator = regexp.MustCompile(`[\s_]+`)
+	nonAlnum      = regexp.MustCompile(`[^a-z0-9]+`)
+	spaces        = regexp.MustCompile(`\s+`)
+)
+
+// splitSymbolWords is splitSymbolWords of symbols/extract.js.
+func splitSymbolWords(symbol string) []string {
+	cleaned := camelHump.ReplaceAllString(nonIdentifier.ReplaceAllString(symbol, " "), "$1 $2")
+	var words []string
+	for _, word := range wordSeparator.Split(cleaned, -1) {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// mentionsWord reports whether query has a word starting with word, the
+// \bword[a-z0-9_]*\b test of the Node ranking code. word is ASCII letters
+// and digits, so only the boundary before it needs checking.
+func mentionsWord(query, word string) bool {
+	if len(word) < 3 {
+		return false
+	}
+	query, word = strings.ToLower(query), strings.ToLower(word)
+	for from := 0; ; {
+		at := strings.Index(query[from:], word)
+		if at < 0 {
+			return false
+		}
+		at += from
+		if at == 0 || !isWordByte(query[at-1]) {
+			return true
+		}
+		from = at + 1
+	}
+}
+
+// isWordByte reports whether b is a \w character.
+func isWordByte(b byte) bool {
+	return b == '_' || '0' <= b && b <= '9' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+}
+
+func symbolOf(entry codemap.ChunkMetadata) string {
+	if entry.Symbol == nil {
+		return ""
+	}
+	return *entry.Symbol
+}
+
+// QueryMatchesSignature ports queryMatchesSignature: the query names the
+// chunk's symbol, quotes its signature, mentions one of its parameters or
+// a word of the symbol.
+func QueryMatchesSignature(query string, entry codemap.ChunkMetadata) bool {
+	queryLower := strings.ToLower(query)
+	symbol := strings.ToLower(symbolOf(entry))
+	signature := strings.ToLower(entry.SymbolSignature)
+
+	if symbol != "" && strings.Contains(queryLower, symbol) {
+		return true
+	}
+	if signature != "" && strings.Contains(queryLower, signature) {
+		return true
+	}
+	for _, param := range entry.SymbolParameters {
+		if len(param) > 2 && strings.Contains(queryLower, strings.ToLower(param)) {
+			return true
+		}
+	}
+	for _, word := range splitSymbolWords(symbolOf(entry)) {
+		if mentionsWord(query, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// SignatureMatchStrength ports computeSignatureMatchStrength: how strongly,
+// from 0 to 1, the query points at the chunk's symbol.
+func SignatureMatchStrength(query string, entry codemap.ChunkMetadata) float64 {
+	queryLower := strings.ToLower(query)
+	rawSymbol := symbolOf(entry)
+	symbol := strings.ToLower(rawSymbol)
+	signature := strings.ToLower(entry.SymbolSignature)
+
+	weight := 0.0
+	matched := map[string]bool{}
+
+	if symbol != "" && strings.Contains(queryLower, symbol) {
+		weight += 4
+	}
+	if signature != "" && strings.Contains(queryLower, spaces.ReplaceAllString(signature, " ")) {
+		weight = max(weight, 3.5)
+	}
+
+	symbolMatches := 0
+	for _, token := range splitSymbolWords(rawSymbol) {
+		if matched[token] || !mentionsWord(query, token) {
+			continue
+		}
+		matched[token] = true
+		symbolMatches++
+	}
+	if symbolMatches > 0 {
+		weight += 1 + 0.5*float64(symbolMatches-1)
+	}
+
+	parameterMatches := 0
+	for _, param := range entry.SymbolParameters {
+		for _, part := range nonAlnum.Split(strings.ToLower(param), -1) {
+			if len(part) < 3 || matched[part] || !mentionsWord(query, part) {
+				continue
+			}
+			matched[part] = true
+			parameterMatches++
+			break
+		}
+	}
+	weight += 0.35 * float64(parameterMatches)
+
+	if weight <= 0 {
+		return 0
+	}
+	return min(weight/4, 1)
+}
+
+// ApplySymbolBoost ports applySymbolBoost: results whose chunk, or a chunk
+// of its symbol neighbors, matches the query by signature get a score boost
+// of up to MaxSymbolBoost. Results are updated in place, not re-sorted.
+func ApplySymbolBoost(results []Result, query string, entries map[string]codemap.ChunkMetadata) {
+	if len(results) == 0 || len(entries) == 0 {
+		return
+	}
+
+	bySHA := make(map[string]codemap.ChunkMetadata, len(entries))
+	for _, entry := range entries {
+		if entry.SHA != "" {
+			bySHA[entry.SHA] = entry
+		}
+	}
+
+	for i := range results {
+		result := &results[i]
+		entry, ok := entries[result.ID]
+		if !ok {
+			continue
+		}
+
+		boost := 0.0
+		var sources []string
+		if strength := SignatureMatchStrength(query, entry); strength > 0 {
+			boost += SignatureMatchBoost * strength
+			sources = append(sources, "signature")
+			result.SymbolMatchStrength = strength
+		}
+
+		best := 0.0
+		for _, sha := range entry.SymbolNeighbors {
+			if neighbor, ok := bySHA[sha]; ok {
+				best = max(best, SignatureMatchStrength(query, neighbor))
+			}
+		}
+		if best > 0 {
+			boost += NeighborMatchBoost * best
+			sources = append(sources, "neighbor")
+			result.SymbolNeighborStrength = best
+		}
+
+		if boost > 0 {
+			boost = min(boost, MaxSymbolBoost)
+			result.Score += boost
+			result.SymbolBoost = boost
+			result.SymbolBoostSources = sources
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package tags
+
+import "strings"
+
+// Stem strips English inflections so "payments" and "payment", or
+// "processing" and "process", share one form. It implements steps 1a and
+// 1b of the Porter stemmer only: plurals, -ed and -ing. Derivational
+// suffixes such as -ation are kept, since "validation" and "validate" read
+// as different tags.
+func Stem(word string) string {
+	if len(word) <= 3 {
+		return word
+	}
+
+	// Step 1a: plurals.
+	switch {
+	case strings.HasSuffix(word, "sses"):
+		word = word[:len(word)-2]
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		word = word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"), strings.HasSuffix(word, "is"):
+	case strings.HasSuffix(word, "s"):
+		word = word[:len(word)-1]
+	}
+
+	// Step 1b: -eed, -ed and -ing when the rest still has a vowel.
+	switch {
+	case strings.HasSuffix(word, "eed"):
+		if measure(word[:len(word)-3]) > 0 {
+			word = word[:len(word)-1]
+		}
+		return word
+	case strings.HasSuffix(word, "ed") && hasVowel(word[:len(word)-2]):
+		word = word[:len(word)-2]
+	case strings.HasSuffix(word, "ing") && hasVowel(word[:len(word)-3]) && len(word) > 5:
+		word = word[:len(word)-3]
+	default:
+		return word
+	}
+
+	switch {
+	case strings.HasSuffix(word, "at"), strings.HasSuffix(word, "bl"), strings.HasSuffix(word, "iz"):
+		return word + "e"
+	case doubleConsonant(word) && !strings.ContainsAny(word[len(word)-1:], "lsz"):
+		return word[:len(word)-1]
+	case measure(word) == 1 && cvc(word):
+		return word + "e"
+	}
+	return word
+}
+
+func isConsonant(word string, i int) bool {
+	switch word[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !isConsonant(word, i-1)
+	}
+	return true
+}
+
+func hasVowel(word string) bool {
+	for i := range len(word) {
+		if !isConsonant(word, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// measure counts the vowel-consonant sequences of word, Porter's m.
+func measure(word string) int {
+	m := 0
+	inVowel := false
+	for i := range len(word) {
+		if isConsonant(word, i) {
+			if inVowel {
+				m++
+			}
+			inVowel = false
+		} else {
+			inVowel = true
+		}
+	}
+	return m
+}
+
+func doubleConsonant(word string) bool {
+	n := len(word)
+	return n >= 2 && word[n-1] == word[n-2] && isConsonant(word, n-1)
+}
+
+// cvc reports whether word ends consonant-vowel-consonant with the last
+// consonant not w, x or y, as in "hop".
+func cvc(word string) bool {
+	n := len(word)
+	if n < 3 || !isConsonant(word, n-1) || isConsonant(word, n-2) || !isConsonant(word, n-3) {
+		return false
+	}
+	return !strings.ContainsAny(word[n-1:], "wxy")
+}
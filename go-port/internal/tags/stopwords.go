@@ -0,0 +1,28 @@
+package tags
+
+// DefaultStopwords are English function words plus keywords and names so
+// common across languages that they say nothing about a chunk.
+var DefaultStopwords = []string{
+	// English
+	"about", "after", "all", "also", "and", "any", "are", "because", "been", "before", "being",
+	"but", "can", "could", "does", "each", "for", "from", "had", "has", "have", "here", "how",
+	"into", "its", "may", "more", "most", "must", "not", "now", "only", "other", "our", "should",
+	"some", "such", "than", "that", "the", "their", "them", "then", "there", "these", "they",
+	"this", "those", "through", "too", "under", "until", "use", "used", "using", "very", "was",
+	"were", "what", "when", "where", "which", "while", "who", "why", "will", "with", "would",
+	"you", "your",
+	// Keywords
+	"abstract", "async", "await", "bool", "boolean", "break", "case", "catch", "char", "class",
+	"const", "continue", "def", "default", "defer", "del", "do", "elif", "else", "end", "enum",
+	"except", "export", "extends", "false", "final", "finally", "float", "fn", "func", "function",
+	"implements", "import", "impl", "int", "interface", "let", "lambda", "local", "module", "mut",
+	"namespace", "new", "nil", "none", "null", "object", "package", "pass", "private",
+	"protected", "pub", "public", "raise", "require", "return", "self", "static", "string",
+	"struct", "super", "switch", "throw", "throws", "true", "try", "type",
+	"typeof", "undefined", "unless", "var", "void", "yield",
+	// Names that carry no domain
+	"arg", "args", "err", "error", "foo", "bar", "baz", "index", "item", "items", "len", "main",
+	"obj", "part", "res", "result", "tmp", "val", "value", "values",
+	// Layout directories
+	"internal", "lib", "pkg", "src",
+}
@@ -0,0 +1,207 @@
+package tags
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// DefaultMax is the number of tags kept per chunk, as in the Node indexer.
+const DefaultMax = 10
+
+// DefaultDictionary is the technicalKeywords list of extractSemanticTags:
+// domain words that become tags when the code mentions them.
+var DefaultDictionary = []string{
+	"stripe", "payment", "session", "checkout", "purchase", "auth", "authentication", "login",
+	"register", "middleware", "database", "connection", "pool", "config", "service",
+	"controller", "model", "repository", "test", "api", "customer", "user", "admin",
+	"notification", "email", "validation", "request", "response", "http", "route",
+}
+
+// Alias adds Tag whenever Term is among a chunk's tags, e.g. "stripe"
+// implies "payment".
+type Alias struct {
+	Term string
+	Tag  string
+}
+
+// DefaultAliases group vendor and jargon terms under their domain.
+var DefaultAliases = []Alias{
+	{"stripe", "payment"},
+	{"paypal", "payment"},
+	{"invoice", "billing"},
+	{"jwt", "auth"},
+	{"oauth", "auth"},
+	{"login", "auth"},
+	{"password", "auth"},
+	{"postgres", "database"},
+	{"mysql", "database"},
+	{"sqlite", "database"},
+	{"sql", "database"},
+	{"smtp", "email"},
+}
+
+// Options configures an Extractor; nil lists use the defaults and the
+// Extra lists are added to them.
+type Options struct {
+	// Max caps the tags per chunk; 0 means DefaultMax.
+	Max             int
+	Dictionary      []string
+	ExtraDictionary []string
+	Aliases         []Alias
+	ExtraAliases    []Alias
+	Stopwords       []string
+	ExtraStopwords  []string
+}
+
+// Extractor derives tags from a chunk's file path, symbol and code.
+type Extractor struct {
+	max        int
+	stopwords  map[string]bool
+	dictionary map[string]string
+	aliases    map[string][]string
+}
+
+// NewExtractor builds an Extractor from opts.
+func NewExtractor(opts Options) *Extractor {
+	e := &Extractor{
+		max:        opts.Max,
+		stopwords:  map[string]bool{},
+		dictionary: map[string]string{},
+		aliases:    map[string][]string{},
+	}
+	if e.max <= 0 {
+		e.max = DefaultMax
+	}
+
+	for _, word := range withDefault(opts.Stopwords, DefaultStopwords, opts.ExtraStopwords) {
+		e.stopwords[strings.ToLower(word)] = true
+	}
+	// Dictionary entries are matched by stem but reported as written.
+	for _, word := range withDefault(opts.Dictionary, DefaultDictionary, opts.ExtraDictionary) {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word != "" {
+			e.dictionary[Stem(word)] = word
+		}
+	}
+	for _, alias := range withDefault(opts.Aliases, DefaultAliases, opts.ExtraAliases) {
+		term, tag := Normalize(alias.Term), strings.ToLower(strings.TrimSpace(alias.Tag))
+		if term != "" && tag != "" {
+			e.aliases[term] = append(e.aliases[term], tag)
+		}
+	}
+	return e
+}
+
+func withDefault[T any](values, defaults, extra []T) []T {
+	if values == nil {
+		values = defaults
+	}
+	return append(append([]T{}, values...), extra...)
+}
+
+// declarationName matches the names a chunk declares, extending the
+// patterns of extractSemanticTags to the other supported languages.
+var declarationName = regexp.MustCompile(`\b(?:class|function|const|interface|trait|def|func|fn|struct|type|module|enum)\s+(?:\([^)]*\)\s*)?([A-Za-z_]\w*)`)
+
+// partSuffix is the "_partN" suffix of subdivided chunk symbols.
+var partSuffix = regexp.MustCompile(`_part\d+$`)
+
+// Extract ports extractSemanticTags: words of the file path, then of the
+// symbol, then dictionary words the code uses, then words of the names the
+// code declares, each stemmed and without stopwords. Aliases of every tag
+// follow it, and the list is cut at the configured maximum.
+func (e *Extractor) Extract(filePath, symbol, code string) []string {
+	var list tagList
+
+	dir, base := path.Split(filePath)
+	base = strings.TrimSuffix(base, path.Ext(base))
+	for _, part := range append(strings.Split(dir, "/"), base) {
+		e.addWords(&list, part)
+	}
+	e.addWords(&list, partSuffix.ReplaceAllString(symbol, ""))
+
+	for _, word := range SplitIdentifier(code) {
+		if tag, ok := e.dictionary[Stem(word)]; ok {
+			e.add(&list, tag)
+		}
+	}
+	for _, match := range declarationName.FindAllStringSubmatch(code, -1) {
+		e.addWords(&list, match[1])
+	}
+
+	if len(list.tags) > e.max {
+		list.tags = list.tags[:e.max]
+	}
+	return list.tags
+}
+
+func (e *Extractor) addWords(list *tagList, text string) {
+	for _, term := range Terms(text, e.stopwords) {
+		e.add(list, term)
+	}
+}
+
+func (e *Extractor) add(list *tagList, tag string) {
+	if !list.add(tag) {
+		return
+	}
+	for _, alias := range e.aliases[Normalize(tag)] {
+		list.add(alias)
+	}
+}
+
+type tagList struct {
+	tags []string
+	seen map[string]bool
+}
+
+func (l *tagList) add(tag string) bool {
+	if l.seen == nil {
+		l.seen = map[string]bool{}
+	}
+	key := Normalize(tag)
+	if l.seen[key] {
+		return false
+	}
+	l.seen[key] = true
+	l.tags = append(l.tags, tag)
+	return true
+}
+
+// Normalize is the form tags are compared in: lower case and stemmed, so
+// a "payments" filter matches the "payment" tag.
+func Normalize(tag string) string {
+	return Stem(strings.ToLower(strings.TrimSpace(tag)))
+}
+
+// Merge returns the annotated tags followed by the automatic ones not
+// already present, as processChunk combines them.
+func Merge(annotated, automatic []string) []string {
+	var list tagList
+	for _, tag := range annotated {
+		list.add(tag)
+	}
+	for _, tag := range automatic {
+		list.add(tag)
+	}
+	return list.tags
+}
+
+// Match reports whether chunkTags contains any of the wanted tags, the
+// rule of the tags scope filter.
+func Match(chunkTags, wanted []string) bool {
+	if len(wanted) == 0 {
+		return true
+	}
+	want := make(map[string]bool, len(wanted))
+	for _, tag := range wanted {
+		want[Normalize(tag)] = true
+	}
+	for _, tag := range chunkTags {
+		if want[Normalize(tag)] {
+			return true
+		}
+	}
+	return false
+}
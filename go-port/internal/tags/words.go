@@ -0,0 +1,72 @@
+package tags
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SplitIdentifier breaks an identifier, path or any text into lower-case
+// words at camelCase humps, acronym ends and any non-alphanumeric
+// separator: "parseHTTPRequest_v2" gives parse, http, request, v2.
+func SplitIdentifier(s string) []string {
+	var words []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if len(current) > 0 && unicode.IsUpper(r) {
+			prev := current[len(current)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			// fooBar splits before B; HTTPRequest splits before the R.
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+	return words
+}
+
+// Terms returns the search terms of text: its words without stopwords and
+// words shorter than three characters, stemmed. Tags and BM25 fields share
+// it so a query term matches both the same way.
+func Terms(text string, stopwords map[string]bool) []string {
+	var terms []string
+	for _, word := range SplitIdentifier(text) {
+		if term, ok := term(word, stopwords); ok {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+func term(word string, stopwords map[string]bool) (string, bool) {
+	if len(word) < 3 || stopwords[word] || isNumber(word) {
+		return "", false
+	}
+	stem := Stem(word)
+	if stopwords[stem] {
+		return "", false
+	}
+	return stem, true
+}
+
+func isNumber(word string) bool {
+	for _, r := range word {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+package tokens
+
+import "sync"
+
+// Size decisions, matching analyzeCodeSize in chunking/token-counter.js.
+const (
+	DecisionTooSmall         = "too_small"
+	DecisionTooLarge         = "too_large"
+	DecisionOptimal          = "optimal"
+	DecisionNeedsSubdivision = "needs_subdivision"
+	DecisionNeedsTokenizing  = "needs_tokenization"
+)
+
+// Size methods reported by Analyze.
+const (
+	MethodCharEstimate = "char_estimate"
+	MethodTokenized    = "tokenized"
+)
+
+// SizeAnalysis is the outcome of sizing one piece of code against Limits.
+type SizeAnalysis struct {
+	Size     int
+	Decision string
+	Method   string
+}
+
+// CountCache memoizes sizes per chunk SHA so repeated analysis of the same
+// content (regrouping, subdivision retries, re-indexing) tokenizes it once.
+type CountCache struct {
+	counter Counter
+
+	mu     sync.Mutex
+	counts map[string]int
+	hits   int
+	misses int
+}
+
+// NewCountCache wraps counter with a per-SHA cache.
+func NewCountCache(counter Counter) *CountCache {
+	return &CountCache{counter: counter, counts: make(map[string]int)}
+}
+
+// Count returns the cached size for sha, computing it from text on a miss.
+// An empty sha bypasses the cache.
+func (c *CountCache) Count(sha, text string) int {
+	if sha == "" {
+		return c.counter.Count(text)
+	}
+
+	c.mu.Lock()
+	if n, ok := c.counts[sha]; ok {
+		c.hits++
+		c.mu.Unlock()
+		return n
+	}
+	c.mu.Unlock()
+
+	n := c.counter.Count(text)
+
+	c.mu.Lock()
+	c.counts[sha] = n
+	c.misses++
+	c.mu.Unlock()
+
+	return n
+}
+
+// Stats returns the number of cache hits and misses so far.
+func (c *CountCache) Stats() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// Analyze ports analyzeCodeSize. With allowEstimate set, code whose character
+// estimate is clearly over the limit skips tokenization, since it will be
+// subdivided regardless; every other decision uses the exact count.
+func Analyze(code string, limits Limits, count func(string) int, allowEstimate bool) SizeAnalysis {
+	if allowEstimate {
+		if decision, estimate := preFilterByChars(code, limits); decision == DecisionTooLarge {
+			return SizeAnalysis{Size: estimate, Decision: decision, Method: MethodCharEstimate}
+		}
+	}
+
+	size := count(code)
+	return SizeAnalysis{Size: size, Decision: decide(size, limits), Method: MethodTokenized}
+}
+
+func decide(size int, limits Limits) string {
+	switch {
+	case size < limits.Min:
+		return DecisionTooSmall
+	case size > limits.Max:
+		return DecisionTooLarge
+	case size <= limits.Optimal:
+		return DecisionOptimal
+	default:
+		return DecisionNeedsSubdivision
+	}
+}
+
+func preFilterByChars(code string, limits Limits) (string, int) {
+	estimate := Estimate(code)
+	value := float64(estimate)
+
+	switch {
+	case value < float64(limits.Min)*0.8:
+		return DecisionTooSmall, estimate
+	case value > float64(limits.Max)*1.2:
+		return DecisionTooLarge, estimate
+	case value >= float64(limits.Optimal)*0.8 && value <= float64(limits.Optimal)*1.2:
+		return DecisionOptimal, estimate
+	default:
+		return DecisionNeedsTokenizing, estimate
+	}
+}
@@ -0,0 +1,146 @@
+package tokens
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+)
+
+//go:embed assets/cl100k_base.tiktoken
+var cl100kRanks []byte
+
+// BPE is a byte-pair encoder driven by a tiktoken rank table.
+type BPE struct {
+	name  string
+	ranks map[string]int
+}
+
+var (
+	cl100kOnce sync.Once
+	cl100k     *BPE
+	cl100kErr  error
+)
+
+// CL100K returns the cl100k_base encoder used by the OpenAI embedding models.
+// The rank table is embedded in the binary and parsed on first use.
+func CL100K() (*BPE, error) {
+	cl100kOnce.Do(func() {
+		ranks, err := parseRanks(cl100kRanks)
+		if err != nil {
+			cl100kErr = fmt.Errorf("load cl100k_base ranks: %w", err)
+			return
+		}
+		cl100k = &BPE{name: "cl100k_base", ranks: ranks}
+	})
+
+	return cl100k, cl100kErr
+}
+
+// Name returns the encoding name, e.g. "cl100k_base".
+func (b *BPE) Name() string {
+	return b.name
+}
+
+// Encode returns the token ids for text.
+func (b *BPE) Encode(text string) []int {
+	out := make([]int, 0, len(text)/3)
+	for _, piece := range splitCL100K(text) {
+		out = b.encodePiece([]byte(piece), out)
+	}
+	return out
+}
+
+// Count returns the number of tokens in text.
+func (b *BPE) Count(text string) int {
+	count := 0
+	for _, piece := range splitCL100K(text) {
+		if _, ok := b.ranks[piece]; ok {
+			count++
+			continue
+		}
+		count += len(b.mergePiece([]byte(piece))) - 1
+	}
+	return count
+}
+
+func (b *BPE) encodePiece(piece []byte, out []int) []int {
+	if rank, ok := b.ranks[string(piece)]; ok {
+		return append(out, rank)
+	}
+
+	bounds := b.mergePiece(piece)
+	for i := 0; i+1 < len(bounds); i++ {
+		out = append(out, b.ranks[string(piece[bounds[i]:bounds[i+1]])])
+	}
+	return out
+}
+
+// mergePiece applies the lowest-rank merge repeatedly and returns the token
+// boundaries within piece (len(tokens)+1 offsets).
+func (b *BPE) mergePiece(piece []byte) []int {
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+
+	for len(bounds) > 2 {
+		bestRank := math.MaxInt
+		bestIndex := -1
+
+		for i := 0; i+2 < len(bounds); i++ {
+			rank, ok := b.ranks[string(piece[bounds[i]:bounds[i+2]])]
+			if ok && rank < bestRank {
+				bestRank = rank
+				bestIndex = i
+			}
+		}
+
+		if bestIndex < 0 {
+			break
+		}
+
+		bounds = append(bounds[:bestIndex+1], bounds[bestIndex+2:]...)
+	}
+
+	return bounds
+}
+
+func parseRanks(data []byte) (map[string]int, error) {
+	ranks := make(map[string]int, 100_256)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		sep := bytes.IndexByte(line, ' ')
+		if sep < 0 {
+			return nil, fmt.Errorf("malformed rank line %q", line)
+		}
+
+		token, err := base64.StdEncoding.DecodeString(string(line[:sep]))
+		if err != nil {
+			return nil, fmt.Errorf("decode rank token: %w", err)
+		}
+
+		rank, err := strconv.Atoi(string(line[sep+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("parse rank: %w", err)
+		}
+
+		ranks[string(token)] = rank
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return ranks, nil
+}
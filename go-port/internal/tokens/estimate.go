@@ -0,0 +1,41 @@
+package tokens
+
+import "unicode/utf16"
+
+// Counter sizes text in the unit its profile uses (tokens or characters).
+type Counter interface {
+	Count(text string) int
+}
+
+// CounterFunc adapts a plain function to Counter.
+type CounterFunc func(string) int
+
+func (f CounterFunc) Count(text string) int {
+	return f(text)
+}
+
+// CharLen returns the JavaScript String.length of text (UTF-16 code units), so
+// character budgets line up with the Node implementation.
+func CharLen(text string) int {
+	n := 0
+	for _, r := range text {
+		if utf16.RuneLen(r) == 2 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// Estimate approximates tokens as one per four characters, the fallback Node
+// uses when no tokenizer is available.
+func Estimate(text string) int {
+	return (CharLen(text) + 3) / 4
+}
+
+// Chars counts characters; used when a profile works in character mode.
+var Chars = CounterFunc(CharLen)
+
+// Estimator counts tokens with the 4:1 character heuristic.
+var Estimator = CounterFunc(Estimate)
@@ -0,0 +1,150 @@
+package tokens
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// splitCL100K splits text into pieces the same way as the cl100k_base pattern:
+//
+//	(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}|
+//	 ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+
+//
+// Go's regexp has no lookahead, so the alternatives are matched by hand in the
+// same leftmost-first order.
+func splitCL100K(text string) []string {
+	pieces := make([]string, 0, len(text)/4+1)
+
+	for pos := 0; pos < len(text); {
+		end := matchCL100K(text, pos)
+		pieces = append(pieces, text[pos:end])
+		pos = end
+	}
+
+	return pieces
+}
+
+func matchCL100K(text string, pos int) int {
+	if end := matchContraction(text, pos); end > pos {
+		return end
+	}
+
+	r0, w0 := utf8.DecodeRuneInString(text[pos:])
+
+	// [^\r\n\p{L}\p{N}]?\p{L}+
+	if isLetter(r0) {
+		return scanWhile(text, pos+w0, isLetter)
+	}
+	if r0 != '\r' && r0 != '\n' && !isNumber(r0) && pos+w0 < len(text) {
+		if r1, _ := utf8.DecodeRuneInString(text[pos+w0:]); isLetter(r1) {
+			return scanWhile(text, pos+w0, isLetter)
+		}
+	}
+
+	// \p{N}{1,3}
+	if isNumber(r0) {
+		end := pos + w0
+		for n := 1; n < 3 && end < len(text); n++ {
+			r, w := utf8.DecodeRuneInString(text[end:])
+			if !isNumber(r) {
+				break
+			}
+			end += w
+		}
+		return end
+	}
+
+	// ' ?[^\s\p{L}\p{N}]+[\r\n]*'
+	start := pos
+	if r0 == ' ' {
+		start += w0
+	}
+	if start < len(text) {
+		if r, _ := utf8.DecodeRuneInString(text[start:]); isSymbol(r) {
+			end := scanWhile(text, start, isSymbol)
+			return scanWhile(text, end, isNewline)
+		}
+	}
+
+	// Remaining alternatives all start with whitespace.
+	runEnd := scanWhile(text, pos, unicode.IsSpace)
+
+	// \s*[\r\n]+ backtracks to the last newline inside the whitespace run.
+	lastNewline := -1
+	for i := pos; i < runEnd; {
+		r, w := utf8.DecodeRuneInString(text[i:])
+		if isNewline(r) {
+			lastNewline = i + w
+		}
+		i += w
+	}
+	if lastNewline > 0 {
+		return lastNewline
+	}
+
+	// \s+(?!\S) leaves the final space to prefix the next word.
+	if runEnd < len(text) {
+		_, lastWidth := utf8.DecodeLastRuneInString(text[pos:runEnd])
+		if runEnd-lastWidth > pos {
+			return runEnd - lastWidth
+		}
+	}
+
+	// \s+
+	if runEnd > pos {
+		return runEnd
+	}
+
+	// Unreachable for valid input; consume one rune to guarantee progress.
+	return pos + w0
+}
+
+// matchContraction matches (?i:'s|'t|'re|'ve|'m|'ll|'d).
+func matchContraction(text string, pos int) int {
+	if text[pos] != '\'' || pos+1 >= len(text) {
+		return pos
+	}
+
+	next := unicode.ToLower(rune(text[pos+1]))
+	switch next {
+	case 's', 't', 'm', 'd':
+		return pos + 2
+	}
+
+	if pos+2 < len(text) {
+		pair := string([]rune{next, unicode.ToLower(rune(text[pos+2]))})
+		switch pair {
+		case "re", "ve", "ll":
+			return pos + 3
+		}
+	}
+
+	return pos
+}
+
+func scanWhile(text string, pos int, keep func(rune) bool) int {
+	for pos < len(text) {
+		r, w := utf8.DecodeRuneInString(text[pos:])
+		if !keep(r) {
+			break
+		}
+		pos += w
+	}
+	return pos
+}
+
+func isLetter(r rune) bool {
+	return unicode.IsLetter(r)
+}
+
+func isNumber(r rune) bool {
+	return unicode.IsNumber(r)
+}
+
+func isNewline(r rune) bool {
+	return r == '\r' || r == '\n'
+}
+
+func isSymbol(r rune) bool {
+	return !unicode.IsSpace(r) && !isLetter(r) && !isNumber(r)
+}
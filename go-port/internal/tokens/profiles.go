@@ -0,0 +1,165 @@
+package tokens
+
+// Tokenizer kinds, matching tokenizerType in the Node model profiles.
+const (
+	TokenizerTiktoken     = "tiktoken"
+	TokenizerTransformers = "transformers"
+	TokenizerEstimate     = "estimate"
+)
+
+// Size units reported by Limits.
+const (
+	UnitTokens     = "tokens"
+	UnitCharacters = "characters"
+)
+
+// Profile holds the context limits and chunk sizing for one embedding model.
+type Profile struct {
+	MaxTokens      int
+	OptimalTokens  int
+	MinChunkTokens int
+	MaxChunkTokens int
+	OverlapTokens  int
+	OptimalChars   int
+	MinChunkChars  int
+	MaxChunkChars  int
+	OverlapChars   int
+	Dimensions     int
+	UseTokens      bool
+	Tokenizer      string
+	Encoding       string
+}
+
+// Limits are the chunk size bounds in the unit the profile counts in.
+type Limits struct {
+	Optimal int
+	Min     int
+	Max     int
+	Overlap int
+	Unit    string
+}
+
+// Overrides mirror the PAMPAX_MAX_TOKENS and PAMPAX_DIMENSIONS settings.
+type Overrides struct {
+	MaxTokens  int
+	Dimensions int
+}
+
+// DefaultProfileName is the conservative profile used for unknown models.
+const DefaultProfileName = "default"
+
+var modelProfiles = map[string]Profile{
+	"text-embedding-3-large": {
+		MaxTokens: 8191, OptimalTokens: 4000, MinChunkTokens: 400, MaxChunkTokens: 6000, OverlapTokens: 100,
+		OptimalChars: 16000, MinChunkChars: 1600, MaxChunkChars: 24000, OverlapChars: 400,
+		Dimensions: 3072, UseTokens: true, Tokenizer: TokenizerTiktoken, Encoding: "cl100k_base",
+	},
+	"text-embedding-3-small": {
+		MaxTokens: 8191, OptimalTokens: 4000, MinChunkTokens: 400, MaxChunkTokens: 6000, OverlapTokens: 100,
+		OptimalChars: 16000, MinChunkChars: 1600, MaxChunkChars: 24000, OverlapChars: 400,
+		Dimensions: 1536, UseTokens: true, Tokenizer: TokenizerTiktoken, Encoding: "cl100k_base",
+	},
+	"text-embedding-ada-002": {
+		MaxTokens: 8191, OptimalTokens: 4000, MinChunkTokens: 400, MaxChunkTokens: 6000, OverlapTokens: 100,
+		OptimalChars: 16000, MinChunkChars: 1600, MaxChunkChars: 24000, OverlapChars: 400,
+		Dimensions: 1536, UseTokens: true, Tokenizer: TokenizerTiktoken, Encoding: "cl100k_base",
+	},
+	"Xenova/all-MiniLM-L6-v2": {
+		MaxTokens: 256, OptimalTokens: 200, MinChunkTokens: 50, MaxChunkTokens: 512, OverlapTokens: 20,
+		OptimalChars: 800, MinChunkChars: 200, MaxChunkChars: 2048, OverlapChars: 80,
+		Dimensions: 384, UseTokens: true, Tokenizer: TokenizerTransformers,
+	},
+	"Xenova/all-mpnet-base-v2": {
+		MaxTokens: 384, OptimalTokens: 300, MinChunkTokens: 75, MaxChunkTokens: 768, OverlapTokens: 30,
+		OptimalChars: 1200, MinChunkChars: 300, MaxChunkChars: 3072, OverlapChars: 120,
+		Dimensions: 768, UseTokens: true, Tokenizer: TokenizerTransformers,
+	},
+	"nomic-embed-text": {
+		MaxTokens: 8192, OptimalTokens: 4000, MinChunkTokens: 400, MaxChunkTokens: 6000, OverlapTokens: 100,
+		OptimalChars: 16000, MinChunkChars: 1600, MaxChunkChars: 24000, OverlapChars: 400,
+		Dimensions: 768, UseTokens: true, Tokenizer: TokenizerTiktoken, Encoding: "cl100k_base",
+	},
+	"embed-english-v3.0": {
+		MaxTokens: 512, OptimalTokens: 450, MinChunkTokens: 75, MaxChunkTokens: 480, OverlapTokens: 30,
+		OptimalChars: 1800, MinChunkChars: 300, MaxChunkChars: 1920, OverlapChars: 120,
+		Dimensions: 1024, UseTokens: false, Tokenizer: TokenizerEstimate,
+	},
+	DefaultProfileName: {
+		MaxTokens: 512, OptimalTokens: 400, MinChunkTokens: 50, MaxChunkTokens: 480, OverlapTokens: 30,
+		OptimalChars: 1600, MinChunkChars: 200, MaxChunkChars: 1920, OverlapChars: 120,
+		Dimensions: 384, UseTokens: false, Tokenizer: TokenizerEstimate,
+	},
+}
+
+var providerDefaults = map[string]string{
+	"OpenAI":                  "text-embedding-3-large",
+	"Transformers.js (Local)": "Xenova/all-MiniLM-L6-v2",
+	"Ollama":                  "nomic-embed-text",
+	"Cohere":                  "embed-english-v3.0",
+}
+
+// LookupProfile ports getModelProfile: exact model match, then the provider
+// default, then the conservative fallback, with overrides applied on top.
+func LookupProfile(providerName, modelName string, overrides Overrides) Profile {
+	profile, ok := modelProfiles[modelName]
+	if !ok {
+		profile = modelProfiles[DefaultProfileName]
+		if fallback, exists := providerDefaults[providerName]; exists {
+			profile = modelProfiles[fallback]
+		}
+	}
+
+	if overrides.MaxTokens > 0 {
+		scalingRatio := float64(overrides.MaxTokens) / float64(profile.MaxTokens)
+
+		profile.MaxTokens = overrides.MaxTokens
+		profile.OptimalTokens = int(float64(overrides.MaxTokens) * 0.82)
+		profile.MinChunkTokens = max(int(float64(profile.MinChunkTokens)*scalingRatio), 50)
+		profile.MaxChunkTokens = int(float64(overrides.MaxTokens) * 0.95)
+		profile.OverlapTokens = int(float64(profile.OverlapTokens) * scalingRatio)
+	}
+
+	if overrides.Dimensions > 0 {
+		profile.Dimensions = overrides.Dimensions
+	}
+
+	return profile
+}
+
+// SizeLimits ports getSizeLimits: token bounds in token mode, character bounds otherwise.
+func (p Profile) SizeLimits() Limits {
+	if p.UseTokens {
+		return Limits{
+			Optimal: p.OptimalTokens,
+			Min:     p.MinChunkTokens,
+			Max:     p.MaxChunkTokens,
+			Overlap: p.OverlapTokens,
+			Unit:    UnitTokens,
+		}
+	}
+
+	return Limits{
+		Optimal: p.OptimalChars,
+		Min:     p.MinChunkChars,
+		Max:     p.MaxChunkChars,
+		Overlap: p.OverlapChars,
+		Unit:    UnitCharacters,
+	}
+}
+
+// Counter returns the size counter matching SizeLimits. Tiktoken profiles use
+// the embedded cl100k_base BPE; transformers profiles use the heuristic
+// estimator because WordPiece vocabularies are not bundled.
+func (p Profile) Counter() Counter {
+	if !p.UseTokens {
+		return Chars
+	}
+
+	if p.Tokenizer == TokenizerTiktoken {
+		if bpe, err := CL100K(); err == nil {
+			return bpe
+		}
+	}
+
+	return Estimator
+}
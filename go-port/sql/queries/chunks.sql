@@ -0,0 +1,22 @@
+-- name: UpsertChunk :exec
+INSERT OR REPLACE INTO code_chunks
+    (id, file_path, symbol, sha, lang, chunk_type, embedding, embedding_provider, embedding_dimensions,
+     pampa_tags, pampa_intent, pampa_description, doc_comments, variables_used, context_info, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP);
+
+-- name: GetChunk :one
+SELECT * FROM code_chunks
+WHERE id = ?;
+
+-- name: ListChunksByFile :many
+SELECT * FROM code_chunks
+WHERE file_path = ?
+ORDER BY id;
+
+-- name: DeleteChunk :exec
+DELETE FROM code_chunks
+WHERE id = ?;
+
+-- name: DeleteChunksByFile :exec
+DELETE FROM code_chunks
+WHERE file_path = ?;
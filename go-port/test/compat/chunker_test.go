@@ -0,0 +1,123 @@
+package compat
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+	"github.com/alessandrojcm/pampax-go/internal/tokens"
+)
+
+// goldenChunk is one chunk the Node indexer's chunking (collectNodes,
+// yieldChunk and processChunk in service.js) makes of a fixture with the
+// default model profile. record-goldens.mjs records the goldens with Node.
+type goldenChunk struct {
+	Symbol    string `json:"symbol"`
+	SHA       string `json:"sha"`
+	ChunkType string `json:"chunkType"`
+	StartLine int    `json:"startLine"`
+	EndLine   int    `json:"endLine"`
+}
+
+func TestChunkerMatchesNodeGoldens(t *testing.T) {
+	entries, err := os.ReadDir("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fixtures []string
+	for _, entry := range entries {
+		if name := entry.Name(); !strings.HasSuffix(name, ".golden.json") && name != "recorded.json" {
+			fixtures = append(fixtures, filepath.Join("testdata", name))
+		}
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("no fixtures found")
+	}
+
+	recorded := readRecorded(t)
+	chunker := indexer.NewChunker(tokens.LookupProfile("", tokens.DefaultProfileName, tokens.Overrides{}), indexer.ChunkerOptions{})
+	for _, fixture := range fixtures {
+		t.Run(filepath.Base(fixture), func(t *testing.T) {
+			source, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rule, ok := indexer.RuleForExtension(filepath.Ext(fixture))
+			if !ok {
+				t.Fatalf("no language rule for %s", fixture)
+			}
+			tree, err := indexer.ParseFile(rule, source)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(fixture + ".golden.json")
+			if errors.Is(err, fs.ErrNotExist) {
+				t.Skip("no golden recorded yet; run record-goldens.mjs")
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []goldenChunk
+			if err := json.Unmarshal(data, &want); err != nil {
+				t.Fatal(err)
+			}
+			if recorded != nil {
+				sum := sha1.Sum(source)
+				if recorded.Fixtures[filepath.Base(fixture)] != hex.EncodeToString(sum[:]) {
+					t.Fatal("fixture changed since its golden was recorded; run record-goldens.mjs")
+				}
+			}
+
+			got, _ := chunker.Chunk(tree, rule)
+			if len(got) != len(want) {
+				t.Errorf("got %d chunks, want %d", len(got), len(want))
+			}
+			for i := range min(len(got), len(want)) {
+				g := goldenChunk{
+					Symbol:    got[i].Symbol,
+					SHA:       got[i].SHA,
+					ChunkType: got[i].ChunkType,
+					StartLine: got[i].StartLine,
+					EndLine:   got[i].EndLine,
+				}
+				if g != want[i] {
+					t.Errorf("chunk %d = %+v, want %+v", i, g, want[i])
+				}
+			}
+		})
+	}
+}
+
+// recordedManifest is testdata/recorded.json, which record-goldens.mjs
+// writes: the Node version and the sha1 of each fixture it recorded.
+type recordedManifest struct {
+	Node     string            `json:"node"`
+	Fixtures map[string]string `json:"fixtures"`
+}
+
+// readRecorded returns the manifest, or nil when the goldens in testdata
+// were not recorded with Node.
+func readRecorded(t *testing.T) *recordedManifest {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "recorded.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Log("testdata/recorded.json is missing: the goldens were not recorded with Node")
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest recordedManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("testdata/recorded.json: %v", err)
+	}
+	return &manifest
+}
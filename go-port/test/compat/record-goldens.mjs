@@ -0,0 +1,90 @@
+#!/usr/bin/env node
+// Records testdata/<fixture>.golden.json for every fixture in testdata by
+// indexing it with the Node indexer of the repository root, with a stub
+// embedding provider and so the default model profile, then lists the
+// fixtures it recorded in testdata/recorded.json. Run it from the
+// repository root after `npm install`:
+//
+//   node go-port/test/compat/record-goldens.mjs
+import crypto from 'node:crypto';
+import fs from 'node:fs/promises';
+import os from 'node:os';
+import path from 'node:path';
+import { fileURLToPath } from 'node:url';
+import sqlite3 from 'sqlite3';
+
+import { __resetTestProviderFactory, __setTestProviderFactory } from '../../../src/providers.js';
+import { clearBasePath, indexProject } from '../../../src/service.js';
+
+const testdata = path.join(path.dirname(fileURLToPath(import.meta.url)), 'testdata');
+const manifest = 'recorded.json';
+
+// PAMPAX_MAX_TOKENS and friends would change the chunk sizes.
+for (const key of Object.keys(process.env)) {
+    if (key.startsWith('PAMPAX_')) {
+        delete process.env[key];
+    }
+}
+
+__setTestProviderFactory(() => ({
+    init: async () => { },
+    generateEmbedding: async () => [0, 0, 0],
+    getDimensions: () => 3,
+    getName: () => 'TestProvider'
+}));
+
+function readChunks(dbPath) {
+    return new Promise((resolve, reject) => {
+        const db = new sqlite3.Database(dbPath, sqlite3.OPEN_READONLY, (error) => {
+            if (error) {
+                reject(error);
+                return;
+            }
+            db.all('SELECT symbol, sha, chunk_type, context_info FROM code_chunks ORDER BY rowid', (error, rows) => {
+                db.close();
+                if (error) {
+                    reject(error);
+                    return;
+                }
+                resolve(rows.map((row) => {
+                    const context = JSON.parse(row.context_info);
+                    return {
+                        symbol: row.symbol,
+                        sha: row.sha,
+                        chunkType: row.chunk_type,
+                        startLine: context.startLine,
+                        endLine: context.endLine
+                    };
+                }));
+            });
+        });
+    });
+}
+
+async function record(fixture) {
+    const project = await fs.mkdtemp(path.join(os.tmpdir(), 'pampax-golden-'));
+    try {
+        await fs.copyFile(path.join(testdata, fixture), path.join(project, fixture));
+        await indexProject({ repoPath: project });
+        const chunks = await readChunks(path.join(project, '.pampa', 'pampa.db'));
+        await fs.writeFile(path.join(testdata, `${fixture}.golden.json`), JSON.stringify(chunks, null, 2) + '\n');
+        console.log(`${fixture}: ${chunks.length} chunks`);
+        return crypto.createHash('sha1').update(await fs.readFile(path.join(testdata, fixture))).digest('hex');
+    } finally {
+        clearBasePath();
+        await fs.rm(project, { recursive: true, force: true });
+    }
+}
+
+try {
+    const fixtures = (await fs.readdir(testdata))
+        .filter((name) => !name.endsWith('.golden.json') && name !== manifest)
+        .sort();
+    const recorded = {};
+    for (const fixture of fixtures) {
+        recorded[fixture] = await record(fixture);
+    }
+    await fs.writeFile(path.join(testdata, manifest), JSON.stringify({ node: process.version, fixtures: recorded }, null, 2) + '\n');
+} finally {
+    __resetTestProviderFactory();
+}
@@ -0,0 +1,39 @@
+using System;
+using System.Collections.Generic;
+
+namespace Bank
+{
+    /// <summary>Account holds a balance and its history.</summary>
+    public class Account
+    {
+        private readonly List<decimal> history = new List<decimal>();
+
+        public decimal Balance { get; private set; }
+
+        public void Deposit(decimal amount)
+        {
+            if (amount <= 0)
+            {
+                throw new ArgumentOutOfRangeException(nameof(amount));
+            }
+            Balance += amount;
+            history.Add(amount);
+        }
+
+        public bool Withdraw(decimal amount)
+        {
+            if (amount > Balance)
+            {
+                return false;
+            }
+            Balance -= amount;
+            history.Add(-amount);
+            return true;
+        }
+    }
+
+    public interface IStatement
+    {
+        string Render(Account account);
+    }
+}
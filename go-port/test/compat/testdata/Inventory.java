@@ -0,0 +1,30 @@
+package shop.stock;
+
+import java.util.HashMap;
+import java.util.Map;
+
+/**
+ * Inventory counts the units in stock per product.
+ */
+public class Inventory {
+    private final Map<String, Integer> units = new HashMap<>();
+
+    public void receive(String product, int count) {
+        units.merge(product, count, Integer::sum);
+    }
+
+    public boolean take(String product, int count) {
+        int available = units.getOrDefault(product, 0);
+        if (available < count) {
+            return false;
+        }
+        units.put(product, available - count);
+        return true;
+    }
+}
+
+interface Supplier {
+    int deliver(String product);
+}
+
+enum Unit { PIECE, BOX }
@@ -0,0 +1,26 @@
+package shop.orders
+
+import java.time.Instant
+
+/**
+ * Order is a customer's basket at checkout.
+ */
+data class Order(val id: String, val lines: List<Line>, val placedAt: Instant)
+
+data class Line(val sku: String, val quantity: Int, val price: Long)
+
+class OrderService(private val repository: OrderRepository) {
+    fun place(lines: List<Line>): Order {
+        val order = Order(newId(), lines, Instant.now())
+        repository.save(order)
+        return order
+    }
+
+    fun total(order: Order): Long = order.lines.sumOf { it.quantity * it.price }
+}
+
+interface OrderRepository {
+    fun save(order: Order)
+}
+
+fun newId(): String = java.util.UUID.randomUUID().toString()
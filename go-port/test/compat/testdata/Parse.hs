@@ -0,0 +1,24 @@
+module Parse (Token (..), tokenize, evaluate) where
+
+import Data.Char (isDigit, isSpace)
+
+-- | Token is a lexeme of an arithmetic expression.
+data Token = Number Int | Plus | Times
+  deriving (Show, Eq)
+
+tokenize :: String -> [Token]
+tokenize [] = []
+tokenize (c : cs)
+  | isSpace c = tokenize cs
+  | c == '+' = Plus : tokenize cs
+  | c == '*' = Times : tokenize cs
+  | isDigit c = let (digits, rest) = span isDigit (c : cs) in Number (read digits) : tokenize rest
+  | otherwise = error ("unexpected " ++ [c])
+
+evaluate :: [Token] -> Int
+evaluate tokens = sum (map product (terms tokens))
+  where
+    terms ts = case break (== Plus) ts of
+      (term, []) -> [numbers term]
+      (term, _ : rest) -> numbers term : terms rest
+    numbers ts = [n | Number n <- ts]
@@ -0,0 +1,22 @@
+package geometry
+
+/** Shape is anything with an area. */
+trait Shape {
+  def area: Double
+}
+
+case class Circle(radius: Double) extends Shape {
+  def area: Double = math.Pi * radius * radius
+}
+
+case class Rect(width: Double, height: Double) extends Shape {
+  def area: Double = width * height
+}
+
+object Shapes {
+  def total(shapes: Seq[Shape]): Double =
+    shapes.map(_.area).sum
+
+  def largest(shapes: Seq[Shape]): Option[Shape] =
+    if (shapes.isEmpty) None else Some(shapes.maxBy(_.area))
+}
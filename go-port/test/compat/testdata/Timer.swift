@@ -0,0 +1,31 @@
+import Foundation
+
+/// Stopwatch measures elapsed time between laps.
+class Stopwatch {
+    private var start: Date?
+    private(set) var laps: [TimeInterval] = []
+
+    func begin() {
+        start = Date()
+    }
+
+    func lap() -> TimeInterval {
+        guard let start = start else { return 0 }
+        let elapsed = Date().timeIntervalSince(start)
+        laps.append(elapsed)
+        return elapsed
+    }
+}
+
+struct Lap {
+    let index: Int
+    let duration: TimeInterval
+}
+
+protocol Clock {
+    func now() -> Date
+}
+
+func formatDuration(_ interval: TimeInterval) -> String {
+    return String(format: "%.2fs", interval)
+}
@@ -0,0 +1,25 @@
+<?php
+namespace App\Billing;
+
+/**
+ * Invoice totals a list of line items.
+ */
+class Invoice
+{
+    private array $items = [];
+
+    public function add(string $name, float $amount): void
+    {
+        $this->items[] = ['name' => $name, 'amount' => $amount];
+    }
+
+    public function total(): float
+    {
+        return array_sum(array_column($this->items, 'amount'));
+    }
+}
+
+function format_amount(float $amount): string
+{
+    return number_format($amount, 2);
+}
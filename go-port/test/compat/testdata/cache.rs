@@ -0,0 +1,35 @@
+use std::collections::HashMap;
+
+/// Cache keeps at most `capacity` values, dropping the oldest.
+pub struct Cache {
+    capacity: usize,
+    order: Vec<String>,
+    values: HashMap<String, String>,
+}
+
+impl Cache {
+    pub fn new(capacity: usize) -> Self {
+        Cache { capacity, order: Vec::new(), values: HashMap::new() }
+    }
+
+    pub fn insert(&mut self, key: String, value: String) {
+        if self.values.len() == self.capacity {
+            let oldest = self.order.remove(0);
+            self.values.remove(&oldest);
+        }
+        self.order.push(key.clone());
+        self.values.insert(key, value);
+    }
+
+    pub fn get(&self, key: &str) -> Option<&String> {
+        self.values.get(key)
+    }
+}
+
+pub trait Store {
+    fn load(&self, key: &str) -> Option<String>;
+}
+
+fn hash_key(key: &str) -> u64 {
+    key.bytes().fold(0, |acc, b| acc.wrapping_mul(31).wrapping_add(b as u64))
+}
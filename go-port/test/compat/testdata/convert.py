@@ -0,0 +1,97 @@
+"""Conversion helpers."""
+
+def convert_0(value):
+    scaled = float(value) * 1.5
+    label = "unit-0-" + str(round(scaled, 0))
+    return label, scaled
+
+
+def convert_1(value):
+    scaled = float(value) * 2.5
+    label = "unit-1-" + str(round(scaled, 1))
+    return label, scaled
+
+
+def convert_2(value):
+    scaled = float(value) * 3.5
+    label = "unit-2-" + str(round(scaled, 2))
+    return label, scaled
+
+
+def convert_3(value):
+    scaled = float(value) * 4.5
+    label = "unit-3-" + str(round(scaled, 3))
+    return label, scaled
+
+
+def convert_4(value):
+    scaled = float(value) * 5.5
+    label = "unit-4-" + str(round(scaled, 0))
+    return label, scaled
+
+
+def convert_5(value):
+    scaled = float(value) * 6.5
+    label = "unit-5-" + str(round(scaled, 1))
+    return label, scaled
+
+
+def convert_6(value):
+    scaled = float(value) * 7.5
+    label = "unit-6-" + str(round(scaled, 2))
+    return label, scaled
+
+
+def convert_7(value):
+    scaled = float(value) * 8.5
+    label = "unit-7-" + str(round(scaled, 3))
+    return label, scaled
+
+
+def convert_8(value):
+    scaled = float(value) * 9.5
+    label = "unit-8-" + str(round(scaled, 0))
+    return label, scaled
+
+
+def convert_9(value):
+    scaled = float(value) * 10.5
+    label = "unit-9-" + str(round(scaled, 1))
+    return label, scaled
+
+
+def convert_10(value):
+    scaled = float(value) * 11.5
+    label = "unit-10-" + str(round(scaled, 2))
+    return label, scaled
+
+
+def convert_11(value):
+    scaled = float(value) * 12.5
+    label = "unit-11-" + str(round(scaled, 3))
+    return label, scaled
+
+
+def convert_12(value):
+    scaled = float(value) * 13.5
+    label = "unit-12-" + str(round(scaled, 0))
+    return label, scaled
+
+
+def convert_13(value):
+    scaled = float(value) * 14.5
+    label = "unit-13-" + str(round(scaled, 1))
+    return label, scaled
+
+
+def convert_14(value):
+    scaled = float(value) * 15.5
+    label = "unit-14-" + str(round(scaled, 2))
+    return label, scaled
+
+
+def convert_15(value):
+    scaled = float(value) * 16.5
+    label = "unit-15-" + str(round(scaled, 3))
+    return label, scaled
+
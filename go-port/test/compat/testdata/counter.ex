@@ -0,0 +1,22 @@
+defmodule Counter do
+  @moduledoc """
+  Counter keeps named counts in an agent.
+  """
+  use Agent
+
+  def start_link(_opts) do
+    Agent.start_link(fn -> %{} end, name: __MODULE__)
+  end
+
+  def increment(name, by \\ 1) do
+    Agent.update(__MODULE__, &Map.update(&1, name, by, fn count -> count + by end))
+  end
+
+  def value(name) do
+    Agent.get(__MODULE__, &Map.get(&1, name, 0))
+  end
+
+  defp reset_all do
+    Agent.update(__MODULE__, fn _ -> %{} end)
+  end
+end
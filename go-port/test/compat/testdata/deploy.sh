@@ -0,0 +1,26 @@
+#!/usr/bin/env bash
+set -euo pipefail
+
+TARGET_DIR="/srv/app"
+RELEASES=5
+
+# build compiles the release into dist/.
+build() {
+  npm ci
+  npm run build
+}
+
+deploy() {
+  local release="$TARGET_DIR/releases/$(date +%s)"
+  mkdir -p "$release"
+  cp -r dist/. "$release"
+  ln -sfn "$release" "$TARGET_DIR/current"
+}
+
+prune() {
+  ls -1dt "$TARGET_DIR"/releases/* | tail -n +$((RELEASES + 1)) | xargs rm -rf
+}
+
+build
+deploy
+prune
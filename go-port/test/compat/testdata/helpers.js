@@ -0,0 +1,144 @@
+// String and collection helpers shared across the app.
+
+function helper0(input) {
+  var prepared = String(input).trim().padStart(4, "0");
+  var suffix = "helper-0-" + prepared.length;
+  return prepared + ":" + suffix.repeat(1);
+}
+
+function helper1(input) {
+  var prepared = String(input).trim().padStart(5, "0");
+  var suffix = "helper-1-" + prepared.length;
+  return prepared + ":" + suffix.repeat(2);
+}
+
+function helper2(input) {
+  var prepared = String(input).trim().padStart(6, "0");
+  var suffix = "helper-2-" + prepared.length;
+  return prepared + ":" + suffix.repeat(3);
+}
+
+function helper3(input) {
+  var prepared = String(input).trim().padStart(7, "0");
+  var suffix = "helper-3-" + prepared.length;
+  return prepared + ":" + suffix.repeat(1);
+}
+
+function helper4(input) {
+  var prepared = String(input).trim().padStart(8, "0");
+  var suffix = "helper-4-" + prepared.length;
+  return prepared + ":" + suffix.repeat(2);
+}
+
+function helper5(input) {
+  var prepared = String(input).trim().padStart(9, "0");
+  var suffix = "helper-5-" + prepared.length;
+  return prepared + ":" + suffix.repeat(3);
+}
+
+function helper6(input) {
+  var prepared = String(input).trim().padStart(10, "0");
+  var suffix = "helper-6-" + prepared.length;
+  return prepared + ":" + suffix.repeat(1);
+}
+
+function helper7(input) {
+  var prepared = String(input).trim().padStart(11, "0");
+  var suffix = "helper-7-" + prepared.length;
+  return prepared + ":" + suffix.repeat(2);
+}
+
+function helper8(input) {
+  var prepared = String(input).trim().padStart(12, "0");
+  var suffix = "helper-8-" + prepared.length;
+  return prepared + ":" + suffix.repeat(3);
+}
+
+function helper9(input) {
+  var prepared = String(input).trim().padStart(13, "0");
+  var suffix = "helper-9-" + prepared.length;
+  return prepared + ":" + suffix.repeat(1);
+}
+
+function helper10(input) {
+  var prepared = String(input).trim().padStart(14, "0");
+  var suffix = "helper-10-" + prepared.length;
+  return prepared + ":" + suffix.repeat(2);
+}
+
+function helper11(input) {
+  var prepared = String(input).trim().padStart(15, "0");
+  var suffix = "helper-11-" + prepared.length;
+  return prepared + ":" + suffix.repeat(3);
+}
+
+function helper12(input) {
+  var prepared = String(input).trim().padStart(16, "0");
+  var suffix = "helper-12-" + prepared.length;
+  return prepared + ":" + suffix.repeat(1);
+}
+
+function helper13(input) {
+  var prepared = String(input).trim().padStart(17, "0");
+  var suffix = "helper-13-" + prepared.length;
+  return prepared + ":" + suffix.repeat(2);
+}
+
+class Cache {
+  get(key) {
+    return this.store && this.store[key];
+  }
+}
+
+function helper14(input) {
+  return [input, 14].join("-");
+}
+
+function helper15(input) {
+  return [input, 15].join("-");
+}
+
+function helper16(input) {
+  return [input, 16].join("-");
+}
+
+function helper17(input) {
+  return [input, 17].join("-");
+}
+
+function helper18(input) {
+  return [input, 18].join("-");
+}
+
+function helper19(input) {
+  return [input, 19].join("-");
+}
+
+function buildIndex(table) {
+  var entry0 = table[0] ? table[0].name.toLowerCase() + "/" + table[0].id : "missing-0";
+  var entry1 = table[1] ? table[1].name.toLowerCase() + "/" + table[1].id : "missing-1";
+  var entry2 = table[2] ? table[2].name.toLowerCase() + "/" + table[2].id : "missing-2";
+  var entry3 = table[3] ? table[3].name.toLowerCase() + "/" + table[3].id : "missing-3";
+  var entry4 = table[4] ? table[4].name.toLowerCase() + "/" + table[4].id : "missing-4";
+  var entry5 = table[5] ? table[5].name.toLowerCase() + "/" + table[5].id : "missing-5";
+  var entry6 = table[6] ? table[6].name.toLowerCase() + "/" + table[6].id : "missing-6";
+  var entry7 = table[7] ? table[7].name.toLowerCase() + "/" + table[7].id : "missing-7";
+  var entry8 = table[8] ? table[8].name.toLowerCase() + "/" + table[8].id : "missing-8";
+  var entry9 = table[9] ? table[9].name.toLowerCase() + "/" + table[9].id : "missing-9";
+  var entry10 = table[10] ? table[10].name.toLowerCase() + "/" + table[10].id : "missing-10";
+  var entry11 = table[11] ? table[11].name.toLowerCase() + "/" + table[11].id : "missing-11";
+  var entry12 = table[12] ? table[12].name.toLowerCase() + "/" + table[12].id : "missing-12";
+  var entry13 = table[13] ? table[13].name.toLowerCase() + "/" + table[13].id : "missing-13";
+  var entry14 = table[14] ? table[14].name.toLowerCase() + "/" + table[14].id : "missing-14";
+  var entry15 = table[15] ? table[15].name.toLowerCase() + "/" + table[15].id : "missing-15";
+  var entry16 = table[16] ? table[16].name.toLowerCase() + "/" + table[16].id : "missing-16";
+  var entry17 = table[17] ? table[17].name.toLowerCase() + "/" + table[17].id : "missing-17";
+  var entry18 = table[18] ? table[18].name.toLowerCase() + "/" + table[18].id : "missing-18";
+  var entry19 = table[19] ? table[19].name.toLowerCase() + "/" + table[19].id : "missing-19";
+  var entry20 = table[20] ? table[20].name.toLowerCase() + "/" + table[20].id : "missing-20";
+  var entry21 = table[21] ? table[21].name.toLowerCase() + "/" + table[21].id : "missing-21";
+  var entry22 = table[22] ? table[22].name.toLowerCase() + "/" + table[22].id : "missing-22";
+  var entry23 = table[23] ? table[23].name.toLowerCase() + "/" + table[23].id : "missing-23";
+  var entry24 = table[24] ? table[24].name.toLowerCase() + "/" + table[24].id : "missing-24";
+  return table.length;
+}
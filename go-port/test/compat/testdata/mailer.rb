@@ -0,0 +1,27 @@
+require 'net/smtp'
+
+# Mailer sends plain text messages through one SMTP host.
+class Mailer
+  attr_reader :host
+
+  def initialize(host, port = 25)
+    @host = host
+    @port = port
+  end
+
+  def deliver(from, to, body)
+    Net::SMTP.start(@host, @port) do |smtp|
+      smtp.send_message(body, from, to)
+    end
+  end
+end
+
+module Templates
+  def self.welcome(name)
+    "Welcome, #{name}!"
+  end
+end
+
+def format_address(name, email)
+  "#{name} <#{email}>"
+end
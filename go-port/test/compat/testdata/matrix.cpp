@@ -0,0 +1,42 @@
+#include <vector>
+#include <stdexcept>
+
+namespace linalg {
+
+// Matrix is a dense row-major matrix of doubles.
+class Matrix {
+public:
+    Matrix(int rows, int cols) : rows_(rows), cols_(cols), data_(rows * cols) {}
+
+    double& at(int row, int col) {
+        if (row >= rows_ || col >= cols_) {
+            throw std::out_of_range("index");
+        }
+        return data_[row * cols_ + col];
+    }
+
+    Matrix multiply(Matrix& other) {
+        Matrix result(rows_, other.cols_);
+        for (int i = 0; i < rows_; i++) {
+            for (int j = 0; j < other.cols_; j++) {
+                for (int k = 0; k < cols_; k++) {
+                    result.at(i, j) += at(i, k) * other.at(k, j);
+                }
+            }
+        }
+        return result;
+    }
+
+private:
+    int rows_;
+    int cols_;
+    std::vector<double> data_;
+};
+
+}
+
+int main() {
+    linalg::Matrix m(2, 2);
+    m.at(0, 0) = 1;
+    return 0;
+}
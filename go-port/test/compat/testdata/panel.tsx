@@ -0,0 +1,27 @@
+import React, { useState } from 'react';
+
+interface PanelProps {
+  title: string;
+  items: string[];
+}
+
+/**
+ * Panel lists items under a collapsible title.
+ */
+export function Panel({ title, items }: PanelProps) {
+  const [open, setOpen] = useState(true);
+  return (
+    <section>
+      <h2 onClick={() => setOpen(!open)}>{title}</h2>
+      {open && (
+        <ul>
+          {items.map((item) => (
+            <li key={item}>{item}</li>
+          ))}
+        </ul>
+      )}
+    </section>
+  );
+}
+
+export const EmptyPanel = () => <Panel title="Nothing here" items={[]} />;
@@ -0,0 +1,39 @@
+export interface Job {
+  id: string;
+  attempts: number;
+}
+
+type Handler = (job: Job) => Promise<void>;
+
+/**
+ * Queue runs jobs one at a time, retrying failures.
+ */
+export class Queue {
+  private jobs: Job[] = [];
+
+  constructor(private readonly handler: Handler, private readonly maxAttempts = 3) {}
+
+  push(job: Job): void {
+    this.jobs.push(job);
+  }
+
+  async drain(): Promise<number> {
+    let done = 0;
+    while (this.jobs.length > 0) {
+      const job = this.jobs.shift()!;
+      try {
+        await this.handler(job);
+        done++;
+      } catch {
+        if (++job.attempts < this.maxAttempts) {
+          this.jobs.push(job);
+        }
+      }
+    }
+    return done;
+  }
+}
+
+export function createJob(id: string): Job {
+  return { id, attempts: 0 };
+}
@@ -0,0 +1,64 @@
+function buildReport(rows, options) {
+  var total = 0;
+  function formatHeader(title) {
+    var line0 = "formatHeader section line 0 " + title.toUpperCase() + " with padding";
+    var line1 = "formatHeader section line 1 " + title.toUpperCase() + " with padding";
+    var line2 = "formatHeader section line 2 " + title.toUpperCase() + " with padding";
+    var line3 = "formatHeader section line 3 " + title.toUpperCase() + " with padding";
+    var line4 = "formatHeader section line 4 " + title.toUpperCase() + " with padding";
+    var line5 = "formatHeader section line 5 " + title.toUpperCase() + " with padding";
+    return [line0, line1, line2, line3, line4, line5].join("\n");
+  }
+  function formatFooter(title) {
+    var line0 = "formatFooter section line 0 " + title.toUpperCase() + " with padding";
+    var line1 = "formatFooter section line 1 " + title.toUpperCase() + " with padding";
+    var line2 = "formatFooter section line 2 " + title.toUpperCase() + " with padding";
+    var line3 = "formatFooter section line 3 " + title.toUpperCase() + " with padding";
+    var line4 = "formatFooter section line 4 " + title.toUpperCase() + " with padding";
+    var line5 = "formatFooter section line 5 " + title.toUpperCase() + " with padding";
+    return [line0, line1, line2, line3, line4, line5].join("\n");
+  }
+  function isEmpty(row) {
+    return row.isEmpty === true;
+  }
+  function isWide(row) {
+    return row.isWide === true;
+  }
+  function isTall(row) {
+    return row.isTall === true;
+  }
+  if (rows.length > 0) {
+      var part00 = rows[0].values[0] * options.weight0 + options.offset;
+      var part01 = rows[0].values[1] * options.weight1 + options.offset;
+      var part02 = rows[0].values[2] * options.weight2 + options.offset;
+      var part03 = rows[0].values[3] * options.weight3 + options.offset;
+      var part04 = rows[0].values[4] * options.weight4 + options.offset;
+  }
+  if (rows.length > 1) {
+      var part10 = rows[1].values[0] * options.weight0 + options.offset;
+      var part11 = rows[1].values[1] * options.weight1 + options.offset;
+      var part12 = rows[1].values[2] * options.weight2 + options.offset;
+      var part13 = rows[1].values[3] * options.weight3 + options.offset;
+      var part14 = rows[1].values[4] * options.weight4 + options.offset;
+  }
+  if (rows.length > 2) {
+      var part20 = rows[2].values[0] * options.weight0 + options.offset;
+      var part21 = rows[2].values[1] * options.weight1 + options.offset;
+      var part22 = rows[2].values[2] * options.weight2 + options.offset;
+      var part23 = rows[2].values[3] * options.weight3 + options.offset;
+      var part24 = rows[2].values[4] * options.weight4 + options.offset;
+  }
+  if (rows.length > 3) {
+      var part30 = rows[3].values[0] * options.weight0 + options.offset;
+      var part31 = rows[3].values[1] * options.weight1 + options.offset;
+      var part32 = rows[3].values[2] * options.weight2 + options.offset;
+      var part33 = rows[3].values[3] * options.weight3 + options.offset;
+      var part34 = rows[3].values[4] * options.weight4 + options.offset;
+  }
+  try {
+    var rounded = Math.round(total);
+  } catch (error) {
+    var rounded = 0;
+  }
+  return formatHeader("report") + rounded + formatFooter("end");
+}
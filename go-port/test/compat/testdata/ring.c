@@ -0,0 +1,34 @@
+#include <stdlib.h>
+#include <string.h>
+
+/* ring is a fixed-size byte ring buffer. */
+struct ring {
+    unsigned char *data;
+    size_t size;
+    size_t head;
+    size_t tail;
+};
+
+struct ring *ring_new(size_t size) {
+    struct ring *r = malloc(sizeof(struct ring));
+    r->data = calloc(size, 1);
+    r->size = size;
+    r->head = 0;
+    r->tail = 0;
+    return r;
+}
+
+int ring_push(struct ring *r, unsigned char byte) {
+    size_t next = (r->head + 1) % r->size;
+    if (next == r->tail) {
+        return -1;
+    }
+    r->data[r->head] = byte;
+    r->head = next;
+    return 0;
+}
+
+void ring_free(struct ring *r) {
+    free(r->data);
+    free(r);
+}
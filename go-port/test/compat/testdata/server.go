@@ -0,0 +1,55 @@
+package server
+
+import "strings"
+
+type Server struct {
+	name string
+}
+
+// Name returns the server name.
+func (s *Server) Name() string {
+	return s.name
+}
+
+func routes() []string {
+	var parts []string
+	parts = append(parts, strings.Repeat("route-0/", 1)+"handler-0-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-1/", 2)+"handler-1-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-2/", 3)+"handler-2-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-3/", 4)+"handler-3-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-4/", 1)+"handler-4-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-5/", 2)+"handler-5-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-6/", 3)+"handler-6-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-7/", 4)+"handler-7-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-8/", 1)+"handler-8-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-9/", 2)+"handler-9-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-10/", 3)+"handler-10-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-11/", 4)+"handler-11-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-12/", 1)+"handler-12-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-13/", 2)+"handler-13-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-14/", 3)+"handler-14-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-15/", 4)+"handler-15-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-16/", 1)+"handler-16-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-17/", 2)+"handler-17-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-18/", 3)+"handler-18-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-19/", 4)+"handler-19-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-20/", 1)+"handler-20-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-21/", 2)+"handler-21-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-22/", 3)+"handler-22-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-23/", 4)+"handler-23-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-24/", 1)+"handler-24-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-25/", 2)+"handler-25-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-26/", 3)+"handler-26-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-27/", 4)+"handler-27-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-28/", 1)+"handler-28-with-a-long-descriptive-name")
+	parts = append(parts, strings.Repeat("route-29/", 2)+"handler-29-with-a-long-descriptive-name")
+	return parts
+}
+
+func banner() string {
+	banner := ""
+	banner += "==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0==========section-0"
+	banner += "==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1==========section-1"
+	banner += "==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2==========section-2"
+	return banner
+}
@@ -0,0 +1,25 @@
+/**
+ * Geometry helpers used by the renderer.
+ */
+export class Rectangle {
+  constructor(width, height) {
+    this.width = width;
+    this.height = height;
+  }
+
+  area() {
+    return this.width * this.height;
+  }
+
+  scale(factor) {
+    return new Rectangle(this.width * factor, this.height * factor);
+  }
+}
+
+export function perimeter(rect) {
+  return 2 * (rect.width + rect.height);
+}
+
+const UNIT = new Rectangle(1, 1);
+
+export default UNIT;
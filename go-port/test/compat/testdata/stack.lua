@@ -0,0 +1,28 @@
+-- Stack is a simple LIFO container.
+local Stack = {}
+Stack.__index = Stack
+
+function Stack.new()
+  return setmetatable({ items = {}, size = 0 }, Stack)
+end
+
+function Stack:push(value)
+  self.size = self.size + 1
+  self.items[self.size] = value
+end
+
+function Stack:pop()
+  if self.size == 0 then
+    return nil
+  end
+  local value = self.items[self.size]
+  self.items[self.size] = nil
+  self.size = self.size - 1
+  return value
+end
+
+local function describe(stack)
+  return "stack of " .. stack.size
+end
+
+return Stack
@@ -0,0 +1,20 @@
+(* A binary search tree of integers. *)
+type tree = Leaf | Node of tree * int * tree
+
+let rec insert value = function
+  | Leaf -> Node (Leaf, value, Leaf)
+  | Node (left, v, right) as node ->
+    if value < v then Node (insert value left, v, right)
+    else if value > v then Node (left, v, insert value right)
+    else node
+
+let rec mem value = function
+  | Leaf -> false
+  | Node (left, v, right) ->
+    if value = v then true else if value < v then mem value left else mem value right
+
+module Stats = struct
+  let rec size = function
+    | Leaf -> 0
+    | Node (l, _, r) -> size l + 1 + size r
+end
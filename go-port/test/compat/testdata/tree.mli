@@ -0,0 +1,10 @@
+(** A binary search tree of integers. *)
+type tree
+
+val insert : int -> tree -> tree
+
+val mem : int -> tree -> bool
+
+module Stats : sig
+  val size : tree -> int
+end
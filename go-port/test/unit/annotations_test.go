@@ -0,0 +1,131 @@
+package unit
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alessandrojcm/pampax-go/internal/db"
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+	"github.com/alessandrojcm/pampax-go/internal/tokens"
+)
+
+func TestExtractPampaMetadata(t *testing.T) {
+	got := indexer.ExtractPampaMetadata("/**\n * @pampax-tags: auth, login , ,session\n * @pampa-intent: sign a user in */")
+	want := indexer.PampaMetadata{Tags: []string{"auth", "login", "session"}, Intent: "sign a user in"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ExtractPampaMetadata() = %+v, want %+v", got, want)
+	}
+	if got := indexer.ExtractPampaMetadata("// no annotations"); got.Tags != nil || got.Intent != "" || got.Description != "" {
+		t.Fatalf("ExtractPampaMetadata() = %+v, want empty", got)
+	}
+}
+
+func TestChunkAnnotationsAcrossCommentSyntaxes(t *testing.T) {
+	tests := []struct {
+		file   string
+		source string
+	}{
+		{"auth.js", "const x = 1;\n\n/**\n * Signs in.\n * @pampax-tags: auth, login\n * @pampax-intent: sign a user in\n * @pampax-description: checks the password */\nfunction login(user) {\n  return user;\n}\n"},
+		{"auth.ts", "/** @pampax-description: unrelated */\nconst y = 2;\n\n// @pampax-tags: auth, login\n// @pampax-intent: sign a user in\n// @pampax-description: checks the password\nexport function login(user: string) {\n  return user;\n}\n"},
+		{"auth.go", "package auth\n\n// Login signs in.\n// @pampax-tags: auth, login\n// @pampax-intent: sign a user in\n// @pampax-description: checks the password\nfunc Login(user string) string {\n\treturn user\n}\n"},
+		{"auth.py", "def login(user):\n    \"\"\"Signs in.\n\n    @pampax-tags: auth, login\n    @pampax-intent: sign a user in\n    @pampax-description: checks the password\n    \"\"\"\n    return user\n"},
+		{"auth.rb", "# @pampax-tags: auth, login\n# @pampax-intent: sign a user in\n# @pampax-description: checks the password\ndef login(user)\n  user\nend\n"},
+		{"auth.lua", "-- @pampax-tags: auth, login\n-- @pampax-intent: sign a user in\n-- @pampax-description: checks the password\nfunction login(user)\n  return user\nend\n"},
+	}
+
+	want := indexer.PampaMetadata{Tags: []string{"auth", "login"}, Intent: "sign a user in", Description: "checks the password"}
+	chunker := indexer.NewChunker(tokens.LookupProfile("", tokens.DefaultProfileName, tokens.Overrides{}), indexer.ChunkerOptions{})
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			rule, ok := indexer.RuleForExtension(filepath.Ext(tt.file))
+			if !ok {
+				t.Fatalf("no rule for %s", tt.file)
+			}
+			chunks, _ := chunker.ChunkFile(tt.file, rule, []byte(tt.source))
+
+			var login *indexer.Chunk
+			for i := range chunks {
+				if strings.EqualFold(chunks[i].Symbol, "login") {
+					login = &chunks[i]
+				}
+			}
+			if login == nil {
+				t.Fatalf("no login chunk in %+v", chunks)
+			}
+			if !reflect.DeepEqual(login.Pampa, want) || !login.HasDocumentation {
+				t.Fatalf("login annotations = %+v (doc %q)", login.Pampa, login.DocComment)
+			}
+			text := login.EmbeddingText()
+			if strings.Count(text, "@pampax-tags") != 1 || !strings.HasSuffix(text, "// Tags: auth, login") || !strings.Contains(text, "// Intent: sign a user in") {
+				t.Fatalf("EmbeddingText() =\n%s", text)
+			}
+		})
+	}
+}
+
+func TestDocCommentMustBeAdjacent(t *testing.T) {
+	source := "/** @pampax-tags: config */\nconst x = 1;\n\nfunction login(user) {\n  return user;\n}\n\n// @pampax-tags: gap\n\nfunction logout(user) {\n  return user;\n}\n"
+	rule, _ := indexer.RuleForExtension(".js")
+	chunks, _ := indexer.NewChunker(tokens.LookupProfile("", tokens.DefaultProfileName, tokens.Overrides{}), indexer.ChunkerOptions{}).ChunkFile("a.js", rule, []byte(source))
+	for _, chunk := range chunks {
+		if chunk.Symbol == "x" {
+			continue
+		}
+		if chunk.DocComment != "" || chunk.Pampa.Tags != nil {
+			t.Errorf("chunk %s picked up a detached comment %q", chunk.Symbol, chunk.DocComment)
+		}
+	}
+}
+
+func TestChunkTableStoresAnnotations(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), ".pampa", "pampa.db")
+	conn, err := db.Open(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	queries := db.New(conn)
+	err = queries.UpsertChunk(ctx, db.UpsertChunkParams{
+		ID:          "auth.go:Login:abc",
+		FilePath:    "auth.go",
+		Symbol:      "Login",
+		Sha:         "abc",
+		Lang:        "go",
+		ChunkType:   db.Text(indexer.ChunkTypeFunction),
+		PampaTags:   db.StringList([]string{"auth", "login"}),
+		PampaIntent: db.Text("sign a user in"),
+		DocComments: db.Text("// @pampax-tags: auth, login"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row, err := queries.GetChunk(ctx, "auth.go:Login:abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := db.ParseStringList(row.PampaTags); !reflect.DeepEqual(got, []string{"auth", "login"}) {
+		t.Errorf("pampa_tags = %v", got)
+	}
+	if row.PampaIntent == nil || *row.PampaIntent != "sign a user in" || row.PampaDescription != nil {
+		t.Errorf("intent = %v, description = %v", row.PampaIntent, row.PampaDescription)
+	}
+	if row.UpdatedAt == nil {
+		t.Error("updated_at not set")
+	}
+
+	// Reopening finds the migration applied and keeps the rows.
+	conn.Close()
+	if conn, err = db.Open(ctx, path); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.New(conn).ListChunksByFile(ctx, "auth.go")
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("ListChunksByFile() = %d rows, %v", len(rows), err)
+	}
+}
@@ -0,0 +1,184 @@
+package unit
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+	"github.com/alessandrojcm/pampax-go/internal/providers"
+)
+
+type fakeProvider struct {
+	limits   providers.BatchLimits
+	failOnce map[string]bool
+	// model overrides the default "fake-model".
+	model string
+
+	mu       sync.Mutex
+	calls    [][]string
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (p *fakeProvider) Name() string                       { return "fake" }
+func (p *fakeProvider) Model() string                      { return cmp.Or(p.model, "fake-model") }
+func (p *fakeProvider) Dimensions() int                    { return 2 }
+func (p *fakeProvider) BatchLimits() providers.BatchLimits { return p.limits }
+
+func (p *fakeProvider) EmbedBatch(ctx context.Context, texts []string) ([][]float64, error) {
+	current := p.inFlight.Add(1)
+	defer p.inFlight.Add(-1)
+	for {
+		peak := p.peak.Load()
+		if current <= peak || p.peak.CompareAndSwap(peak, current) {
+			break
+		}
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	p.mu.Lock()
+	p.calls = append(p.calls, append([]string(nil), texts...))
+	fail := false
+	for _, text := range texts {
+		if p.failOnce[text] {
+			delete(p.failOnce, text)
+			fail = true
+		}
+	}
+	p.mu.Unlock()
+
+	if fail {
+		return nil, errors.New("transient provider error")
+	}
+
+	out := make([][]float64, len(texts))
+	for i, text := range texts {
+		var n int
+		fmt.Sscanf(text, "text-%d", &n)
+		out[i] = []float64{float64(n), float64(len(text))}
+	}
+	return out, nil
+}
+
+func makeTexts(n int) []string {
+	texts := make([]string, n)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("text-%d", i)
+	}
+	return texts
+}
+
+func TestBatcherPlanRespectsCountAndTokenLimits(t *testing.T) {
+	provider := &fakeProvider{limits: providers.BatchLimits{MaxBatchSize: 3, MaxBatchTokens: 10}}
+	batcher := indexer.NewBatcher(provider, indexer.BatcherOptions{
+		CountTokens: func(text string) int { return len(text) },
+	})
+
+	texts := []string{"aaaa", "bbbb", "c", "dd", strings.Repeat("e", 25), "f", "g", "h", "i"}
+	got := batcher.Plan(texts)
+	want := []indexer.Batch{
+		{Start: 0, End: 3, Tokens: 9},
+		{Start: 3, End: 4, Tokens: 2},
+		{Start: 4, End: 5, Tokens: 25},
+		{Start: 5, End: 8, Tokens: 3},
+		{Start: 8, End: 9, Tokens: 1},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("Plan() returned %d batches, want %d: %+v", len(got), len(want), got)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Plan()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestBatcherEmbedPreservesOrderAndBoundsConcurrency(t *testing.T) {
+	provider := &fakeProvider{limits: providers.BatchLimits{MaxBatchSize: 4, MaxConcurrency: 3}}
+	batcher := indexer.NewBatcher(provider, indexer.BatcherOptions{})
+
+	texts := makeTexts(50)
+	vectors, err := batcher.Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+
+	if len(vectors) != len(texts) {
+		t.Fatalf("Embed() returned %d vectors, want %d", len(vectors), len(texts))
+	}
+
+	for i, vector := range vectors {
+		if vector[0] != float64(i) {
+			t.Fatalf("vector %d belongs to input %v", i, vector[0])
+		}
+	}
+
+	if len(provider.calls) != 13 {
+		t.Fatalf("expected 13 provider calls, got %d", len(provider.calls))
+	}
+
+	if peak := provider.peak.Load(); peak > 3 {
+		t.Fatalf("expected at most 3 requests in flight, got %d", peak)
+	}
+}
+
+func TestBatcherRetriesOnlyFailedBatch(t *testing.T) {
+	provider := &fakeProvider{
+		limits:   providers.BatchLimits{MaxBatchSize: 2, MaxConcurrency: 1},
+		failOnce: map[string]bool{"text-3": true},
+	}
+	batcher := indexer.NewBatcher(provider, indexer.BatcherOptions{
+		RetryDelays: []time.Duration{time.Millisecond},
+	})
+
+	vectors, err := batcher.Embed(context.Background(), makeTexts(6))
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+
+	if vectors[3][0] != 3 {
+		t.Fatalf("expected retried batch to fill slot 3, got %v", vectors[3])
+	}
+
+	counts := make(map[string]int)
+	for _, call := range provider.calls {
+		counts[strings.Join(call, ",")]++
+	}
+
+	if counts["text-2,text-3"] != 2 {
+		t.Fatalf("expected failed batch to be sent twice, got calls %v", provider.calls)
+	}
+
+	for _, other := range []string{"text-0,text-1", "text-4,text-5"} {
+		if counts[other] != 1 {
+			t.Fatalf("expected batch %q to be sent once, got %d", other, counts[other])
+		}
+	}
+}
+
+func TestBatcherReturnsErrorWhenRetriesExhausted(t *testing.T) {
+	provider := &fakeProvider{
+		limits:   providers.BatchLimits{MaxBatchSize: 2},
+		failOnce: map[string]bool{"text-1": true},
+	}
+	batcher := indexer.NewBatcher(provider, indexer.BatcherOptions{RetryDelays: []time.Duration{}})
+
+	_, err := batcher.Embed(context.Background(), makeTexts(4))
+	if err == nil {
+		t.Fatal("expected Embed() to fail without retries")
+	}
+
+	if !strings.Contains(err.Error(), "embed batch 0-1") {
+		t.Fatalf("expected error to name the failed batch, got: %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package unit
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+	"github.com/alessandrojcm/pampax-go/internal/tokens"
+)
+
+func TestChunkerOverlapContext(t *testing.T) {
+	var body strings.Builder
+	body.WriteString("function outer(rows) {\n  var seen = 0;\n")
+	for i := range 3 {
+		body.WriteString("  function step" + string(rune('A'+i)) + "(row) {\n")
+		for range 4 {
+			body.WriteString("    var value = row.values.map(function (x) { return x * 2 + seen; }).join(',');\n")
+		}
+		body.WriteString("    return value;\n  }\n")
+	}
+	body.WriteString("  return rows;\n}\n")
+
+	rule, _ := indexer.RuleForExtension(".js")
+	tree, err := indexer.ParseFile(rule, []byte(body.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profile := tokens.LookupProfile("", tokens.DefaultProfileName, tokens.Overrides{})
+	profile.MaxChunkChars = 600
+
+	plain, stats := indexer.NewChunker(profile, indexer.ChunkerOptions{}).Chunk(tree, rule)
+	if stats.Subdivided != 1 {
+		t.Fatalf("stats = %+v, want the outer function subdivided", stats)
+	}
+	overlapped, _ := indexer.NewChunker(profile, indexer.ChunkerOptions{OverlapLines: 2}).Chunk(tree, rule)
+	if len(plain) != len(overlapped) {
+		t.Fatalf("chunk counts differ: %d vs %d", len(plain), len(overlapped))
+	}
+
+	for i, chunk := range overlapped {
+		if !chunk.HasParentContext {
+			if chunk.SHA != plain[i].SHA {
+				t.Errorf("top-level chunk %s changed with overlap enabled", chunk.Symbol)
+			}
+			continue
+		}
+		if !strings.HasPrefix(chunk.Code, "function outer(rows) {\n...\n") || !strings.HasSuffix(chunk.Code, plain[i].Code) {
+			t.Errorf("chunk %s lacks the parent signature:\n%s", chunk.Symbol, chunk.Code)
+		}
+	}
+}
+
+func TestChunkerGroupsSmallFunctions(t *testing.T) {
+	var source strings.Builder
+	var names []string
+	for i := range 12 {
+		name := "fn" + string(rune('a'+i))
+		names = append(names, name)
+		source.WriteString("function " + name + "(x) {\n  return x + 1;\n}\n\n")
+	}
+
+	rule, _ := indexer.RuleForExtension(".js")
+	tree, err := indexer.ParseFile(rule, []byte(source.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile := tokens.LookupProfile("", tokens.DefaultProfileName, tokens.Overrides{})
+
+	grouped, stats := indexer.NewChunker(profile, indexer.ChunkerOptions{}).Chunk(tree, rule)
+	if len(grouped) != 1 || stats.FunctionsGrouped != 12 {
+		t.Fatalf("got %d chunks (stats %+v), want one group of 12", len(grouped), stats)
+	}
+	if got := strings.Join(grouped[0].GroupSymbols, ","); got != strings.Join(names, ",") {
+		t.Fatalf("group symbols = %s", got)
+	}
+	if grouped[0].Symbol != "fna_partgroup_12funcs" {
+		t.Fatalf("group symbol = %q", grouped[0].Symbol)
+	}
+
+	separate, _ := indexer.NewChunker(profile, indexer.ChunkerOptions{
+		Grouping: indexer.GroupingProfile{KeepSeparateMaxNodes: 20},
+	}).Chunk(tree, rule)
+	if len(separate) != 12 || separate[0].GroupSymbols != nil {
+		t.Fatalf("got %d chunks with a 20-node threshold, want 12 separate", len(separate))
+	}
+}
+
+func TestChunkFileFallsBackToLineWindows(t *testing.T) {
+	profile := tokens.LookupProfile("", tokens.DefaultProfileName, tokens.Overrides{})
+	profile.OptimalChars = 120
+	profile.MaxChunkChars = 200
+	profile.OverlapChars = 30
+	chunker := indexer.NewChunker(profile, indexer.ChunkerOptions{})
+
+	var doc strings.Builder
+	for i := range 6 {
+		doc.WriteString("server.port = 80" + string(rune('0'+i)) + "\nserver.host = example.org\n\n")
+	}
+	windows, stats := chunker.ChunkFile("conf/app.conf", indexer.LangRule{}, []byte(doc.String()))
+	if stats.Windows != len(windows) || len(windows) < 2 {
+		t.Fatalf("got %d windows (stats %+v)", len(windows), stats)
+	}
+	for i, window := range windows {
+		if window.ChunkType != indexer.ChunkTypeWindow {
+			t.Errorf("window %d type = %q", i, window.ChunkType)
+		}
+		if want := "app.conf_part" + string(rune('1'+i)); window.Symbol != want {
+			t.Errorf("window %d symbol = %q, want %q", i, window.Symbol, want)
+		}
+		if tokens.CharLen(window.Code) > profile.MaxChunkChars {
+			t.Errorf("window %d has %d chars", i, tokens.CharLen(window.Code))
+		}
+		if i > 0 && window.StartLine > windows[i-1].EndLine {
+			t.Errorf("window %d starts at line %d, after the previous end %d", i, window.StartLine, windows[i-1].EndLine)
+		}
+	}
+	for _, window := range windows[:len(windows)-1] {
+		if !strings.HasSuffix(window.Code, "example.org") {
+			t.Errorf("window not cut at a blank line:\n%s", window.Code)
+		}
+	}
+
+	// Go files without functions yield no syntax chunks.
+	rule, _ := indexer.RuleForExtension(".go")
+	types, _ := chunker.ChunkFile("types.go", rule, []byte("package types\n\ntype ID string\n"))
+	if len(types) != 1 || types[0].Symbol != "types.go" || types[0].Code != "package types\n\ntype ID string" {
+		t.Fatalf("types.go chunks = %+v", types)
+	}
+}
+
+func TestChunkFileSplitsMarkdownByHeadings(t *testing.T) {
+	fence := "```go\n" + strings.Repeat("fmt.Println(\"step\")\n", 12) + "```\n"
+	doc := "# Guide\n\nIntro text.\n\n## Install\n\n" + strings.Repeat("Install notes go here.\n", 4) +
+		"\n### Linux\n\n" + fence + "\n" + strings.Repeat("Linux paragraph.\n", 5) +
+		"\n## Usage ##\n\nRun it.\n"
+
+	profile := tokens.LookupProfile("", tokens.DefaultProfileName, tokens.Overrides{})
+	profile.OptimalChars = 300
+	profile.MaxChunkChars = 400
+
+	rule, _ := indexer.RuleForExtension(".md")
+	got, stats := indexer.NewChunker(profile, indexer.ChunkerOptions{}).ChunkFile("docs/guide.md", rule, []byte(doc))
+	if stats.Sections != len(got) {
+		t.Fatalf("stats = %+v for %d chunks", stats, len(got))
+	}
+
+	var symbols []string
+	for _, chunk := range got {
+		symbols = append(symbols, chunk.Symbol)
+		if !chunk.HasDocumentation || chunk.ChunkType != indexer.ChunkTypeSection {
+			t.Errorf("chunk %s: documentation %v, type %q", chunk.Symbol, chunk.HasDocumentation, chunk.ChunkType)
+		}
+		if strings.Count(chunk.Code, "```")%2 != 0 {
+			t.Errorf("chunk %s splits a code fence:\n%s", chunk.Symbol, chunk.Code)
+		}
+	}
+	want := []string{
+		"Guide",
+		"Guide > Install",
+		"Guide > Install > Linux_part1",
+		"Guide > Install > Linux_part2",
+		"Guide > Usage",
+	}
+	if strings.Join(symbols, "|") != strings.Join(want, "|") {
+		t.Fatalf("symbols = %q, want %q", symbols, want)
+	}
+	if linux := got[3]; !strings.HasPrefix(linux.Code, "### Linux\n\nLinux paragraph.") {
+		t.Fatalf("continuation chunk lacks its heading:\n%s", linux.Code)
+	}
+}
+
+func TestChunkFileKeepsHeadingWithOversizedFence(t *testing.T) {
+	fence := "```go\n" + strings.Repeat("fmt.Println(\"step\")\n", 40) + "```\n"
+	doc := "# Big\n\n" + fence
+
+	profile := tokens.LookupProfile("", tokens.DefaultProfileName, tokens.Overrides{})
+	profile.OptimalChars = 300
+	profile.MaxChunkChars = 400
+
+	rule, _ := indexer.RuleForExtension(".md")
+	got, _ := indexer.NewChunker(profile, indexer.ChunkerOptions{}).ChunkFile("docs/big.md", rule, []byte(doc))
+	if len(got) < 2 {
+		t.Fatalf("got %d chunks, want the fence cut into windows", len(got))
+	}
+	for i, chunk := range got {
+		if want := fmt.Sprintf("Big_part%d", i+1); chunk.Symbol != want {
+			t.Errorf("chunk %d symbol = %q, want %q", i, chunk.Symbol, want)
+		}
+		if !strings.HasPrefix(chunk.Code, "# Big\n\n```go\n") && !strings.HasPrefix(chunk.Code, "# Big\n\nfmt.Println") {
+			t.Errorf("chunk %s does not open with its heading:\n%s", chunk.Symbol, chunk.Code)
+		}
+	}
+	if first := got[0]; first.StartLine != 1 || first.EndLine <= 3 || !strings.Contains(first.Code, "fmt.Println") {
+		t.Fatalf("first chunk spans lines %d-%d:\n%s", first.StartLine, first.EndLine, first.Code)
+	}
+}
@@ -0,0 +1,90 @@
+package unit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alessandrojcm/pampax-go/internal/config"
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	detector, err := indexer.NewDetector([]indexer.LanguageOverride{
+		{Pattern: "*.tpl", Lang: "php"},
+		{Pattern: "templates/*.html", Lang: ".tsx"},
+		{Pattern: "*.js", Lang: "typescript"},
+	})
+	if err != nil {
+		t.Fatalf("NewDetector() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		head string
+		tail string
+		want string
+	}{
+		{name: "extension", path: "src/main.go", want: "go"},
+		{name: "extension is case-insensitive", path: "lib/App.PY", want: "python"},
+		{name: "extension beats shebang", path: "run.rb", head: "#!/usr/bin/env python3\n", want: "ruby"},
+		{name: "override by base name", path: "views/page.tpl", want: "php"},
+		{name: "override by path", path: "templates/index.html", want: "tsx"},
+		{name: "path override only matches its directory", path: "static/index.html", want: "html"},
+		{name: "override beats extension", path: "app.js", want: "typescript"},
+		{name: "shebang", path: "bin/deploy", head: "#!/bin/bash\nset -e\n", want: "bash"},
+		{name: "shebang via env with version", path: "tool", head: "#!/usr/bin/env python3.12\n", want: "python"},
+		{name: "shebang via env -S", path: "tool", head: "#!/usr/bin/env -S node --no-warnings\n", want: "javascript"},
+		{name: "vim modeline", path: "Rakefile.local", head: "task :x\n# vim: set ft=ruby ts=2 :\n", want: "ruby"},
+		{name: "vim filetype", path: "conf", head: "// vi: filetype=javascript\n", want: "javascript"},
+		{name: "emacs mode", path: "script", head: ";; -*- mode: lua; coding: utf-8 -*-\n", want: "lua"},
+		{name: "emacs bare mode", path: "script", head: "# -*- shell-script -*-\n", want: "bash"},
+		{name: "modeline at end of file", path: "notes", head: "a\nb\nc\nd\ne\nf\ng\n<!-- vim: ft=markdown -->\n", want: "markdown"},
+		{name: "modeline at end of a long file", path: "notes", head: "a\nb\nc\nd\ne\nf\n", tail: "cut\nx\n<!-- vim: ft=markdown -->\n", want: "markdown"},
+		{name: "modeline in the middle of a long file", path: "notes", head: "a\nb\nc\nd\ne\n# vim: ft=python\n", tail: "cut\nx\ny\n", want: ""},
+		{name: "cut line starting the tail", path: "notes", head: "a\n", tail: "# vim: ft=python\nx\n", want: ""},
+		{name: "unknown", path: "LICENSE", head: "MIT License\n", want: ""},
+		{name: "unknown interpreter", path: "x", head: "#!/usr/bin/perl\n", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tail []byte
+			if tt.tail != "" {
+				tail = []byte(tt.tail)
+			}
+			rule, ok := detector.Detect(tt.path, []byte(tt.head), tail)
+			if got := rule.Lang; got != tt.want || ok != (tt.want != "") {
+				t.Fatalf("Detect(%q) = %q, %v, want %q", tt.path, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewDetectorRejectsBadOverrides(t *testing.T) {
+	if _, err := indexer.NewDetector([]indexer.LanguageOverride{{Pattern: "*.x", Lang: "cobol"}}); !errors.Is(err, indexer.ErrUnsupportedLanguage) {
+		t.Fatalf("unknown language error = %v, want ErrUnsupportedLanguage", err)
+	}
+	if _, err := indexer.NewDetector([]indexer.LanguageOverride{{Pattern: "[", Lang: "php"}}); err == nil {
+		t.Fatal("malformed pattern accepted")
+	}
+}
+
+func TestLanguageOverridesLoadFromConfig(t *testing.T) {
+	root := t.TempDir()
+	yaml := "language_overrides:\n  - pattern: \"*.tpl\"\n    lang: php\n"
+	if err := os.WriteFile(filepath.Join(root, config.FileName), []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.Load(root)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []config.LanguageOverride{{Pattern: "*.tpl", Lang: "php"}}
+	if len(cfg.LanguageOverrides) != 1 || cfg.LanguageOverrides[0] != want[0] {
+		t.Fatalf("LanguageOverrides = %+v, want %+v", cfg.LanguageOverrides, want)
+	}
+}
@@ -0,0 +1,182 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alessandrojcm/pampax-go/internal/app"
+	"github.com/alessandrojcm/pampax-go/internal/embedcache"
+	"github.com/alessandrojcm/pampax-go/internal/embedtext"
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+	"github.com/alessandrojcm/pampax-go/internal/providers"
+	"github.com/alessandrojcm/pampax-go/internal/tokens"
+)
+
+func TestEmbedCacheRoundtripPersistsAcrossOpen(t *testing.T) {
+	dir := t.TempDir()
+	key := embedcache.Key{SHA: "abc123", Provider: "OpenAI", Model: "text-embedding-3-small", Dimensions: 3}
+
+	cache, err := embedcache.Open(dir, embedcache.Limits{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	if err := cache.Put(key, []float64{0.1, -0.25, 1e-9}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	reopened, err := embedcache.Open(dir, embedcache.Limits{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	vector, ok, err := reopened.Get(key)
+	if err != nil || !ok {
+		t.Fatalf("Get() = %v, %v, %v; want hit", vector, ok, err)
+	}
+
+	if vector[0] != 0.1 || vector[1] != -0.25 || vector[2] != 1e-9 {
+		t.Fatalf("Get() returned %v", vector)
+	}
+
+	otherModel := key
+	otherModel.Model = "text-embedding-3-large"
+	if _, ok, _ := reopened.Get(otherModel); ok {
+		t.Fatal("expected a different model to miss the cache")
+	}
+}
+
+func TestEmbedCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache, err := embedcache.Open(t.TempDir(), embedcache.Limits{MaxEntries: 10})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	keys := make([]embedcache.Key, 11)
+	for i := range keys {
+		keys[i] = embedcache.Key{SHA: fmt.Sprintf("sha-%d", i)}
+	}
+
+	for _, key := range keys[:10] {
+		if err := cache.Put(key, []float64{1}); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+
+	if _, ok, _ := cache.Get(keys[0]); !ok {
+		t.Fatal("expected hit for sha-0")
+	}
+
+	if err := cache.Put(keys[10], []float64{1}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	// Overflow evicts down to 90% of the limit, oldest first.
+	if got := cache.Stats().Entries; got != 9 {
+		t.Fatalf("expected 9 entries after eviction, got %d", got)
+	}
+
+	for _, evicted := range keys[1:3] {
+		if _, ok, _ := cache.Get(evicted); ok {
+			t.Fatalf("expected %s to be evicted as least recently used", evicted.SHA)
+		}
+	}
+
+	for _, kept := range []embedcache.Key{keys[0], keys[3], keys[10]} {
+		if _, ok, _ := cache.Get(kept); !ok {
+			t.Fatalf("expected %s to remain cached", kept.SHA)
+		}
+	}
+
+	removed, err := cache.Prune(embedcache.Limits{})
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+
+	if removed != 9 || cache.Stats().Entries != 0 {
+		t.Fatalf("Prune() removed %d, stats %+v", removed, cache.Stats())
+	}
+}
+
+func TestBatcherEmbedChunksServesCacheHits(t *testing.T) {
+	cache, err := embedcache.Open(t.TempDir(), embedcache.Limits{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	provider := &fakeProvider{limits: providers.BatchLimits{MaxBatchSize: 8}}
+	batcher := indexer.NewBatcher(provider, indexer.BatcherOptions{Cache: cache})
+
+	texts := makeTexts(3)
+	if _, err := batcher.EmbedChunks(context.Background(), texts[:2]); err != nil {
+		t.Fatalf("EmbedChunks() error = %v", err)
+	}
+
+	vectors, err := batcher.EmbedChunks(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("EmbedChunks() error = %v", err)
+	}
+
+	for i, vector := range vectors {
+		if vector[0] != float64(i) {
+			t.Fatalf("vector %d belongs to input %v", i, vector[0])
+		}
+	}
+
+	if len(provider.calls) != 2 || len(provider.calls[1]) != 1 || provider.calls[1][0] != "text-2" {
+		t.Fatalf("expected second run to embed only the miss, got calls %v", provider.calls)
+	}
+}
+
+// TestUpdateKeysCachedEmbeddingsByRenderedText checks that the embedding
+// cache serves a chunk only when its rendered text matches, not just its code.
+func TestUpdateKeysCachedEmbeddingsByRenderedText(t *testing.T) {
+	cache, err := embedcache.Open(t.TempDir(), embedcache.Limits{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	p := newUpdateProject(t)
+	useTemplate := func(name string) {
+		template, err := embedtext.Lookup(name, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.ix = app.New(p.root, app.Options{
+			Chunker:  indexer.NewChunker(tokens.LookupProfile("", tokens.DefaultProfileName, tokens.Overrides{}), indexer.ChunkerOptions{}),
+			Provider: p.provider,
+			Cache:    cache,
+			Template: template,
+		})
+	}
+	source := "def render(items):\n    return [str(item) for item in items]\n"
+
+	useTemplate(embedtext.NodeTemplate)
+	p.write("a/report.py", source)
+	p.update(false)
+	embedded := len(p.provider.calls)
+	if embedded == 0 {
+		t.Fatal("a/report.py was not embedded")
+	}
+
+	// The node template leaves the path out, so the text is the same.
+	p.write("b/report.py", source)
+	p.update(false)
+	if len(p.provider.calls) != embedded {
+		t.Fatalf("b/report.py was embedded again: %v", p.provider.calls[embedded:])
+	}
+
+	useTemplate(embedtext.HeaderTemplate)
+	p.write("c/report.py", source)
+	p.update(false)
+	if len(p.provider.calls) == embedded {
+		t.Fatal("c/report.py was served the embedding of another template")
+	}
+	for _, text := range p.provider.calls[len(p.provider.calls)-1] {
+		if !strings.Contains(text, "// File: c/report.py") {
+			t.Errorf("embedded text %q was not rendered with the header template", text)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alessandrojcm/pampax-go/internal/config"
+	"github.com/alessandrojcm/pampax-go/internal/embedtext"
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+	"github.com/alessandrojcm/pampax-go/internal/tokens"
+)
+
+var sampleFields = embedtext.Fields{
+	FilePath:    "billing/charge.go",
+	Lang:        "go",
+	Symbol:      "Charge",
+	Signature:   "Charge(id string) : error",
+	DocComment:  "// Charge bills a customer.",
+	Intent:      "bill a customer",
+	Description: "creates a stripe charge",
+	Tags:        []string{"billing", "stripe"},
+	Variables:   []string{"apiKey"},
+	Code:        "func Charge(id string) error {\n\treturn nil\n}",
+}
+
+func TestNodeTemplateMatchesGenerateEnhancedEmbeddingText(t *testing.T) {
+	got, err := embedtext.Default.Render(sampleFields, embedtext.Budget{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "// Charge bills a customer.\n\nfunc Charge(id string) error {\n\treturn nil\n}" +
+		"\n\n// Intent: bill a customer\n\n// Description: creates a stripe charge" +
+		"\n\n// Tags: billing, stripe\n\n// Uses variables: apiKey"
+	if got != want {
+		t.Fatalf("Render() =\n%s\nwant\n%s", got, want)
+	}
+
+	bare, _ := embedtext.Default.Render(embedtext.Fields{Code: "x := 1"}, embedtext.Budget{})
+	if bare != "x := 1" {
+		t.Fatalf("Render(code only) = %q", bare)
+	}
+}
+
+func TestTemplateBudgetDropsLeastImportantSectionsFirst(t *testing.T) {
+	header, err := embedtext.Lookup(embedtext.HeaderTemplate, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, _ := header.Render(sampleFields, embedtext.Budget{})
+	if !strings.HasPrefix(full, "// File: billing/charge.go (go)\n\n// Symbol: Charge\n\n// Signature: ") || !strings.HasSuffix(full, sampleFields.Code) {
+		t.Fatalf("header Render() =\n%s", full)
+	}
+
+	measure := tokens.CharLen
+	budget := embedtext.Budget{Limit: measure(full) - 10, Measure: measure}
+	got, _ := header.Render(sampleFields, budget)
+	if strings.Contains(got, "Uses variables") || !strings.Contains(got, "// Tags:") {
+		t.Fatalf("over budget by a little, only the variables should go:\n%s", got)
+	}
+
+	budget.Limit = measure("// Symbol: Charge\n\n" + sampleFields.Code)
+	got, _ = header.Render(sampleFields, budget)
+	if got != "// Symbol: Charge\n\n"+sampleFields.Code {
+		t.Fatalf("Render() kept the wrong sections:\n%s", got)
+	}
+
+	budget.Limit = measure("func Charge(id string) error {\n\treturn")
+	got, _ = header.Render(sampleFields, budget)
+	if got != "func Charge(id string) error {" {
+		t.Fatalf("code truncated to %q, want its first line", got)
+	}
+	budget.Limit = 4
+	if got, _ = header.Render(sampleFields, budget); got != "func" {
+		t.Fatalf("code truncated to %q, want %q", got, "func")
+	}
+}
+
+func TestCustomEmbeddingTextTemplateFromConfig(t *testing.T) {
+	root := t.TempDir()
+	yaml := "embedding_text:\n  sections:\n    - name: symbol\n      template: \"{{.Lang}}: {{.Symbol}}\"\n      priority: 5\n    - name: code\n      template: \"{{.Code}}\"\n"
+	if err := os.WriteFile(filepath.Join(root, config.FileName), []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sections []embedtext.Section
+	for _, s := range cfg.EmbeddingText.Sections {
+		sections = append(sections, embedtext.Section{Name: s.Name, Template: s.Template, Priority: s.Priority})
+	}
+	tmpl, err := embedtext.Lookup(cfg.EmbeddingText.Template, sections)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := tmpl.Render(sampleFields, embedtext.Budget{}); got != "go: Charge\n\n"+sampleFields.Code {
+		t.Fatalf("Render() = %q", got)
+	}
+
+	if _, err := embedtext.New([]embedtext.Section{{Name: "symbol", Template: "{{.Symbol}}"}}); err == nil {
+		t.Fatal("template without a code section accepted")
+	}
+	if _, err := embedtext.Lookup("nope", nil); err == nil {
+		t.Fatal("unknown built-in template accepted")
+	}
+}
+
+func TestChunkEmbeddingFieldsCarryVariablesAndSignature(t *testing.T) {
+	source := "package billing\n\nfunc Charge(id string) error {\n\tconst stripeAPIKey = \"sk_test\"\n\tvar count = 1\n\treturn call(stripeAPIKey, id, count)\n}\n"
+	rule, _ := indexer.RuleForExtension(".go")
+	chunks, _ := indexer.NewChunker(tokens.LookupProfile("", tokens.DefaultProfileName, tokens.Overrides{}), indexer.ChunkerOptions{}).
+		ChunkFile("charge.go", rule, []byte(source))
+	if len(chunks) != 1 {
+		t.Fatalf("got %d chunks", len(chunks))
+	}
+
+	fields := chunks[0].EmbeddingFields("billing/charge.go", "go")
+	if fields.Signature != "Charge(id string) : error" {
+		t.Errorf("Signature = %q", fields.Signature)
+	}
+	if strings.Join(fields.Variables, ",") != "stripeAPIKey" {
+		t.Errorf("Variables = %v, want only the important stripeAPIKey", chunks[0].Variables)
+	}
+	if !strings.HasSuffix(chunks[0].EmbeddingText(), "// Uses variables: stripeAPIKey") {
+		t.Errorf("EmbeddingText() =\n%s", chunks[0].EmbeddingText())
+	}
+}
@@ -0,0 +1,125 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+)
+
+var testSignature = &object.Signature{Name: "test", Email: "test@example.com", When: time.Unix(1700000000, 0)}
+
+func commitAll(t *testing.T, worktree *git.Worktree, message string) {
+	t.Helper()
+	if err := worktree.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worktree.Commit(message, &git.CommitOptions{Author: testSignature}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGitFilesAndChanges(t *testing.T) {
+	root := t.TempDir()
+	repo, err := git.PlainInit(root, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeTree(t, root, map[string]string{
+		".gitignore":       "gen/\n",
+		"app/main.go":      "package main\n",
+		"app/old.go":       "package main\n",
+		"app/util/util.py": "x = 1\n",
+		"docs/guide.md":    "# Guide\n",
+		"app/gen/stub.go":  "package gen\n",
+		"app/untracked.rb": "puts 1\n",
+		"app/gen/other.go": "package gen\n",
+	})
+
+	// app/untracked.rb stays untracked; app/gen/stub.go is force-added past
+	// .gitignore.
+	for _, name := range []string{".gitignore", "app/main.go", "app/old.go", "app/util/util.py", "docs/guide.md", "app/gen/stub.go"} {
+		if _, err := worktree.Add(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first, err := worktree.Commit("first", &git.CommitOptions{Author: testSignature})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	project := filepath.Join(root, "app")
+	tracked, err := indexer.GitFiles(context.Background(), project, indexer.GitOptions{})
+	if err != nil {
+		t.Fatalf("GitFiles() error = %v", err)
+	}
+	if got := strings.Join(walkedPaths(tracked), ","); got != "gen/stub.go,main.go,old.go,util/util.py" {
+		t.Fatalf("tracked files = %s", got)
+	}
+
+	all, err := indexer.GitFiles(context.Background(), project, indexer.GitOptions{IncludeUntracked: true})
+	if err != nil {
+		t.Fatalf("GitFiles(untracked) error = %v", err)
+	}
+	if got := strings.Join(walkedPaths(all), ","); got != "gen/stub.go,main.go,old.go,untracked.rb,util/util.py" {
+		t.Fatalf("tracked+untracked files = %s", got)
+	}
+	// The walk for untracked files passes over tracked ones, so none is
+	// reported twice or skipped as git-ignored.
+	seen := map[string]bool{}
+	for _, path := range walkedPaths(all) {
+		seen[path] = true
+	}
+	for _, skipped := range all.Skipped {
+		if seen[skipped.Path] {
+			t.Errorf("%s reported twice (skipped as %s)", skipped.Path, skipped.Reason)
+		}
+		seen[skipped.Path] = true
+	}
+
+	writeTree(t, root, map[string]string{
+		"app/main.go":      "package main\n\nfunc main() {}\n",
+		"app/new.go":       "package main\n",
+		"docs/guide.md":    "# Guide v2\n",
+		"app/untracked.rb": "puts 2\n",
+	})
+	if err := os.Remove(filepath.Join(root, "app", "old.go")); err != nil {
+		t.Fatal(err)
+	}
+	commitAll(t, worktree, "second")
+
+	changes, err := indexer.GitChanges(context.Background(), project, first.String(), "")
+	if err != nil {
+		t.Fatalf("GitChanges() error = %v", err)
+	}
+	if got := strings.Join(changes.Changed, ","); got != "main.go,new.go,untracked.rb" {
+		t.Fatalf("changed = %s", got)
+	}
+	if got := strings.Join(changes.Deleted, ","); got != "old.go" {
+		t.Fatalf("deleted = %s", got)
+	}
+
+	if _, err := indexer.GitChanges(context.Background(), project, "no-such-ref", ""); err == nil {
+		t.Fatal("GitChanges accepted an unknown revision")
+	}
+}
+
+func TestGitFilesOutsideRepository(t *testing.T) {
+	_, err := indexer.GitFiles(context.Background(), t.TempDir(), indexer.GitOptions{})
+	if !errors.Is(err, indexer.ErrNotGitRepository) {
+		t.Fatalf("GitFiles() error = %v, want ErrNotGitRepository", err)
+	}
+}
@@ -0,0 +1,92 @@
+package unit
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/alessandrojcm/pampax-go/internal/codemap"
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+	"github.com/alessandrojcm/pampax-go/internal/tokens"
+)
+
+func TestChunkCallsSkipKeywordsAndQualifiers(t *testing.T) {
+	source := "function checkout(cart) {\n  const total = pricing.sumTotal(cart);\n  if (total > 0) { stripe.charges.create({ total }); }\n  return new Receipt(format(total), format(0));\n}\n"
+	rule, _ := indexer.RuleForExtension(".js")
+	chunks, _ := indexer.NewChunker(tokens.LookupProfile("", tokens.DefaultProfileName, tokens.Overrides{}), indexer.ChunkerOptions{}).
+		ChunkFile("checkout.js", rule, []byte(source))
+	if len(chunks) == 0 || chunks[0].Symbol != "checkout" {
+		t.Fatalf("got %d chunks, want checkout first", len(chunks))
+	}
+
+	want := []indexer.Call{{Qualifier: "pricing", Name: "sumTotal"}, {Qualifier: "stripe.charges", Name: "create"}, {Name: "format"}}
+	if !reflect.DeepEqual(chunks[0].Calls, want) {
+		t.Fatalf("Calls = %v, want %v", chunks[0].Calls, want)
+	}
+	if got := chunks[0].Metadata("checkout.js", "javascript").SymbolCalls; !reflect.DeepEqual(got, []string{"sumTotal", "create", "format"}) {
+		t.Fatalf("SymbolCalls = %v", got)
+	}
+}
+
+func graphEntry(file, symbol, sha string, calls ...string) codemap.ChunkMetadata {
+	return codemap.ChunkMetadata{File: file, Symbol: &symbol, SHA: sha, SymbolCalls: calls}
+}
+
+func TestAttachSymbolGraphResolvesCallsPreferringSameFile(t *testing.T) {
+	entries := codemap.NewOrderedMap()
+	entries.Set("lib/format.js:format:f1", graphEntry("lib/format.js", "format", "f1"))
+	entries.Set("api/checkout.js:checkout:c1", graphEntry("api/checkout.js", "checkout", "c1", "Format", "validate", "checkout", "missing"))
+	entries.Set("api/checkout.js:format:f2", graphEntry("api/checkout.js", "format", "f2"))
+	entries.Set("api/checkout.js:validate:v1", graphEntry("api/checkout.js", "validate", "v1", "format"))
+	entries.Set("lib/report.js:report:r1", graphEntry("lib/report.js", "report", "r1", "format", "checkout"))
+
+	codemap.AttachSymbolGraph(entries, nil)
+
+	get := func(key string) codemap.ChunkMetadata {
+		value, _ := entries.Get(key)
+		return value.(codemap.ChunkMetadata)
+	}
+	checkout := get("api/checkout.js:checkout:c1")
+	if !reflect.DeepEqual(checkout.SymbolCallTargets, []string{"f2", "v1"}) {
+		t.Errorf("checkout targets = %v, want the same-file format and no self edge", checkout.SymbolCallTargets)
+	}
+	if !reflect.DeepEqual(checkout.SymbolCallers, []string{"r1"}) || !reflect.DeepEqual(checkout.SymbolNeighbors, []string{"f2", "v1", "r1"}) {
+		t.Errorf("checkout callers = %v, neighbors = %v", checkout.SymbolCallers, checkout.SymbolNeighbors)
+	}
+	if report := get("lib/report.js:report:r1"); !reflect.DeepEqual(report.SymbolCallTargets, []string{"f1", "c1"}) {
+		t.Errorf("report targets = %v, want the first format outside its file", report.SymbolCallTargets)
+	}
+	if format := get("api/checkout.js:format:f2"); !reflect.DeepEqual(format.SymbolCallers, []string{"c1", "v1"}) || len(format.SymbolCallTargets) != 0 {
+		t.Errorf("format callers = %v, targets = %v", format.SymbolCallers, format.SymbolCallTargets)
+	}
+
+	first, _ := codemap.MarshalCodemap(entries)
+	codemap.AttachSymbolGraph(entries, nil)
+	second, _ := codemap.MarshalCodemap(entries)
+	if string(first) != string(second) {
+		t.Fatal("codemap changed when the graph was attached twice")
+	}
+}
+
+func TestAttachSymbolGraphCapsNeighbors(t *testing.T) {
+	entries := codemap.NewOrderedMap()
+	var calls []string
+	for i := range 20 {
+		callee, caller := fmt.Sprintf("callee%d", i), fmt.Sprintf("caller%d", i)
+		calls = append(calls, callee)
+		entries.Set(callee, graphEntry("a.js", callee, callee+"-sha"))
+		entries.Set(caller, graphEntry("a.js", caller, caller+"-sha", "hub"))
+	}
+	entries.Set("hub", graphEntry("a.js", "hub", "hub-sha", calls...))
+
+	codemap.AttachSymbolGraph(entries, nil)
+
+	value, _ := entries.Get("hub")
+	hub := value.(codemap.ChunkMetadata)
+	if len(hub.SymbolCallTargets) != codemap.MaxNeighbors || len(hub.SymbolCallers) != codemap.MaxNeighbors {
+		t.Fatalf("targets = %d, callers = %d, want %d each", len(hub.SymbolCallTargets), len(hub.SymbolCallers), codemap.MaxNeighbors)
+	}
+	if len(hub.SymbolNeighbors) != 2*codemap.MaxNeighbors {
+		t.Fatalf("neighbors = %d, want %d", len(hub.SymbolNeighbors), 2*codemap.MaxNeighbors)
+	}
+}
@@ -0,0 +1,252 @@
+package unit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alessandrojcm/pampax-go/internal/codemap"
+	"github.com/alessandrojcm/pampax-go/internal/graph"
+	"github.com/alessandrojcm/pampax-go/internal/mcp"
+)
+
+// queryCodemap is handler -> service -> {store, format}, store -> format,
+// with report also calling format.
+func queryCodemap(t *testing.T) *codemap.OrderedMap {
+	t.Helper()
+	entries := codemap.NewOrderedMap()
+	entries.Set("api/handler.go:handle:a1", graphEntry("api/handler.go", "handle", "a1aaaaaaaa", "Checkout"))
+	entries.Set("svc/service.go:Checkout:b1", graphEntry("svc/service.go", "Checkout", "b1bbbbbbbb", "Save", "money"))
+	entries.Set("svc/store.go:Save:c1", graphEntry("svc/store.go", "Save", "c1cccccccc", "money"))
+	entries.Set("svc/format.go:money:d1", graphEntry("svc/format.go", "money", "d1dddddddd"))
+	entries.Set("svc/report.go:report:e1", graphEntry("svc/report.go", "report", "e1eeeeeeee", "money"))
+
+	value, _ := entries.Get("svc/report.go:report:e1")
+	report := value.(codemap.ChunkMetadata)
+	report.SymbolSignature = "func buildReport(rows []Row) : string"
+	entries.Set("svc/report.go:report:e1", report)
+
+	codemap.AttachSymbolGraph(entries, nil)
+	return entries
+}
+
+func TestGraphFindsSymbolsBySignatureKeyAndSHA(t *testing.T) {
+	g := graph.New(queryCodemap(t))
+
+	for query, want := range map[string]string{
+		"checkout":               "b1bbbbbbbb",
+		"buildreport":            "e1eeeeeeee",
+		"svc/store.go:save":      "c1cccccccc",
+		"svc/format.go:money:d1": "d1dddddddd",
+		"e1eeeee":                "e1eeeeeeee",
+	} {
+		nodes := g.Find(query)
+		if len(nodes) != 1 || nodes[0].SHA != want {
+			t.Errorf("Find(%q) = %v, want %s", query, nodes, want)
+		}
+	}
+	if nodes := g.Find("api/handler.go:money"); len(nodes) != 0 {
+		t.Errorf("file-qualified query matched another file: %v", nodes)
+	}
+	if _, err := g.Query("nothing", graph.CallersOf, 2); !errors.Is(err, graph.ErrNotFound) {
+		t.Errorf("Query error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGraphCallersTreeIsTheImpactRadius(t *testing.T) {
+	g := graph.New(queryCodemap(t))
+
+	result, err := g.Query("money", graph.CallersOf, 2)
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(result.Trees) != 1 {
+		t.Fatalf("got %d trees, want 1", len(result.Trees))
+	}
+	// service reaches money directly, so it is not repeated under store.
+	var out strings.Builder
+	if err := graph.WriteTraversal(&out, result, graph.FormatText); err != nil {
+		t.Fatalf("WriteTraversal: %v", err)
+	}
+	want := "svc/format.go: money (callers, 4 within depth 2)\n" +
+		"├── svc/service.go: Checkout\n" +
+		"│   └── api/handler.go: handle\n" +
+		"├── svc/store.go: Save\n" +
+		"└── svc/report.go: func buildReport(rows []Row) : string\n"
+	if out.String() != want {
+		t.Errorf("text tree =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	shallow, _ := g.Query("money", graph.CallersOf, 1)
+	if size := shallow.Trees[0].Size(); size != 4 {
+		t.Errorf("depth 1 tree has %d nodes, want 4", size)
+	}
+
+	out.Reset()
+	callees, _ := g.Query("handle", graph.CalleesOf, 3)
+	if err := graph.WriteTraversal(&out, callees, graph.FormatDOT); err != nil {
+		t.Fatalf("WriteTraversal: %v", err)
+	}
+	for _, edge := range []string{`"a1aaaaaaaa" -> "b1bbbbbbbb";`, `"b1bbbbbbbb" -> "c1cccccccc";`, `"b1bbbbbbbb" -> "d1dddddddd";`} {
+		if !strings.Contains(out.String(), edge) {
+			t.Errorf("DOT output misses %s:\n%s", edge, out.String())
+		}
+	}
+}
+
+func TestGraphPathFollowsCallees(t *testing.T) {
+	g := graph.New(queryCodemap(t))
+
+	result, err := g.PathBetween("handle", "money", 3)
+	if err != nil {
+		t.Fatalf("PathBetween: %v", err)
+	}
+	var symbols []string
+	for _, node := range result.Path {
+		symbols = append(symbols, node.SHA)
+	}
+	if !reflect.DeepEqual(symbols, []string{"a1aaaaaaaa", "b1bbbbbbbb", "d1dddddddd"}) {
+		t.Errorf("path = %v, want handle -> Checkout -> money", symbols)
+	}
+
+	if short, _ := g.PathBetween("handle", "money", 1); len(short.Path) != 0 {
+		t.Errorf("path within depth 1 = %v, want none", short.Path)
+	}
+	var out strings.Builder
+	none, _ := g.PathBetween("money", "handle", 3)
+	if err := graph.WritePath(&out, none, graph.FormatJSON); err != nil {
+		t.Fatalf("WritePath: %v", err)
+	}
+	if !strings.Contains(out.String(), `"path": []`) {
+		t.Errorf("JSON of a missing path = %s", out.String())
+	}
+}
+
+func TestGraphLoadsWrittenCodemap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pampa.codemap.json")
+	if err := codemap.WriteCodemap(path, queryCodemap(t)); err != nil {
+		t.Fatalf("WriteCodemap: %v", err)
+	}
+	read, err := codemap.ReadCodemap(path)
+	if err != nil {
+		t.Fatalf("ReadCodemap: %v", err)
+	}
+	if keys := read.Keys(); len(keys) != 5 || keys[0] != "api/handler.go:handle:a1" {
+		t.Fatalf("keys = %v, want the written order", keys)
+	}
+
+	g, err := graph.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	result, _ := g.Query("save", graph.CallersOf, 3)
+	if size := result.Trees[0].Size(); size != 3 {
+		t.Errorf("Save impact = %d nodes, want 3", size)
+	}
+}
+
+func TestMCPServerListsAndCallsTools(t *testing.T) {
+	server := mcp.NewServer("pampax", "test")
+	server.AddTool(mcp.Tool{
+		Name:        "echo",
+		InputSchema: map[string]any{"type": "object"},
+		Handler: func(_ context.Context, args json.RawMessage) (string, error) {
+			var in struct{ Text string }
+			_ = json.Unmarshal(args, &in)
+			if in.Text == "" {
+				return "", errors.New("text is required")
+			}
+			return in.Text, nil
+		},
+	})
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"echo"}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"resources/list"}`,
+	}, "\n")
+	var out strings.Builder
+	if err := server.Serve(context.Background(), strings.NewReader(input), &out); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+
+	var responses []map[string]any
+	scanner := bufio.NewScanner(strings.NewReader(out.String()))
+	for scanner.Scan() {
+		var resp map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			t.Fatalf("response %q: %v", scanner.Text(), err)
+		}
+		responses = append(responses, resp)
+	}
+	if len(responses) != 5 {
+		t.Fatalf("got %d responses, want 5 (none for the notification)", len(responses))
+	}
+	result := func(i int) map[string]any { return responses[i]["result"].(map[string]any) }
+
+	if got := result(0)["protocolVersion"]; got != "2025-03-26" {
+		t.Errorf("protocolVersion = %v", got)
+	}
+	if tools := result(1)["tools"].([]any); len(tools) != 1 || tools[0].(map[string]any)["name"] != "echo" {
+		t.Errorf("tools = %v", tools)
+	}
+	if text := result(2)["content"].([]any)[0].(map[string]any)["text"]; text != "hi" || result(2)["isError"] != nil {
+		t.Errorf("call result = %v", result(2))
+	}
+	if result(3)["isError"] != true {
+		t.Errorf("failing call result = %v, want isError", result(3))
+	}
+	if code := responses[4]["error"].(map[string]any)["code"]; code != float64(-32601) {
+		t.Errorf("unknown method error code = %v", code)
+	}
+}
+
+func TestMCPServerTellsNotificationsFromNullIDs(t *testing.T) {
+	server := mcp.NewServer("pampax", "test")
+	calls := 0
+	server.AddTool(mcp.Tool{
+		Name:        "count",
+		InputSchema: map[string]any{"type": "object"},
+		Handler: func(context.Context, json.RawMessage) (string, error) {
+			calls++
+			return "ok", nil
+		},
+	})
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","method":"tools/call","params":{"name":"count"}}`,
+		`{"jsonrpc":"2.0","id":null,"method":"tools/call","params":{"name":"count"}}`,
+	}, "\n")
+	var out strings.Builder
+	if err := server.Serve(context.Background(), strings.NewReader(input), &out); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+
+	// The notification runs without an answer; the request with a null id
+	// is answered with an error and does not run.
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("responses = %q, want one for the null id", lines)
+	}
+	var resp struct {
+		ID    json.RawMessage
+		Error struct{ Code int }
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.ID) != "null" || resp.Error.Code != -32600 {
+		t.Errorf("response = %s, want an invalid request error with a null id", lines[0])
+	}
+	if calls != 1 {
+		t.Errorf("tool ran %d times, want once for the notification", calls)
+	}
+}
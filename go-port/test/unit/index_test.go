@@ -0,0 +1,374 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/alessandrojcm/pampax-go/internal/app"
+	"github.com/alessandrojcm/pampax-go/internal/codemap"
+	"github.com/alessandrojcm/pampax-go/internal/config"
+	"github.com/alessandrojcm/pampax-go/internal/embedtext"
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+	"github.com/alessandrojcm/pampax-go/internal/tokens"
+)
+
+func (p *updateProject) index(ctx context.Context, progress func(app.Progress)) (app.IndexResult, error) {
+	return p.ix.Index(ctx, app.IndexRequest{
+		Discover: func(ctx context.Context) (indexer.WalkResult, error) {
+			return indexer.Walk(ctx, p.root, indexer.WalkOptions{})
+		},
+		Workers:  2,
+		Progress: progress,
+	})
+}
+
+func TestIndexRunsFilesThroughThePipeline(t *testing.T) {
+	p := newUpdateProject(t)
+	p.write("store/store.go", storeSource)
+	p.write("report.py", reportSource)
+	p.write("notes/todo.txt", "binary? no, just text\n")
+
+	var events []app.Progress
+	result, err := p.index(context.Background(), func(progress app.Progress) { events = append(events, progress) })
+	if err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	if result.Files != 2 || result.Indexed != 2 || result.ChunksStored != 3 || result.TotalChunks != 3 || len(result.Failed) != 0 {
+		t.Errorf("result = %+v", result)
+	}
+	for _, stage := range app.Stages {
+		if _, ok := result.Timings[stage]; !ok {
+			t.Errorf("no timing for stage %s", stage)
+		}
+	}
+	if len(events) != 3 || events[0] != (app.Progress{Stage: app.StageDiscover, Total: 2}) {
+		t.Fatalf("progress events = %+v", events)
+	}
+	if last := events[2]; last.Done != 2 || last.Total != 2 || last.Chunks != 3 || last.Stage != app.StageStore {
+		t.Errorf("last progress event = %+v", last)
+	}
+	if got := p.symbols("store/store.go"); !reflect.DeepEqual(got, []string{"Save", "Load"}) {
+		t.Errorf("store.go symbols = %v", got)
+	}
+
+	// An update finds nothing to do after an index, and an index after
+	// changes behaves like an update.
+	if again := p.update(true); !again.Changes.Empty() {
+		t.Errorf("update after index = %+v", again.Changes)
+	}
+	p.write("store/store.go", strings.Replace(storeSource, "return read(id)", "return readCached(id)", 1))
+	if err := os.Remove(filepath.Join(p.root, "report.py")); err != nil {
+		t.Fatal(err)
+	}
+	calls := len(p.provider.calls)
+	second, err := p.index(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("second Index: %v", err)
+	}
+	if second.Indexed != 1 || second.Removed != 1 || second.ChunksStored != 1 || second.ChunksKept != 1 || second.ChunksDeleted != 2 || second.TotalChunks != 2 {
+		t.Errorf("second result = %+v", second)
+	}
+	if len(p.provider.calls) != calls+1 || len(p.provider.calls[calls]) != 1 {
+		t.Errorf("second index embedded %v, want only the changed Load", p.provider.calls[calls:])
+	}
+	if got := p.symbols("report.py"); len(got) != 0 {
+		t.Errorf("report.py still has chunks %v", got)
+	}
+
+	unchanged, err := p.index(context.Background(), nil)
+	if err != nil || unchanged.Unchanged != 1 || unchanged.Indexed != 0 || unchanged.ChunksStored != 0 {
+		t.Errorf("unchanged index = %+v, %v", unchanged, err)
+	}
+}
+
+func TestIndexStopsWhenCancelled(t *testing.T) {
+	p := newUpdateProject(t)
+	p.write("store/store.go", storeSource)
+	p.write("report.py", reportSource)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := p.ix.Index(ctx, app.IndexRequest{
+		Discover: func(ctx context.Context) (indexer.WalkResult, error) {
+			defer cancel()
+			return indexer.Walk(ctx, p.root, indexer.WalkOptions{})
+		},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Index = %v, want context.Canceled", err)
+	}
+	paths := config.ResolvePaths(p.root)
+	for _, path := range []string{paths.Codemap, paths.Merkle} {
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s written by a cancelled run", filepath.Base(path))
+		}
+	}
+
+	if _, err := app.New(p.root, app.Options{}).Index(context.Background(), app.IndexRequest{}); !errors.Is(err, app.ErrNoProvider) {
+		t.Errorf("Index without a provider = %v", err)
+	}
+}
+
+func TestIndexResumesFromCheckpoint(t *testing.T) {
+	p := newUpdateProject(t)
+	p.write("store/store.go", storeSource)
+	p.write("report.py", reportSource)
+	embedded := func() (texts int) {
+		for _, call := range p.provider.calls {
+			texts += len(call)
+		}
+		return texts
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := p.index(ctx, func(progress app.Progress) {
+		if progress.Stage == app.StageStore {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("interrupted Index = %v", err)
+	}
+	paths := config.ResolvePaths(p.root)
+	if _, err := os.Stat(paths.Checkpoint); err != nil {
+		t.Fatalf("no checkpoint after an interrupted run: %v", err)
+	}
+	first := embedded()
+
+	result, err := p.index(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("resumed Index: %v", err)
+	}
+	if result.ChunksResumed == 0 || result.ChunksResumed+result.ChunksStored != 3 || result.TotalChunks != 3 {
+		t.Errorf("resumed result = %+v", result)
+	}
+	// Chunks embedded but not stored before the interruption are embedded
+	// again; the resumed ones are not.
+	if again := embedded() - first; again != result.ChunksStored {
+		t.Errorf("resumed run embedded %d texts, want the %d it stored", again, result.ChunksStored)
+	}
+	if got := p.symbols("store/store.go"); !reflect.DeepEqual(got, []string{"Save", "Load"}) {
+		t.Errorf("store.go symbols = %v", got)
+	}
+	if got := p.symbols("report.py"); !reflect.DeepEqual(got, []string{"render"}) {
+		t.Errorf("report.py symbols = %v", got)
+	}
+	if _, err := os.Stat(paths.Checkpoint); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("checkpoint left after a complete run: %v", err)
+	}
+}
+
+func TestIndexDiscardsCheckpointOfAnotherModelOrTemplate(t *testing.T) {
+	header, err := embedtext.Lookup(embedtext.HeaderTemplate, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, change := range map[string]func(*app.Options){
+		"model":    func(opts *app.Options) { opts.Provider.(*fakeProvider).model = "other-model" },
+		"template": func(opts *app.Options) { opts.Template = header },
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := newUpdateProject(t)
+			p.write("store/store.go", storeSource)
+			p.write("report.py", reportSource)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			_, err := p.index(ctx, func(progress app.Progress) {
+				if progress.Stage == app.StageStore {
+					cancel()
+				}
+			})
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("interrupted Index = %v", err)
+			}
+
+			opts := app.Options{
+				Chunker:  indexer.NewChunker(tokens.LookupProfile("", tokens.DefaultProfileName, tokens.Overrides{}), indexer.ChunkerOptions{}),
+				Provider: p.provider,
+			}
+			change(&opts)
+			p.ix = app.New(p.root, opts)
+			result, err := p.index(context.Background(), nil)
+			if err != nil {
+				t.Fatalf("Index: %v", err)
+			}
+			if result.ChunksResumed != 0 || result.ChunksStored != 3 {
+				t.Errorf("Index with another %s resumed %d chunks and stored %d", name, result.ChunksResumed, result.ChunksStored)
+			}
+		})
+	}
+}
+
+func TestIndexIsolatesFailingFiles(t *testing.T) {
+	p := newUpdateProject(t)
+	p.write("store/store.go", storeSource)
+	p.write("report.py", reportSource)
+
+	// The embedding text of report.py cannot be rendered, so the file
+	// fails to embed while the rest of its group goes through.
+	template, err := embedtext.New([]embedtext.Section{{
+		Name:     embedtext.CodeSection,
+		Template: `{{if eq .FilePath "report.py"}}{{index .Tags 5}}{{end}}{{.Code}}`,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var logs bytes.Buffer
+	logger := zerolog.New(&logs)
+	ix := app.New(p.root, app.Options{
+		Chunker:  indexer.NewChunker(tokens.LookupProfile("", tokens.DefaultProfileName, tokens.Overrides{}), indexer.ChunkerOptions{}),
+		Provider: p.provider,
+		Template: template,
+		Logger:   &logger,
+	})
+	result, err := ix.Index(context.Background(), app.IndexRequest{
+		Discover: func(ctx context.Context) (indexer.WalkResult, error) {
+			return indexer.Walk(ctx, p.root, indexer.WalkOptions{})
+		},
+	})
+	if err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	if result.Indexed != 1 || result.ChunksStored != 2 || len(result.Failed) != 1 {
+		t.Fatalf("result = %+v", result)
+	}
+	if failed := result.Failed[0]; failed.Path != "report.py" || failed.Category != app.ErrorEmbed {
+		t.Errorf("failed = %+v", failed)
+	}
+	// By default one bad file does not fail the command.
+	cfg, err := config.Load(p.root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TooManyFailures(cfg.Index.MaxErrors) {
+		t.Errorf("one failed file is too many with the default index.max_errors %d", cfg.Index.MaxErrors)
+	}
+	if !result.TooManyFailures(0) || result.TooManyFailures(1) {
+		t.Errorf("TooManyFailures does not compare against max_errors")
+	}
+	if got := p.symbols("store/store.go"); !reflect.DeepEqual(got, []string{"Save", "Load"}) {
+		t.Errorf("store.go symbols = %v", got)
+	}
+
+	report, err := app.ReadIndexReport(config.ResolvePaths(p.root).Report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Status != app.ReportOK || report.Indexed != 1 || report.ErrorCount != 1 || report.ByCategory[app.ErrorEmbed] != 1 || report.ByCategory[app.ErrorRead] != 0 {
+		t.Errorf("report = %+v", report)
+	}
+	if len(report.Errors) != 1 || report.Errors[0].File != "report.py" || report.Errors[0].Category != app.ErrorEmbed {
+		t.Errorf("reported errors = %+v", report.Errors)
+	}
+
+	var logged struct {
+		Level    string `json:"level"`
+		File     string `json:"file"`
+		Category string `json:"category"`
+		Error    string `json:"error"`
+	}
+	line, _, _ := bytes.Cut(logs.Bytes(), []byte("\n"))
+	if err := json.Unmarshal(line, &logged); err != nil {
+		t.Fatalf("log line %q: %v", line, err)
+	}
+	if logged.Level != "warn" || logged.File != "report.py" || logged.Category != "embed" || logged.Error == "" {
+		t.Errorf("logged %+v", logged)
+	}
+
+	// The failed file is not recorded, so the next run picks it up.
+	again, err := p.index(context.Background(), nil)
+	if err != nil || again.Indexed != 1 || again.Unchanged != 1 || len(again.Failed) != 0 {
+		t.Errorf("second Index = %+v, %v", again, err)
+	}
+	if got := p.symbols("report.py"); !reflect.DeepEqual(got, []string{"render"}) {
+		t.Errorf("report.py symbols = %v", got)
+	}
+}
+
+func TestIndexGivesUpWhenFilesKeepFailing(t *testing.T) {
+	p := newUpdateProject(t)
+	for i := range 12 {
+		p.write(fmt.Sprintf("report%d.py", i), reportSource)
+	}
+	template, err := embedtext.New([]embedtext.Section{{Name: embedtext.CodeSection, Template: `{{index .Tags 5}}`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix := app.New(p.root, app.Options{
+		Chunker:  indexer.NewChunker(tokens.LookupProfile("", tokens.DefaultProfileName, tokens.Overrides{}), indexer.ChunkerOptions{}),
+		Provider: p.provider,
+		Template: template,
+	})
+	_, err = ix.Index(context.Background(), app.IndexRequest{
+		Discover: func(ctx context.Context) (indexer.WalkResult, error) {
+			return indexer.Walk(ctx, p.root, indexer.WalkOptions{})
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "in a row failed to embed") {
+		t.Fatalf("Index = %v, want it to give up", err)
+	}
+	report, err := app.ReadIndexReport(config.ResolvePaths(p.root).Report)
+	if err != nil || report.Status != app.ReportFailed || report.Error == "" {
+		t.Errorf("report = %+v, %v", report, err)
+	}
+}
+
+func TestIndexResolvesCallsThroughImports(t *testing.T) {
+	p := newUpdateProject(t)
+	p.write("go.mod", "module example.com/shop\n")
+	p.write("store/store.go", "package store\n\ntype Store struct{}\n\nfunc New() *Store {\n\treturn &Store{}\n}\n\nfunc (s *Store) Save(id int) error {\n\treturn nil\n}\n")
+	p.write("cache/cache.go", "package cache\n\ntype Cache struct{}\n\nfunc New() *Cache {\n\treturn &Cache{}\n}\n\nfunc (c *Cache) Save(id int) error {\n\treturn nil\n}\n")
+	p.write("main.go", "package main\n\nimport (\n\t\"example.com/shop/cache\"\n\t\"example.com/shop/store\"\n)\n\nfunc run() {\n\ts := store.New()\n\tc := cache.New()\n\t_, _ = s.Save(1), c\n}\n")
+
+	result, err := p.index(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	if len(result.Ambiguous) != 1 || result.Ambiguous[0].Call != "s.Save" || result.Ambiguous[0].File != "main.go" {
+		t.Fatalf("ambiguous = %+v, want s.Save from main.go", result.Ambiguous)
+	}
+	report, err := app.ReadIndexReport(config.ResolvePaths(p.root).Report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.AmbiguousCalls) != 1 || report.AmbiguousCalls[0].Symbol != "run" || len(report.AmbiguousCalls[0].Candidates) != 2 {
+		t.Errorf("reported ambiguous calls = %+v", report.AmbiguousCalls)
+	}
+
+	// run calls both New functions, which a lookup by name cannot tell
+	// apart. main.go is unchanged by the update, so it is chunked again
+	// for the graph.
+	p.write("cache/cache.go", "package cache\n\n// Cache keeps nothing.\ntype Cache struct{}\n\nfunc New() *Cache {\n\treturn &Cache{}\n}\n\nfunc (c *Cache) Save(id int) error {\n\treturn nil\n}\n")
+	updated := p.update(false)
+	if len(updated.Changes.Modified) != 1 || len(updated.Ambiguous) != 1 {
+		t.Fatalf("update = %+v", updated)
+	}
+	cm, err := codemap.ReadCodemap(config.ResolvePaths(p.root).Codemap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	news := map[string]bool{}
+	var run codemap.ChunkMetadata
+	for _, key := range cm.Keys() {
+		value, _ := cm.Get(key)
+		entry := value.(codemap.ChunkMetadata)
+		switch {
+		case entry.File == "main.go":
+			run = entry
+		case entry.Symbol != nil && *entry.Symbol == "New":
+			news[entry.SHA] = true
+		}
+	}
+	if len(run.SymbolCallTargets) != 2 || !news[run.SymbolCallTargets[0]] || !news[run.SymbolCallTargets[1]] {
+		t.Errorf("run targets = %v, want both New functions %v", run.SymbolCallTargets, news)
+	}
+}
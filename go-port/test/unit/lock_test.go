@@ -0,0 +1,133 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alessandrojcm/pampax-go/internal/db"
+	"github.com/alessandrojcm/pampax-go/internal/lock"
+)
+
+func writeLock(t *testing.T, path string, info lock.Info) {
+	t.Helper()
+	payload, _ := json.Marshal(info)
+	if err := os.WriteFile(path, payload, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLockExcludesSecondWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".pampa", "index.lock")
+	held, err := lock.Acquire(path, "pampax watch")
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if info, err := lock.Read(path); err != nil || info.PID != os.Getpid() || info.Command != "pampax watch" || info.Hostname == "" {
+		t.Errorf("lock file = %+v, %v", info, err)
+	}
+
+	_, err = lock.Acquire(path, "pampax update")
+	var locked *lock.LockedError
+	if !errors.Is(err, lock.ErrLocked) || !errors.As(err, &locked) || locked.Holder.Command != "pampax watch" {
+		t.Fatalf("second Acquire = %v, want the watch holding the lock", err)
+	}
+
+	if err := held.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	again, err := lock.Acquire(path, "pampax update")
+	if err != nil {
+		t.Fatalf("Acquire after Release: %v", err)
+	}
+	again.Release()
+}
+
+func TestLockReplacesStaleLocks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.lock")
+	hostname, _ := os.Hostname()
+
+	// A child that has exited and been reaped leaves a free PID.
+	child := exec.Command(os.Args[0], "-test.run=^$")
+	if err := child.Run(); err != nil {
+		t.Fatal(err)
+	}
+	writeLock(t, path, lock.Info{PID: child.Process.Pid, Hostname: hostname, StartedAt: time.Now()})
+	held, err := lock.Acquire(path, "pampax update")
+	if err != nil {
+		t.Fatalf("Acquire over a crashed process's lock: %v", err)
+	}
+	held.Release()
+
+	writeLock(t, path, lock.Info{PID: child.Process.Pid, Hostname: hostname + "-elsewhere", StartedAt: time.Now()})
+	if _, err := lock.Acquire(path, "pampax update"); !errors.Is(err, lock.ErrLocked) {
+		t.Errorf("Acquire over another host's lock = %v, want ErrLocked", err)
+	}
+
+	os.WriteFile(path, []byte("{"), 0o644)
+	if _, err := lock.Acquire(path, "pampax update"); !errors.Is(err, lock.ErrLocked) {
+		t.Errorf("Acquire over a lock being written = %v, want ErrLocked", err)
+	}
+	old := time.Now().Add(-time.Minute)
+	os.Chtimes(path, old, old)
+	held, err = lock.Acquire(path, "pampax update")
+	if err != nil {
+		t.Fatalf("Acquire over an old unreadable lock: %v", err)
+	}
+
+	// A lock taken over by another process is left alone.
+	writeLock(t, path, lock.Info{PID: os.Getpid(), Hostname: hostname, StartedAt: time.Now().Add(time.Hour)})
+	held.Release()
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Release removed another process's lock: %v", err)
+	}
+}
+
+func TestReadersRunWhileIndexIsWritten(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "pampa.db")
+	writer, err := db.Open(ctx, path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer writer.Close()
+
+	var mode string
+	if err := writer.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&mode); err != nil || mode != "wal" {
+		t.Fatalf("journal_mode = %q, %v; want wal", mode, err)
+	}
+	chunk := db.UpsertChunkParams{ID: "a.go:A:12345678", FilePath: "a.go", Symbol: "A", Sha: "1234567890", Lang: "go"}
+	if err := db.New(writer).UpsertChunk(ctx, chunk); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := writer.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if err := db.New(tx).DeleteChunk(ctx, chunk.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := db.OpenReadOnly(ctx, path)
+	if err != nil {
+		t.Fatalf("OpenReadOnly: %v", err)
+	}
+	defer reader.Close()
+	row, err := db.New(reader).GetChunk(ctx, chunk.ID)
+	if err != nil || row.Symbol != "A" {
+		t.Errorf("read during a write transaction = %+v, %v; want the committed row", row, err)
+	}
+	if err := db.New(reader).DeleteChunk(ctx, chunk.ID); err == nil {
+		t.Error("a read-only connection deleted a chunk")
+	}
+	if _, err := db.OpenReadOnly(ctx, filepath.Join(t.TempDir(), "missing.db")); err == nil {
+		t.Error("OpenReadOnly created a missing database")
+	}
+}
@@ -0,0 +1,192 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/alessandrojcm/pampax-go/internal/merkle"
+)
+
+func TestFastHashMatchesXXH64(t *testing.T) {
+	for input, want := range map[string]string{
+		"":    "17241709254077376921",
+		"a":   "15154266338359012955",
+		"abc": "4952883123889572249",
+		"Nobody inspects the spammish repetition": "18144624926692707313",
+	} {
+		if got := merkle.FastHash([]byte(input)); got != want {
+			t.Errorf("FastHash(%q) = %s, want %s", input, got, want)
+		}
+	}
+	if merkle.FastHash([]byte{'a', 0xff}) != merkle.FastHash([]byte("a�")) {
+		t.Error("invalid UTF-8 should hash like Node's decoded string")
+	}
+}
+
+func TestMerkleDirectoryHashesTrackChanges(t *testing.T) {
+	tree := merkle.New()
+	tree.Set("src/api/handler.go", merkle.Entry{ShaFile: "1"})
+	tree.Set("src/db/store.go", merkle.Entry{ShaFile: "2"})
+	tree.Set("README.md", merkle.Entry{ShaFile: "3"})
+
+	root, _ := tree.Hash("")
+	api, _ := tree.Hash("src/api")
+	db, _ := tree.Hash("src/db")
+
+	tree.Set("src/db/store.go", merkle.Entry{ShaFile: "4"})
+	if got, _ := tree.Hash("src/api"); got != api {
+		t.Error("an unrelated directory hash changed")
+	}
+	if got, _ := tree.Hash("src/db"); got == db {
+		t.Error("the changed directory kept its hash")
+	}
+	if got, _ := tree.Hash(""); got == root {
+		t.Error("the root kept its hash")
+	}
+
+	if !tree.Remove("src/db/store.go") || tree.Remove("src/db/store.go") {
+		t.Error("Remove should report the file only once")
+	}
+	if _, ok := tree.Hash("src/db"); ok {
+		t.Error("an emptied directory should be dropped")
+	}
+	if got := tree.Files(); !reflect.DeepEqual(got, []string{"README.md", "src/api/handler.go"}) {
+		t.Errorf("Files = %v", got)
+	}
+}
+
+func TestMerkleDiffReportsChangedFiles(t *testing.T) {
+	before := merkle.New()
+	before.Set("a/keep.go", merkle.Entry{ShaFile: "1"})
+	before.Set("a/edit.go", merkle.Entry{ShaFile: "2"})
+	before.Set("gone/old.go", merkle.Entry{ShaFile: "3"})
+	after := before.Clone()
+	after.Set("a/edit.go", merkle.Entry{ShaFile: "20"})
+	after.Remove("gone/old.go")
+	after.Set("b/c/new.go", merkle.Entry{ShaFile: "5"})
+
+	want := merkle.Changes{Added: []string{"b/c/new.go"}, Modified: []string{"a/edit.go"}, Removed: []string{"gone/old.go"}}
+	if got := merkle.Diff(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %+v, want %+v", got, want)
+	}
+	if !merkle.Diff(after, after.Clone()).Empty() {
+		t.Error("a tree should not differ from its clone")
+	}
+}
+
+func TestMerkleReadsAndWritesNodeLayout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".pampa", "merkle.json")
+	node := `{"src/app.js": {"shaFile": "42", "chunkShas": ["c1", "c2"]}, "bad": 7}`
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(node), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := merkle.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if entry, ok := tree.Get("src/app.js"); !ok || entry.ShaFile != "42" || !reflect.DeepEqual(entry.ChunkShas, []string{"c1", "c2"}) {
+		t.Errorf("entry = %+v", entry)
+	}
+	if tree.Len() != 1 {
+		t.Errorf("Len = %d, want the malformed entry skipped", tree.Len())
+	}
+
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.Set("lib/util.js", merkle.Entry{ShaFile: "7"})
+	if err := merkle.Save(path, tree); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	reloaded, _ := merkle.Load(path)
+	if !merkle.Diff(tree, reloaded).Empty() {
+		t.Error("the saved tree did not round-trip")
+	}
+	// Save renames a new file over the old one rather than rewriting it in
+	// place, so a crash cannot leave it truncated.
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if os.SameFile(before, after) {
+		t.Error("Save rewrote merkle.json in place")
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("Save left %d files in .pampa, want only merkle.json", len(entries))
+	}
+
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if corrupt, err := merkle.Load(path); err != nil || corrupt.Len() != 0 {
+		t.Errorf("corrupt file = %d entries, %v; want an empty tree", corrupt.Len(), err)
+	}
+}
+
+func TestMerkleScanReusesUnchangedEntries(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(root, filepath.Dir(rel)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, rel), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("src/a.go", "package a\n")
+	write("src/b.go", "package b\n")
+
+	first, err := merkle.Scan(root, []string{"src/a.go", "src/b.go", "src/missing.go"}, nil)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if a, _ := first.Get("src/a.go"); a.ShaFile != merkle.FastHash([]byte("package a\n")) {
+		t.Errorf("a.go hash = %s", a.ShaFile)
+	}
+	if first.Len() != 2 {
+		t.Errorf("Len = %d, want missing files left out", first.Len())
+	}
+
+	// A matching size and mtime is trusted, so a planted hash survives.
+	a, _ := first.Get("src/a.go")
+	a.ShaFile, a.ChunkShas = "planted", []string{"c1"}
+	first.Set("src/a.go", a)
+	write("src/b.go", "package bb\n")
+
+	second, err := merkle.Scan(root, []string{"src/a.go", "src/b.go"}, first)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if got, _ := second.Get("src/a.go"); got.ShaFile != "planted" || !reflect.DeepEqual(got.ChunkShas, []string{"c1"}) {
+		t.Errorf("a.go = %+v, want the previous entry", got)
+	}
+	if changes := merkle.Diff(first, second); !reflect.DeepEqual(changes.Modified, []string{"src/b.go"}) {
+		t.Errorf("Diff = %+v, want only b.go modified", changes)
+	}
+}
+
+func TestNormalizeToProjectPath(t *testing.T) {
+	base := t.TempDir()
+	cases := map[string]string{
+		filepath.Join(base, "src", "a.go"): "src/a.go",
+		filepath.Join("lib", "b.js"):       "lib/b.js",
+	}
+	for input, want := range cases {
+		if got, ok := merkle.NormalizeToProjectPath(base, input); !ok || got != want {
+			t.Errorf("NormalizeToProjectPath(%q) = %q, %v; want %q", input, got, ok, want)
+		}
+	}
+	for _, outside := range []string{"", base, filepath.Join(base, "..", "other.go")} {
+		if got, ok := merkle.NormalizeToProjectPath(base, outside); ok {
+			t.Errorf("NormalizeToProjectPath(%q) = %q, want rejected", outside, got)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alessandrojcm/pampax-go/internal/db"
+)
+
+// TestMigrationsMatchSchemaDump guards sql/schema.sql, which sqlc reads,
+// against drifting from the migrations the index is actually built with.
+func TestMigrationsMatchSchemaDump(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	migrated, err := db.Open(ctx, filepath.Join(dir, "migrated.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer migrated.Close()
+
+	schema, err := os.ReadFile("../../sql/schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dumped, err := sql.Open("sqlite", filepath.Join(dir, "dumped.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dumped.Close()
+	if _, err := dumped.ExecContext(ctx, string(schema)); err != nil {
+		t.Fatalf("apply sql/schema.sql: %v", err)
+	}
+
+	want, got := schemaObjects(t, dumped), schemaObjects(t, migrated)
+	if !reflect.DeepEqual(got, want) {
+		for name, statement := range want {
+			if got[name] != statement {
+				t.Errorf("%s\n migrations: %q\n schema.sql: %q", name, got[name], statement)
+			}
+		}
+		for name := range got {
+			if _, ok := want[name]; !ok {
+				t.Errorf("%s is created by the migrations but missing from sql/schema.sql", name)
+			}
+		}
+	}
+}
+
+// schemaObjects maps the tables and indexes of conn to their CREATE
+// statements, without the IF NOT EXISTS the migrations use.
+func schemaObjects(t *testing.T, conn *sql.DB) map[string]string {
+	t.Helper()
+	rows, err := conn.Query(`SELECT type, name, sql FROM sqlite_master WHERE sql IS NOT NULL`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	objects := map[string]string{}
+	for rows.Next() {
+		var kind, name, statement string
+		if err := rows.Scan(&kind, &name, &statement); err != nil {
+			t.Fatal(err)
+		}
+		objects[kind+" "+name] = strings.ReplaceAll(statement, " IF NOT EXISTS", "")
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return objects
+}
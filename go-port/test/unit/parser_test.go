@@ -0,0 +1,324 @@
+package unit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+)
+
+func TestLangRulesCoverSupportedExtensions(t *testing.T) {
+	exts := indexer.SupportedExtensions()
+	for _, ext := range []string{".go", ".ts", ".tsx", ".py", ".rb", ".rs", ".md", ".sh"} {
+		if !slices.Contains(exts, ext) {
+			t.Fatalf("SupportedExtensions() missing %s", ext)
+		}
+	}
+
+	for _, ext := range exts {
+		rule, ok := indexer.RuleForExtension(ext)
+		if !ok {
+			t.Fatalf("RuleForExtension(%q) not found", ext)
+		}
+		if _, ok := indexer.ParserFor(rule.Lang); !ok {
+			t.Fatalf("no parser registered for %s (%s)", rule.Lang, ext)
+		}
+	}
+
+	if rule, ok := indexer.RuleForPath("src/App.TSX"); !ok || rule.Lang != "tsx" {
+		t.Fatalf("RuleForPath(App.TSX) = %q, %v", rule.Lang, ok)
+	}
+}
+
+// topLevel returns "type:name" for each child of the root, using the name
+// field when present.
+func topLevel(tree *indexer.Tree) []string {
+	var out []string
+	for _, child := range tree.Root.Children {
+		entry := child.Type
+		if name := child.ChildByField("name"); name != nil {
+			entry += ":" + name.Text(tree.Source)
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+func TestParseEmitsTreeSitterNodeTypes(t *testing.T) {
+	tests := []struct {
+		lang   string
+		source string
+		want   []string
+	}{
+		{
+			lang:   "go",
+			source: "package p\n\nfunc Run() {}\n\nfunc (s *S) Stop() error { return nil }\n",
+			want:   []string{"package_clause", "function_declaration:Run", "method_declaration:Stop"},
+		},
+		{
+			lang:   "javascript",
+			source: "class A { m() { go(); } }\nexport function f() {}\nconst x = 1\nrun(x)\n",
+			want:   []string{"class_declaration:A", "export_statement", "lexical_declaration", "expression_statement"},
+		},
+		{
+			lang:   "python",
+			source: "import os\n\ndef handler(req):\n    return req\n\nclass A(Base):\n    pass\n",
+			want:   []string{"import_statement", "function_definition:handler", "class_definition:A"},
+		},
+		{
+			lang:   "ruby",
+			source: "class Foo < Bar\n  def run\n    puts 1\n  end\nend\n",
+			want:   []string{"class:Foo"},
+		},
+		{
+			lang:   "rust",
+			source: "pub struct P { x: i32 }\nfn main() { run(); }\n",
+			want:   []string{"struct_item:P", "function_item:main"},
+		},
+		{
+			lang:   "bash",
+			source: "build() {\n  go build ./...\n}\nbuild\n",
+			want:   []string{"function_definition:build", "command:build"},
+		},
+		{
+			lang:   "markdown",
+			source: "# Title\n\ntext\n\n## Sub\n",
+			want:   []string{"section"},
+		},
+	}
+
+	for _, tt := range tests {
+		tree, err := indexer.Parse(tt.lang, []byte(tt.source))
+		if err != nil {
+			t.Fatalf("Parse(%s) error = %v", tt.lang, err)
+		}
+		if tree.HasError {
+			t.Fatalf("Parse(%s) reported errors on valid input", tt.lang)
+		}
+		if got := topLevel(tree); !slices.Equal(got, tt.want) {
+			t.Fatalf("Parse(%s) top level = %v, want %v", tt.lang, got, tt.want)
+		}
+	}
+}
+
+// declarations returns "type:name" for every named node below the root.
+func declarations(tree *indexer.Tree) []string {
+	var out []string
+	tree.Root.Walk(func(n *indexer.Node) bool {
+		if name := n.ChildByField("name"); name != nil && n.Parent != tree.Root && n != tree.Root {
+			out = append(out, n.Type+":"+name.Text(tree.Source))
+		}
+		return true
+	})
+	return out
+}
+
+func TestParseCoversEveryLanguage(t *testing.T) {
+	tests := map[string]struct {
+		source string
+		top    []string
+		// nested lists declarations expected below the top level.
+		nested []string
+	}{
+		"go":         {source: "package p\n\ntype S struct{}\n\nfunc (s *S) Run() {}\n", top: []string{"package_clause", "type_declaration", "method_declaration:Run"}},
+		"javascript": {source: "class A { m() {} }\n", top: []string{"class_declaration:A"}, nested: []string{"method_definition:m"}},
+		"typescript": {
+			source: "interface I { x: number }\ntype T = string\nexport class S implements I { run(): void {} }\nfunction f(a: number): number { return a }\n",
+			top:    []string{"interface_declaration:I", "type_alias_declaration:T", "export_statement", "function_declaration:f"},
+			nested: []string{"class_declaration:S", "method_definition:run"},
+		},
+		"tsx": {
+			source: "import React from 'react'\nexport function App() { return <div>{run()}</div> }\n",
+			top:    []string{"import_statement", "export_statement"},
+			nested: []string{"function_declaration:App"},
+		},
+		"java": {
+			source: "package a.b;\nimport java.util.List;\npublic class Svc { void run() { go(); } }\ninterface I {}\nenum E { A }\n",
+			top:    []string{"package_declaration", "import_declaration", "class_declaration:Svc", "interface_declaration:I", "enum_declaration:E"},
+			nested: []string{"method_declaration:run"},
+		},
+		"csharp": {
+			source: "using System;\nnamespace App { public class Svc { public void Run() {} } }\n",
+			top:    []string{"using_directive", "namespace_declaration:App"},
+			nested: []string{"class_declaration:Svc", "method_declaration:Run"},
+		},
+		"c": {
+			source: "#include <stdio.h>\nstruct P { int x; };\nint main(void) { return run(); }\n",
+			top:    []string{"preproc_include", "struct_specifier:P", "function_definition"},
+		},
+		"cpp": {
+			source: "#include <vector>\nnamespace n { class A { public: void m(); }; }\nint main() { return 0; }\n",
+			top:    []string{"preproc_include", "namespace_definition:n", "function_definition"},
+			nested: []string{"class_specifier:A"},
+		},
+		"rust": {source: "impl P { fn new() -> P { P } }\n", top: []string{"impl_item:P"}, nested: []string{"function_item:new"}},
+		"php": {
+			source: "<?php\nnamespace App;\nclass Svc { public function run() { go(); } }\nfunction helper() {}\n",
+			top:    []string{"namespace_definition:App", "class_declaration:Svc", "function_definition:helper"},
+			nested: []string{"method_declaration:run"},
+		},
+		"kotlin": {
+			source: "package a\nimport b.C\nclass Svc { fun run() { go() } }\nfun main() {}\n",
+			top:    []string{"package_header", "import_header", "class_declaration:Svc", "function_declaration:main"},
+			nested: []string{"function_declaration:run"},
+		},
+		"scala": {
+			source: "package a\nimport b.C\nclass Svc { def run(): Unit = go() }\nobject Main { def main(): Unit = {} }\n",
+			top:    []string{"package_clause", "import_declaration", "class_definition:Svc", "object_definition:Main"},
+			nested: []string{"function_definition:run", "function_definition:main"},
+		},
+		"swift": {
+			source: "import Foundation\nclass Svc { func run() { go() } }\nstruct P { var x: Int }\nfunc main() {}\n",
+			top:    []string{"import_declaration", "class_declaration:Svc", "class_declaration:P", "function_declaration:main"},
+			nested: []string{"function_declaration:run"},
+		},
+		"python": {source: "class A:\n    def m(self):\n        pass\n", top: []string{"class_definition:A"}, nested: []string{"function_definition:m"}},
+		"ruby":   {source: "module M\n  def self.run; end\nend\n", top: []string{"module:M"}, nested: []string{"singleton_method:run"}},
+		"lua": {
+			source: "local M = {}\nfunction M.run() go() end\nlocal function helper() end\nreturn M\n",
+			top:    []string{"variable_declaration", "function_declaration:M.run", "function_declaration:helper", "return_statement"},
+		},
+		"elixir": {source: "defmodule App.Svc do\n  def run(x), do: go(x)\nend\n", top: []string{"call"}},
+		"bash":   {source: "if true; then run; fi\n", top: []string{"if_statement"}},
+		"haskell": {
+			source: "module Main where\nimport Data.List\nrun :: Int -> Int\nrun x = x + 1\ndata P = P Int\n",
+			top:    []string{"header", "imports", "declarations"},
+			nested: []string{"signature:run", "function:run", "data_type:P"},
+		},
+		"ocaml": {
+			source: "open Printf\nlet run x = x + 1\ntype p = { x : int }\nmodule M = struct let y = 2 end\n",
+			top:    []string{"open_module", "value_definition", "type_definition", "module_definition"},
+			nested: []string{"type_binding:p", "module_binding:M"},
+		},
+		"html":     {source: "<!DOCTYPE html>\n<html><body><script>run()</script></body></html>\n", top: []string{"doctype", "element"}},
+		"css":      {source: "@import 'a.css';\n.btn { color: red; }\n@media (max-width: 1px) { .a { margin: 0 } }\n", top: []string{"import_statement", "rule_set", "media_statement"}},
+		"json":     {source: `{"a": [1, 2], "b": {"c": null}}`, top: []string{"object"}},
+		"markdown": {source: "# A\n\ntext\n\n# B\n", top: []string{"section", "section"}},
+	}
+
+	for _, lang := range indexer.Languages() {
+		tt, ok := tests[lang]
+		if !ok {
+			t.Errorf("no structure test for %s", lang)
+			continue
+		}
+		tree, err := indexer.Parse(lang, []byte(tt.source))
+		if err != nil {
+			t.Fatalf("Parse(%s) error = %v", lang, err)
+		}
+		if tree.HasError {
+			t.Errorf("Parse(%s) reported errors on valid input", lang)
+		}
+		if got := topLevel(tree); !slices.Equal(got, tt.top) {
+			t.Errorf("Parse(%s) top level = %v, want %v", lang, got, tt.top)
+		}
+		got := declarations(tree)
+		for _, want := range tt.nested {
+			if !slices.Contains(got, want) {
+				t.Errorf("Parse(%s) declarations = %v, want %s among them", lang, got, want)
+			}
+		}
+	}
+}
+
+func TestParseFindsNestedMethodsAndCalls(t *testing.T) {
+	source := []byte("class A {\n  m() { this.run(1); }\n}\n")
+	tree, err := indexer.Parse("javascript", source)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	var methods, calls []string
+	tree.Root.Walk(func(n *indexer.Node) bool {
+		switch n.Type {
+		case "method_definition":
+			methods = append(methods, n.ChildByField("name").Text(source))
+		case "call_expression":
+			calls = append(calls, n.ChildByField("function").Text(source))
+		}
+		return true
+	})
+
+	if !slices.Equal(methods, []string{"m"}) || !slices.Equal(calls, []string{"this.run"}) {
+		t.Fatalf("methods = %v, calls = %v", methods, calls)
+	}
+	if line := tree.Root.Children[0].EndLine(source); line != 3 {
+		t.Fatalf("class EndLine = %d, want 3", line)
+	}
+}
+
+func TestParseMarksMalformedInput(t *testing.T) {
+	for lang, source := range map[string]string{
+		"javascript": "function f() { if (x) {\n",
+		"python":     "def f(:\n    pass\n",
+		"json":       `{"a": }`,
+		"html":       "<div><span></div>",
+		"css":        `{)""}c`,
+		"bash":       "echo a )|",
+	} {
+		tree, err := indexer.Parse(lang, []byte(source))
+		if err != nil {
+			t.Fatalf("Parse(%s) error = %v", lang, err)
+		}
+		if !tree.HasError {
+			t.Fatalf("Parse(%s) HasError = false for %q", lang, source)
+		}
+	}
+}
+
+func TestParseGoWithoutPackageClause(t *testing.T) {
+	for _, source := range []string{"", "x", "func", "// hi\n"} {
+		if tree, err := indexer.Parse("go", []byte(source)); err == nil {
+			t.Errorf("Parse(go, %q) = %v, want an error", source, tree)
+		}
+	}
+}
+
+func TestParseUnsupportedLanguage(t *testing.T) {
+	if _, err := indexer.Parse("cobol", []byte("x")); !errors.Is(err, indexer.ErrUnsupportedLanguage) {
+		t.Fatalf("Parse(cobol) error = %v, want ErrUnsupportedLanguage", err)
+	}
+}
+
+// parseWithin parses source as lang, failing the test if the parser panics
+// or has not returned after a few seconds.
+func parseWithin(t *testing.T, lang string, source []byte) {
+	t.Helper()
+	done := make(chan any, 1)
+	go func() {
+		defer func() { done <- recover() }()
+		indexer.ParseFile(indexer.LangRule{Lang: lang}, source)
+	}()
+	select {
+	case p := <-done:
+		if p != nil {
+			t.Fatalf("Parse(%s, %q) panicked: %v", lang, source, p)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Parse(%s, %q) did not return", lang, source)
+	}
+}
+
+func FuzzParseFile(f *testing.F) {
+	for _, seed := range []string{"", "x", "func", "// hi\n", `{)""}c`, ")|", "echo a )|", "@ *", "}", "{", "(", ")", "\"", "'", "<", "<<EOF\n", "#", "/*", "`"} {
+		f.Add([]byte(seed))
+	}
+	// The repo's own sources are a corpus of realistic input that is
+	// mostly in the wrong language for each parser.
+	sources, _ := filepath.Glob("../../internal/indexer/*.go")
+	for _, path := range append(sources, "../../internal/config/config.go", "../../README.md") {
+		if source, err := os.ReadFile(path); err == nil {
+			f.Add(source)
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, source []byte) {
+		for _, lang := range indexer.Languages() {
+			parseWithin(t, lang, source)
+		}
+	})
+}
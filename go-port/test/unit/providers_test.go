@@ -0,0 +1,93 @@
+package unit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alessandrojcm/pampax-go/internal/chunks"
+	"github.com/alessandrojcm/pampax-go/internal/providers"
+)
+
+func TestOpenAIProviderOrdersEmbeddingsByIndex(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if r.URL.Path != "/v1/embeddings" || r.Header.Get("Authorization") != "Bearer sk-test" || body.Model != "text-embedding-3-small" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"data":[{"index":1,"embedding":[2]},{"index":0,"embedding":[1]}]}`))
+	}))
+	defer server.Close()
+
+	env := map[string]string{"OPENAI_API_KEY": "sk-test", "OPENAI_BASE_URL": server.URL + "/v1/", "OPENAI_MODEL": "text-embedding-3-small"}
+	provider, err := providers.FromEnv("auto", 0, func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("FromEnv: %v", err)
+	}
+	if provider.Name() != "OpenAI" || provider.Dimensions() != 1536 {
+		t.Errorf("provider = %s/%d", provider.Name(), provider.Dimensions())
+	}
+	got, err := provider.EmbedBatch(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("EmbedBatch: %v", err)
+	}
+	if !reflect.DeepEqual(got, [][]float64{{1}, {2}}) {
+		t.Errorf("embeddings = %v", got)
+	}
+}
+
+func TestOllamaProviderReportsHTTPErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"model not found"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	provider := providers.NewOllama(providers.OllamaOptions{Host: strings.TrimPrefix(server.URL, "http://"), Dimensions: 512})
+	if provider.Dimensions() != 512 {
+		t.Errorf("Dimensions = %d, want the override", provider.Dimensions())
+	}
+	_, err := provider.EmbedBatch(context.Background(), []string{"a"})
+	if err == nil || !strings.Contains(err.Error(), "model not found") {
+		t.Errorf("EmbedBatch error = %v", err)
+	}
+}
+
+func TestFromEnvWithoutKeys(t *testing.T) {
+	none := func(string) string { return "" }
+	if _, err := providers.FromEnv("auto", 0, none); !errors.Is(err, providers.ErrNoProvider) {
+		t.Errorf("auto without keys = %v, want ErrNoProvider", err)
+	}
+	if _, err := providers.FromEnv("transformers", 0, none); err == nil {
+		t.Error("transformers should be unavailable")
+	}
+	if provider, err := providers.FromEnv("ollama", 0, none); err != nil || provider.Model() != "nomic-embed-text" {
+		t.Errorf("ollama = %v, %v", provider, err)
+	}
+}
+
+func TestDecodeMasterKey(t *testing.T) {
+	raw := []byte("0123456789abcdef0123456789abcdef")
+	for _, encoded := range []string{base64.StdEncoding.EncodeToString(raw), " " + hex.EncodeToString(raw) + "\n"} {
+		if key, err := chunks.DecodeMasterKey(encoded); err != nil || string(key) != string(raw) {
+			t.Errorf("DecodeMasterKey(%q) = %q, %v", encoded, key, err)
+		}
+	}
+	if key, err := chunks.DecodeMasterKey(""); key != nil || err != nil {
+		t.Errorf("empty key = %q, %v", key, err)
+	}
+	if _, err := chunks.DecodeMasterKey("short"); !errors.Is(err, chunks.ErrInvalidMasterKey) {
+		t.Errorf("short key error = %v", err)
+	}
+}
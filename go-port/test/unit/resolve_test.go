@@ -0,0 +1,166 @@
+package unit
+
+import (
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/alessandrojcm/pampax-go/internal/codemap"
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+	"github.com/alessandrojcm/pampax-go/internal/tokens"
+)
+
+// graphRepo chunks files into a codemap and a CallGraph and attaches the
+// resolved graph. It returns the codemap entries by "file:declared name"
+// and the ambiguous calls.
+func graphRepo(t *testing.T, goModule string, files map[string]string) (map[string]codemap.ChunkMetadata, []codemap.AmbiguousCall) {
+	t.Helper()
+	chunker := indexer.NewChunker(tokens.LookupProfile("", tokens.DefaultProfileName, tokens.Overrides{}), indexer.ChunkerOptions{})
+	graph := indexer.NewCallGraph(goModule)
+	entries := codemap.NewOrderedMap()
+
+	paths := make([]string, 0, len(files))
+	for file := range files {
+		paths = append(paths, file)
+	}
+	slices.Sort(paths)
+	names := map[string]string{}
+	for _, file := range paths {
+		rule, _ := indexer.RuleForPath(file)
+		chunks, _ := chunker.ChunkFile(file, rule, []byte(files[file]))
+		graph.AddFile(file, rule.Lang, []byte(files[file]), chunks)
+		for _, chunk := range chunks {
+			key := file + ":" + chunk.Symbol + ":" + chunk.SHA[:8]
+			entries.Set(key, chunk.Metadata(file, rule.Lang))
+			for _, decl := range chunk.Declares {
+				name := decl.Name
+				if decl.Owner != "" {
+					name = decl.Owner + "." + decl.Name
+				}
+				names[file+":"+name] = key
+			}
+		}
+	}
+
+	ambiguous := codemap.AttachSymbolGraph(entries, graph)
+	out := map[string]codemap.ChunkMetadata{}
+	for name, key := range names {
+		value, _ := entries.Get(key)
+		out[name] = value.(codemap.ChunkMetadata)
+	}
+	return out, ambiguous
+}
+
+func targetsOf(t *testing.T, entries map[string]codemap.ChunkMetadata, name string) []string {
+	t.Helper()
+	entry, ok := entries[name]
+	if !ok {
+		t.Fatalf("no chunk declares %s", name)
+	}
+	return entry.SymbolCallTargets
+}
+
+func shaOf(t *testing.T, entries map[string]codemap.ChunkMetadata, names ...string) []string {
+	t.Helper()
+	var shas []string
+	for _, name := range names {
+		entry, ok := entries[name]
+		if !ok {
+			t.Fatalf("no chunk declares %s", name)
+		}
+		shas = append(shas, entry.SHA)
+	}
+	return shas
+}
+
+func TestCallGraphResolvesGoImportsAndReceivers(t *testing.T) {
+	entries, ambiguous := graphRepo(t, "example.com/shop", map[string]string{
+		"store/store.go": "package store\n\ntype Store struct{ rows map[int]int }\n\nfunc New() *Store {\n\treturn &Store{}\n}\n\n" +
+			"func (s *Store) Save(id int) error {\n\treturn s.validate(id)\n}\n\nfunc (s *Store) validate(id int) error {\n\treturn nil\n}\n",
+		"cache/cache.go": "package cache\n\ntype Cache struct{}\n\nfunc New() *Cache {\n\treturn &Cache{}\n}\n\n" +
+			"func (c *Cache) Save(id int) error {\n\treturn nil\n}\n",
+		"main.go": "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/shop/cache\"\n\tst \"example.com/shop/store\"\n)\n\n" +
+			"func run() {\n\ts := st.New()\n\tc := cache.New()\n\tfmt.Println(s.Save(1), c)\n}\n",
+	})
+
+	if got, want := targetsOf(t, entries, "main.go:run"), shaOf(t, entries, "store/store.go:New", "cache/cache.go:New"); !reflect.DeepEqual(got, want) {
+		t.Errorf("run targets = %v, want store.New and cache.New %v", got, want)
+	}
+	if got, want := targetsOf(t, entries, "store/store.go:Store.Save"), shaOf(t, entries, "store/store.go:Store.validate"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Save targets = %v, want its receiver's validate %v", got, want)
+	}
+
+	if len(ambiguous) != 1 || ambiguous[0].Call != "s.Save" || ambiguous[0].File != "main.go" {
+		t.Fatalf("ambiguous = %+v, want only s.Save from main.go", ambiguous)
+	}
+	if want := shaOf(t, entries, "cache/cache.go:Cache.Save", "store/store.go:Store.Save"); !reflect.DeepEqual(ambiguous[0].Candidates, want) {
+		t.Errorf("candidates = %v, want %v", ambiguous[0].Candidates, want)
+	}
+}
+
+func TestCallGraphResolvesScriptImports(t *testing.T) {
+	entries, ambiguous := graphRepo(t, "", map[string]string{
+		"src/api/client.ts": "export function request(url: string) {\n  return fetch(url);\n}\n\n" +
+			"export class Client {\n  get(id: string) {\n    return this.send(id);\n  }\n\n  send(id: string) {\n    return request(id);\n  }\n}\n",
+		"src/util/request.ts": "export function request(url: string) {\n  return url.trim();\n}\n",
+		"src/app.ts": "import { Client } from './api/client';\nimport * as util from './util/request';\n\n" +
+			"export function main(c: Client) {\n  util.request('x');\n  return c.get('1');\n}\n",
+	})
+
+	if got, want := targetsOf(t, entries, "src/app.ts:main"), shaOf(t, entries, "src/util/request.ts:request", "src/api/client.ts:Client.get"); !reflect.DeepEqual(got, want) {
+		t.Errorf("main targets = %v, want %v", got, want)
+	}
+	if got, want := targetsOf(t, entries, "src/api/client.ts:Client.get"), shaOf(t, entries, "src/api/client.ts:Client.send"); !reflect.DeepEqual(got, want) {
+		t.Errorf("get targets = %v, want %v", got, want)
+	}
+	if got, want := targetsOf(t, entries, "src/api/client.ts:Client.send"), shaOf(t, entries, "src/api/client.ts:request"); !reflect.DeepEqual(got, want) {
+		t.Errorf("send targets = %v, want the same-file request %v", got, want)
+	}
+	if len(ambiguous) != 0 {
+		t.Errorf("ambiguous = %+v", ambiguous)
+	}
+}
+
+func TestCallGraphResolvesPythonModules(t *testing.T) {
+	entries, _ := graphRepo(t, "", map[string]string{
+		"pkg/__init__.py": "",
+		"pkg/billing/charge.py": "from .gateway import Gateway\nfrom ..util import fmt as f\n\n\n" +
+			"class Charger:\n    def run(me, amount):\n        me.validate(amount)\n        return Gateway(f.money(amount))\n\n" +
+			"    def validate(me, amount):\n        return amount > 0\n",
+		"pkg/billing/gateway.py": "class Gateway:\n    def __init__(self, amount):\n        self.amount = amount\n",
+		"pkg/util/fmt.py":        "def money(value):\n    return round(value, 2)\n",
+		"pkg/other/fmt.py":       "def money(value):\n    return value\n",
+	})
+
+	want := shaOf(t, entries, "pkg/billing/charge.py:Charger.validate", "pkg/billing/gateway.py:Gateway", "pkg/util/fmt.py:money")
+	if got := targetsOf(t, entries, "pkg/billing/charge.py:Charger.run"); !reflect.DeepEqual(got, want) {
+		t.Errorf("run targets = %v, want %v", got, want)
+	}
+	if got := targetsOf(t, entries, "pkg/util/fmt.py:money"); len(got) != 0 {
+		t.Errorf("money targets = %v", got)
+	}
+}
+
+func TestExtractImports(t *testing.T) {
+	tests := []struct {
+		file, source string
+		want         []indexer.Import
+	}{
+		{"a.go", "package a\n\nimport \"os\"\n\nimport (\n\tst \"example.com/store\" // storage\n\t_ \"embed\"\n\t. \"example.com/dsl\"\n)\n",
+			[]indexer.Import{{Path: "example.com/store", Alias: "st"}, {Path: "example.com/dsl", Alias: "."}, {Path: "os"}}},
+		{"a.ts", "import React, { useState as state, type Props } from 'react';\nimport * as api from './api';\nconst { join } = require('path');\n",
+			[]indexer.Import{
+				{Path: "react", Name: "useState", Alias: "state"}, {Path: "react", Name: "Props", Alias: "Props"},
+				{Path: "react", Name: "default", Alias: "React"}, {Path: "./api", Alias: "api"}, {Path: "path", Name: "join", Alias: "join"},
+			}},
+		{"a.py", "import os.path, json as j\nfrom ..core import (\n    Base,  # models\n    helper as h,\n)\nfrom x import *\n",
+			[]indexer.Import{{Path: "os.path", Alias: "os.path"}, {Path: "json", Alias: "j"}, {Path: "..core", Name: "Base", Alias: "Base"}, {Path: "..core", Name: "helper", Alias: "h"}, {Path: "x", Name: "*", Alias: "*"}}},
+	}
+	for _, tt := range tests {
+		rule, _ := indexer.RuleForExtension(filepath.Ext(tt.file))
+		if got := indexer.ExtractImports([]byte(tt.source), rule.Lang); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s imports = %+v\nwant %+v", tt.file, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package unit
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/alessandrojcm/pampax-go/internal/codemap"
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+	"github.com/alessandrojcm/pampax-go/internal/search"
+	"github.com/alessandrojcm/pampax-go/internal/tokens"
+)
+
+func TestChunkSignaturesPerLanguage(t *testing.T) {
+	tests := []struct {
+		file   string
+		source string
+		symbol string
+		want   indexer.Signature
+	}{
+		// Node names Go method chunks after the receiver; the signature does not.
+		{"store.go", "package a\n\nfunc (s *Store[K, V]) Get(ctx context.Context, key K) (V, error) {\n\treturn s.m[key], nil\n}\n", "s",
+			indexer.Signature{Text: "(s *Store[K, V]) Get(ctx context.Context, key K) : (V, error)", Parameters: []string{"ctx context.Context", "key K"}, Return: "(V, error)"}},
+		{"maps.go", "package a\n\nfunc Map[T any, U comparable](xs []T, f func(T) U) []U {\n\treturn nil\n}\n", "Map",
+			indexer.Signature{Text: "Map[T any, U comparable](xs []T, f func(T) U) : []U", Parameters: []string{"xs []T", "f func(T) U"}, Return: "[]U"}},
+		{"user.ts", "export async function fetchUser<T extends User>(id: string, opts: Options = {}): Promise<T> {\n  return api.get(id);\n}\n", "fetchUser",
+			indexer.Signature{Text: "fetchUser<T extends User>(id: string, opts: Options) : Promise<T>", Parameters: []string{"id: string", "opts: Options"}, Return: "Promise<T>"}},
+		{"charge.py", "def charge(customer: Customer, amount: int = 0, *args, **kwargs) -> Receipt:\n    return stripe.charge(customer)\n", "charge",
+			indexer.Signature{Text: "charge(customer: Customer, amount: int, args, kwargs) : Receipt", Parameters: []string{"customer: Customer", "amount: int", "args", "kwargs"}, Return: "Receipt"}},
+		{"Repo.java", "class Repo {\n  @Override\n  public <T> List<T> findAll(Map<String, T> filter, int limit) throws IOException { return null; }\n}\n", "findAll",
+			indexer.Signature{Text: "findAll<T>(Map<String, T> filter, int limit) : List<T>", Parameters: []string{"Map<String, T> filter", "int limit"}, Return: "List<T>"}},
+		{"parse.rs", "fn parse<'a, T: Into<String>>(input: &'a str, n: usize) -> Result<T, Error> where T: Clone { todo!() }\n", "parse",
+			indexer.Signature{Text: "parse<'a, T: Into<String>>(input: &'a str, n: usize) : Result<T, Error>", Parameters: []string{"input: &'a str", "n: usize"}, Return: "Result<T, Error>"}},
+		{"name.cpp", "static const std::string& name_of(const Widget* w, int id) { return w->name; }\n", "name_of",
+			indexer.Signature{Text: "name_of(const Widget* w, int id) : const std::string&", Parameters: []string{"const Widget* w", "int id"}, Return: "const std::string&"}},
+		{"charge.rb", "def charge(customer, amount = 0)\n  1\nend\n", "charge",
+			indexer.Signature{Text: "charge(customer, amount)", Parameters: []string{"customer", "amount"}}},
+	}
+
+	chunker := indexer.NewChunker(tokens.LookupProfile("", tokens.DefaultProfileName, tokens.Overrides{}), indexer.ChunkerOptions{})
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			rule, _ := indexer.RuleForExtension(filepath.Ext(tt.file))
+			chunks, _ := chunker.ChunkFile(tt.file, rule, []byte(tt.source))
+			for _, chunk := range chunks {
+				if chunk.Symbol != tt.symbol {
+					continue
+				}
+				if !reflect.DeepEqual(chunk.Signature, tt.want) {
+					t.Fatalf("Signature = %#v\nwant %#v", chunk.Signature, tt.want)
+				}
+				return
+			}
+			t.Fatalf("no %s chunk in %d chunks", tt.symbol, len(chunks))
+		})
+	}
+}
+
+func TestClassSignatureAndCodemapMetadata(t *testing.T) {
+	source := "// @pampax-intent: hold users\nclass UserRepository {\n  find(id) { return this.rows[id]; }\n}\n"
+	rule, _ := indexer.RuleForExtension(".js")
+	chunks, _ := indexer.NewChunker(tokens.LookupProfile("", tokens.DefaultProfileName, tokens.Overrides{}), indexer.ChunkerOptions{}).
+		ChunkFile("repo.js", rule, []byte(source))
+	if len(chunks) != 2 || chunks[0].Symbol != "UserRepository" {
+		t.Fatalf("got %d chunks, want the class and its method", len(chunks))
+	}
+
+	meta := chunks[0].Metadata("src/repo.js", "javascript")
+	if meta.SymbolSignature != "class UserRepository" || meta.SymbolParameters != nil || !meta.HasIntent || !meta.HasDocumentation {
+		t.Fatalf("Metadata() = %+v", meta)
+	}
+}
+
+func symbolEntry(symbol, sha, signature string, params []string, neighbors ...string) codemap.ChunkMetadata {
+	return codemap.ChunkMetadata{Symbol: &symbol, SHA: sha, SymbolSignature: signature, SymbolParameters: params, SymbolNeighbors: neighbors}
+}
+
+func TestQueryMatchesSignature(t *testing.T) {
+	entry := symbolEntry("createCheckoutSession", "a", "createCheckoutSession(customerId: string) : Session", []string{"customerId: string"})
+	for query, want := range map[string]bool{
+		"where is createCheckoutSession defined": true,
+		"checkouts for a customer":               true,
+		"customerid: string handling":            true,
+		"stripe webhook":                         false,
+		"go to the ses":                          false,
+		"precheckout hooks":                      false,
+		"the_checkout flow":                      false,
+		"CHECKOUTS, sessions":                    true,
+	} {
+		if got := search.QueryMatchesSignature(query, entry); got != want {
+			t.Errorf("QueryMatchesSignature(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
+
+func TestApplySymbolBoost(t *testing.T) {
+	entries := map[string]codemap.ChunkMetadata{
+		"pay.js:createCheckoutSession:a": symbolEntry("createCheckoutSession", "a", "createCheckoutSession(customerId)", []string{"customerId"}),
+		"pay.js:handler:b":               symbolEntry("handler", "b", "handler(req, res)", []string{"req", "res"}, "a"),
+		"misc.js:format:c":               symbolEntry("format", "c", "format(value)", []string{"value"}),
+	}
+	results := []search.Result{
+		{ID: "pay.js:createCheckoutSession:a", Score: 0.5},
+		{ID: "pay.js:handler:b", Score: 0.5},
+		{ID: "misc.js:format:c", Score: 0.5},
+	}
+
+	search.ApplySymbolBoost(results, "createCheckoutSession customer", entries)
+
+	if results[0].SymbolMatchStrength != 1 || results[0].SymbolBoost != search.SignatureMatchBoost || !reflect.DeepEqual(results[0].SymbolBoostSources, []string{"signature"}) {
+		t.Errorf("direct match = %+v", results[0])
+	}
+	if results[1].SymbolNeighborStrength != 1 || results[1].Score != 0.5+search.NeighborMatchBoost {
+		t.Errorf("neighbor match = %+v", results[1])
+	}
+	if results[2].SymbolBoost != 0 || results[2].Score != 0.5 {
+		t.Errorf("unrelated chunk boosted: %+v", results[2])
+	}
+}
@@ -0,0 +1,90 @@
+package unit
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+	"github.com/alessandrojcm/pampax-go/internal/tags"
+	"github.com/alessandrojcm/pampax-go/internal/tokens"
+)
+
+func TestSplitIdentifierAndStem(t *testing.T) {
+	splits := map[string]string{
+		"parseHTTPRequest_v2":    "parse http request v2",
+		"StripeCheckoutService":  "stripe checkout service",
+		"user-profile.settings":  "user profile settings",
+		"getURL":                 "get url",
+		"snake_case_Name":        "snake case name",
+		"src/payments/refund.js": "src payments refund js",
+	}
+	for in, want := range splits {
+		if got := strings.Join(tags.SplitIdentifier(in), " "); got != want {
+			t.Errorf("SplitIdentifier(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	stems := map[string]string{
+		"payments": "payment", "classes": "class", "queries": "query", "status": "status",
+		"processing": "process", "validated": "validate", "running": "run", "hoping": "hope",
+		"agreed": "agree", "filling": "fill", "auth": "auth", "string": "string",
+	}
+	for in, want := range stems {
+		if got := tags.Stem(in); got != want {
+			t.Errorf("Stem(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestExtractorDerivesDomainTags(t *testing.T) {
+	code := "async function createCheckoutSession(customer) {\n  const session = await stripe.checkout.sessions.create({ customer: customer.id });\n  return session;\n}"
+
+	got := tags.NewExtractor(tags.Options{}).Extract("src/payments/checkout.js", "createCheckoutSession", code)
+	want := []string{"payment", "checkout", "create", "session", "customer", "stripe"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Extract() = %v, want %v", got, want)
+	}
+
+	custom := tags.NewExtractor(tags.Options{
+		Max:             3,
+		ExtraDictionary: []string{"ledger"},
+		ExtraAliases:    []tags.Alias{{Term: "ledger", Tag: "accounting"}},
+		ExtraStopwords:  []string{"post"},
+	})
+	got = custom.Extract("src/books.py", "post", "def post(entries):\n    ledgers.append(entries)\n")
+	if want := []string{"book", "ledger", "accounting"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Extract(custom) = %v, want %v", got, want)
+	}
+}
+
+func TestMergeAndMatchTags(t *testing.T) {
+	merged := tags.Merge([]string{"Billing", "stripe"}, []string{"payments", "billing", "stripe"})
+	if want := []string{"Billing", "stripe", "payments"}; !reflect.DeepEqual(merged, want) {
+		t.Fatalf("Merge() = %v, want %v", merged, want)
+	}
+	if !tags.Match(merged, []string{"payment"}) || !tags.Match(merged, []string{"BILLING"}) || tags.Match(merged, []string{"auth"}) {
+		t.Fatal("Match() disagrees with the tags scope filter")
+	}
+	if !tags.Match(nil, nil) {
+		t.Fatal("an empty filter must match every chunk")
+	}
+}
+
+func TestChunkerTaggerMergesAnnotatedTags(t *testing.T) {
+	source := "// @pampax-tags: billing\nfunction chargeCustomer(customer) {\n  return stripe.charges.create({ customer });\n}\n"
+	rule, _ := indexer.RuleForExtension(".js")
+	chunker := indexer.NewChunker(tokens.LookupProfile("", tokens.DefaultProfileName, tokens.Overrides{}), indexer.ChunkerOptions{
+		Tagger: tags.NewExtractor(tags.Options{}),
+	})
+	chunks, _ := chunker.ChunkFile("billing/charge.js", rule, []byte(source))
+	if len(chunks) != 1 {
+		t.Fatalf("got %d chunks", len(chunks))
+	}
+	if want := []string{"billing", "charge", "customer", "stripe", "payment"}; !reflect.DeepEqual(chunks[0].Tags, want) {
+		t.Fatalf("Tags = %v, want %v", chunks[0].Tags, want)
+	}
+	if !strings.HasSuffix(chunks[0].EmbeddingText(), "// Tags: billing, charge, customer, stripe, payment") {
+		t.Fatalf("EmbeddingText() =\n%s", chunks[0].EmbeddingText())
+	}
+}
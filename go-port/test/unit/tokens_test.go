@@ -0,0 +1,123 @@
+package unit
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alessandrojcm/pampax-go/internal/tokens"
+)
+
+func TestCL100KEncodesKnownVectors(t *testing.T) {
+	bpe, err := tokens.CL100K()
+	if err != nil {
+		t.Fatalf("CL100K() error = %v", err)
+	}
+
+	tests := []struct {
+		input string
+		want  []int
+	}{
+		{input: "hello world", want: []int{15339, 1917}},
+		{input: "tiktoken is great!", want: []int{83, 1609, 5963, 374, 2294, 0}},
+		{input: "", want: []int{}},
+	}
+
+	for _, tt := range tests {
+		got := bpe.Encode(tt.input)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("Encode(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+
+		if count := bpe.Count(tt.input); count != len(tt.want) {
+			t.Fatalf("Count(%q) = %d, want %d", tt.input, count, len(tt.want))
+		}
+	}
+}
+
+func TestCL100KCountMatchesEncodeForCode(t *testing.T) {
+	bpe, err := tokens.CL100K()
+	if err != nil {
+		t.Fatalf("CL100K() error = %v", err)
+	}
+
+	code := "func (s *Service) Save(ctx context.Context) error {\r\n\tif s == nil {\n\t\treturn errNil // it's nil\n\t}\n\n   \n\treturn s.db.Save(ctx, 12345)\n}\n  const 日本語 = '値';  "
+	if got, want := bpe.Count(code), len(bpe.Encode(code)); got != want {
+		t.Fatalf("Count() = %d, len(Encode()) = %d", got, want)
+	}
+
+	if bpe.Count(code) >= len(code) {
+		t.Fatalf("expected BPE to merge bytes, got %d tokens for %d bytes", bpe.Count(code), len(code))
+	}
+}
+
+func TestCharLenAndEstimateMatchJavaScriptLength(t *testing.T) {
+	if got := tokens.CharLen("a😀b"); got != 4 {
+		t.Fatalf("CharLen() = %d, want 4 (UTF-16 units)", got)
+	}
+
+	if got := tokens.Estimate(strings.Repeat("x", 9)); got != 3 {
+		t.Fatalf("Estimate() = %d, want 3", got)
+	}
+}
+
+func TestLookupProfileFallbacksAndMaxTokensScaling(t *testing.T) {
+	openai := tokens.LookupProfile("OpenAI", "unknown-model", tokens.Overrides{})
+	if openai.MaxTokens != 8191 || openai.Dimensions != 3072 {
+		t.Fatalf("expected OpenAI provider default, got %+v", openai)
+	}
+
+	fallback := tokens.LookupProfile("Mystery", "mystery", tokens.Overrides{})
+	if fallback.UseTokens || fallback.SizeLimits().Unit != tokens.UnitCharacters || fallback.SizeLimits().Max != 1920 {
+		t.Fatalf("expected conservative character profile, got %+v", fallback)
+	}
+
+	scaled := tokens.LookupProfile("OpenAI", "text-embedding-3-small", tokens.Overrides{MaxTokens: 1000, Dimensions: 256})
+	want := tokens.Limits{Optimal: 820, Min: 50, Max: 950, Overlap: 12, Unit: tokens.UnitTokens}
+	if got := scaled.SizeLimits(); got != want {
+		t.Fatalf("SizeLimits() = %+v, want %+v", got, want)
+	}
+
+	if scaled.Dimensions != 256 {
+		t.Fatalf("expected dimensions override, got %d", scaled.Dimensions)
+	}
+
+	if _, ok := scaled.Counter().(*tokens.BPE); !ok {
+		t.Fatalf("expected tiktoken profile to count with BPE, got %T", scaled.Counter())
+	}
+}
+
+func TestAnalyzeDecisionsAndCountCache(t *testing.T) {
+	limits := tokens.Limits{Optimal: 10, Min: 3, Max: 20, Unit: tokens.UnitTokens}
+	cache := tokens.NewCountCache(tokens.CounterFunc(func(text string) int { return len(strings.Fields(text)) }))
+
+	tests := []struct {
+		words    int
+		decision string
+	}{
+		{words: 2, decision: tokens.DecisionTooSmall},
+		{words: 8, decision: tokens.DecisionOptimal},
+		{words: 15, decision: tokens.DecisionNeedsSubdivision},
+		{words: 21, decision: tokens.DecisionTooLarge},
+	}
+
+	for _, tt := range tests {
+		code := strings.TrimSpace(strings.Repeat("w ", tt.words))
+		got := tokens.Analyze(code, limits, func(text string) int { return cache.Count(code, text) }, false)
+		if got.Decision != tt.decision || got.Size != tt.words || got.Method != tokens.MethodTokenized {
+			t.Fatalf("Analyze(%d words) = %+v, want %s", tt.words, got, tt.decision)
+		}
+	}
+
+	huge := strings.Repeat("x", 400)
+	got := tokens.Analyze(huge, limits, func(string) int { t.Fatal("expected estimate to skip tokenization"); return 0 }, true)
+	if got.Decision != tokens.DecisionTooLarge || got.Method != tokens.MethodCharEstimate {
+		t.Fatalf("Analyze(huge) = %+v", got)
+	}
+
+	cache.Count("sha-1", "a b c")
+	cache.Count("sha-1", "ignored on hit")
+	if hits, _ := cache.Stats(); hits != 1 {
+		t.Fatalf("expected one cache hit, got %d", hits)
+	}
+}
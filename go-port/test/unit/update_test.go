@@ -0,0 +1,226 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alessandrojcm/pampax-go/internal/app"
+	"github.com/alessandrojcm/pampax-go/internal/codemap"
+	"github.com/alessandrojcm/pampax-go/internal/config"
+	"github.com/alessandrojcm/pampax-go/internal/db"
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+	"github.com/alessandrojcm/pampax-go/internal/merkle"
+	"github.com/alessandrojcm/pampax-go/internal/providers"
+	"github.com/alessandrojcm/pampax-go/internal/tokens"
+)
+
+const storeSource = `package store
+
+// Save writes the record.
+func Save(id int) error {
+	return write(id)
+}
+
+// Load reads the record.
+func Load(id int) (string, error) {
+	return read(id)
+}
+`
+
+const reportSource = `def render(rows):
+    return "\n".join(str(row) for row in rows)
+`
+
+type updateProject struct {
+	t        *testing.T
+	root     string
+	provider *fakeProvider
+	ix       *app.Indexer
+}
+
+func newUpdateProject(t *testing.T) *updateProject {
+	t.Helper()
+	p := &updateProject{t: t, root: t.TempDir(), provider: &fakeProvider{limits: providers.BatchLimits{MaxBatchSize: 8}}}
+	p.ix = app.New(p.root, app.Options{
+		Chunker:  indexer.NewChunker(tokens.LookupProfile("", tokens.DefaultProfileName, tokens.Overrides{}), indexer.ChunkerOptions{}),
+		Provider: p.provider,
+	})
+	return p
+}
+
+func (p *updateProject) write(rel, content string) {
+	p.t.Helper()
+	path := filepath.Join(p.root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		p.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+func (p *updateProject) update(dryRun bool) app.UpdateResult {
+	p.t.Helper()
+	walked, err := indexer.Walk(context.Background(), p.root, indexer.WalkOptions{})
+	if err != nil {
+		p.t.Fatalf("Walk: %v", err)
+	}
+	result, err := p.ix.Update(context.Background(), app.UpdateRequest{Files: walked.Files, DryRun: dryRun})
+	if err != nil {
+		p.t.Fatalf("Update: %v", err)
+	}
+	return result
+}
+
+// symbols returns the codemap symbols of file, checking that each has its
+// code_chunks row and chunk file.
+func (p *updateProject) symbols(file string) []string {
+	p.t.Helper()
+	paths := config.ResolvePaths(p.root)
+	cm, err := codemap.ReadCodemap(paths.Codemap)
+	if err != nil {
+		p.t.Fatalf("ReadCodemap: %v", err)
+	}
+	conn, err := db.Open(context.Background(), paths.DBPath)
+	if err != nil {
+		p.t.Fatalf("db.Open: %v", err)
+	}
+	defer conn.Close()
+	rows, err := db.New(conn).ListChunksByFile(context.Background(), file)
+	if err != nil {
+		p.t.Fatalf("ListChunksByFile: %v", err)
+	}
+	ids := map[string]bool{}
+	for _, row := range rows {
+		ids[row.ID] = true
+	}
+
+	var symbols []string
+	for _, key := range cm.Keys() {
+		value, _ := cm.Get(key)
+		entry := value.(codemap.ChunkMetadata)
+		if entry.File != file {
+			continue
+		}
+		symbols = append(symbols, *entry.Symbol)
+		if !ids[key] {
+			p.t.Errorf("codemap entry %s has no code_chunks row", key)
+		}
+		delete(ids, key)
+		if _, err := os.Stat(filepath.Join(paths.ChunkDir, entry.SHA+".gz")); err != nil {
+			p.t.Errorf("chunk file of %s: %v", key, err)
+		}
+		if entry.Provider != "fake" || entry.Dimensions != 2 {
+			p.t.Errorf("entry %s provider = %s/%d", key, entry.Provider, entry.Dimensions)
+		}
+	}
+	for id := range ids {
+		p.t.Errorf("code_chunks row %s is not in the codemap", id)
+	}
+	return symbols
+}
+
+func TestUpdateIndexesOnlyChangedFiles(t *testing.T) {
+	p := newUpdateProject(t)
+	p.write("store/store.go", storeSource)
+	p.write("report.py", reportSource)
+
+	first := p.update(false)
+	if !reflect.DeepEqual(first.Changes.Added, []string{"report.py", "store/store.go"}) || first.ChunksStored != 3 {
+		t.Fatalf("first update = %+v", first)
+	}
+	if got := p.symbols("store/store.go"); !reflect.DeepEqual(got, []string{"Save", "Load"}) {
+		t.Errorf("store.go symbols = %v", got)
+	}
+	if tree, _ := merkle.Load(config.ResolvePaths(p.root).Merkle); tree.Len() != 2 {
+		t.Errorf("merkle.json has %d files, want 2", tree.Len())
+	}
+
+	calls := len(p.provider.calls)
+	if again := p.update(false); !again.Changes.Empty() || again.ChunksStored != 0 || len(p.provider.calls) != calls {
+		t.Errorf("unchanged update = %+v after %d provider calls", again, len(p.provider.calls)-calls)
+	}
+
+	p.write("store/store.go", strings.Replace(storeSource, "return read(id)", "return readCached(id)", 1))
+	if err := os.Remove(filepath.Join(p.root, "report.py")); err != nil {
+		t.Fatal(err)
+	}
+	dry := p.update(true)
+	if !reflect.DeepEqual(dry.Changes.Modified, []string{"store/store.go"}) || !reflect.DeepEqual(dry.Changes.Removed, []string{"report.py"}) {
+		t.Errorf("dry run changes = %+v", dry.Changes)
+	}
+	if len(p.symbols("report.py")) != 1 {
+		t.Error("a dry run removed chunks")
+	}
+
+	second := p.update(false)
+	if second.ChunksStored != 1 || second.ChunksKept != 1 || second.ChunksDeleted != 2 {
+		t.Errorf("second update = %+v, want Load stored, Save kept, old Load and render deleted", second)
+	}
+	if got := p.symbols("report.py"); len(got) != 0 {
+		t.Errorf("report.py still has chunks %v", got)
+	}
+	if got := p.symbols("store/store.go"); !reflect.DeepEqual(got, []string{"Save", "Load"}) {
+		t.Errorf("store.go symbols = %v", got)
+	}
+	entries, _ := os.ReadDir(config.ResolvePaths(p.root).ChunkDir)
+	if len(entries) != 2 {
+		t.Errorf("%d chunk files left, want the 2 of store.go", len(entries))
+	}
+}
+
+func TestPartialUpdateOnlyLooksAtListedFiles(t *testing.T) {
+	p := newUpdateProject(t)
+	p.write("store/store.go", storeSource)
+	p.write("report.py", reportSource)
+	p.update(false)
+
+	p.write("report.py", reportSource+"\n\ndef empty():\n    return []\n")
+	if err := os.Remove(filepath.Join(p.root, "store", "store.go")); err != nil {
+		t.Fatal(err)
+	}
+	result, err := p.ix.Update(context.Background(), app.UpdateRequest{Deleted: []string{"store/store.go"}, Partial: true})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if !reflect.DeepEqual(result.Changes, merkle.Changes{Removed: []string{"store/store.go"}}) {
+		t.Errorf("changes = %+v, want only the listed deletion", result.Changes)
+	}
+	if got := p.symbols("report.py"); !reflect.DeepEqual(got, []string{"render"}) {
+		t.Errorf("report.py symbols = %v, want the unlisted change ignored", got)
+	}
+}
+
+func TestUpdateIsolatesFilesThatFailToParse(t *testing.T) {
+	p := newUpdateProject(t)
+	p.write("store/store.go", storeSource)
+	p.update(false)
+
+	// A parser that panics stands in for one choking on its input.
+	jsonParser, _ := indexer.ParserFor("json")
+	indexer.RegisterParser("json", indexer.ParserFunc(func([]byte) (*indexer.Tree, error) { panic("boom") }))
+	t.Cleanup(func() { indexer.RegisterParser("json", jsonParser) })
+	p.write("empty.go", "")
+	p.write("data.json", `{"a": 1}`)
+
+	result := p.update(false)
+	if len(result.Failed) != 1 || result.Failed[0].Path != "data.json" || result.Failed[0].Category != app.ErrorParse {
+		t.Fatalf("failed = %v, want only data.json", result.Failed)
+	}
+	if !reflect.DeepEqual(result.Changes.Added, []string{"data.json", "empty.go"}) {
+		t.Errorf("added = %v", result.Changes.Added)
+	}
+	if again := p.update(true); !reflect.DeepEqual(again.Changes.Added, []string{"data.json"}) {
+		t.Errorf("after the update, added = %v, want data.json to be retried", again.Changes.Added)
+	}
+
+	_, err := app.New(p.root, app.Options{}).Update(context.Background(), app.UpdateRequest{})
+	if !errors.Is(err, app.ErrNoProvider) {
+		t.Errorf("Update without a provider = %v", err)
+	}
+}
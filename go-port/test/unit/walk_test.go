@@ -0,0 +1,192 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+)
+
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		file := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func walkedPaths(result indexer.WalkResult) []string {
+	paths := make([]string, 0, len(result.Files))
+	for _, file := range result.Files {
+		paths = append(paths, file.Path)
+	}
+	return paths
+}
+
+func skipReasons(result indexer.WalkResult) map[string]indexer.SkipReason {
+	reasons := map[string]indexer.SkipReason{}
+	for _, skipped := range result.Skipped {
+		reasons[skipped.Path] = skipped.Reason
+	}
+	return reasons
+}
+
+func TestWalkHonorsIgnoreFilesAndFilters(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		".gitignore":           "*.log\n/generated/\n!keep.log\n",
+		".pampaxignore":        "secret.go\n!generated/\n",
+		"main.go":              "package main\n",
+		"debug.log":            "x\n",
+		"keep.log":             "x\n",
+		"secret.go":            "package main\n",
+		"generated/api.go":     "package generated\n",
+		"lib/.gitignore":       "/local.py\n",
+		"lib/local.py":         "x = 1\n",
+		"lib/util.py":          "x = 2\n",
+		"lib/nested/local.py":  "x = 3\n",
+		"node_modules/a/a.js":  "module.exports = 1\n",
+		"bin/tool":             "#!/usr/bin/env python3\nprint(1)\n",
+		"image.png":            "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR",
+		"app.min.js":           "var a=1;\n",
+		"big.go":               "package main\n" + strings.Repeat("// padding\n", 200),
+		"README":               "plain text\n",
+		"src/vendor.bundle.js": "var x=" + strings.Repeat("1+", 500) + "1;\n",
+	})
+
+	result, err := indexer.Walk(context.Background(), root, indexer.WalkOptions{
+		Excludes:    append([]string(nil), indexer.DefaultExcludes...),
+		MaxFileSize: 1024,
+	})
+	if err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+
+	want := []string{"bin/tool", "generated/api.go", "lib/nested/local.py", "lib/util.py", "main.go"}
+	if got := walkedPaths(result); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+	if lang := result.Files[0].Rule.Lang; lang != "python" {
+		t.Fatalf("bin/tool lang = %q, want python (shebang)", lang)
+	}
+
+	reasons := skipReasons(result)
+	for path, want := range map[string]indexer.SkipReason{
+		".gitignore":           indexer.SkipExcluded,
+		"node_modules":         indexer.SkipExcluded,
+		"debug.log":            indexer.SkipGitignore,
+		"keep.log":             indexer.SkipUnsupported,
+		"secret.go":            indexer.SkipPampaxignore,
+		"lib/local.py":         indexer.SkipGitignore,
+		"image.png":            indexer.SkipBinary,
+		"app.min.js":           indexer.SkipMinified,
+		"src/vendor.bundle.js": indexer.SkipMinified,
+		"big.go":               indexer.SkipTooLarge,
+		"README":               indexer.SkipUnsupported,
+	} {
+		if reasons[path] != want {
+			t.Errorf("skip reason for %s = %q, want %q", path, reasons[path], want)
+		}
+	}
+}
+
+func TestWalkSymlinkPolicy(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	writeTree(t, root, map[string]string{"pkg/a.go": "package pkg\n"})
+	writeTree(t, outside, map[string]string{"b.go": "package b\n"})
+
+	links := map[string]string{
+		"link.go":  filepath.Join(root, "pkg", "a.go"),
+		"external": outside,
+		"loop":     root,
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Skipf("symlinks unavailable: %v", err)
+		}
+	}
+
+	result, err := indexer.Walk(context.Background(), root, indexer.WalkOptions{})
+	if err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+	if got := walkedPaths(result); strings.Join(got, ",") != "pkg/a.go" {
+		t.Fatalf("files without following = %v", got)
+	}
+	if reason := skipReasons(result)["link.go"]; reason != indexer.SkipSymlink {
+		t.Fatalf("link.go skip reason = %q", reason)
+	}
+
+	result, err = indexer.Walk(context.Background(), root, indexer.WalkOptions{FollowSymlinks: true})
+	if err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+	if got := walkedPaths(result); strings.Join(got, ",") != "link.go,pkg/a.go" {
+		t.Fatalf("files when following = %v", got)
+	}
+	for _, skipped := range result.Skipped {
+		switch skipped.Path {
+		case "external":
+			if skipped.Detail != "target outside project" {
+				t.Fatalf("external detail = %q", skipped.Detail)
+			}
+		case "loop":
+			if skipped.Detail != "cycle" {
+				t.Fatalf("loop detail = %q", skipped.Detail)
+			}
+		}
+	}
+}
+
+func TestWalkIncludeUnknown(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"README":    "plain text\n",
+		"main.go":   "package main\n",
+		"image.png": "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR",
+	})
+
+	result, err := indexer.Walk(context.Background(), root, indexer.WalkOptions{IncludeUnknown: true})
+	if err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+	if got := strings.Join(walkedPaths(result), ","); got != "README,main.go" {
+		t.Fatalf("files = %s", got)
+	}
+	if lang := result.Files[0].Rule.Lang; lang != "" {
+		t.Fatalf("README lang = %q, want none", lang)
+	}
+	if reason := skipReasons(result)["image.png"]; reason != indexer.SkipBinary {
+		t.Fatalf("image.png skip reason = %q", reason)
+	}
+}
+
+func TestWalkFindsModelinesAtTheEndOfLongFiles(t *testing.T) {
+	root := t.TempDir()
+	padding := strings.Repeat("plain text\n", 2000)
+	writeTree(t, root, map[string]string{
+		"tail": padding + "# vim: ft=python\n",
+		"head": "plain text\n# vim: ft=ruby\n" + padding + padding,
+	})
+
+	result, err := indexer.Walk(context.Background(), root, indexer.WalkOptions{IncludeUnknown: true})
+	if err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+	langs := map[string]string{}
+	for _, file := range result.Files {
+		langs[file.Path] = file.Rule.Lang
+	}
+	if langs["tail"] != "python" || langs["head"] != "ruby" {
+		t.Fatalf("langs = %v, want tail python and head ruby", langs)
+	}
+}
@@ -0,0 +1,233 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alessandrojcm/pampax-go/internal/app"
+	"github.com/alessandrojcm/pampax-go/internal/config"
+	"github.com/alessandrojcm/pampax-go/internal/indexer"
+)
+
+func (p *updateProject) watch(debounce time.Duration, batches chan app.WatchBatch) (context.CancelFunc, chan error) {
+	p.t.Helper()
+	w, err := p.ix.Watch(app.WatchOptions{
+		Debounce: debounce,
+		OnBatch:  func(batch app.WatchBatch) { batches <- batch },
+		OnError:  func(err error) { p.t.Errorf("watch error: %v", err) },
+	})
+	if err != nil {
+		p.t.Fatalf("Watch: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+	return cancel, done
+}
+
+func nextBatch(t *testing.T, batches chan app.WatchBatch) app.WatchBatch {
+	t.Helper()
+	select {
+	case batch := <-batches:
+		if batch.Err != nil {
+			t.Fatalf("batch failed: %v", batch.Err)
+		}
+		return batch
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update after 5s")
+		return app.WatchBatch{}
+	}
+}
+
+func TestWatchBatchesChangesIntoPartialUpdates(t *testing.T) {
+	p := newUpdateProject(t)
+	p.write("store/store.go", storeSource)
+	p.write(".gitignore", "generated/\n")
+	p.update(false)
+
+	batches := make(chan app.WatchBatch, 8)
+	cancel, done := p.watch(app.MinDebounce, batches)
+	defer cancel()
+
+	p.write("generated/gen.py", reportSource)
+	p.write("lib/report.py", reportSource)
+	batch := nextBatch(t, batches)
+	if !reflect.DeepEqual(batch.Changed, []string{"lib/report.py"}) || batch.Deleted != nil {
+		t.Errorf("batch = %+v, want only the file in the new directory", batch)
+	}
+	if got := p.symbols("lib/report.py"); !reflect.DeepEqual(got, []string{"render"}) {
+		t.Errorf("report.py symbols = %v", got)
+	}
+
+	if err := os.RemoveAll(filepath.Join(p.root, "store")); err != nil {
+		t.Fatal(err)
+	}
+	batch = nextBatch(t, batches)
+	if !reflect.DeepEqual(batch.Deleted, []string{"store/store.go"}) || batch.Result.ChunksDeleted != 2 {
+		t.Errorf("batch = %+v, want the files of the removed directory deleted", batch)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := p.symbols("generated/gen.py"); len(got) != 0 {
+		t.Errorf("ignored file indexed: %v", got)
+	}
+}
+
+func TestWatchFlushesPendingChangesOnShutdown(t *testing.T) {
+	p := newUpdateProject(t)
+	p.update(false)
+
+	batches := make(chan app.WatchBatch, 8)
+	cancel, done := p.watch(time.Hour, batches)
+	p.write("report.py", reportSource)
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	batch := nextBatch(t, batches)
+	if !reflect.DeepEqual(batch.Changed, []string{"report.py"}) {
+		t.Errorf("batch = %+v", batch)
+	}
+	if got := p.symbols("report.py"); !reflect.DeepEqual(got, []string{"render"}) {
+		t.Errorf("report.py symbols = %v", got)
+	}
+}
+
+func TestWatchSurvivesFilesThatFailToParse(t *testing.T) {
+	p := newUpdateProject(t)
+	p.update(false)
+	jsonParser, _ := indexer.ParserFor("json")
+	indexer.RegisterParser("json", indexer.ParserFunc(func([]byte) (*indexer.Tree, error) { panic("boom") }))
+	t.Cleanup(func() { indexer.RegisterParser("json", jsonParser) })
+
+	batches := make(chan app.WatchBatch, 8)
+	cancel, done := p.watch(app.MinDebounce, batches)
+	defer cancel()
+
+	p.write("data.json", `{"a": 1}`)
+	p.write("empty.go", "")
+	var changed []string
+	var failed []app.FileError
+	for len(changed) < 2 {
+		batch := nextBatch(t, batches)
+		changed = append(changed, batch.Changed...)
+		failed = append(failed, batch.Result.Failed...)
+	}
+	if len(failed) != 1 || failed[0].Path != "data.json" || failed[0].Category != app.ErrorParse {
+		t.Errorf("failed = %v, want only data.json", failed)
+	}
+
+	p.write("report.py", reportSource)
+	if batch := nextBatch(t, batches); !reflect.DeepEqual(batch.Changed, []string{"report.py"}) {
+		t.Errorf("batch after the failure = %+v", batch)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+}
+
+func TestMatcherSharesWalkIgnoreRules(t *testing.T) {
+	root := t.TempDir()
+	for rel, content := range map[string]string{
+		".gitignore":           "build/\n*.log\n",
+		"src/.gitignore":       "gen.go\n",
+		indexer.IgnoreFileName: "secret/\n",
+	} {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := indexer.NewMatcher(root, indexer.WalkOptions{Excludes: []string{"vendor/"}})
+	if err != nil {
+		t.Fatalf("NewMatcher: %v", err)
+	}
+	for rel, want := range map[string]bool{
+		"src/main.go":        false,
+		"src/gen.go":         true,
+		"build/out.go":       true,
+		"build":              true,
+		"debug.log":          true,
+		"secret/key.go":      true,
+		"vendor/x/lib.go":    true,
+		".pampa/pampa.db":    true,
+		"pampa.codemap.json": true,
+	} {
+		isDir := rel == "build"
+		if got, err := m.Ignored(rel, isDir); err != nil || got != want {
+			t.Errorf("Ignored(%q) = %v, %v; want %v", rel, got, err, want)
+		}
+	}
+}
+
+func TestWatchRetriesFailedBatches(t *testing.T) {
+	p := newUpdateProject(t)
+	p.write("store/store.go", storeSource)
+	p.update(false)
+
+	// A codemap that does not parse fails every update until it is put
+	// back; the watcher does not see it change.
+	codemapPath := config.ResolvePaths(p.root).Codemap
+	good, err := os.ReadFile(codemapPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(codemapPath, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	batches := make(chan app.WatchBatch, 8)
+	cancel, done := p.watch(app.MinDebounce, batches)
+	defer cancel()
+
+	p.write("report.py", reportSource)
+	select {
+	case batch := <-batches:
+		if batch.Err == nil || !reflect.DeepEqual(batch.Changed, []string{"report.py"}) {
+			t.Fatalf("first batch = %+v, want report.py to fail", batch)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update after 5s")
+	}
+	if err := os.WriteFile(codemapPath, good, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// report.py is not touched again: the failed batch is retried.
+	deadline := time.After(5 * time.Second)
+	for retried := false; !retried; {
+		select {
+		case batch := <-batches:
+			if batch.Err == nil {
+				if !reflect.DeepEqual(batch.Changed, []string{"report.py"}) {
+					t.Fatalf("retried batch = %+v", batch)
+				}
+				retried = true
+			}
+		case <-deadline:
+			t.Fatal("the failed batch was not retried")
+		}
+	}
+	if got := p.symbols("report.py"); !reflect.DeepEqual(got, []string{"render"}) {
+		t.Errorf("report.py symbols = %v", got)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+}